@@ -16,6 +16,7 @@ package main
 
 import (
 	"github.com/apigee/registry/cmd/capabilities/worker-server/worker"
+	"github.com/apigee/registry/connection"
 	"log"
 	"net/http"
 	"os"
@@ -23,6 +24,10 @@ import (
 
 func main() {
 	log.Print("starting server...")
+	// Check the client settings that commands will use before accepting requests.
+	if _, err := connection.ActiveSettings(); err != nil {
+		log.Fatalf("Invalid client settings: %s", err)
+	}
 	http.HandleFunc("/", worker.RequestHandler)
 
 	// Determine port for HTTP service.
@@ -67,11 +67,13 @@ func RequestHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	os.Setenv("APG_REGISTRY_TOKEN", idToken)
 
 	split_cmd := strings.Split(req.Command, " ")
 	args := append(split_cmd[1:], req.Resource)
 	cmd := exec.Command(split_cmd[0], args...)
+	// Commands get the worker's client settings, such as APG_REGISTRY_TIMEOUT
+	// and APG_REGISTRY_RETRIES, along with a token for this request.
+	cmd.Env = append(os.Environ(), "APG_REGISTRY_TOKEN="+idToken)
 	var output []byte
 	output, err = cmd.CombinedOutput()
 	log.Print(string(output))
@@ -17,7 +17,6 @@ package registry
 import (
 	"errors"
 
-	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/graphql-go/graphql"
 )
@@ -40,6 +39,11 @@ var apiType = graphql.NewObject(
 				Args:    argumentsForCollectionQuery,
 				Resolve: resolveVersions,
 			},
+			"deployments": &graphql.Field{
+				Type:    connectionType(deploymentType),
+				Args:    argumentsForCollectionQuery,
+				Resolve: resolveDeployments,
+			},
 			"artifacts": &graphql.Field{
 				Type:    connectionType(artifactType),
 				Args:    argumentsForCollectionQuery,
@@ -69,7 +73,7 @@ func representationForAPI(api *rpc.Api) map[string]interface{} {
 
 func resolveAPIs(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +111,7 @@ func resolveAPIs(p graphql.ResolveParams) (interface{}, error) {
 
 func resolveAPI(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
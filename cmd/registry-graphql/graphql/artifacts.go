@@ -17,7 +17,6 @@ package registry
 import (
 	"errors"
 
-	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/graphql-go/graphql"
 )
@@ -49,7 +48,7 @@ func representationForArtifact(artifact *rpc.Artifact) map[string]interface{} {
 
 func resolveArtifacts(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +82,7 @@ func resolveArtifacts(p graphql.ResolveParams) (interface{}, error) {
 
 func resolveArtifact(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"sync"
+
+	"github.com/apigee/registry/connection"
+)
+
+var (
+	clientMutex sync.Mutex
+	client      connection.Client
+)
+
+// getClient returns a client that is shared by all requests, so that its
+// connection, keepalive pings, and limits on concurrent calls are too.
+func getClient() (connection.Client, error) {
+	clientMutex.Lock()
+	defer clientMutex.Unlock()
+	if client == nil {
+		// The client outlives the request that creates it.
+		c, err := connection.NewClient(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		client = c
+	}
+	return client, nil
+}
@@ -17,7 +17,6 @@ package registry
 import (
 	"errors"
 
-	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/graphql-go/graphql"
 )
@@ -67,7 +66,7 @@ func representationForProject(project *rpc.Project) map[string]interface{} {
 
 func resolveProjects(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +102,7 @@ func resolveProjects(p graphql.ResolveParams) (interface{}, error) {
 
 func resolveProject(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +17,6 @@ package registry
 import (
 	"errors"
 
-	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/graphql-go/graphql"
 	"github.com/graphql-go/graphql/language/ast"
@@ -84,7 +83,7 @@ func representationForSpec(spec *rpc.ApiSpec) map[string]interface{} {
 
 func resolveSpecs(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -144,7 +143,7 @@ func selectionSetContainsPath(selectionSet *ast.SelectionSet, path []string) boo
 
 func resolveSpec(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +17,6 @@ package registry
 import (
 	"errors"
 
-	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/graphql-go/graphql"
 )
@@ -67,7 +66,7 @@ func representationForVersion(version *rpc.ApiVersion) map[string]interface{} {
 }
 func resolveVersions(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +104,7 @@ func resolveVersions(p graphql.ResolveParams) (interface{}, error) {
 
 func resolveVersion(p graphql.ResolveParams) (interface{}, error) {
 	ctx := p.Context
-	c, err := connection.NewClient(ctx)
+	c, err := getClient()
 	if err != nil {
 		return nil, err
 	}
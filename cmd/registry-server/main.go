@@ -60,13 +60,23 @@ func main() {
 	defer listener.Close()
 
 	srv := server.New(config)
-	go srv.Start(context.Background(), listener)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		srv.Start(ctx, listener)
+		close(stopped)
+	}()
 	log.Printf("Listening on %s", listener.Addr())
 
 	// Wait for an interruption signal.
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 	<-done
+
+	// Wait for the server to finish running operations.
+	log.Printf("Shutting down")
+	cancel()
+	<-stopped
 }
 
 func parseConfig(config *server.Config, filepath string) error {
@@ -118,5 +128,21 @@ func validateConfig(c server.Config) error {
 		return fmt.Errorf("invalid project %q: notifications cannot be enabled without GCP project ID", c.ProjectID)
 	}
 
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Quotas.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.RateLimits.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,244 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document describes the resources of a project. Fields that are omitted
+// are left unchanged by apply, so documents can describe only the fields
+// that they manage.
+type Document struct {
+	Project     string               `yaml:"project"`
+	DisplayName *string              `yaml:"display_name"`
+	Description *string              `yaml:"description"`
+	Apis        map[string]*Api      `yaml:"apis"`
+	Artifacts   map[string]*Artifact `yaml:"artifacts"`
+}
+
+// Api describes an API and its children.
+type Api struct {
+	DisplayName        *string              `yaml:"display_name"`
+	Description        *string              `yaml:"description"`
+	Availability       *string              `yaml:"availability"`
+	RecommendedVersion *string              `yaml:"recommended_version"`
+	Labels             map[string]string    `yaml:"labels"`
+	Annotations        map[string]string    `yaml:"annotations"`
+	Versions           map[string]*Version  `yaml:"versions"`
+	Artifacts          map[string]*Artifact `yaml:"artifacts"`
+}
+
+// Version describes an API version and its children.
+type Version struct {
+	DisplayName *string              `yaml:"display_name"`
+	Description *string              `yaml:"description"`
+	State       *string              `yaml:"state"`
+	Labels      map[string]string    `yaml:"labels"`
+	Annotations map[string]string    `yaml:"annotations"`
+	Specs       map[string]*Spec     `yaml:"specs"`
+	Artifacts   map[string]*Artifact `yaml:"artifacts"`
+}
+
+// Spec describes an API spec. Its contents are read from File, which is
+// relative to the document that refers to it.
+type Spec struct {
+	Filename    *string              `yaml:"filename"`
+	Description *string              `yaml:"description"`
+	MimeType    *string              `yaml:"mime_type"`
+	SourceURI   *string              `yaml:"source_uri"`
+	File        string               `yaml:"file"`
+	Labels      map[string]string    `yaml:"labels"`
+	Annotations map[string]string    `yaml:"annotations"`
+	Artifacts   map[string]*Artifact `yaml:"artifacts"`
+
+	contents []byte
+}
+
+// Artifact describes an artifact. Its contents are given as text or read
+// from File, which is relative to the document that refers to it.
+type Artifact struct {
+	MimeType string  `yaml:"mime_type"`
+	Contents *string `yaml:"contents"`
+	File     string  `yaml:"file"`
+
+	contents []byte
+}
+
+// Read reads the documents in a YAML file or in the YAML files of a
+// directory and its subdirectories. Documents that describe the same
+// project are merged, and defaultProject is used for documents that
+// don't name a project.
+func Read(path, defaultProject string) ([]*Document, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(p); !info.IsDir() && (p == path || ext == ".yaml" || ext == ".yml") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*Document, 0)
+	for _, file := range files {
+		doc, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if doc.Project == "" {
+			doc.Project = defaultProject
+		}
+		if doc.Project == "" {
+			return nil, fmt.Errorf("%s: no project, set one in the file or with --project", file)
+		}
+		docs = append(docs, doc)
+	}
+	return merge(docs)
+}
+
+func readFile(file string) (*Document, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	// Contents are read now so that files are resolved relative to their documents.
+	dir := filepath.Dir(file)
+	read := func(name string) ([]byte, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		return b, nil
+	}
+	readArtifacts := func(artifacts map[string]*Artifact) error {
+		for _, a := range artifacts {
+			if a == nil {
+				return fmt.Errorf("%s: artifacts must have contents or a file", file)
+			} else if a.File != "" && a.Contents != nil {
+				return fmt.Errorf("%s: artifacts can't have both contents and a file", file)
+			} else if a.File != "" {
+				if a.contents, err = read(a.File); err != nil {
+					return err
+				}
+			} else if a.Contents != nil {
+				a.contents = []byte(*a.Contents)
+			}
+		}
+		return nil
+	}
+
+	if err := readArtifacts(doc.Artifacts); err != nil {
+		return nil, err
+	}
+	for apiID, api := range doc.Apis {
+		if api == nil {
+			api = &Api{}
+			doc.Apis[apiID] = api
+		}
+		if err := readArtifacts(api.Artifacts); err != nil {
+			return nil, err
+		}
+		for versionID, version := range api.Versions {
+			if version == nil {
+				version = &Version{}
+				api.Versions[versionID] = version
+			}
+			if err := readArtifacts(version.Artifacts); err != nil {
+				return nil, err
+			}
+			for specID, spec := range version.Specs {
+				if spec == nil {
+					spec = &Spec{}
+					version.Specs[specID] = spec
+				}
+				if spec.File != "" {
+					if spec.contents, err = read(spec.File); err != nil {
+						return nil, err
+					}
+				}
+				if err := readArtifacts(spec.Artifacts); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return doc, nil
+}
+
+// merge combines documents that describe the same project. Each API and
+// project artifact must be described by only one document.
+func merge(docs []*Document) ([]*Document, error) {
+	merged := make([]*Document, 0)
+	byProject := make(map[string]*Document)
+	for _, doc := range docs {
+		m, ok := byProject[doc.Project]
+		if !ok {
+			byProject[doc.Project] = doc
+			merged = append(merged, doc)
+			continue
+		}
+
+		if doc.DisplayName != nil {
+			m.DisplayName = doc.DisplayName
+		}
+		if doc.Description != nil {
+			m.Description = doc.Description
+		}
+		if doc.Apis != nil && m.Apis == nil {
+			m.Apis = make(map[string]*Api)
+		}
+		for id, api := range doc.Apis {
+			if _, ok := m.Apis[id]; ok {
+				return nil, fmt.Errorf("projects/%s/apis/%s is described more than once", doc.Project, id)
+			}
+			m.Apis[id] = api
+		}
+		if doc.Artifacts != nil && m.Artifacts == nil {
+			m.Artifacts = make(map[string]*Artifact)
+		}
+		for id, artifact := range doc.Artifacts {
+			if _, ok := m.Artifacts[id]; ok {
+				return nil, fmt.Errorf("projects/%s/artifacts/%s is described more than once", doc.Project, id)
+			}
+			m.Artifacts[id] = artifact
+		}
+	}
+	return merged, nil
+}
+
+// isRevision returns true for spec IDs that name revisions, such as
+// those written by "registry export yaml". Revisions can't be applied.
+func isRevision(id string) bool {
+	return strings.Contains(id, "@")
+}
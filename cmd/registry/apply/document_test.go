@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Setup: MkdirAll() returned error: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("Setup: WriteFile() returned error: %s", err)
+		}
+	}
+	return root
+}
+
+func TestRead(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"petstore/api.yaml": `
+project: demo
+apis:
+  petstore:
+    display_name: Petstore
+    labels:
+      team: pets
+    versions:
+      1.0.0:
+        specs:
+          openapi.yaml:
+            file: openapi.yaml
+          openapi.yaml@abc:
+  empty:
+`,
+		"petstore/openapi.yaml": "openapi: 3.0.0",
+		"artifacts.yml": `
+artifacts:
+  style:
+    mime_type: text/plain
+    contents: lowerCamelCase
+`,
+		"README.md": "ignored",
+	})
+
+	docs, err := Read(root, "demo")
+	if err != nil {
+		t.Fatalf("Read() returned error: %s", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Read() returned %d documents, want 1 merged document", len(docs))
+	}
+	doc := docs[0]
+	if doc.Project != "demo" {
+		t.Errorf("Read() returned project %q, want %q", doc.Project, "demo")
+	}
+	if got := len(doc.Apis); got != 2 {
+		t.Errorf("Read() returned %d apis, want 2", got)
+	}
+	if doc.Apis["empty"] == nil {
+		t.Errorf("Read() returned nil for an api without fields, want an empty api")
+	}
+	specs := doc.Apis["petstore"].Versions["1.0.0"].Specs
+	if got := string(specs["openapi.yaml"].contents); got != "openapi: 3.0.0" {
+		t.Errorf("Read() returned spec contents %q, want %q", got, "openapi: 3.0.0")
+	}
+	if !isRevision("openapi.yaml@abc") || isRevision("openapi.yaml") {
+		t.Errorf("isRevision() doesn't recognize revision IDs")
+	}
+	if got := string(doc.Artifacts["style"].contents); got != "lowerCamelCase" {
+		t.Errorf("Read() returned artifact contents %q, want %q", got, "lowerCamelCase")
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		files   map[string]string
+		project string
+	}{
+		{
+			desc:  "no project",
+			files: map[string]string{"a.yaml": "apis:\n  a:\n"},
+		},
+		{
+			desc: "duplicate api",
+			files: map[string]string{
+				"a.yaml": "apis:\n  a:\n",
+				"b.yaml": "apis:\n  a:\n",
+			},
+			project: "demo",
+		},
+		{
+			desc:    "missing file",
+			files:   map[string]string{"a.yaml": "artifacts:\n  x:\n    file: missing.txt\n"},
+			project: "demo",
+		},
+		{
+			desc:    "contents and file",
+			files:   map[string]string{"a.yaml": "artifacts:\n  x:\n    contents: x\n    file: a.yaml\n"},
+			project: "demo",
+		},
+		{
+			desc:    "invalid yaml",
+			files:   map[string]string{"a.yaml": "apis: ["},
+			project: "demo",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if _, err := Read(writeFiles(t, test.files), test.project); err == nil {
+				t.Errorf("Read() succeeded, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Action is the kind of a change.
+type Action int
+
+const (
+	Create Action = iota
+	Update
+	Delete
+)
+
+func (a Action) String() string {
+	switch a {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	default:
+		return "delete"
+	}
+}
+
+// Change is a change to a resource. Diff describes the fields that are set
+// by creates and updates, e.g. `state: "DESIGN" => "PRODUCTION"`.
+type Change struct {
+	Action Action
+	Name   string
+	Diff   []string
+
+	apply func(context.Context, connection.Client) error
+}
+
+// Plan is a list of changes that make the registry match a set of documents.
+// Changes are ordered so that parents are created before their children.
+type Plan struct {
+	Changes []*Change
+}
+
+// NewPlan compares documents with the registry. If prune is true, resources
+// in collections that documents describe, but that aren't in the documents,
+// are deleted.
+func NewPlan(ctx context.Context, client connection.Client, docs []*Document, prune bool) (*Plan, error) {
+	p := &planner{ctx: ctx, client: client, prune: prune, plan: &Plan{}}
+	for _, doc := range docs {
+		if err := p.project(doc); err != nil {
+			return nil, err
+		}
+	}
+	return p.plan, nil
+}
+
+// Apply makes the changes in a plan, stopping at the first error.
+func (p *Plan) Apply(ctx context.Context, client connection.Client) error {
+	for _, c := range p.Changes {
+		if err := c.apply(ctx, client); err != nil {
+			return fmt.Errorf("failed to %s %s: %s", c.Action, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Write describes a plan.
+func (p *Plan) Write(w io.Writer) {
+	if len(p.Changes) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	counts := map[Action]int{}
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		fmt.Fprintf(w, "%s %s %s\n", map[Action]string{Create: "+", Update: "~", Delete: "-"}[c.Action], c.Action, c.Name)
+		for _, d := range c.Diff {
+			fmt.Fprintf(w, "    %s\n", d)
+		}
+	}
+	fmt.Fprintf(w, "%d changes: %d to create, %d to update, %d to delete.\n",
+		len(p.Changes), counts[Create], counts[Update], counts[Delete])
+}
+
+type planner struct {
+	ctx    context.Context
+	client connection.Client
+	prune  bool
+	plan   *Plan
+}
+
+func (p *planner) add(c *Change) {
+	p.plan.Changes = append(p.plan.Changes, c)
+}
+
+func (p *planner) project(doc *Document) error {
+	name := "projects/" + doc.Project
+	want := &rpc.Project{Name: name}
+	d := &diff{}
+	d.str("display_name", "", doc.DisplayName, &want.DisplayName)
+	d.str("description", "", doc.Description, &want.Description)
+
+	live, err := p.client.GetProject(p.ctx, &rpc.GetProjectRequest{Name: name})
+	created := isNotFound(err)
+	if created {
+		p.add(&Change{Action: Create, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.CreateProject(ctx, &rpc.CreateProjectRequest{ProjectId: doc.Project, Project: want})
+			return err
+		}})
+	} else if err != nil {
+		return err
+	} else {
+		d = &diff{}
+		d.str("display_name", live.GetDisplayName(), doc.DisplayName, &want.DisplayName)
+		d.str("description", live.GetDescription(), doc.Description, &want.Description)
+		if len(d.lines) > 0 {
+			p.add(&Change{Action: Update, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+				_, err := c.UpdateProject(ctx, &rpc.UpdateProjectRequest{Project: want, UpdateMask: d.mask()})
+				return err
+			}})
+		}
+	}
+
+	for _, id := range sortedKeys(doc.Apis) {
+		if err := p.api(name, id, doc.Apis[id], created); err != nil {
+			return err
+		}
+	}
+	if doc.Apis != nil && !created && p.prune {
+		it := p.client.ListApis(p.ctx, &rpc.ListApisRequest{Parent: name})
+		for api, err := it.Next(); err != iterator.Done; api, err = it.Next() {
+			if err != nil {
+				return err
+			}
+			if name := api.GetName(); doc.Apis[id(name)] == nil {
+				p.delete(name, func(ctx context.Context, c connection.Client) error {
+					return c.DeleteApi(ctx, &rpc.DeleteApiRequest{Name: name})
+				})
+			}
+		}
+	}
+	return p.artifacts(name, doc.Artifacts, created)
+}
+
+func (p *planner) api(parent, apiID string, api *Api, parentCreated bool) error {
+	name := parent + "/apis/" + apiID
+	want := &rpc.Api{Name: name}
+	live := &rpc.Api{}
+	created := parentCreated
+	if !created {
+		var err error
+		live, err = p.client.GetApi(p.ctx, &rpc.GetApiRequest{Name: name})
+		if created = isNotFound(err); created {
+			live = &rpc.Api{}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	d := &diff{}
+	d.str("display_name", live.GetDisplayName(), api.DisplayName, &want.DisplayName)
+	d.str("description", live.GetDescription(), api.Description, &want.Description)
+	d.str("availability", live.GetAvailability(), api.Availability, &want.Availability)
+	d.str("recommended_version", live.GetRecommendedVersion(), api.RecommendedVersion, &want.RecommendedVersion)
+	d.labels("labels", live.GetLabels(), api.Labels, &want.Labels)
+	d.labels("annotations", live.GetAnnotations(), api.Annotations, &want.Annotations)
+	if created {
+		p.add(&Change{Action: Create, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.CreateApi(ctx, &rpc.CreateApiRequest{Parent: parent, ApiId: apiID, Api: want})
+			return err
+		}})
+	} else if len(d.lines) > 0 {
+		p.add(&Change{Action: Update, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.UpdateApi(ctx, &rpc.UpdateApiRequest{Api: want, UpdateMask: d.mask()})
+			return err
+		}})
+	}
+
+	for _, id := range sortedKeys(api.Versions) {
+		if err := p.version(name, id, api.Versions[id], created); err != nil {
+			return err
+		}
+	}
+	if api.Versions != nil && !created && p.prune {
+		it := p.client.ListApiVersions(p.ctx, &rpc.ListApiVersionsRequest{Parent: name})
+		for version, err := it.Next(); err != iterator.Done; version, err = it.Next() {
+			if err != nil {
+				return err
+			}
+			if name := version.GetName(); api.Versions[id(name)] == nil {
+				p.delete(name, func(ctx context.Context, c connection.Client) error {
+					return c.DeleteApiVersion(ctx, &rpc.DeleteApiVersionRequest{Name: name})
+				})
+			}
+		}
+	}
+	return p.artifacts(name, api.Artifacts, created)
+}
+
+func (p *planner) version(parent, versionID string, version *Version, parentCreated bool) error {
+	name := parent + "/versions/" + versionID
+	want := &rpc.ApiVersion{Name: name}
+	live := &rpc.ApiVersion{}
+	created := parentCreated
+	if !created {
+		var err error
+		live, err = p.client.GetApiVersion(p.ctx, &rpc.GetApiVersionRequest{Name: name})
+		if created = isNotFound(err); created {
+			live = &rpc.ApiVersion{}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	d := &diff{}
+	d.str("display_name", live.GetDisplayName(), version.DisplayName, &want.DisplayName)
+	d.str("description", live.GetDescription(), version.Description, &want.Description)
+	d.str("state", live.GetState(), version.State, &want.State)
+	d.labels("labels", live.GetLabels(), version.Labels, &want.Labels)
+	d.labels("annotations", live.GetAnnotations(), version.Annotations, &want.Annotations)
+	if created {
+		p.add(&Change{Action: Create, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.CreateApiVersion(ctx, &rpc.CreateApiVersionRequest{Parent: parent, ApiVersionId: versionID, ApiVersion: want})
+			return err
+		}})
+	} else if len(d.lines) > 0 {
+		p.add(&Change{Action: Update, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.UpdateApiVersion(ctx, &rpc.UpdateApiVersionRequest{ApiVersion: want, UpdateMask: d.mask()})
+			return err
+		}})
+	}
+
+	for _, id := range sortedKeys(version.Specs) {
+		if isRevision(id) {
+			continue
+		}
+		if err := p.spec(name, id, version.Specs[id], created); err != nil {
+			return err
+		}
+	}
+	if version.Specs != nil && !created && p.prune {
+		it := p.client.ListApiSpecs(p.ctx, &rpc.ListApiSpecsRequest{Parent: name})
+		for spec, err := it.Next(); err != iterator.Done; spec, err = it.Next() {
+			if err != nil {
+				return err
+			}
+			if name := spec.GetName(); version.Specs[id(name)] == nil {
+				p.delete(name, func(ctx context.Context, c connection.Client) error {
+					return c.DeleteApiSpec(ctx, &rpc.DeleteApiSpecRequest{Name: name})
+				})
+			}
+		}
+	}
+	return p.artifacts(name, version.Artifacts, created)
+}
+
+func (p *planner) spec(parent, specID string, spec *Spec, parentCreated bool) error {
+	name := parent + "/specs/" + specID
+	want := &rpc.ApiSpec{Name: name}
+	live := &rpc.ApiSpec{}
+	created := parentCreated
+	if !created {
+		var err error
+		live, err = p.client.GetApiSpec(p.ctx, &rpc.GetApiSpecRequest{Name: name})
+		if created = isNotFound(err); created {
+			live = &rpc.ApiSpec{}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	d := &diff{}
+	d.str("filename", live.GetFilename(), spec.Filename, &want.Filename)
+	d.str("description", live.GetDescription(), spec.Description, &want.Description)
+	d.str("mime_type", live.GetMimeType(), spec.MimeType, &want.MimeType)
+	d.str("source_uri", live.GetSourceUri(), spec.SourceURI, &want.SourceUri)
+	d.labels("labels", live.GetLabels(), spec.Labels, &want.Labels)
+	d.labels("annotations", live.GetAnnotations(), spec.Annotations, &want.Annotations)
+	if spec.File != "" {
+		d.contents(live.GetHash(), spec.File, spec.contents, &want.Contents)
+	}
+	if created {
+		p.add(&Change{Action: Create, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.CreateApiSpec(ctx, &rpc.CreateApiSpecRequest{Parent: parent, ApiSpecId: specID, ApiSpec: want})
+			return err
+		}})
+	} else if len(d.lines) > 0 {
+		p.add(&Change{Action: Update, Name: name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			_, err := c.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{ApiSpec: want, UpdateMask: d.mask()})
+			return err
+		}})
+	}
+	return p.artifacts(name, spec.Artifacts, created)
+}
+
+// artifacts plans changes to the artifacts of a parent. Artifacts are
+// replaced when their MIME types or contents change.
+func (p *planner) artifacts(parent string, artifacts map[string]*Artifact, parentCreated bool) error {
+	live := make(map[string]*rpc.Artifact)
+	if !parentCreated && (len(artifacts) > 0 || (artifacts != nil && p.prune)) {
+		it := p.client.ListArtifacts(p.ctx, &rpc.ListArtifactsRequest{Parent: parent})
+		for artifact, err := it.Next(); err != iterator.Done; artifact, err = it.Next() {
+			if err != nil {
+				return err
+			}
+			live[id(artifact.GetName())] = artifact
+		}
+	}
+
+	for _, artifactID := range sortedKeys(artifacts) {
+		artifact := artifacts[artifactID]
+		want := &rpc.Artifact{
+			Name:     parent + "/artifacts/" + artifactID,
+			MimeType: artifact.MimeType,
+			Contents: artifact.contents,
+		}
+		current, ok := live[artifactID]
+		if !ok {
+			current = &rpc.Artifact{}
+		}
+		d := &diff{}
+		d.str("mime_type", current.GetMimeType(), &artifact.MimeType, &want.MimeType)
+		source := artifact.File
+		if source == "" {
+			source = "contents"
+		}
+		d.contents(current.GetHash(), source, artifact.contents, &want.Contents)
+		if ok && len(d.lines) == 0 {
+			continue
+		}
+		action := Update
+		if !ok {
+			action = Create
+		}
+		p.add(&Change{Action: action, Name: want.Name, Diff: d.lines, apply: func(ctx context.Context, c connection.Client) error {
+			return core.SetArtifact(ctx, c, want)
+		}})
+	}
+
+	if artifacts != nil && p.prune {
+		for _, artifactID := range sortedKeys(live) {
+			if _, ok := artifacts[artifactID]; !ok {
+				name := live[artifactID].GetName()
+				p.delete(name, func(ctx context.Context, c connection.Client) error {
+					return c.DeleteArtifact(ctx, &rpc.DeleteArtifactRequest{Name: name})
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (p *planner) delete(name string, apply func(context.Context, connection.Client) error) {
+	p.add(&Change{Action: Delete, Name: name, apply: apply})
+}
+
+// diff describes fields that are set by a change and builds its update mask.
+type diff struct {
+	lines []string
+	paths []string
+}
+
+// str sets a string field if it is described and has changed.
+func (d *diff) str(field, live string, want *string, dst *string) {
+	if want == nil || *want == live {
+		return
+	}
+	*dst = *want
+	d.add(field, fmt.Sprintf("%s: %q => %q", field, live, *want))
+}
+
+// labels sets a map field if it is described and has changed.
+func (d *diff) labels(field string, live, want map[string]string, dst *map[string]string) {
+	if want == nil {
+		return
+	}
+	lines := make([]string, 0)
+	for _, k := range sortedKeys(want) {
+		if v, ok := live[k]; !ok {
+			lines = append(lines, fmt.Sprintf("%s.%s: %q", field, k, want[k]))
+		} else if v != want[k] {
+			lines = append(lines, fmt.Sprintf("%s.%s: %q => %q", field, k, v, want[k]))
+		}
+	}
+	for _, k := range sortedKeys(live) {
+		if _, ok := want[k]; !ok {
+			lines = append(lines, fmt.Sprintf("%s.%s: removed", field, k))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	*dst = want
+	d.add(field, lines...)
+}
+
+// contents sets contents if their hash differs from a stored hash.
+func (d *diff) contents(liveHash, source string, want []byte, dst *[]byte) {
+	if hash(want) == liveHash {
+		return
+	}
+	*dst = want
+	d.add("contents", fmt.Sprintf("contents: %d bytes from %s", len(want), source))
+}
+
+func (d *diff) add(path string, lines ...string) {
+	d.paths = append(d.paths, path)
+	d.lines = append(d.lines, lines...)
+}
+
+func (d *diff) mask() *fieldmaskpb.FieldMask {
+	return &fieldmaskpb.FieldMask{Paths: d.paths}
+}
+
+// hash returns the hash that the registry stores for contents.
+func hash(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+func isNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// id returns the last segment of a resource name.
+func id(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// sortedKeys returns the keys of a map in order, so that plans are stable.
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	switch x := m.(type) {
+	case map[string]*Api:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	case map[string]*Version:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	case map[string]*Spec:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	case map[string]*Artifact:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	case map[string]*rpc.Artifact:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	case map[string]string:
+		for k := range x {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
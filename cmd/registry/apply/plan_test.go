@@ -0,0 +1,99 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	state := "production"
+	same := "Petstore"
+	var gotState, gotName string
+	var gotLabels map[string]string
+	var gotContents []byte
+
+	d := &diff{}
+	d.str("state", "design", &state, &gotState)
+	d.str("display_name", "Petstore", &same, &gotName)
+	d.str("description", "unmanaged", nil, &gotName)
+	d.labels("labels",
+		map[string]string{"team": "pets", "tier": "1", "old": "x"},
+		map[string]string{"team": "pets", "tier": "2", "new": "y"},
+		&gotLabels)
+	d.contents(hash([]byte("a")), "openapi.yaml", []byte("a"), &gotContents)
+
+	wantLines := []string{
+		`state: "design" => "production"`,
+		`labels.new: "y"`,
+		`labels.tier: "1" => "2"`,
+		`labels.old: removed`,
+	}
+	if diff := cmp.Diff(wantLines, d.lines); diff != "" {
+		t.Errorf("diff returned unexpected lines (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"state", "labels"}, d.mask().GetPaths()); diff != "" {
+		t.Errorf("diff returned unexpected mask (-want +got):\n%s", diff)
+	}
+	if gotState != state || gotName != "" || gotLabels["new"] != "y" || gotContents != nil {
+		t.Errorf("diff set unexpected fields: %q %q %v %q", gotState, gotName, gotLabels, gotContents)
+	}
+
+	d = &diff{}
+	d.contents("", "openapi.yaml", []byte("openapi: 3.0.0"), &gotContents)
+	if want := []string{"contents: 14 bytes from openapi.yaml"}; !cmp.Equal(want, d.lines) {
+		t.Errorf("diff returned %v for changed contents, want %v", d.lines, want)
+	}
+}
+
+func TestPlanWrite(t *testing.T) {
+	tests := []struct {
+		desc    string
+		changes []*Change
+		want    string
+	}{
+		{
+			desc: "no changes",
+			want: "No changes.\n",
+		},
+		{
+			desc: "changes",
+			changes: []*Change{
+				{Action: Create, Name: "projects/demo/apis/a", Diff: []string{`display_name: "" => "A"`}},
+				{Action: Update, Name: "projects/demo/apis/b", Diff: []string{`labels.team: "x"`}},
+				{Action: Delete, Name: "projects/demo/apis/c"},
+			},
+			want: "+ create projects/demo/apis/a\n" +
+				"    display_name: \"\" => \"A\"\n" +
+				"~ update projects/demo/apis/b\n" +
+				"    labels.team: \"x\"\n" +
+				"- delete projects/demo/apis/c\n" +
+				"3 changes: 1 to create, 1 to update, 1 to delete.\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			(&Plan{Changes: test.changes}).Write(&out)
+			if diff := cmp.Diff(test.want, out.String()); diff != "" {
+				t.Errorf("Write() printed unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
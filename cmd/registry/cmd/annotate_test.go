@@ -47,9 +47,12 @@ func TestAnnotate(t *testing.T) {
 	}
 	defer registryClient.Close()
 	// Clear the test project.
-	err = registryClient.DeleteProject(ctx, &rpc.DeleteProjectRequest{
+	op, err := registryClient.DeleteProject(ctx, &rpc.DeleteProjectRequest{
 		Name: projectName,
 	})
+	if err == nil {
+		err = op.Wait(ctx)
+	}
 	if err != nil && status.Code(err) != codes.NotFound {
 		t.Fatalf("error deleting test project: %+v", err)
 	}
@@ -170,7 +173,10 @@ func TestAnnotate(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: projectName,
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		if err != nil {
 			t.Fatalf("failed to delete test project: %s", err)
 		}
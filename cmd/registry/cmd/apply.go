@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/apigee/registry/cmd/registry/apply"
+	"github.com/apigee/registry/connection"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile    string
+	applyProject string
+	applyDryRun  bool
+	applyPrune   bool
+)
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "YAML file or directory of YAML files to apply")
+	applyCmd.Flags().StringVar(&applyProject, "project", "", "Project of documents that don't name one (default is the project of the current profile)")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print changes without making them")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Delete resources that aren't described in collections that are")
+	_ = applyCmd.MarkFlagRequired("file")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Make the API Registry match YAML descriptions of APIs",
+	Long: `Make the API Registry match YAML descriptions of APIs.
+
+Documents describe a project and its APIs, versions, specs, labels,
+annotations, and artifacts. Spec and artifact contents can be read from
+files that are named relative to the document. Fields that are omitted
+are left unchanged, and with --prune, resources that aren't described
+are deleted from the collections that are described. For example:
+
+  project: demo
+  apis:
+    petstore:
+      display_name: Swagger Petstore
+      labels:
+        team: pets
+      versions:
+        1.0.0:
+          state: production
+          specs:
+            openapi.yaml:
+              mime_type: application/x.openapi;version=3.0.0
+              file: petstore/openapi.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.TODO()
+		docs, err := apply.Read(applyFile, defaultProjectID(applyProject))
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		plan, err := apply.NewPlan(ctx, client, docs, applyPrune)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		plan.Write(os.Stdout)
+		if applyDryRun {
+			return
+		}
+		if err := plan.Apply(ctx, client); err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+	},
+}
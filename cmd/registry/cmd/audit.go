@@ -0,0 +1,61 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/server/names"
+	"github.com/spf13/cobra"
+)
+
+var auditFilter string
+var auditDetail bool
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().StringVar(&auditFilter, "filter", "", "Filter option to send with list calls")
+	auditCmd.Flags().BoolVar(&auditDetail, "detail", false, "Print all fields of each event")
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit PROJECT",
+	Short: "List recorded changes to resources in a project",
+	Long: "List recorded changes to resources in a project, oldest first.\n" +
+		"Use projects/- to list changes in all projects.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.TODO()
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		project, err := names.ParseProject(args[0])
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		handler := core.PrintAuditEvent
+		if auditDetail {
+			handler = core.PrintAuditEventDetail
+		}
+		err = core.ListAuditEvents(ctx, client, project, auditFilter, handler)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+	},
+}
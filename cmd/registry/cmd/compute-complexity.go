@@ -135,14 +135,14 @@ func (task *computeComplexityTask) Run() error {
 	} else {
 		return fmt.Errorf("we don't know how to summarize %s", spec.Name)
 	}
-	subject := spec.GetName()
+	subject := core.SpecArtifactParent(spec.GetName())
 	messageData, err := proto.Marshal(complexity)
 	artifact := &rpc.Artifact{
 		Name:     subject + "/artifacts/" + relation,
 		MimeType: core.MimeTypeForMessageType("gnostic.metrics.Complexity"),
 		Contents: messageData,
 	}
-	err = core.SetArtifact(task.ctx, task.client, artifact)
+	err = setResultArtifact(task.ctx, task.client, artifact)
 	if err != nil {
 		return err
 	}
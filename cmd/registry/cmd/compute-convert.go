@@ -0,0 +1,256 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/apigee/registry/cmd/registry/conversion"
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	discovery_v1 "github.com/googleapis/gnostic/discovery"
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// convertedFromAnnotation is the annotation of converted specs that names
+// the spec revision that they were converted from.
+const convertedFromAnnotation = "converted-from"
+
+func init() {
+	computeCmd.AddCommand(computeConvertCmd)
+	computeConvertCmd.Flags().String("to", "openapi-v3", "style to convert specs to (openapi-v3, openapi-v2)")
+}
+
+var computeConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert API specs to other styles",
+	Long: `Convert API specs to other styles.
+
+OpenAPI v2 and v3 specs are converted to each other, Discovery documents are
+converted to either version of OpenAPI, and zipped protos are converted to
+OpenAPI v3 using their HTTP annotations. Each converted spec is stored in the
+same version as its source, named after the source with its last extension
+replaced by the target style (e.g. discovery.openapi-v3.yaml), and annotated
+with the revision that it was converted from (converted-from). Specs with
+that name that weren't converted from the source aren't overwritten. Specs
+that are already in the target style or that were themselves converted are
+skipped, as are specs whose current revisions have already been converted.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		to, err := cmd.LocalFlags().GetString("to")
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		if to != "openapi-v3" && to != "openapi-v2" {
+			log.Fatalf("Unsupported target style %q (use openapi-v3 or openapi-v2)", to)
+		}
+		ctx := context.TODO()
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		// Initialize task queue.
+		taskQueue := make(chan core.Task, 1024)
+		workerCount := 16
+		for i := 0; i < workerCount; i++ {
+			core.WaitGroup().Add(1)
+			go core.Worker(ctx, taskQueue)
+		}
+		// Generate tasks.
+		name := args[0]
+		if m := names.SpecRegexp().FindStringSubmatch(name); m != nil {
+			err = core.ListSpecs(ctx, client, m, computeFilter, func(spec *rpc.ApiSpec) {
+				taskQueue <- &computeConvertTask{
+					ctx:      ctx,
+					client:   client,
+					specName: spec.Name,
+					to:       to,
+				}
+			})
+			if err != nil {
+				log.Fatalf("%s", err.Error())
+			}
+			close(taskQueue)
+			core.WaitGroup().Wait()
+		}
+	},
+}
+
+type computeConvertTask struct {
+	ctx      context.Context
+	client   connection.Client
+	specName string
+	to       string
+}
+
+func (task *computeConvertTask) String() string {
+	return fmt.Sprintf("compute convert %s to %s", task.specName, task.to)
+}
+
+func (task *computeConvertTask) Run() error {
+	spec, err := task.client.GetApiSpec(task.ctx, &rpc.GetApiSpecRequest{
+		Name: task.specName,
+	})
+	if err != nil {
+		return err
+	}
+	if _, ok := spec.GetAnnotations()[convertedFromAnnotation]; ok {
+		return nil
+	}
+	mimeType := spec.GetMimeType()
+	if (task.to == "openapi-v3" && core.IsOpenAPIv3(mimeType)) ||
+		(task.to == "openapi-v2" && core.IsOpenAPIv2(mimeType)) {
+		return nil
+	}
+
+	source := core.SpecArtifactParent(spec.GetName()) + "@" + spec.GetRevisionId()
+	name := convertedSpecName(spec.GetName(), task.to)
+	current, err := task.client.GetApiSpec(task.ctx, &rpc.GetApiSpecRequest{
+		Name: name,
+	})
+	exists := err == nil
+	if err != nil && !core.NotFound(err) {
+		return err
+	}
+	if exists && current.GetAnnotations()[convertedFromAnnotation] == source {
+		return nil
+	}
+	// Specs whose names differ only in their extensions are converted into
+	// the same spec, which is only replaced by conversions of its source.
+	if exists && convertedFrom(current) != core.SpecArtifactParent(spec.GetName()) {
+		return fmt.Errorf("can't convert %s to %s, which wasn't converted from it", spec.GetName(), name)
+	}
+
+	data, err := core.GetBytesForSpec(task.ctx, task.client, spec)
+	if err != nil {
+		return err
+	}
+	doc, err := convertSpec(data, mimeType, task.to)
+	if err != nil {
+		return fmt.Errorf("error converting %s: %s", spec.GetName(), err.Error())
+	}
+	contents, err := doc.YAMLValue("")
+	if err != nil {
+		return err
+	}
+	contents, err = core.GZippedBytes(contents)
+	if err != nil {
+		return err
+	}
+	version := "3"
+	if task.to == "openapi-v2" {
+		version = "2"
+	}
+	log.Printf("converting %s to %s", source, name)
+	converted := &rpc.ApiSpec{
+		Name:     name,
+		MimeType: core.OpenAPIMimeType("+gzip", version),
+		Contents: contents,
+		Annotations: map[string]string{
+			convertedFromAnnotation: source,
+		},
+	}
+	if exists {
+		_, err = task.client.UpdateApiSpec(task.ctx, &rpc.UpdateApiSpecRequest{
+			ApiSpec:    converted,
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents", "mime_type", "annotations"}},
+		})
+	} else {
+		_, err = task.client.CreateApiSpec(task.ctx, &rpc.CreateApiSpecRequest{
+			Parent:    path.Dir(path.Dir(name)),
+			ApiSpecId: path.Base(name),
+			ApiSpec:   converted,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return printResult(map[string]interface{}{
+		"name":          name,
+		"mimeType":      converted.GetMimeType(),
+		"convertedFrom": source,
+	})
+}
+
+// convertedSpecName returns the name of the spec that a spec is converted
+// into, a sibling named after the spec and the style, e.g. converting
+// "specs/discovery.json" to openapi-v3 gives "specs/discovery.openapi-v3.yaml".
+// Only the last extension is replaced, so that specs with the same base name
+// and different extensions, such as "library.v1.json" and "library.v2.json",
+// are converted into different specs.
+func convertedSpecName(name, to string) string {
+	name = core.SpecArtifactParent(name)
+	dir, id := path.Dir(name), path.Base(name)
+	return dir + "/" + strings.TrimSuffix(id, path.Ext(id)) + "." + to + ".yaml"
+}
+
+// convertedFrom returns the name of the spec that a spec was converted from,
+// without its revision, or the empty string if the spec wasn't converted.
+func convertedFrom(spec *rpc.ApiSpec) string {
+	from := spec.GetAnnotations()[convertedFromAnnotation]
+	if i := strings.Index(from, "@"); i >= 0 {
+		from = from[:i]
+	}
+	return from
+}
+
+// convertedDocument is implemented by the gnostic OpenAPI documents.
+type convertedDocument interface {
+	YAMLValue(string) ([]byte, error)
+}
+
+// convertSpec converts the contents of a spec with the specified MIME type
+// to a document in the style "openapi-v3" or "openapi-v2".
+func convertSpec(data []byte, mimeType, to string) (convertedDocument, error) {
+	switch {
+	case core.IsOpenAPIv2(mimeType) && to == "openapi-v3":
+		doc, err := openapi_v2.ParseDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		return conversion.OpenAPIv2ToOpenAPIv3(doc)
+	case core.IsOpenAPIv3(mimeType) && to == "openapi-v2":
+		doc, err := openapi_v3.ParseDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		return conversion.OpenAPIv3ToOpenAPIv2(doc)
+	case core.IsDiscovery(mimeType):
+		doc, err := discovery_v1.ParseDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		if to == "openapi-v2" {
+			return conversion.DiscoveryToOpenAPIv2(doc)
+		}
+		return conversion.DiscoveryToOpenAPIv3(doc)
+	case core.IsProto(mimeType) && core.IsZipArchive(mimeType) && to == "openapi-v3":
+		files, err := core.UnzipArchiveToFiles(data)
+		if err != nil {
+			return nil, err
+		}
+		return conversion.ProtosToOpenAPIv3(files)
+	}
+	return nil, fmt.Errorf("can't convert %s to %s", mimeType, to)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/apigee/registry/rpc"
+)
+
+func TestConvertedSpecName(t *testing.T) {
+	const version = "projects/p/apis/a/versions/v/specs/"
+	tests := []struct {
+		name, to, want string
+	}{
+		{version + "discovery.json", "openapi-v3", version + "discovery.openapi-v3.yaml"},
+		{version + "discovery.json@r1", "openapi-v2", version + "discovery.openapi-v2.yaml"},
+		{version + "protos.zip", "openapi-v3", version + "protos.openapi-v3.yaml"},
+		{version + "openapi", "openapi-v2", version + "openapi.openapi-v2.yaml"},
+		{version + "library.v1.json", "openapi-v3", version + "library.v1.openapi-v3.yaml"},
+	}
+	for _, test := range tests {
+		if got := convertedSpecName(test.name, test.to); got != test.want {
+			t.Errorf("convertedSpecName(%q, %q) returned %q, want %q", test.name, test.to, got, test.want)
+		}
+	}
+}
+
+func TestConvertedSpecNameCollisions(t *testing.T) {
+	const version = "projects/p/apis/a/versions/v/specs/"
+	v1 := convertedSpecName(version+"library.v1.json", "openapi-v3")
+	v2 := convertedSpecName(version+"library.v2.json", "openapi-v3")
+	if v1 == v2 {
+		t.Errorf("library.v1.json and library.v2.json are both converted to %q", v1)
+	}
+
+	// Specs that differ only in their extensions are converted into the same
+	// spec, which is only replaced by conversions of the spec it came from.
+	json, yaml := version+"library.json", version+"library.yaml"
+	if convertedSpecName(json, "openapi-v2") != convertedSpecName(yaml, "openapi-v2") {
+		t.Fatalf("library.json and library.yaml are converted to different specs")
+	}
+	converted := &rpc.ApiSpec{Annotations: map[string]string{convertedFromAnnotation: json + "@r1"}}
+	if from := convertedFrom(converted); from != json {
+		t.Errorf("convertedFrom() returned %q, want %q", from, json)
+	}
+	if from := convertedFrom(&rpc.ApiSpec{}); from != "" {
+		t.Errorf("convertedFrom() of an uploaded spec returned %q, want none", from)
+	}
+}
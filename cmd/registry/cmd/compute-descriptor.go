@@ -95,7 +95,7 @@ func (task *computeDescriptorTask) Run() error {
 	if err != nil {
 		return nil
 	}
-	subject := spec.GetName()
+	subject := core.SpecArtifactParent(spec.GetName())
 	var typeURL string
 	var document proto.Message
 	if core.IsOpenAPIv2(spec.GetMimeType()) {
@@ -130,5 +130,5 @@ func (task *computeDescriptorTask) Run() error {
 		MimeType: core.MimeTypeForMessageType(typeURL),
 		Contents: messageData,
 	}
-	return core.SetArtifact(task.ctx, task.client, artifact)
+	return setResultArtifact(task.ctx, task.client, artifact)
 }
@@ -213,8 +213,15 @@ func (task *computeDetailsTask) Run() error {
 	} else {
 		return fmt.Errorf("we don't know how to compute the title of %s", task.apiName)
 	}
-	if request != nil {
-		_, err = task.client.UpdateApi(task.ctx, request)
+	if request == nil {
+		return nil
+	}
+	if _, err := task.client.UpdateApi(task.ctx, request); err != nil {
+		return err
 	}
-	return err
+	return printResult(map[string]interface{}{
+		"name":        request.Api.GetName(),
+		"displayName": request.Api.GetDisplayName(),
+		"description": request.Api.GetDescription(),
+	})
 }
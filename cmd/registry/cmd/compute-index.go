@@ -101,14 +101,14 @@ func (task *computeIndexTask) Run() error {
 	} else {
 		return fmt.Errorf("we don't know how to compute the index of %s", spec.Name)
 	}
-	subject := spec.GetName()
+	subject := core.SpecArtifactParent(spec.GetName())
 	messageData, err := proto.Marshal(index)
 	artifact := &rpc.Artifact{
 		Name:     subject + "/artifacts/" + relation,
 		MimeType: core.MimeTypeForMessageType("google.cloud.apigee.registry.applications.v1alpha1.Index"),
 		Contents: messageData,
 	}
-	err = core.SetArtifact(task.ctx, task.client, artifact)
+	err = setResultArtifact(task.ctx, task.client, artifact)
 	if err != nil {
 		return err
 	}
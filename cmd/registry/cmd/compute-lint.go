@@ -20,6 +20,7 @@ import (
 	"log"
 
 	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/cmd/registry/rules"
 	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
 	"github.com/apigee/registry/server/names"
@@ -29,7 +30,8 @@ import (
 
 func init() {
 	computeCmd.AddCommand(computeLintCmd)
-	computeLintCmd.Flags().String("linter", "", "name of linter to use (aip, spectral, gnostic)")
+	computeLintCmd.Flags().String("linter", "", "name of linter to use (aip, rules, gnostic)")
+	computeLintCmd.Flags().String("ruleset", "", "YAML file of Spectral rules for the rules linter (default is the built-in ruleset)")
 }
 
 var computeLintCmd = &cobra.Command{
@@ -41,6 +43,16 @@ var computeLintCmd = &cobra.Command{
 		if err != nil { // ignore errors
 			linter = ""
 		}
+		var ruleset *rules.Ruleset
+		if filename, _ := cmd.LocalFlags().GetString("ruleset"); filename != "" {
+			if linter != "rules" {
+				log.Fatalf("--ruleset can only be used with --linter=rules")
+			}
+			ruleset, err = rules.ReadRuleset(filename)
+			if err != nil {
+				log.Fatalf("%s", err.Error())
+			}
+		}
 		ctx := context.TODO()
 		client, err := connection.NewClient(ctx)
 		if err != nil {
@@ -63,6 +75,7 @@ var computeLintCmd = &cobra.Command{
 					client:   client,
 					specName: spec.Name,
 					linter:   linter,
+					ruleset:  ruleset,
 				}
 			})
 			if err != nil {
@@ -79,6 +92,7 @@ type computeLintTask struct {
 	client   connection.Client
 	specName string
 	linter   string
+	ruleset  *rules.Ruleset
 }
 
 func (task *computeLintTask) String() string {
@@ -110,7 +124,7 @@ func (task *computeLintTask) Run() error {
 		}
 		relation = lintRelation(task.linter)
 		log.Printf("computing %s/artifacts/%s", spec.Name, relation)
-		lint, err = core.NewLintFromOpenAPI(spec.Name, data, task.linter)
+		lint, err = core.NewLintFromOpenAPI(spec.Name, data, task.linter, task.ruleset)
 		if err != nil {
 			return fmt.Errorf("error processing OpenAPI: %s (%s)", spec.Name, err.Error())
 		}
@@ -130,14 +144,14 @@ func (task *computeLintTask) Run() error {
 	} else {
 		return fmt.Errorf("we don't know how to lint %s", spec.Name)
 	}
-	subject := spec.GetName()
+	subject := core.SpecArtifactParent(spec.GetName())
 	messageData, err := proto.Marshal(lint)
 	artifact := &rpc.Artifact{
 		Name:     subject + "/artifacts/" + relation,
 		MimeType: core.MimeTypeForMessageType("google.cloud.apigee.registry.applications.v1alpha1.Lint"),
 		Contents: messageData,
 	}
-	err = core.SetArtifact(task.ctx, task.client, artifact)
+	err = setResultArtifact(task.ctx, task.client, artifact)
 	if err != nil {
 		return err
 	}
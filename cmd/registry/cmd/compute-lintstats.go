@@ -30,7 +30,7 @@ import (
 
 func init() {
 	computeCmd.AddCommand(computeLintStatsCmd)
-	computeLintStatsCmd.Flags().String("linter", "", "name of linter associated with these lintstats (aip, spectral, gnostic)")
+	computeLintStatsCmd.Flags().String("linter", "", "name of linter associated with these lintstats (aip, rules, gnostic)")
 }
 
 func lintStatsRelation(linter string) string {
@@ -61,7 +61,7 @@ var computeLintStatsCmd = &cobra.Command{
 				fmt.Printf("%s\n", spec.Name)
 				// get the lint results
 				request := rpc.GetArtifactContentsRequest{
-					Name: spec.Name + "/artifacts/" + lintRelation(linter) + "/contents",
+					Name: core.SpecArtifactParent(spec.GetName()) + "/artifacts/" + lintRelation(linter) + "/contents",
 				}
 				contents, err := client.GetArtifactContents(ctx, &request)
 				if contents == nil {
@@ -81,7 +81,7 @@ var computeLintStatsCmd = &cobra.Command{
 				lintStats := computeLintStats(lint)
 				{
 					// store the lintstats artifact
-					subject := spec.GetName()
+					subject := core.SpecArtifactParent(spec.GetName())
 					relation := lintStatsRelation(linter)
 					messageData, err := proto.Marshal(lintStats)
 					artifact := &rpc.Artifact{
@@ -89,7 +89,7 @@ var computeLintStatsCmd = &cobra.Command{
 						MimeType: core.MimeTypeForMessageType("google.cloud.apigee.registry.applications.v1alpha1.LintStats"),
 						Contents: messageData,
 					}
-					err = core.SetArtifact(ctx, client, artifact)
+					err = setResultArtifact(ctx, client, artifact)
 					if err != nil {
 						log.Printf("%+v", err)
 						return
@@ -141,7 +141,7 @@ var computeLintStatsCmd = &cobra.Command{
 						MimeType: core.MimeTypeForMessageType("google.cloud.apigee.registry.applications.v1alpha1.LintStats"),
 						Contents: messageData,
 					}
-					err = core.SetArtifact(ctx, client, artifact)
+					err = setResultArtifact(ctx, client, artifact)
 					if err != nil {
 						log.Printf("%+v", err)
 						return
@@ -101,14 +101,14 @@ func (task *computeReferencesTask) Run() error {
 	} else {
 		return fmt.Errorf("we don't know how to compute references for %s of type %s", spec.Name, spec.MimeType)
 	}
-	subject := spec.Name
+	subject := core.SpecArtifactParent(spec.GetName())
 	messageData, err := proto.Marshal(references)
 	artifact := &rpc.Artifact{
 		Name:     subject + "/artifacts/" + relation,
 		MimeType: core.MimeTypeForMessageType("google.cloud.apigee.registry.applications.v1alpha1.References"),
 		Contents: messageData,
 	}
-	err = core.SetArtifact(task.ctx, task.client, artifact)
+	err = setResultArtifact(task.ctx, task.client, artifact)
 	if err != nil {
 		return err
 	}
@@ -26,6 +26,8 @@ func init() {
 }
 
 var computeCmd = &cobra.Command{
-	Use:   "compute",
-	Short: "Compute properties of resources in the API Registry",
+	Use:               "compute",
+	Short:             "Compute properties of resources in the API Registry",
+	PersistentPreRun:  startResults,
+	PersistentPostRun: finishResults,
 }
@@ -0,0 +1,42 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configDeleteCmd)
+}
+
+var configDeleteCmd = &cobra.Command{
+	Use:   "delete PROFILE",
+	Short: "Delete a profile",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, config := readConfig()
+		if _, ok := config.Profiles[args[0]]; !ok {
+			log.Fatalf("Profile %q is not in %s", args[0], path)
+		}
+		delete(config.Profiles, args[0])
+		if config.Current == args[0] {
+			config.Current = ""
+		}
+		writeConfig(path, config)
+	},
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles, marking the current profile with *",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, config := readConfig()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+		for _, name := range config.ProfileNames() {
+			current := " "
+			if name == config.Current {
+				current = "*"
+			}
+			profile := config.Profiles[name]
+			fmt.Fprintf(w, "%s %s\t%s\t%s\n", current, name, profile.Address, profile.Project)
+		}
+	},
+}
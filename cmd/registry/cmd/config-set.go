@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/apigee/registry/connection"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configProfile connection.Profile
+	configRetries int
+)
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+	flags := configSetCmd.Flags()
+	flags.StringVar(&configProfile.Address, "address", "", "Service address, e.g. localhost:8080")
+	flags.BoolVar(&configProfile.Insecure, "insecure", false, "Connect over HTTP")
+	flags.StringVar(&configProfile.Token, "token", "", "Bearer token")
+	flags.StringVar(&configProfile.TokenCommand, "token-command", "", "Shell command that prints a bearer token")
+	flags.StringVar(&configProfile.CAFile, "ca-file", "", "PEM file of CA certificates used to verify the server")
+	flags.StringVar(&configProfile.CertFile, "cert-file", "", "PEM file containing a client certificate")
+	flags.StringVar(&configProfile.KeyFile, "key-file", "", "PEM file containing the client certificate's key")
+	flags.StringVar(&configProfile.ServerName, "server-name", "", "Server name used to verify the server certificate")
+	flags.StringVar(&configProfile.Project, "project", "", "Default project of commands that take one")
+	flags.DurationVar(&configProfile.Timeout, "timeout", 0, "Deadline of calls, e.g. 30s")
+	flags.IntVar(&configRetries, "retries", connection.DefaultRetries, "Retries of idempotent calls that fail because the server is unavailable or overloaded")
+	flags.DurationVar(&configProfile.RetryDelay, "retry-delay", connection.DefaultRetryDelay, "Delay before the first retry, doubled for each retry")
+	flags.DurationVar(&configProfile.Keepalive, "keepalive", 0, "Interval of keepalive pings, e.g. 1m")
+	flags.IntVar(&configProfile.MaxMessageSize, "max-message-size", 0, "Maximum size in bytes of messages sent and received")
+	flags.IntVar(&configProfile.MaxConcurrentCalls, "max-concurrent-calls", 0, "Maximum number of calls in progress at once")
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set PROFILE",
+	Short: "Create a profile or change the settings of a profile",
+	Long: `Create a profile or change the settings of a profile.
+
+Only the settings that are given as flags are changed. The first profile
+that is created becomes the current profile.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, config := readConfig()
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]*connection.Profile)
+		}
+		name := args[0]
+		profile, ok := config.Profiles[name]
+		if !ok {
+			profile = &connection.Profile{}
+			config.Profiles[name] = profile
+		}
+
+		flags := cmd.Flags()
+		set := func(flag string, apply func()) {
+			if flags.Changed(flag) {
+				apply()
+			}
+		}
+		set("address", func() { profile.Address = configProfile.Address })
+		set("insecure", func() { profile.Insecure = configProfile.Insecure })
+		set("token", func() { profile.Token = configProfile.Token })
+		set("token-command", func() { profile.TokenCommand = configProfile.TokenCommand })
+		set("ca-file", func() { profile.CAFile = configProfile.CAFile })
+		set("cert-file", func() { profile.CertFile = configProfile.CertFile })
+		set("key-file", func() { profile.KeyFile = configProfile.KeyFile })
+		set("server-name", func() { profile.ServerName = configProfile.ServerName })
+		set("project", func() { profile.Project = configProfile.Project })
+		set("timeout", func() { profile.Timeout = configProfile.Timeout })
+		set("retries", func() { profile.Retries = &configRetries })
+		set("retry-delay", func() { profile.RetryDelay = configProfile.RetryDelay })
+		set("keepalive", func() { profile.Keepalive = configProfile.Keepalive })
+		set("max-message-size", func() { profile.MaxMessageSize = configProfile.MaxMessageSize })
+		set("max-concurrent-calls", func() { profile.MaxConcurrentCalls = configProfile.MaxConcurrentCalls })
+
+		if config.Current == "" {
+			config.Current = name
+		}
+		writeConfig(path, config)
+		log.Printf("Updated profile %s in %s", name, path)
+	},
+}
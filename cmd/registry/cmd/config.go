@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"log"
+
+	"github.com/apigee/registry/connection"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage profiles of connection settings",
+	Long: `Manage profiles of connection settings.
+
+Profiles are stored in ~/.config/registry/config.yaml, or in the file named by
+APG_REGISTRY_CONFIG. Clients use the current profile, or the profile named by
+APG_REGISTRY_PROFILE, and APG_REGISTRY_* environment variables override the
+settings of profiles.`,
+}
+
+// readConfig reads the configuration file, exiting if it can't be read.
+func readConfig() (string, *connection.Config) {
+	path, err := connection.ConfigPath()
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	config, err := connection.ReadConfig(path)
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	return path, config
+}
+
+// writeConfig writes the configuration file, exiting if it can't be written.
+func writeConfig(path string, config *connection.Config) {
+	if err := config.Write(path); err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// defaultProjectID returns projectID, or the project of the active profile
+// if projectID is empty.
+func defaultProjectID(projectID string) string {
+	if projectID != "" {
+		return projectID
+	}
+	settings, err := connection.ActiveSettings()
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	return settings.Project
+}
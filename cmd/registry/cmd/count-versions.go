@@ -107,5 +107,8 @@ func (task *countVersionsTask) Run() error {
 	if err != nil {
 		return err
 	}
-	return nil
+	return printResult(map[string]interface{}{
+		"name":         task.apiName,
+		"versionCount": count,
+	})
 }
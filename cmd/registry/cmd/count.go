@@ -26,6 +26,8 @@ func init() {
 }
 
 var countCmd = &cobra.Command{
-	Use:   "count",
-	Short: "Count quantities in the API Registry",
+	Use:               "count",
+	Short:             "Count quantities in the API Registry",
+	PersistentPreRun:  startResults,
+	PersistentPostRun: finishResults,
 }
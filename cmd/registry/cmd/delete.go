@@ -83,6 +83,12 @@ func (task *deleteTask) Run() error {
 		return task.client.DeleteApiVersion(task.ctx, &rpc.DeleteApiVersionRequest{Name: task.resourceName})
 	case "spec":
 		return task.client.DeleteApiSpec(task.ctx, &rpc.DeleteApiSpecRequest{Name: task.resourceName})
+	case "revision":
+		return task.client.DeleteApiSpecRevision(task.ctx, &rpc.DeleteApiSpecRevisionRequest{Name: task.resourceName})
+	case "deployment":
+		return task.client.DeleteApiDeployment(task.ctx, &rpc.DeleteApiDeploymentRequest{Name: task.resourceName})
+	case "deployment-revision":
+		return task.client.DeleteApiDeploymentRevision(task.ctx, &rpc.DeleteApiDeploymentRevisionRequest{Name: task.resourceName})
 	case "artifact":
 		return task.client.DeleteArtifact(task.ctx, &rpc.DeleteArtifactRequest{Name: task.resourceName})
 	default:
@@ -102,6 +108,8 @@ func matchAndHandleDeleteCmd(
 		return deleteVersions(ctx, client, m, deleteFilter, taskQueue)
 	} else if m := names.SpecRegexp().FindStringSubmatch(name); m != nil {
 		return deleteSpecs(ctx, client, m, deleteFilter, taskQueue)
+	} else if m := names.DeploymentRegexp().FindStringSubmatch(name); m != nil {
+		return deleteDeployments(ctx, client, m, deleteFilter, taskQueue)
 	} else if m := names.ArtifactRegexp().FindStringSubmatch(name); m != nil {
 		return deleteArtifacts(ctx, client, m, deleteFilter, taskQueue)
 	} else {
@@ -147,12 +155,38 @@ func deleteSpecs(
 	segments []string,
 	filterFlag string,
 	taskQueue chan core.Task) error {
+	// Names with a revision ID or tag delete only that revision.
+	kind := "spec"
+	if len(segments) > 6 && segments[6] != "" {
+		kind = "revision"
+	}
 	return core.ListSpecs(ctx, client, segments, filterFlag, func(spec *rpc.ApiSpec) {
 		taskQueue <- &deleteTask{
 			ctx:          ctx,
 			client:       client,
 			resourceName: spec.Name,
-			resourceKind: "spec",
+			resourceKind: kind,
+		}
+	})
+}
+
+func deleteDeployments(
+	ctx context.Context,
+	client *gapic.RegistryClient,
+	segments []string,
+	filterFlag string,
+	taskQueue chan core.Task) error {
+	// Names with a revision ID or tag delete only that revision.
+	kind := "deployment"
+	if len(segments) > 5 && segments[5] != "" {
+		kind = "deployment-revision"
+	}
+	return core.ListDeployments(ctx, client, segments, filterFlag, func(deployment *rpc.ApiDeployment) {
+		taskQueue <- &deleteTask{
+			ctx:          ctx,
+			client:       client,
+			resourceName: deployment.Name,
+			resourceKind: kind,
 		}
 	})
 }
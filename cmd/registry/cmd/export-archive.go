@@ -0,0 +1,71 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/server/names"
+	"github.com/spf13/cobra"
+)
+
+var exportArchiveOutput string
+
+func init() {
+	exportCmd.AddCommand(exportArchiveCmd)
+	exportArchiveCmd.Flags().StringVarP(&exportArchiveOutput, "output", "o", "", "Archive file to write (default PROJECT_ID.zip)")
+}
+
+var exportArchiveCmd = &cobra.Command{
+	Use:   "archive PROJECT",
+	Short: "Export a project and all of its resources to an archive",
+	Long: "Export a project to a zip archive containing its APIs, versions, all spec and\n" +
+		"deployment revisions and their tags, artifacts, labels, and annotations. Spec contents\n" +
+		"are archived as they are stored, including gzipped contents. Archives can be imported\n" +
+		"into another registry with \"registry import archive\".",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.TODO()
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		project, err := names.ParseProject(args[0])
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		output := exportArchiveOutput
+		if output == "" {
+			output = project.ProjectID + ".zip"
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		if err := core.ExportArchive(ctx, client, project, f); err != nil {
+			f.Close()
+			os.Remove(output)
+			log.Fatalf("Failed to export %s: %s", project, err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		log.Printf("exported %s to %s", project, output)
+	},
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExportImportArchive(t *testing.T) {
+	const (
+		sourceID       = "archive-source-test"
+		targetID       = "archive-target-test"
+		specPath       = "/apis/my-api/versions/v1/specs/openapi.yaml"
+		gzipSpecPath   = "/apis/my-api/versions/v1/specs/protos.zip"
+		deploymentPath = "/apis/my-api/deployments/prod"
+	)
+
+	ctx := context.Background()
+	client, err := connection.NewClient(ctx)
+	if err != nil {
+		t.Fatalf("Setup: Failed to create client: %s", err)
+	}
+	defer client.Close()
+
+	for _, id := range []string{sourceID, targetID} {
+		op, err := client.DeleteProject(ctx, &rpc.DeleteProjectRequest{Name: "projects/" + id})
+		if err == nil {
+			err = op.Wait(ctx)
+		}
+		if err != nil && status.Code(err) != codes.NotFound {
+			t.Fatalf("Setup: Failed to delete project: %s", err)
+		}
+	}
+
+	// Setup
+	if _, err := client.CreateProject(ctx, &rpc.CreateProjectRequest{
+		ProjectId: sourceID,
+		Project:   &rpc.Project{DisplayName: "Source"},
+	}); err != nil {
+		t.Fatalf("Setup: Failed to create project: %s", err)
+	}
+	if _, err := client.CreateApi(ctx, &rpc.CreateApiRequest{
+		Parent: "projects/" + sourceID,
+		ApiId:  "my-api",
+		Api:    &rpc.Api{Labels: map[string]string{"team": "blue"}},
+	}); err != nil {
+		t.Fatalf("Setup: Failed to create api: %s", err)
+	}
+	if _, err := client.CreateApiVersion(ctx, &rpc.CreateApiVersionRequest{
+		Parent:       "projects/" + sourceID + "/apis/my-api",
+		ApiVersionId: "v1",
+		ApiVersion:   &rpc.ApiVersion{Annotations: map[string]string{"owner": "someone"}},
+	}); err != nil {
+		t.Fatalf("Setup: Failed to create version: %s", err)
+	}
+	for i, contents := range []string{"openapi: 3.0.0", "openapi: 3.0.1", "openapi: 3.0.2"} {
+		spec, err := client.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+			ApiSpec: &rpc.ApiSpec{
+				Name:     "projects/" + sourceID + specPath,
+				MimeType: "application/x.openapi;version=3",
+				Contents: []byte(contents),
+			},
+			AllowMissing: true,
+		})
+		if err != nil {
+			t.Fatalf("Setup: Failed to update spec: %s", err)
+		}
+		if i == 1 {
+			if _, err := client.TagApiSpecRevision(ctx, &rpc.TagApiSpecRevisionRequest{
+				Name: spec.GetName() + "@" + spec.GetRevisionId(),
+				Tag:  "prod",
+			}); err != nil {
+				t.Fatalf("Setup: Failed to tag spec revision: %s", err)
+			}
+		}
+	}
+	gzipped, err := core.GZippedBytes([]byte("syntax = \"proto3\";"))
+	if err != nil {
+		t.Fatalf("Setup: Failed to gzip contents: %s", err)
+	}
+	gzipSpec, err := client.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+		ApiSpec: &rpc.ApiSpec{
+			Name:     "projects/" + sourceID + gzipSpecPath,
+			MimeType: "application/x.protobuf+gzip",
+			Contents: gzipped,
+		},
+		AllowMissing: true,
+	})
+	if err != nil {
+		t.Fatalf("Setup: Failed to update spec: %s", err)
+	}
+	for i, environment := range []string{"staging", "production"} {
+		deployment, err := client.UpdateApiDeployment(ctx, &rpc.UpdateApiDeploymentRequest{
+			ApiDeployment: &rpc.ApiDeployment{
+				Name:            "projects/" + sourceID + deploymentPath,
+				ApiSpecRevision: gzipSpec.GetName() + "@" + gzipSpec.GetRevisionId(),
+				Environment:     environment,
+			},
+			AllowMissing: true,
+		})
+		if err != nil {
+			t.Fatalf("Setup: Failed to update deployment: %s", err)
+		}
+		if i == 0 {
+			if _, err := client.TagApiDeploymentRevision(ctx, &rpc.TagApiDeploymentRevisionRequest{
+				Name: deployment.GetName() + "@" + deployment.GetRevisionId(),
+				Tag:  "first",
+			}); err != nil {
+				t.Fatalf("Setup: Failed to tag deployment revision: %s", err)
+			}
+		}
+	}
+	if _, err := client.CreateArtifact(ctx, &rpc.CreateArtifactRequest{
+		Parent:     "projects/" + sourceID + specPath,
+		ArtifactId: "notes",
+		Artifact:   &rpc.Artifact{MimeType: "text/plain", Contents: []byte("hello")},
+	}); err != nil {
+		t.Fatalf("Setup: Failed to create artifact: %s", err)
+	}
+
+	// Execute
+	var buf bytes.Buffer
+	if err := core.ExportArchive(ctx, client, names.Project{ProjectID: sourceID}, &buf); err != nil {
+		t.Fatalf("ExportArchive() returned error: %s", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ExportArchive() wrote an invalid archive: %s", err)
+	}
+	// Importing twice should not duplicate any revisions.
+	for i := 0; i < 2; i++ {
+		if err := core.ImportArchive(ctx, client, r, targetID); err != nil {
+			t.Fatalf("ImportArchive() returned error: %s", err)
+		}
+	}
+
+	// Verify
+	revisions := func(projectID, path string) []*rpc.ApiSpec {
+		var list []*rpc.ApiSpec
+		segments := names.SpecRegexp().FindStringSubmatch("projects/" + projectID + path)
+		if err := core.ListSpecRevisions(ctx, client, segments, "", func(spec *rpc.ApiSpec) {
+			list = append(list, spec)
+		}); err != nil {
+			t.Fatalf("ListSpecRevisions() returned error: %s", err)
+		}
+		return list
+	}
+	for _, path := range []string{specPath, gzipSpecPath} {
+		source, target := revisions(sourceID, path), revisions(targetID, path)
+		if len(source) != len(target) {
+			t.Fatalf("Imported %d revisions of %s, expected %d", len(target), path, len(source))
+		}
+		for i := range source {
+			if source[i].GetHash() != target[i].GetHash() {
+				t.Errorf("Imported revision %d of %s has hash %q, expected %q", i, path, target[i].GetHash(), source[i].GetHash())
+			}
+			if source[i].GetMimeType() != target[i].GetMimeType() {
+				t.Errorf("Imported revision %d of %s has MIME type %q, expected %q", i, path, target[i].GetMimeType(), source[i].GetMimeType())
+			}
+			if diff := cmp.Diff(source[i].GetRevisionTags(), target[i].GetRevisionTags()); diff != "" {
+				t.Errorf("Imported revision %d of %s has unexpected tags (-want +got):\n%s", i, path, diff)
+			}
+		}
+	}
+
+	spec, err := client.GetApiSpecContents(ctx, &rpc.GetApiSpecContentsRequest{
+		Name: "projects/" + targetID + gzipSpecPath + "/contents",
+	})
+	if err != nil {
+		t.Fatalf("GetApiSpecContents() returned error: %s", err)
+	}
+	if string(spec.GetData()) != `syntax = "proto3";` {
+		t.Errorf("Imported spec has contents %q, expected %q", spec.GetData(), `syntax = "proto3";`)
+	}
+
+	var deployments []*rpc.ApiDeployment
+	segments := names.DeploymentRegexp().FindStringSubmatch("projects/" + targetID + deploymentPath)
+	if err := core.ListDeploymentRevisions(ctx, client, segments, "", func(deployment *rpc.ApiDeployment) {
+		deployments = append(deployments, deployment)
+	}); err != nil {
+		t.Fatalf("ListDeploymentRevisions() returned error: %s", err)
+	}
+	if len(deployments) != 2 {
+		t.Fatalf("Imported %d deployment revisions, expected 2", len(deployments))
+	}
+	// Revisions are listed newest first.
+	if got := deployments[0].GetEnvironment(); got != "production" {
+		t.Errorf("Imported deployment has environment %q, expected %q", got, "production")
+	}
+	if diff := cmp.Diff([]string{"first"}, deployments[1].GetRevisionTags()); diff != "" {
+		t.Errorf("Imported deployment revision has unexpected tags (-want +got):\n%s", diff)
+	}
+	// Listed revisions are named with their revision IDs.
+	if want := revisions(targetID, gzipSpecPath)[0].GetName(); deployments[0].GetApiSpecRevision() != want {
+		t.Errorf("Imported deployment serves %q, expected %q", deployments[0].GetApiSpecRevision(), want)
+	}
+
+	api, err := client.GetApi(ctx, &rpc.GetApiRequest{Name: "projects/" + targetID + "/apis/my-api"})
+	if err != nil {
+		t.Fatalf("GetApi() returned error: %s", err)
+	}
+	if api.GetLabels()["team"] != "blue" {
+		t.Errorf("Imported api has labels %v, expected team=blue", api.GetLabels())
+	}
+
+	contents, err := client.GetArtifactContents(ctx, &rpc.GetArtifactContentsRequest{
+		Name: "projects/" + targetID + specPath + "/artifacts/notes/contents",
+	})
+	if err != nil {
+		t.Fatalf("GetArtifactContents() returned error: %s", err)
+	}
+	if string(contents.GetData()) != "hello" {
+		t.Errorf("Imported artifact has contents %q, expected %q", contents.GetData(), "hello")
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/apigee/registry/cmd/registry/core"
 	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
 	"github.com/apigee/registry/server/names"
 	"github.com/spf13/cobra"
 )
@@ -48,29 +49,33 @@ var getCmd = &cobra.Command{
 			name = args[0]
 		}
 
+		p := newPrinter("json", false)
 		if m := names.ProjectRegexp().FindStringSubmatch(name); m != nil {
-			_, err = core.GetProject(ctx, client, m, core.PrintProjectDetail)
+			_, err = core.GetProject(ctx, client, m, func(m *rpc.Project) { printMessage(p, m) })
 		} else if m := names.ApiRegexp().FindStringSubmatch(name); m != nil {
-			_, err = core.GetAPI(ctx, client, m, core.PrintAPIDetail)
+			_, err = core.GetAPI(ctx, client, m, func(m *rpc.Api) { printMessage(p, m) })
 		} else if m := names.VersionRegexp().FindStringSubmatch(name); m != nil {
-			_, err = core.GetVersion(ctx, client, m, core.PrintVersionDetail)
+			_, err = core.GetVersion(ctx, client, m, func(m *rpc.ApiVersion) { printMessage(p, m) })
 		} else if m := names.SpecRegexp().FindStringSubmatch(name); m != nil {
 			if getContents {
 				_, err = core.GetSpec(ctx, client, m, getContents, core.PrintSpecContents)
 			} else {
-				_, err = core.GetSpec(ctx, client, m, getContents, core.PrintSpecDetail)
+				_, err = core.GetSpec(ctx, client, m, getContents, func(m *rpc.ApiSpec) { printMessage(p, m) })
 			}
+		} else if m := names.DeploymentRegexp().FindStringSubmatch(name); m != nil {
+			_, err = core.GetDeployment(ctx, client, m, func(m *rpc.ApiDeployment) { printMessage(p, m) })
 		} else if m := names.ArtifactRegexp().FindStringSubmatch(name); m != nil {
 			if getContents {
 				_, err = core.GetArtifact(ctx, client, m, getContents, core.PrintArtifactContents)
 			} else {
-				_, err = core.GetArtifact(ctx, client, m, getContents, core.PrintArtifactDetail)
+				_, err = core.GetArtifact(ctx, client, m, getContents, func(m *rpc.Artifact) { printMessage(p, m) })
 			}
 		} else {
-			log.Printf("Unsupported entity %+v", args)
+			log.Fatalf("Unsupported entity %+v", args)
 		}
 		if err != nil {
-			log.Printf("%s", err.Error())
+			log.Fatalf("%s", err.Error())
 		}
+		flushPrinter(p)
 	},
 }
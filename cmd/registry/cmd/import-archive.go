@@ -0,0 +1,57 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"log"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/spf13/cobra"
+)
+
+var importArchiveProjectID string
+
+func init() {
+	importCmd.AddCommand(importArchiveCmd)
+	importArchiveCmd.Flags().StringVar(&importArchiveProjectID, "project_id", "", "Project to import into (default is the archived project)")
+}
+
+var importArchiveCmd = &cobra.Command{
+	Use:   "archive FILE",
+	Short: "Import a project archive created with \"registry export archive\"",
+	Long: "Import a project archive, recreating its resources and its spec and deployment\n" +
+		"revisions in order. Imports can be repeated: existing resources are updated and\n" +
+		"previously imported revisions are skipped, so a failed import can be resumed by\n" +
+		"running it again.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.TODO()
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		r, err := zip.OpenReader(args[0])
+		if err != nil {
+			log.Fatalf("Failed to open archive: %s", err)
+		}
+		defer r.Close()
+		if err := core.ImportArchive(ctx, client, &r.Reader, importArchiveProjectID); err != nil {
+			log.Fatalf("Failed to import %s: %s", args[0], err)
+		}
+	},
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/apigee/registry/cmd/registry/core"
 	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
 	"github.com/apigee/registry/server/names"
 	"github.com/spf13/cobra"
 )
@@ -42,10 +43,12 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("%s", err.Error())
 		}
-		err = matchAndHandleListCmd(ctx, client, args[0])
+		p := newPrinter("name", true)
+		err = matchAndHandleListCmd(ctx, client, args[0], p)
 		if err != nil {
 			log.Fatalf("%s", err.Error())
 		}
+		flushPrinter(p)
 	},
 }
 
@@ -53,32 +56,43 @@ func matchAndHandleListCmd(
 	ctx context.Context,
 	client connection.Client,
 	name string,
+	p *core.Printer,
 ) error {
+	printProject := func(m *rpc.Project) { printMessage(p, m) }
+	printAPI := func(m *rpc.Api) { printMessage(p, m) }
+	printVersion := func(m *rpc.ApiVersion) { printMessage(p, m) }
+	printSpec := func(m *rpc.ApiSpec) { printMessage(p, m) }
+	printDeployment := func(m *rpc.ApiDeployment) { printMessage(p, m) }
+	printArtifact := func(m *rpc.Artifact) { printMessage(p, m) }
 
 	// First try to match collection names.
 	if m := names.ProjectsRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListProjects(ctx, client, m, listFilter, core.PrintProject)
+		return core.ListProjects(ctx, client, m, listFilter, printProject)
 	} else if m := names.ApisRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListAPIs(ctx, client, m, listFilter, core.PrintAPI)
+		return core.ListAPIs(ctx, client, m, listFilter, printAPI)
 	} else if m := names.VersionsRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListVersions(ctx, client, m, listFilter, core.PrintVersion)
+		return core.ListVersions(ctx, client, m, listFilter, printVersion)
 	} else if m := names.SpecsRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListSpecs(ctx, client, m, listFilter, core.PrintSpec)
+		return core.ListSpecs(ctx, client, m, listFilter, printSpec)
+	} else if m := names.DeploymentsRegexp().FindStringSubmatch(name); m != nil {
+		return core.ListDeployments(ctx, client, m, listFilter, printDeployment)
 	} else if m := names.ArtifactsRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListArtifacts(ctx, client, m, listFilter, false, core.PrintArtifact)
+		return core.ListArtifacts(ctx, client, m, listFilter, false, printArtifact)
 	}
 
 	// Then try to match resource names.
 	if m := names.ProjectRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListProjects(ctx, client, m, listFilter, core.PrintProject)
+		return core.ListProjects(ctx, client, m, listFilter, printProject)
 	} else if m := names.ApiRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListAPIs(ctx, client, m, listFilter, core.PrintAPI)
+		return core.ListAPIs(ctx, client, m, listFilter, printAPI)
 	} else if m := names.VersionRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListVersions(ctx, client, m, listFilter, core.PrintVersion)
+		return core.ListVersions(ctx, client, m, listFilter, printVersion)
 	} else if m := names.SpecRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListSpecs(ctx, client, m, listFilter, core.PrintSpec)
+		return core.ListSpecs(ctx, client, m, listFilter, printSpec)
+	} else if m := names.DeploymentRegexp().FindStringSubmatch(name); m != nil {
+		return core.ListDeployments(ctx, client, m, listFilter, printDeployment)
 	} else if m := names.ArtifactRegexp().FindStringSubmatch(name); m != nil {
-		return core.ListArtifacts(ctx, client, m, listFilter, false, core.PrintArtifact)
+		return core.ListArtifacts(ctx, client, m, listFilter, false, printArtifact)
 	}
 
 	// If nothing matched, return an error.
@@ -0,0 +1,96 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+// newPrinter returns a printer for the --output format, or for a
+// command's default format if none was given.
+func newPrinter(defaultFormat string, list bool) *core.Printer {
+	format := outputFormat
+	if format == "" {
+		format = defaultFormat
+	}
+	p, err := core.NewPrinter(os.Stdout, format, list)
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	return p
+}
+
+// printMessage prints a message, exiting if it can't be printed.
+func printMessage(p *core.Printer, m proto.Message) {
+	if err := p.Print(m); err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// flushPrinter writes any values buffered by a printer.
+func flushPrinter(p *core.Printer) {
+	if p == nil {
+		return
+	}
+	if err := p.Flush(); err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+}
+
+// results prints the results of count and compute commands when an
+// --output format is given. These commands otherwise only log progress.
+var results *core.Printer
+
+func startResults(cmd *cobra.Command, args []string) {
+	if outputFormat != "" {
+		results = newPrinter("", true)
+	}
+}
+
+func finishResults(cmd *cobra.Command, args []string) {
+	flushPrinter(results)
+}
+
+// printResult prints a computed value if results are printed.
+func printResult(value map[string]interface{}) error {
+	if results == nil {
+		return nil
+	}
+	return results.PrintValue(value)
+}
+
+// setResultArtifact stores a computed artifact and, if results are
+// printed, prints it without its contents.
+func setResultArtifact(ctx context.Context, client connection.Client, artifact *rpc.Artifact) error {
+	if err := core.SetArtifact(ctx, client, artifact); err != nil {
+		return err
+	}
+	if results == nil {
+		return nil
+	}
+	return results.Print(&rpc.Artifact{
+		Name:      artifact.GetName(),
+		MimeType:  artifact.GetMimeType(),
+		SizeBytes: int32(len(artifact.GetContents())),
+	})
+}
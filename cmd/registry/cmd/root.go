@@ -18,9 +18,16 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/apigee/registry/cmd/registry/core"
 	"github.com/spf13/cobra"
 )
 
+var outputFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "", "Output format of get, list, count, and compute results: "+core.OutputFormats)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "registry",
 	Short: "A simple and eclectic utility for working with the API Registry",
@@ -28,9 +35,10 @@ var rootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Errors are written to stderr and cause an exit status of 1.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
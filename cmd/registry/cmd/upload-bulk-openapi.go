@@ -28,12 +28,11 @@ import (
 	"github.com/apigee/registry/connection"
 	rpcpb "github.com/apigee/registry/rpc"
 	"github.com/spf13/cobra"
-	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 func init() {
 	uploadBulkCmd.AddCommand(uploadBulkOpenAPICmd)
-	uploadBulkOpenAPICmd.Flags().String("project_id", "", "Project id.")
+	uploadBulkOpenAPICmd.Flags().String("project_id", "", "Project id (default is the project of the current profile).")
 	uploadBulkOpenAPICmd.Flags().String("base_uri", "", "Base to use for setting source_uri fields of uploaded specs.")
 }
 
@@ -47,6 +46,7 @@ var uploadBulkOpenAPICmd = &cobra.Command{
 		if err != nil {
 			log.Fatal(err.Error())
 		}
+		projectID = defaultProjectID(projectID)
 		if projectID == "" {
 			log.Fatal("Please specify a project_id")
 		}
@@ -60,21 +60,17 @@ var uploadBulkOpenAPICmd = &cobra.Command{
 			log.Fatal(err.Error())
 		}
 		core.EnsureProjectExists(ctx, client, projectID)
+		upload := newBulkUpload(ctx, client, cmd, projectID, args)
 		for _, arg := range args {
-			scanDirectoryForOpenAPI(projectID, baseURI, arg)
+			scanDirectoryForOpenAPI(ctx, upload, baseURI, arg)
 		}
+		upload.finish(func(mimeType string) bool {
+			return core.IsOpenAPIv2(mimeType) || core.IsOpenAPIv3(mimeType)
+		})
 	},
 }
 
-func scanDirectoryForOpenAPI(projectID, baseURI, directory string) {
-	ctx := context.TODO()
-
-	client, err := connection.NewClient(ctx)
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(-1)
-	}
-
+func scanDirectoryForOpenAPI(ctx context.Context, upload *bulkUpload, baseURI, directory string) {
 	// create a queue for upload tasks and wait for the workers to finish after filling it.
 	taskQueue := make(chan core.Task, 1024)
 	for i := 0; i < 64; i++ {
@@ -91,9 +87,7 @@ func scanDirectoryForOpenAPI(projectID, baseURI, directory string) {
 		}
 
 		task := &uploadOpenAPITask{
-			ctx:       ctx,
-			client:    client,
-			projectID: projectID,
+			upload:    upload,
 			baseURI:   baseURI,
 			path:      path,
 			directory: directory,
@@ -110,7 +104,7 @@ func scanDirectoryForOpenAPI(projectID, baseURI, directory string) {
 
 		return nil
 	}); err != nil {
-		log.Println(err)
+		upload.fail(err)
 	}
 }
 
@@ -130,13 +124,11 @@ func sanitize(name string) string {
 }
 
 type uploadOpenAPITask struct {
-	ctx       context.Context
-	client    connection.Client
+	upload    *bulkUpload
 	baseURI   string
 	path      string
 	directory string
 	version   string
-	projectID string
 	apiID     string // computed at runtime
 	versionID string // computed at runtime
 	specID    string // computed at runtime
@@ -149,24 +141,26 @@ func (task *uploadOpenAPITask) String() string {
 func (task *uploadOpenAPITask) Run() error {
 	// Populate API path fields using the file's path.
 	if err := task.populateFields(); err != nil {
-		return err
+		task.upload.fail(err)
+		return nil
 	}
 	log.Printf("^^ apis/%s/versions/%s/specs/%s", task.apiID, task.versionID, task.specID)
 
-	// If the API does not exist, create it.
-	if err := task.createAPI(); err != nil {
-		return err
+	contents, err := task.gzipContents()
+	if err != nil {
+		task.upload.fail(err)
+		return nil
 	}
-	// If the API version does not exist, create it.
-	if err := task.createVersion(); err != nil {
-		return err
+	spec := &rpcpb.ApiSpec{
+		MimeType: core.OpenAPIMimeType("+gzip", task.version),
+		Filename: task.fileName(),
+		Contents: contents,
 	}
-	// If the API spec does not exist, create it.
-	if err := task.createSpec(); err != nil {
-		return err
+	if task.baseURI != "" {
+		spec.SourceUri = fmt.Sprintf("%s/%s", task.baseURI, task.apiPath())
 	}
-	// If the API spec needs a new revision, create it.
-	return task.updateSpec()
+	// Create the spec and its parents if they don't exist, or update the spec if its contents changed.
+	return task.upload.upload(task.apiID, task.versionID, &rpcpb.Api{DisplayName: task.apiID}, spec)
 }
 
 func (task *uploadOpenAPITask) populateFields() error {
@@ -188,132 +182,6 @@ func (task *uploadOpenAPITask) populateFields() error {
 	return nil
 }
 
-func (task *uploadOpenAPITask) createAPI() error {
-	if _, err := task.client.GetApi(task.ctx, &rpcpb.GetApiRequest{
-		Name: task.apiName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when API is found without error.
-	}
-
-	response, err := task.client.CreateApi(task.ctx, &rpcpb.CreateApiRequest{
-		Parent: task.projectName(),
-		ApiId:  task.apiID,
-		Api: &rpcpb.Api{
-			DisplayName: task.apiID,
-		},
-	})
-	if err != nil {
-		log.Printf("error %s: %s", task.apiName(), err.Error())
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadOpenAPITask) createVersion() error {
-	if _, err := task.client.GetApiVersion(task.ctx, &rpcpb.GetApiVersionRequest{
-		Name: task.versionName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when version is found without error.
-	}
-
-	response, err := task.client.CreateApiVersion(task.ctx, &rpcpb.CreateApiVersionRequest{
-		Parent:       task.apiName(),
-		ApiVersionId: task.versionID,
-		ApiVersion:   &rpcpb.ApiVersion{},
-	})
-	if err != nil {
-		log.Printf("error %s: %s", task.versionName(), err.Error())
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadOpenAPITask) createSpec() error {
-	contents, err := task.gzipContents()
-	if err != nil {
-		return err
-	}
-
-	if _, err = task.client.GetApiSpec(task.ctx, &rpcpb.GetApiSpecRequest{
-		Name: task.specName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when spec is found without error.
-	}
-
-	request := &rpcpb.CreateApiSpecRequest{
-		Parent:    task.versionName(),
-		ApiSpecId: task.fileName(),
-		ApiSpec: &rpcpb.ApiSpec{
-			MimeType: core.OpenAPIMimeType("+gzip", task.version),
-			Filename: task.fileName(),
-			Contents: contents,
-		},
-	}
-	if task.baseURI != "" {
-		request.ApiSpec.SourceUri = fmt.Sprintf("%s/%s", task.baseURI, task.apiPath())
-	}
-
-	response, err := task.client.CreateApiSpec(task.ctx, request)
-	if err != nil {
-		log.Printf("error %s: %s [contents-length: %d]", task.specName(), err.Error(), len(contents))
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadOpenAPITask) updateSpec() error {
-	contents, err := task.gzipContents()
-	if err != nil {
-		return err
-	}
-
-	spec, err := task.client.GetApiSpec(task.ctx, &rpcpb.GetApiSpecRequest{
-		Name: task.specName(),
-	})
-	if err != nil && !core.NotFound(err) {
-		return err
-	}
-
-	request := &rpcpb.UpdateApiSpecRequest{
-		ApiSpec: &rpcpb.ApiSpec{
-			Name:     task.specName(),
-			Contents: contents,
-		},
-		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents"}},
-	}
-
-	response, err := task.client.UpdateApiSpec(task.ctx, request)
-	if err != nil {
-		log.Printf("error %s: %s [contents-length: %d]", request.ApiSpec.Name, err.Error(), len(contents))
-	} else if response.RevisionId != spec.RevisionId {
-		log.Printf("updated %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadOpenAPITask) projectName() string {
-	return fmt.Sprintf("projects/%s", task.projectID)
-}
-
-func (task *uploadOpenAPITask) apiName() string {
-	return fmt.Sprintf("%s/apis/%s", task.projectName(), task.apiID)
-}
-
-func (task *uploadOpenAPITask) versionName() string {
-	return fmt.Sprintf("%s/versions/%s", task.apiName(), task.versionID)
-}
-
-func (task *uploadOpenAPITask) specName() string {
-	return fmt.Sprintf("%s/specs/%s", task.versionName(), filepath.Base(task.path))
-}
-
 func (task *uploadOpenAPITask) apiPath() string {
 	prefix := task.directory + "/"
 	return strings.TrimPrefix(task.path, prefix)
@@ -28,12 +28,11 @@ import (
 	"github.com/apigee/registry/connection"
 	rpcpb "github.com/apigee/registry/rpc"
 	"github.com/spf13/cobra"
-	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 func init() {
 	uploadBulkCmd.AddCommand(uploadBulkProtosCmd)
-	uploadBulkProtosCmd.Flags().String("project_id", "", "Project id.")
+	uploadBulkProtosCmd.Flags().String("project_id", "", "Project id (default is the project of the current profile).")
 	uploadBulkProtosCmd.Flags().String("base_uri", "", "Base to use for setting source_uri fields of uploaded specs.")
 }
 
@@ -48,6 +47,7 @@ var uploadBulkProtosCmd = &cobra.Command{
 		if err != nil {
 			log.Fatal(err.Error())
 		}
+		projectID = defaultProjectID(projectID)
 		if projectID == "" {
 			log.Fatalf("Please specify a project_id")
 		}
@@ -61,13 +61,15 @@ var uploadBulkProtosCmd = &cobra.Command{
 			log.Fatal(err.Error())
 		}
 		core.EnsureProjectExists(ctx, client, projectID)
+		upload := newBulkUpload(ctx, client, cmd, projectID, args)
 		for _, arg := range args {
-			scanDirectoryForProtos(ctx, client, projectID, baseURI, arg)
+			scanDirectoryForProtos(ctx, upload, baseURI, arg)
 		}
+		upload.finish(core.IsProto)
 	},
 }
 
-func scanDirectoryForProtos(ctx context.Context, client connection.Client, projectID, baseURI, directory string) {
+func scanDirectoryForProtos(ctx context.Context, upload *bulkUpload, baseURI, directory string) {
 	// create a queue for upload tasks and wait for the workers to finish after filling it.
 	taskQueue := make(chan core.Task, 1024)
 	for i := 0; i < 64; i++ {
@@ -90,25 +92,21 @@ func scanDirectoryForProtos(ctx context.Context, client connection.Client, proje
 		}
 
 		taskQueue <- &uploadProtoTask{
-			ctx:       ctx,
-			client:    client,
+			upload:    upload,
 			baseURI:   baseURI,
-			projectID: projectID,
 			path:      filepath,
 			directory: directory,
 		}
 
 		return nil
 	}); err != nil {
-		log.Println(err)
+		upload.fail(err)
 	}
 }
 
 type uploadProtoTask struct {
-	ctx       context.Context
-	client    connection.Client
+	upload    *bulkUpload
 	baseURI   string
-	projectID string
 	path      string
 	directory string
 	apiID     string // computed at runtime
@@ -125,20 +123,21 @@ func (task *uploadProtoTask) Run() error {
 	task.populateFields()
 	log.Printf("^^ apis/%s/versions/%s/specs/%s", task.apiID, task.versionID, task.specID)
 
-	// If the API does not exist, create it.
-	if err := task.createAPI(); err != nil {
-		return err
+	contents, err := task.zipContents()
+	if err != nil {
+		task.upload.fail(err)
+		return nil
 	}
-	// If the API version does not exist, create it.
-	if err := task.createVersion(); err != nil {
-		return err
+	spec := &rpcpb.ApiSpec{
+		MimeType: core.ProtobufMimeType("+zip"),
+		Filename: task.fileName(),
+		Contents: contents,
 	}
-	// If the API spec does not exist, create it.
-	if err := task.createSpec(); err != nil {
-		return err
+	if task.baseURI != "" {
+		spec.SourceUri = fmt.Sprintf("%s/%s", task.baseURI, task.apiPath())
 	}
-	// If the API spec needs a new revision, create it.
-	return task.updateSpec()
+	// Create the spec and its parents if they don't exist, or update the spec if its contents changed.
+	return task.upload.upload(task.apiID, task.versionID, &rpcpb.Api{}, spec)
 }
 
 func (task *uploadProtoTask) populateFields() {
@@ -150,130 +149,6 @@ func (task *uploadProtoTask) populateFields() {
 	task.specID = task.fileName()
 }
 
-func (task *uploadProtoTask) createAPI() error {
-	if _, err := task.client.GetApi(task.ctx, &rpcpb.GetApiRequest{
-		Name: task.apiName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when API is found without error.
-	}
-
-	response, err := task.client.CreateApi(task.ctx, &rpcpb.CreateApiRequest{
-		Parent: task.projectName(),
-		ApiId:  task.apiID,
-		Api:    &rpcpb.Api{},
-	})
-	if err != nil {
-		log.Printf("error %s: %s", task.apiName(), err.Error())
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadProtoTask) createVersion() error {
-	if _, err := task.client.GetApiVersion(task.ctx, &rpcpb.GetApiVersionRequest{
-		Name: task.versionName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when version is found without error.
-	}
-
-	response, err := task.client.CreateApiVersion(task.ctx, &rpcpb.CreateApiVersionRequest{
-		Parent:       task.apiName(),
-		ApiVersionId: task.versionID,
-		ApiVersion:   &rpcpb.ApiVersion{},
-	})
-	if err != nil {
-		log.Printf("error %s: %s", task.versionName(), err.Error())
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadProtoTask) createSpec() error {
-	contents, err := task.zipContents()
-	if err != nil {
-		return err
-	}
-
-	if _, err := task.client.GetApiSpec(task.ctx, &rpcpb.GetApiSpecRequest{
-		Name: task.specName(),
-	}); !core.NotFound(err) {
-		return err // Returns nil when spec is found without error.
-	}
-
-	request := &rpcpb.CreateApiSpecRequest{
-		Parent:    task.versionName(),
-		ApiSpecId: task.fileName(),
-		ApiSpec: &rpcpb.ApiSpec{
-			MimeType: core.ProtobufMimeType("+zip"),
-			Filename: task.fileName(),
-			Contents: contents,
-		},
-	}
-	if task.baseURI != "" {
-		request.ApiSpec.SourceUri = fmt.Sprintf("%s/%s", task.baseURI, task.apiPath())
-	}
-
-	response, err := task.client.CreateApiSpec(task.ctx, request)
-	if err != nil {
-		log.Printf("error %s: %s [contents-length: %d]", task.specName(), err.Error(), len(contents))
-	} else {
-		log.Printf("created %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadProtoTask) updateSpec() error {
-	contents, err := task.zipContents()
-	if err != nil {
-		return err
-	}
-
-	spec, err := task.client.GetApiSpec(task.ctx, &rpcpb.GetApiSpecRequest{
-		Name: task.specName(),
-	})
-	if err != nil && !core.NotFound(err) {
-		return err
-	}
-
-	request := &rpcpb.UpdateApiSpecRequest{
-		ApiSpec: &rpcpb.ApiSpec{
-			Name:     task.specName(),
-			Contents: contents,
-		},
-		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents"}},
-	}
-
-	response, err := task.client.UpdateApiSpec(task.ctx, request)
-	if err != nil {
-		log.Printf("error %s: %s [contents-length: %d]", request.ApiSpec.Name, err.Error(), len(contents))
-	} else if response.RevisionId != spec.RevisionId {
-		log.Printf("updated %s", response.Name)
-	}
-
-	return nil
-}
-
-func (task *uploadProtoTask) projectName() string {
-	return fmt.Sprintf("projects/%s", task.projectID)
-}
-
-func (task *uploadProtoTask) apiName() string {
-	return fmt.Sprintf("%s/apis/%s", task.projectName(), task.apiID)
-}
-
-func (task *uploadProtoTask) versionName() string {
-	return fmt.Sprintf("%s/versions/%s", task.apiName(), task.versionID)
-}
-
-func (task *uploadProtoTask) specName() string {
-	return fmt.Sprintf("%s/specs/%s", task.versionName(), task.specID)
-}
-
 func (task *uploadProtoTask) apiPath() string {
 	prefix := task.directory + "/"
 	return strings.TrimPrefix(task.path, prefix)
@@ -15,14 +15,293 @@
 package cmd
 
 import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	rpcpb "github.com/apigee/registry/rpc"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var (
+	uploadBulkCheckpoint string
+	uploadBulkPrune      bool
 )
 
 func init() {
 	uploadCmd.AddCommand(uploadBulkCmd)
+	uploadBulkCmd.PersistentFlags().StringVar(&uploadBulkCheckpoint, "checkpoint", "",
+		"File that records progress so that an interrupted upload resumes (default is in the user cache directory)")
+	uploadBulkCmd.PersistentFlags().BoolVar(&uploadBulkPrune, "prune", false,
+		"Delete specs that the last finished upload with the same checkpoint uploaded but that weren't uploaded, e.g. because their files were removed")
 }
 
 var uploadBulkCmd = &cobra.Command{
 	Use:   "bulk",
 	Short: "Bulk-upload API specs of selected styles",
+	Long: `Bulk-upload API specs of selected styles.
+
+Specs whose contents match the registry's are skipped. Progress is recorded in
+a checkpoint file, so an interrupted upload that is run again resumes where it
+stopped; the file is removed when an upload finishes without failures.
+
+The names of the specs that a finished upload uploaded are kept next to its
+checkpoint file (in CHECKPOINT.uploaded). With --prune, specs that the last
+finished upload with the same checkpoint uploaded but that weren't uploaded
+again are deleted, so other specs in the project are never pruned.`,
+}
+
+// Outcomes of spec uploads.
+const (
+	uploadCreated   = "created"
+	uploadUpdated   = "updated"
+	uploadUnchanged = "unchanged"
+	uploadFailed    = "failed"
+	uploadDeleted   = "deleted"
+)
+
+// bulkUpload uploads specs, skipping specs that haven't changed, and
+// counts the outcomes of uploads. Its methods can be called by workers.
+type bulkUpload struct {
+	ctx        context.Context
+	client     connection.Client
+	projectID  string
+	checkpoint *core.Checkpoint
+	record     string
+
+	mutex    sync.Mutex
+	uploaded map[string]bool
+	counts   map[string]int
+}
+
+// newBulkUpload starts an upload, resuming from a checkpoint of an earlier
+// upload of the same style, project, and arguments if there is one. Uploads
+// by commands without the --checkpoint flag aren't checkpointed.
+func newBulkUpload(ctx context.Context, client connection.Client, cmd *cobra.Command, projectID string, args []string) *bulkUpload {
+	u := &bulkUpload{
+		ctx:       ctx,
+		client:    client,
+		projectID: projectID,
+		uploaded:  make(map[string]bool),
+		counts:    make(map[string]int),
+	}
+	if cmd.Flag("checkpoint") == nil {
+		return u
+	}
+
+	path := uploadBulkCheckpoint
+	if path == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			log.Fatalf("%s", err.Error())
+		}
+		key := []string{cmd.Name(), projectID}
+		for _, arg := range args {
+			if abs, err := filepath.Abs(arg); err == nil {
+				arg = abs
+			}
+			key = append(key, arg)
+		}
+		sum := sha256.Sum256([]byte(strings.Join(key, "\n")))
+		path = filepath.Join(dir, "registry", fmt.Sprintf("upload-%x", sum[:8]))
+	}
+	checkpoint, err := core.OpenCheckpoint(path)
+	if err != nil {
+		log.Fatalf("%s", err.Error())
+	}
+	if n := checkpoint.Len(); n > 0 {
+		log.Printf("resuming from %s, which records %d uploaded specs", path, n)
+	}
+	u.checkpoint = checkpoint
+	u.record = path + ".uploaded"
+	return u
+}
+
+func (u *bulkUpload) count(outcome string) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.counts[outcome]++
+}
+
+// fail counts a failure that isn't the failure of an upload, such as an
+// error reading a directory, so that prunes are skipped.
+func (u *bulkUpload) fail(err error) {
+	log.Printf("error: %s", err)
+	u.count(uploadFailed)
+}
+
+// upload creates or updates a spec and its parents. Only the contents of
+// existing specs are updated, and specs whose contents haven't changed
+// aren't updated. The API is created with fields from api if it doesn't exist.
+func (u *bulkUpload) upload(apiID, versionID string, api *rpcpb.Api, spec *rpcpb.ApiSpec) error {
+	apiName := fmt.Sprintf("projects/%s/apis/%s", u.projectID, apiID)
+	versionName := fmt.Sprintf("%s/versions/%s", apiName, versionID)
+	spec.Name = fmt.Sprintf("%s/specs/%s", versionName, spec.GetFilename())
+	hash := hashForBytes(spec.GetContents())
+
+	u.mutex.Lock()
+	u.uploaded[spec.Name] = true
+	u.mutex.Unlock()
+
+	outcome, err := u.uploadSpec(apiName, versionName, api, spec, hash)
+	if err != nil {
+		u.count(uploadFailed)
+		return fmt.Errorf("%s: %s [contents-length: %d]", spec.Name, err, len(spec.GetContents()))
+	}
+	if outcome != uploadUnchanged {
+		log.Printf("%s %s", outcome, spec.Name)
+	}
+	u.count(outcome)
+	if u.checkpoint == nil {
+		return nil
+	}
+	return u.checkpoint.Record(spec.Name, hash)
+}
+
+func (u *bulkUpload) uploadSpec(apiName, versionName string, api *rpcpb.Api, spec *rpcpb.ApiSpec, hash string) (string, error) {
+	if u.checkpoint != nil && u.checkpoint.Done(spec.Name, hash) {
+		return uploadUnchanged, nil
+	}
+
+	current, err := u.client.GetApiSpec(u.ctx, &rpcpb.GetApiSpecRequest{Name: spec.Name})
+	if err == nil {
+		if current.GetHash() == hash {
+			return uploadUnchanged, nil
+		}
+		_, err := u.client.UpdateApiSpec(u.ctx, &rpcpb.UpdateApiSpecRequest{
+			ApiSpec: &rpcpb.ApiSpec{
+				Name:     spec.Name,
+				Contents: spec.Contents,
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents"}},
+		})
+		return uploadUpdated, err
+	} else if !core.NotFound(err) {
+		return "", err
+	}
+
+	// Parents are created if they don't exist. Other workers can create
+	// the same parents at the same time, so existing parents aren't errors.
+	if _, err := u.client.CreateApi(u.ctx, &rpcpb.CreateApiRequest{
+		Parent: fmt.Sprintf("projects/%s", u.projectID),
+		ApiId:  filepath.Base(apiName),
+		Api:    api,
+	}); err != nil && status.Code(err) != codes.AlreadyExists {
+		return "", err
+	}
+	if _, err := u.client.CreateApiVersion(u.ctx, &rpcpb.CreateApiVersionRequest{
+		Parent:       apiName,
+		ApiVersionId: filepath.Base(versionName),
+		ApiVersion:   &rpcpb.ApiVersion{},
+	}); err != nil && status.Code(err) != codes.AlreadyExists {
+		return "", err
+	}
+	_, err = u.client.CreateApiSpec(u.ctx, &rpcpb.CreateApiSpecRequest{
+		Parent:    versionName,
+		ApiSpecId: spec.GetFilename(),
+		ApiSpec:   spec,
+	})
+	return uploadCreated, err
+}
+
+// finish prunes specs if --prune was given, prints a summary of the upload,
+// and if there were no failures, records the uploaded specs and removes the
+// checkpoint. Prunes delete specs with MIME types that match a style that
+// the last finished upload uploaded but that weren't uploaded, and are
+// skipped if there were failures.
+func (u *bulkUpload) finish(style func(mimeType string) bool) {
+	prune := uploadBulkPrune && u.checkpoint != nil
+	if prune && u.counts[uploadFailed] == 0 {
+		u.prune(style)
+	} else if prune {
+		log.Printf("not pruning because of failed uploads")
+	}
+
+	summary := fmt.Sprintf("%d created, %d updated, %d unchanged, %d failed",
+		u.counts[uploadCreated], u.counts[uploadUpdated], u.counts[uploadUnchanged], u.counts[uploadFailed])
+	if prune {
+		summary += fmt.Sprintf(", %d deleted", u.counts[uploadDeleted])
+	}
+	fmt.Println(summary)
+
+	if u.checkpoint == nil {
+		return
+	}
+	if u.counts[uploadFailed] > 0 {
+		if err := u.checkpoint.Close(); err != nil {
+			log.Printf("error: %s", err)
+		}
+		return
+	}
+	if err := core.WriteRecord(u.record, u.uploaded); err != nil {
+		log.Printf("error: %s", err)
+	}
+	if err := u.checkpoint.Remove(); err != nil {
+		log.Printf("error: %s", err)
+	}
+}
+
+// prune deletes the specs that the last finished upload recorded but that
+// weren't uploaded. Specs that no longer have a style's MIME types were
+// replaced by other uploads and are kept.
+func (u *bulkUpload) prune(style func(mimeType string) bool) {
+	previous, err := core.ReadRecord(u.record)
+	if err != nil {
+		u.fail(err)
+		return
+	}
+	if len(previous) == 0 {
+		log.Printf("not pruning because %s records no earlier upload", u.record)
+		return
+	}
+	for _, name := range prunedSpecs(previous, u.uploaded) {
+		spec, err := u.client.GetApiSpec(u.ctx, &rpcpb.GetApiSpecRequest{Name: name})
+		if core.NotFound(err) {
+			continue
+		} else if err != nil {
+			u.fail(fmt.Errorf("%s: %s", name, err))
+			continue
+		}
+		if !style(spec.GetMimeType()) {
+			continue
+		}
+		if err := u.client.DeleteApiSpec(u.ctx, &rpcpb.DeleteApiSpecRequest{Name: name}); err != nil {
+			u.fail(fmt.Errorf("%s: %s", name, err))
+			continue
+		}
+		log.Printf("deleted %s", name)
+		u.count(uploadDeleted)
+	}
+}
+
+// prunedSpecs returns the names of specs that were uploaded previously but
+// not again, in order.
+func prunedSpecs(previous, uploaded map[string]bool) []string {
+	names := make([]string, 0)
+	for name := range previous {
+		if !uploaded[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hashForBytes returns the hash that the registry stores for contents.
+func hashForBytes(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))
 }
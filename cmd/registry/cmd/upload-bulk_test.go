@@ -0,0 +1,53 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrunedSpecs(t *testing.T) {
+	previous := map[string]bool{"specs/c": true, "specs/a": true, "specs/b": true}
+	uploaded := map[string]bool{"specs/b": true, "specs/d": true}
+	want := []string{"specs/a", "specs/c"}
+	if diff := cmp.Diff(want, prunedSpecs(previous, uploaded)); diff != "" {
+		t.Errorf("prunedSpecs() returned unexpected names (-want +got):\n%s", diff)
+	}
+}
+
+func TestBulkUploadCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	old := uploadBulkCheckpoint
+	uploadBulkCheckpoint = path
+	defer func() { uploadBulkCheckpoint = old }()
+
+	u := newBulkUpload(context.Background(), nil, uploadReflectionCmd, "p", []string{"localhost:8080"})
+	if u.checkpoint != nil {
+		t.Errorf("newBulkUpload() for %q has a checkpoint, want none", uploadReflectionCmd.Name())
+	}
+
+	u = newBulkUpload(context.Background(), nil, uploadBulkOpenAPICmd, "p", []string{"specs"})
+	if u.checkpoint == nil {
+		t.Fatalf("newBulkUpload() for %q has no checkpoint", uploadBulkOpenAPICmd.Name())
+	}
+	defer u.checkpoint.Close()
+	if u.record != path+".uploaded" {
+		t.Errorf("newBulkUpload() records uploads in %q, want %q", u.record, path+".uploaded")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	rpcpb "github.com/apigee/registry/rpc"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+	"github.com/spf13/cobra"
+)
+
+// addConvertedFlags adds the flags of commands that upload documents
+// converted to OpenAPI.
+func addConvertedFlags(cmd *cobra.Command) {
+	cmd.Flags().String("project_id", "", "Project id (default is the project of the current profile).")
+	cmd.Flags().String("api_id", "", "API id (default is derived from the title of the converted document).")
+	cmd.Flags().String("version_id", "", "Version id (default is derived from the version of the converted document).")
+	cmd.Flags().String("spec_id", "openapi.yaml", "Spec id.")
+}
+
+// convertedSource is a file that was converted to OpenAPI, which is kept as
+// an artifact of the spec that it was converted to.
+type convertedSource struct {
+	artifactID string
+	mimeType   string
+	contents   []byte
+}
+
+// uploadConverted uploads an OpenAPI v3 document that was converted from
+// another format as a spec and sets an artifact of the spec to the source.
+func uploadConverted(cmd *cobra.Command, args []string, doc *openapi_v3.Document, source *convertedSource) {
+	flagset := cmd.LocalFlags()
+	projectID, err := flagset.GetString("project_id")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	projectID = defaultProjectID(projectID)
+	if projectID == "" {
+		log.Fatal("Please specify a project_id")
+	}
+	apiID, err := flagset.GetString("api_id")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if apiID == "" {
+		apiID = sanitize(doc.GetInfo().GetTitle())
+	}
+	versionID, err := flagset.GetString("version_id")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if versionID == "" {
+		versionID = sanitize(doc.GetInfo().GetVersion())
+	}
+	specID, err := flagset.GetString("spec_id")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if apiID == "" || versionID == "" || specID == "" {
+		log.Fatal("Please specify an api_id, version_id, and spec_id")
+	}
+
+	b, err := doc.YAMLValue("")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	contents, err := core.GZippedBytes(b)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	ctx := context.TODO()
+	client, err := connection.NewClient(ctx)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	core.EnsureProjectExists(ctx, client, projectID)
+	upload := newBulkUpload(ctx, client, cmd, projectID, args)
+	spec := &rpcpb.ApiSpec{
+		MimeType: core.OpenAPIMimeType("+gzip", "3"),
+		Filename: specID,
+		Contents: contents,
+	}
+	api := &rpcpb.Api{DisplayName: doc.GetInfo().GetTitle(), Description: doc.GetInfo().GetDescription()}
+	if err := upload.upload(apiID, versionID, api, spec); err != nil {
+		log.Printf("error: %s", err)
+	} else if err := core.SetArtifact(ctx, client, &rpcpb.Artifact{
+		Name:     fmt.Sprintf("%s/artifacts/%s", spec.GetName(), source.artifactID),
+		MimeType: source.mimeType,
+		Contents: source.contents,
+	}); err != nil {
+		upload.fail(err)
+	}
+	upload.finish(core.IsOpenAPIv3)
+}
@@ -176,9 +176,12 @@ func TestUploadCSV(t *testing.T) {
 				t.Fatalf("Setup: Failed to create client: %s", err)
 			}
 
-			err = client.DeleteProject(ctx, &rpc.DeleteProjectRequest{
+			op, err := client.DeleteProject(ctx, &rpc.DeleteProjectRequest{
 				Name: "projects/" + testProject,
 			})
+			if err == nil {
+				err = op.Wait(ctx)
+			}
 			if err != nil && status.Code(err) != codes.NotFound {
 				t.Fatalf("Setup: Failed to delete test project: %s", err)
 			}
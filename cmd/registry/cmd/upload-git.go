@@ -0,0 +1,91 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/cmd/registry/gitimport"
+	"github.com/apigee/registry/connection"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	uploadCmd.AddCommand(uploadGitCmd)
+	uploadGitCmd.Flags().String("project_id", "", "Project id (default is the project of the current profile).")
+	uploadGitCmd.Flags().String("mapping", "", "YAML file that maps paths in the repository to specs.")
+	_ = uploadGitCmd.MarkFlagRequired("mapping")
+}
+
+var uploadGitCmd = &cobra.Command{
+	Use:   "git REPO_PATH",
+	Short: "Upload the history of specs in a local Git repository as spec revisions",
+	Long: `Upload the history of specs in a local Git repository as spec revisions.
+
+Each commit to the current branch that added or changed a file that the
+mapping maps to a spec is uploaded as a revision of the spec, annotated
+with the commit's SHA (git-commit), author (git-author), and time (git-time).
+Running the command again uploads only commits that are newer than the
+current revisions of specs. A mapping file looks like:
+
+  mappings:
+  - path: apis/{api}/{version}/openapi.yaml
+    mime_type: application/x.openapi+gzip;version=3.0.0
+  - path: google/{group}/{api}/{version}/discovery.json
+    api: "{group}-{api}"
+    mime_type: application/x.discovery+gzip
+
+Placeholders such as {api} match parts of path segments. API and version
+IDs are {api} and {version} and spec IDs are file names unless the api,
+version, and spec fields of a mapping say otherwise.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flagset := cmd.LocalFlags()
+		projectID, err := flagset.GetString("project_id")
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		projectID = defaultProjectID(projectID)
+		if projectID == "" {
+			log.Fatal("Please specify a project_id")
+		}
+		mappingFile, err := flagset.GetString("mapping")
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		mapping, err := gitimport.ReadMapping(mappingFile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		ctx := context.TODO()
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		core.EnsureProjectExists(ctx, client, projectID)
+		summary, err := gitimport.Import(ctx, client, projectID, args[0], mapping)
+		if summary != nil {
+			fmt.Printf("%d specs, %d revisions uploaded, %d specs unchanged, %d specs with rewritten history\n",
+				summary.Specs, summary.Revisions, summary.Unchanged, summary.Diverged)
+		}
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	},
+}
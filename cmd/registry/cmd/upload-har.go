@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/apigee/registry/cmd/registry/conversion"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	uploadCmd.AddCommand(uploadHARCmd)
+	addConvertedFlags(uploadHARCmd)
+}
+
+var uploadHARCmd = &cobra.Command{
+	Use:   "har FILE",
+	Short: "Upload an OpenAPI spec converted from an HTTP Archive (HAR) file",
+	Long: `Upload an OpenAPI spec converted from an HTTP Archive (HAR) file.
+
+The API calls recorded in a HAR file, such as one saved from a browser's
+developer tools, are converted to an OpenAPI v3 description of their paths,
+methods, and parameters, with schemas of bodies inferred from the recorded
+bodies. Path segments that look like IDs are made path parameters. Recorded
+bodies and parameter values aren't included in the spec. The HAR file is
+kept in a "har" artifact of the spec without its cookies, bodies, and the
+values of its headers and parameters, which can hold credentials. The API id
+defaults to the name of the file, and the version id defaults to 1.0.0.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		title := strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+		doc, err := conversion.HARToOpenAPI(b, title)
+		if err != nil {
+			log.Fatalf("%s: %s", args[0], err)
+		}
+		redacted, err := conversion.RedactHAR(b)
+		if err != nil {
+			log.Fatalf("%s: %s", args[0], err)
+		}
+		uploadConverted(cmd, args, doc, &convertedSource{
+			artifactID: "har",
+			mimeType:   conversion.HARMimeType,
+			contents:   redacted,
+		})
+	},
+}
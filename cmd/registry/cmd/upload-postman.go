@@ -0,0 +1,56 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/apigee/registry/cmd/registry/conversion"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	uploadCmd.AddCommand(uploadPostmanCmd)
+	addConvertedFlags(uploadPostmanCmd)
+}
+
+var uploadPostmanCmd = &cobra.Command{
+	Use:   "postman FILE",
+	Short: "Upload an OpenAPI spec converted from a Postman collection",
+	Long: `Upload an OpenAPI spec converted from a Postman collection.
+
+A Postman v2.1 collection is converted to an OpenAPI v3 description of the
+paths, methods, and parameters of its requests, with schemas of bodies
+inferred from the example bodies of requests and saved responses. The API
+and version ids default to the collection's name and version. The
+collection is kept in a "postman-collection" artifact of the spec.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		doc, err := conversion.PostmanToOpenAPI(b)
+		if err != nil {
+			log.Fatalf("%s: %s", args[0], err)
+		}
+		uploadConverted(cmd, args, doc, &convertedSource{
+			artifactID: "postman-collection",
+			mimeType:   conversion.PostmanMimeType,
+			contents:   b,
+		})
+	},
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/cmd/registry/reflection"
+	"github.com/apigee/registry/connection"
+	rpcpb "github.com/apigee/registry/rpc"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func init() {
+	uploadCmd.AddCommand(uploadReflectionCmd)
+	uploadReflectionCmd.Flags().String("project_id", "", "Project id (default is the project of the current profile).")
+	uploadReflectionCmd.Flags().Bool("insecure", false, "Connect to the server without TLS.")
+	uploadReflectionCmd.Flags().Duration("timeout", time.Minute, "Time limit for reading the server's descriptions.")
+}
+
+var uploadReflectionCmd = &cobra.Command{
+	Use:   "reflection ADDRESS",
+	Short: "Upload Protocol Buffer descriptions of the services of a running gRPC server",
+	Long: `Upload Protocol Buffer descriptions of the services of a running gRPC server.
+
+The proto files of the server's services and their dependencies are read
+with gRPC server reflection and uploaded as protos.zip specs, one for each
+proto package. Package google.example.library.v1 is uploaded to API
+google-example-library and version v1; packages that don't end with a
+version are uploaded to version "unversioned". Reconstructed files have
+no comments, and well-known types (google/protobuf/*.proto) are left out.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		flagset := cmd.LocalFlags()
+		projectID, err := flagset.GetString("project_id")
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		projectID = defaultProjectID(projectID)
+		if projectID == "" {
+			log.Fatal("Please specify a project_id")
+		}
+		insecure, err := flagset.GetBool("insecure")
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		timeout, err := flagset.GetDuration("timeout")
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		ctx := context.TODO()
+		transport := grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+		if insecure {
+			transport = grpc.WithInsecure()
+		}
+		conn, err := grpc.DialContext(ctx, args[0], transport)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer conn.Close()
+		// Unresponsive servers would otherwise keep the reflection stream open forever.
+		fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+		apis, err := reflection.Fetch(fetchCtx, conn)
+		cancel()
+		if err != nil {
+			log.Fatalf("%s: %s", args[0], err)
+		}
+		if len(apis) == 0 {
+			log.Fatalf("%s: no services found", args[0])
+		}
+
+		client, err := connection.NewClient(ctx)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		core.EnsureProjectExists(ctx, client, projectID)
+		upload := newBulkUpload(ctx, client, cmd, projectID, args)
+		for _, api := range apis {
+			apiID, versionID := reflectionIDs(api)
+			log.Printf("^^ apis/%s/versions/%s/specs/protos.zip (%s)", apiID, versionID, strings.Join(api.Services, ", "))
+			contents, err := core.ZipArchiveOfFiles(api.Files)
+			if err != nil {
+				upload.fail(err)
+				continue
+			}
+			spec := &rpcpb.ApiSpec{
+				MimeType: core.ProtobufMimeType("+zip"),
+				Filename: "protos.zip",
+				Contents: contents.Bytes(),
+			}
+			if err := upload.upload(apiID, versionID, &rpcpb.Api{DisplayName: api.Package}, spec); err != nil {
+				log.Printf("error: %s", err)
+			}
+		}
+		upload.finish(core.IsProto)
+	},
+}
+
+var versionPattern = regexp.MustCompile(`^v[0-9]+[a-z0-9]*$`)
+
+// reflectionIDs returns the API and version IDs of an API, which are its
+// package without its version and the version, if it has one. Services that
+// aren't in a package are identified by the name of the first one.
+func reflectionIDs(api *reflection.API) (apiID, versionID string) {
+	pkg := api.Package
+	if pkg == "" {
+		pkg = api.Services[0]
+	}
+	parts := strings.Split(pkg, ".")
+	versionID = "unversioned"
+	if last := parts[len(parts)-1]; len(parts) > 1 && versionPattern.MatchString(last) {
+		versionID = last
+		parts = parts[:len(parts)-1]
+	}
+	return sanitize(strings.Join(parts, "-")), versionID
+}
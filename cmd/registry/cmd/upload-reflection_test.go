@@ -0,0 +1,42 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/apigee/registry/cmd/registry/reflection"
+)
+
+func TestReflectionIDs(t *testing.T) {
+	tests := []struct {
+		api         *reflection.API
+		wantAPI     string
+		wantVersion string
+	}{
+		{&reflection.API{Package: "google.example.library.v1"}, "google-example-library", "v1"},
+		{&reflection.API{Package: "grpc.health.v1alpha1"}, "grpc-health", "v1alpha1"},
+		{&reflection.API{Package: "my_company.Inventory"}, "my-company-inventory", "unversioned"},
+		{&reflection.API{Package: "v2"}, "v2", "unversioned"},
+		{&reflection.API{Services: []string{"Echo"}}, "echo", "unversioned"},
+	}
+	for _, test := range tests {
+		apiID, versionID := reflectionIDs(test.api)
+		if apiID != test.wantAPI || versionID != test.wantVersion {
+			t.Errorf("reflectionIDs(%q) returned %q, %q, want %q, %q",
+				test.api.Package, apiID, versionID, test.wantAPI, test.wantVersion)
+		}
+	}
+}
@@ -43,7 +43,10 @@ func deleteProject(
 	req := &rpc.DeleteProjectRequest{
 		Name: "projects/" + projectID,
 	}
-	err := client.DeleteProject(ctx, req)
+	op, err := client.DeleteProject(ctx, req)
+	if err == nil {
+		err = op.Wait(ctx)
+	}
 	if err != nil && status.Code(err) != codes.NotFound {
 		t.Fatalf("Failed DeleteProject(%v): %s", req, err.Error())
 	}
@@ -167,6 +170,38 @@ func createUpdateArtifact(
 	}
 }
 
+func tagSpecRevision(
+	ctx context.Context,
+	client connection.Client,
+	t *testing.T,
+	revisionName, tag string) {
+	t.Helper()
+	req := &rpc.TagApiSpecRevisionRequest{
+		Name: revisionName,
+		Tag:  tag,
+	}
+	_, err := client.TagApiSpecRevision(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed TagApiSpecRevision(%v): %s", req, err.Error())
+	}
+}
+
+func currentRevision(
+	ctx context.Context,
+	client connection.Client,
+	t *testing.T,
+	specName string) string {
+	t.Helper()
+	req := &rpc.GetApiSpecRequest{
+		Name: specName,
+	}
+	spec, err := client.GetApiSpec(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed GetApiSpec(%v): %s", req, err.Error())
+	}
+	return specName + "@" + spec.GetRevisionId()
+}
+
 // Tests for artifacts and resources and specs as dependencies
 
 func TestSingleSpec(t *testing.T) {
@@ -645,3 +680,82 @@ func TestDerivedArtifactsOutdated(t *testing.T) {
 
 	deleteProject(ctx, registryClient, t, "controller-test")
 }
+
+// Tests for tagged spec revisions as dependencies
+
+func TestTaggedSpecs(t *testing.T) {
+	// Setup: 2 specs in project, one of them has a revision tagged "production"
+	// Expect: Create artifact command only for the tagged spec, and again
+	// after the tag is moved to another revision.
+
+	ctx := context.Background()
+	registryClient, err := connection.NewClient(ctx)
+	if err != nil {
+		t.Logf("Failed to create client: %+v", err)
+		t.FailNow()
+	}
+	defer registryClient.Close()
+
+	// Setup
+	deleteProject(ctx, registryClient, t, "controller-test")
+	createProject(ctx, registryClient, t, "controller-test")
+	createApi(ctx, registryClient, t, "projects/controller-test", "petstore")
+	// Version 1.0.0 has two revisions, the second of which is tagged.
+	createVersion(ctx, registryClient, t, "projects/controller-test/apis/petstore", "1.0.0")
+	createSpec(ctx, registryClient, t, "projects/controller-test/apis/petstore/versions/1.0.0", "openapi.yaml", "application/x.openapi;version=3.0.0")
+	first := currentRevision(ctx, registryClient, t, "projects/controller-test/apis/petstore/versions/1.0.0/specs/openapi.yaml")
+	updateReq := &rpc.UpdateApiSpecRequest{
+		ApiSpec: &rpc.ApiSpec{
+			Name:     "projects/controller-test/apis/petstore/versions/1.0.0/specs/openapi.yaml",
+			Contents: []byte("openapi: 3.0.0"),
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents"}},
+	}
+	if _, err := registryClient.UpdateApiSpec(ctx, updateReq); err != nil {
+		t.Fatalf("Failed UpdateApiSpec(%v): %s", updateReq, err.Error())
+	}
+	second := currentRevision(ctx, registryClient, t, "projects/controller-test/apis/petstore/versions/1.0.0/specs/openapi.yaml")
+	tagSpecRevision(ctx, registryClient, t, second, "production")
+	// Version 1.0.1 is not tagged.
+	createVersion(ctx, registryClient, t, "projects/controller-test/apis/petstore", "1.0.1")
+	createSpec(ctx, registryClient, t, "projects/controller-test/apis/petstore/versions/1.0.1", "openapi.yaml", gzipOpenAPIv3)
+
+	manifest, err := ReadManifest(
+		"test/manifest_4.yaml")
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	expectedActions := []string{
+		"compute lint projects/controller-test/apis/petstore/versions/1.0.0/specs/openapi.yaml@production --linter gnostic"}
+
+	actions, err := ProcessManifest(ctx, registryClient, manifest)
+	if err != nil {
+		log.Printf(err.Error())
+	}
+	if diff := cmp.Diff(expectedActions, actions, sortStrings); diff != "" {
+		t.Errorf("ProcessManifest(%+v) returned unexpected diff (-want +got):\n%s", manifest, diff)
+	}
+
+	// An artifact computed after the tag was set is up to date.
+	createUpdateArtifact(ctx, registryClient, t, "projects/controller-test/apis/petstore/versions/1.0.0/specs/openapi.yaml/artifacts/lint-gnostic")
+	actions, err = ProcessManifest(ctx, registryClient, manifest)
+	if err != nil {
+		log.Printf(err.Error())
+	}
+	if diff := cmp.Diff([]string(nil), actions, sortStrings, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("ProcessManifest(%+v) returned unexpected diff (-want +got):\n%s", manifest, diff)
+	}
+
+	// Moving the tag back to the older revision makes the artifact outdated.
+	tagSpecRevision(ctx, registryClient, t, first, "production")
+	actions, err = ProcessManifest(ctx, registryClient, manifest)
+	if err != nil {
+		log.Printf(err.Error())
+	}
+	if diff := cmp.Diff(expectedActions, actions, sortStrings); diff != "" {
+		t.Errorf("ProcessManifest(%+v) returned unexpected diff (-want +got):\n%s", manifest, diff)
+	}
+
+	deleteProject(ctx, registryClient, t, "controller-test")
+}
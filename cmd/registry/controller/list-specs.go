@@ -15,7 +15,13 @@
 package controller
 
 import (
+	"context"
+	"strconv"
+
+	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/api/iterator"
 )
 
 func GenerateSpecHandler(result *[]Resource) func(*rpc.ApiSpec) {
@@ -24,3 +30,31 @@ func GenerateSpecHandler(result *[]Resource) func(*rpc.ApiSpec) {
 		(*result) = append((*result), resource)
 	}
 }
+
+// addTagUpdateTimes records when the tag that selected each spec was last moved,
+// so that moving a tag to an older revision is treated as a change.
+// Revision IDs are not tags and leave the specs unchanged.
+func addTagUpdateTimes(ctx context.Context, client connection.Client, result []Resource, tag string) error {
+	for i, resource := range result {
+		spec, ok := resource.(SpecResource)
+		if !ok {
+			continue
+		}
+		it := client.ListApiSpecRevisionTags(ctx, &rpc.ListApiSpecRevisionTagsRequest{
+			Name:   spec.GetSpec(),
+			Filter: "tag == " + strconv.Quote(tag),
+		})
+		t, err := it.Next()
+		if err == iterator.Done {
+			continue
+		} else if err != nil {
+			return err
+		}
+		spec.TagUpdateTime, err = ptypes.Timestamp(t.GetUpdateTime())
+		if err != nil {
+			return err
+		}
+		result[i] = spec
+	}
+	return nil
+}
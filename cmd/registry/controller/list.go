@@ -39,6 +39,9 @@ func ListResources(ctx context.Context, client connection.Client, pattern, filte
 		err = core.ListAPIs(ctx, client, m, filter, GenerateApiHandler(&result))
 	} else if m := names.SpecRegexp().FindStringSubmatch(pattern); m != nil {
 		err = core.ListSpecs(ctx, client, m, filter, GenerateSpecHandler(&result))
+		if err == nil && m[6] != "" {
+			err = addTagUpdateTimes(ctx, client, result, m[6])
+		}
 	} else if m := names.ArtifactRegexp().FindStringSubmatch(pattern); m != nil {
 		err = core.ListArtifacts(ctx, client, m, filter, false, GenerateArtifactHandler(&result))
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/apigee/registry/rpc"
 	"github.com/golang/protobuf/ptypes"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -34,6 +35,8 @@ type Resource interface {
 
 type SpecResource struct {
 	Spec *rpc.ApiSpec
+	// When the spec was selected by a revision tag, the time the tag was last moved.
+	TagUpdateTime time.Time
 }
 
 func (s SpecResource) GetArtifact() string {
@@ -41,6 +44,10 @@ func (s SpecResource) GetArtifact() string {
 }
 
 func (s SpecResource) GetSpec() string {
+	// Revisions and tags are grouped with their spec.
+	if i := strings.Index(s.Spec.Name, "@"); i >= 0 {
+		return s.Spec.Name[:i]
+	}
 	return s.Spec.Name
 }
 
@@ -60,6 +67,10 @@ func (s SpecResource) GetName() string {
 
 func (s SpecResource) GetUpdateTimestamp() time.Time {
 	ts, _ := ptypes.Timestamp(s.Spec.RevisionUpdateTime)
+	// Moving a tag to another revision changes the tagged spec.
+	if s.TagUpdateTime.After(ts) {
+		return s.TagUpdateTime
+	}
 	return ts
 }
 
@@ -0,0 +1,40 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"github.com/googleapis/gnostic/conversions"
+	discovery_v1 "github.com/googleapis/gnostic/discovery"
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+// DiscoveryToOpenAPIv3 converts a Discovery document to OpenAPI v3 with the
+// conversions of gnostic.
+func DiscoveryToOpenAPIv3(doc *discovery_v1.Document) (*openapi_v3.Document, error) {
+	out, err := conversions.OpenAPIv3(doc)
+	if err != nil {
+		return nil, err
+	}
+	// gnostic doesn't write a patch version, which some tools require.
+	out.Openapi = "3.0.3"
+	return out, nil
+}
+
+// DiscoveryToOpenAPIv2 converts a Discovery document to OpenAPI v2 with the
+// conversions of gnostic.
+func DiscoveryToOpenAPIv2(doc *discovery_v1.Document) (*openapi_v2.Document, error) {
+	return conversions.OpenAPIv2(doc)
+}
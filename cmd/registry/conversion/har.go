@@ -0,0 +1,319 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+// HARMimeType is the MIME type of HTTP Archive (HAR) files.
+const HARMimeType = "application/har+json"
+
+// HARToOpenAPI converts the requests recorded in an HTTP Archive (HAR) file
+// to an OpenAPI v3 document with a title. Requests for web pages, scripts,
+// stylesheets, images, and fonts are ignored, as are CORS preflight requests.
+// Recorded paths are concrete, so path segments that look like IDs, such as
+// numbers and UUIDs, are made path parameters named after the segments
+// before them: /books/42 becomes /books/{bookId}.
+func HARToOpenAPI(b []byte, title string) (*openapi_v3.Document, error) {
+	var archive harArchive
+	if err := json.Unmarshal(b, &archive); err != nil {
+		return nil, err
+	}
+	if archive.Log == nil {
+		return nil, fmt.Errorf("not a HAR file: log is missing")
+	}
+	builder := newBuilder()
+	for _, entry := range archive.Log.Entries {
+		if e := entry.exchange(); e != nil {
+			builder.add(e)
+		}
+	}
+	return builder.document(title, "", ""), nil
+}
+
+// redactedValue replaces the values that RedactHAR removes.
+const redactedValue = "REDACTED"
+
+// keptHeaders are the headers whose values RedactHAR keeps, which describe
+// contents and can't hold credentials.
+var keptHeaders = map[string]bool{
+	"accept":           true,
+	"accept-encoding":  true,
+	"accept-language":  true,
+	"content-encoding": true,
+	"content-length":   true,
+	"content-type":     true,
+}
+
+// RedactHAR removes the recorded values of a HAR file, which can contain
+// credentials and personal data, so that the file can be stored. Cookies and
+// bodies are removed, and the values of query parameters, form parameters,
+// and headers other than content headers are replaced. Methods, URLs without
+// user information, the names of parameters and headers, and statuses are kept.
+func RedactHAR(b []byte) ([]byte, error) {
+	var archive map[string]interface{}
+	if err := json.Unmarshal(b, &archive); err != nil {
+		return nil, err
+	}
+	log, ok := archive["log"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not a HAR file: log is missing")
+	}
+	entries, _ := log["entries"].([]interface{})
+	for _, entry := range entries {
+		entry, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if req, ok := entry["request"].(map[string]interface{}); ok {
+			redactMessage(req)
+			redactURL(req, "url")
+			redactPairs(req["queryString"], nil)
+			if data, ok := req["postData"].(map[string]interface{}); ok {
+				redactContent(data)
+				redactPairs(data["params"], nil)
+			}
+		}
+		if resp, ok := entry["response"].(map[string]interface{}); ok {
+			redactMessage(resp)
+			redactURL(resp, "redirectURL")
+			if content, ok := resp["content"].(map[string]interface{}); ok {
+				redactContent(content)
+			}
+		}
+	}
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+// redactMessage removes the cookies of a request or response and replaces
+// the values of its headers.
+func redactMessage(m map[string]interface{}) {
+	if _, ok := m["cookies"]; ok {
+		m["cookies"] = []interface{}{}
+	}
+	redactPairs(m["headers"], keptHeaders)
+}
+
+// redactPairs replaces the values of name-value pairs, except for pairs
+// with kept names.
+func redactPairs(v interface{}, kept map[string]bool) {
+	pairs, _ := v.([]interface{})
+	for _, pair := range pairs {
+		pair, ok := pair.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := pair["name"].(string)
+		if _, ok := pair["value"]; ok && !kept[strings.ToLower(name)] {
+			pair["value"] = redactedValue
+		}
+	}
+}
+
+// redactContent removes the text of a body.
+func redactContent(m map[string]interface{}) {
+	if _, ok := m["text"]; ok {
+		m["text"] = ""
+	}
+	delete(m, "encoding")
+}
+
+// redactURL removes the user information and fragment of a URL field and
+// replaces the values of its query parameters. Unparseable URLs are removed.
+func redactURL(m map[string]interface{}, field string) {
+	s, ok := m[field].(string)
+	if !ok || s == "" {
+		return
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		m[field] = ""
+		return
+	}
+	u.User = nil
+	u.Fragment = ""
+	query := u.Query()
+	for name := range query {
+		query[name] = []string{redactedValue}
+	}
+	u.RawQuery = query.Encode()
+	m[field] = u.String()
+}
+
+type harArchive struct {
+	Log *struct {
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method      string      `json:"method"`
+		URL         string      `json:"url"`
+		Headers     []harPair   `json:"headers"`
+		QueryString []harPair   `json:"queryString"`
+		PostData    *harPayload `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status     int        `json:"status"`
+		StatusText string     `json:"statusText"`
+		Content    harPayload `json:"content"`
+	} `json:"response"`
+}
+
+type harPair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	FileName string `json:"fileName"`
+}
+
+// harPayload is the body of a request or a response.
+type harPayload struct {
+	MimeType string    `json:"mimeType"`
+	Text     string    `json:"text"`
+	Encoding string    `json:"encoding"`
+	Params   []harPair `json:"params"`
+}
+
+// exchange returns the exchange of an entry, or nil if the entry isn't a call of an API.
+func (entry *harEntry) exchange() *exchange {
+	req, resp := &entry.Request, &entry.Response
+	if req.Method == "OPTIONS" || resp.Status == 0 || !apiContent(resp.Content.MimeType) {
+		return nil
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	e := &exchange{
+		method: req.Method,
+		server: u.Scheme + "://" + u.Host,
+	}
+	e.path, e.pathParams = templatePath(u.Path)
+	for _, q := range req.QueryString {
+		e.query = append(e.query, param{name: q.Name, value: q.Value})
+	}
+	for _, h := range req.Headers {
+		e.headers = append(e.headers, param{name: h.Name, value: h.Value})
+	}
+	if data := req.PostData; data != nil {
+		e.body = &body{mediaType: data.MimeType, text: data.Text}
+		for _, p := range data.Params {
+			e.body.form = append(e.body.form, param{name: p.Name, value: p.Value, file: p.FileName != ""})
+		}
+	}
+	text := resp.Content.Text
+	if resp.Content.Encoding == "base64" {
+		if b, err := base64.StdEncoding.DecodeString(text); err == nil {
+			text = string(b)
+		}
+	}
+	e.responses = append(e.responses, &response{
+		status:      resp.Status,
+		description: resp.StatusText,
+		mediaType:   resp.Content.MimeType,
+		text:        text,
+	})
+	return e
+}
+
+// apiContent returns false for the content types of web pages and their resources.
+func apiContent(contentType string) bool {
+	mediaType := mediaType(contentType)
+	switch mediaType {
+	case "text/html", "text/css", "text/javascript", "application/javascript",
+		"application/x-javascript", "application/wasm", "application/manifest+json":
+		return false
+	}
+	for _, prefix := range []string{"image/", "font/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
+
+// templatePath replaces the segments of a path that look like IDs with
+// path parameters and returns the template and the parameters.
+func templatePath(path string) (string, []param) {
+	var segments []string
+	var params []param
+	used := make(map[string]bool)
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if !idSegment.MatchString(segment) {
+			segments = append(segments, segment)
+			continue
+		}
+		name := "id"
+		if n := len(segments); n > 0 && !strings.HasPrefix(segments[n-1], "{") {
+			name = parameterName(segments[n-1]) + "Id"
+		}
+		for i, base := 2, name; used[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		used[name] = true
+		segments = append(segments, "{"+name+"}")
+		params = append(params, param{name: name, value: segment})
+	}
+	return "/" + strings.Join(segments, "/"), params
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// parameterName returns a name for the parameter that follows a segment,
+// such as book for books, class for classes, and bookCase for book-cases.
+func parameterName(segment string) string {
+	parts := nonIdentifier.Split(segment, -1)
+	name := ""
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(part[:1]) + part[1:]
+		} else {
+			name += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	case name == "":
+		return "resource"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/googleapis/gnostic/compiler"
+	"gopkg.in/yaml.v3"
+)
+
+// Schemas have nearly the same form in OpenAPI v2 and v3, so they are
+// converted by rewriting their YAML forms and reading the rewritten forms
+// with the models of the other version.
+
+// A rewrite changes a copy of a schema's YAML mapping node. Rewrites are
+// applied to schemas and to the schemas that they contain.
+type rewrite func(m *yaml.Node)
+
+// rewriteSchema returns a copy of a schema's YAML form with references
+// renamed and a rewrite applied to it and to the schemas that it contains.
+// Values that aren't schemas, such as examples, are copied unchanged.
+func rewriteSchema(in *yaml.Node, refs map[string]string, r rewrite) *yaml.Node {
+	if in == nil || in.Kind != yaml.MappingNode {
+		return copyNode(in)
+	}
+	out := *in
+	out.Content = make([]*yaml.Node, len(in.Content))
+	for i := 0; i+1 < len(in.Content); i += 2 {
+		key, value := in.Content[i], in.Content[i+1]
+		out.Content[i] = copyNode(key)
+		switch key.Value {
+		case "$ref":
+			value = copyNode(value)
+			value.Value = renameRef(value.Value, refs)
+		case "properties":
+			value = copyNode(value)
+			for j := 1; j < len(value.Content); j += 2 {
+				value.Content[j] = rewriteSchema(in.Content[i+1].Content[j], refs, r)
+			}
+		case "items", "allOf", "oneOf", "anyOf":
+			if value.Kind == yaml.SequenceNode {
+				value = copyNode(value)
+				for j, item := range value.Content {
+					value.Content[j] = rewriteSchema(item, refs, r)
+				}
+			} else {
+				value = rewriteSchema(value, refs, r)
+			}
+		case "not", "additionalProperties":
+			value = rewriteSchema(value, refs, r)
+		default:
+			value = copyNode(value)
+		}
+		out.Content[i+1] = value
+	}
+	if r != nil {
+		r(&out)
+	}
+	return &out
+}
+
+// copyNode returns a deep copy of a node.
+func copyNode(in *yaml.Node) *yaml.Node {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	out.Content = make([]*yaml.Node, len(in.Content))
+	for i, n := range in.Content {
+		out.Content[i] = copyNode(n)
+	}
+	return &out
+}
+
+// renameRef replaces a prefix of a reference, such as #/definitions/, with
+// the prefix that it maps to.
+func renameRef(ref string, refs map[string]string) string {
+	for from, to := range refs {
+		if strings.HasPrefix(ref, from) {
+			return to + strings.TrimPrefix(ref, from)
+		}
+	}
+	return ref
+}
+
+// mapValue returns the value of a key of a mapping node, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setValue sets the value of a key of a mapping node.
+func setValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, scalar(key), value)
+}
+
+// deleteKeys removes keys from a mapping node.
+func deleteKeys(m *yaml.Node, keys ...string) {
+	content := m.Content[:0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if !containsString(keys, m.Content[i].Value) {
+			content = append(content, m.Content[i], m.Content[i+1])
+		}
+	}
+	m.Content = content
+}
+
+// renameKey renames a key of a mapping node.
+func renameKey(m *yaml.Node, from, to string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == from {
+			key := *m.Content[i]
+			key.Value = to
+			m.Content[i] = &key
+		}
+	}
+}
+
+// keepKeys removes the keys of a mapping node that aren't listed.
+func keepKeys(m *yaml.Node, keys ...string) {
+	content := m.Content[:0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if containsString(keys, m.Content[i].Value) {
+			content = append(content, m.Content[i], m.Content[i+1])
+		}
+	}
+	m.Content = content
+}
+
+func newContext(name string, node *yaml.Node) *compiler.Context {
+	return compiler.NewContext(name, node, nil)
+}
+
+func scalar(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+func boolean(b bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+}
@@ -0,0 +1,609 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"fmt"
+	"strings"
+
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+	"gopkg.in/yaml.v3"
+)
+
+// v2Refs maps the prefixes of OpenAPI v2 references to those of OpenAPI v3.
+// References to parameters aren't renamed because they are resolved.
+var v2Refs = map[string]string{
+	"#/definitions/": "#/components/schemas/",
+	"#/responses/":   "#/components/responses/",
+}
+
+// OpenAPIv2ToOpenAPIv3 converts an OpenAPI v2 document to OpenAPI v3. Body
+// and form parameters become request bodies, references to parameters are
+// replaced by the parameters, and the server URLs are built from the host,
+// base path, and schemes. Features of OpenAPI v2 that can't be described
+// in OpenAPI v3, such as the csv and tsv formats of query arrays, are dropped.
+func OpenAPIv2ToOpenAPIv3(doc *openapi_v2.Document) (*openapi_v3.Document, error) {
+	c := &v2Converter{doc: doc}
+	out := &openapi_v3.Document{
+		Openapi:                "3.0.3",
+		Info:                   c.info(doc.GetInfo()),
+		Servers:                c.servers(),
+		Paths:                  &openapi_v3.Paths{},
+		Security:               securityV2ToV3(doc.GetSecurity()),
+		ExternalDocs:           externalDocsV2ToV3(doc.GetExternalDocs()),
+		SpecificationExtension: extensionsV2ToV3(doc.GetVendorExtension()),
+	}
+	for _, tag := range doc.GetTags() {
+		out.Tags = append(out.Tags, &openapi_v3.Tag{
+			Name:                   tag.GetName(),
+			Description:            tag.GetDescription(),
+			ExternalDocs:           externalDocsV2ToV3(tag.GetExternalDocs()),
+			SpecificationExtension: extensionsV2ToV3(tag.GetVendorExtension()),
+		})
+	}
+	for _, pair := range doc.GetPaths().GetPath() {
+		item, err := c.pathItem(pair.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", pair.GetName(), err)
+		}
+		out.Paths.Path = append(out.Paths.Path, &openapi_v3.NamedPathItem{Name: pair.GetName(), Value: item})
+	}
+	out.Paths.SpecificationExtension = extensionsV2ToV3(doc.GetPaths().GetVendorExtension())
+	components, err := c.components()
+	if err != nil {
+		return nil, err
+	}
+	out.Components = components
+	return out, nil
+}
+
+type v2Converter struct {
+	doc *openapi_v2.Document
+}
+
+func (c *v2Converter) info(info *openapi_v2.Info) *openapi_v3.Info {
+	out := &openapi_v3.Info{
+		Title:                  info.GetTitle(),
+		Description:            info.GetDescription(),
+		TermsOfService:         info.GetTermsOfService(),
+		Version:                info.GetVersion(),
+		SpecificationExtension: extensionsV2ToV3(info.GetVendorExtension()),
+	}
+	if contact := info.GetContact(); contact != nil {
+		out.Contact = &openapi_v3.Contact{Name: contact.GetName(), Url: contact.GetUrl(), Email: contact.GetEmail()}
+	}
+	if license := info.GetLicense(); license != nil {
+		out.License = &openapi_v3.License{Name: license.GetName(), Url: license.GetUrl()}
+	}
+	return out
+}
+
+// servers returns a server for each scheme. Documents without a host
+// are served from the host that serves the document, so their server
+// URLs are relative.
+func (c *v2Converter) servers() []*openapi_v3.Server {
+	host, basePath := c.doc.GetHost(), c.doc.GetBasePath()
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []*openapi_v3.Server{{Url: basePath}}
+	}
+	schemes := c.doc.GetSchemes()
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	var servers []*openapi_v3.Server
+	for _, scheme := range schemes {
+		servers = append(servers, &openapi_v3.Server{Url: scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+func (c *v2Converter) pathItem(item *openapi_v2.PathItem) (*openapi_v3.PathItem, error) {
+	out := &openapi_v3.PathItem{
+		XRef:                   item.GetXRef(),
+		SpecificationExtension: extensionsV2ToV3(item.GetVendorExtension()),
+	}
+	for _, op := range []struct {
+		in  *openapi_v2.Operation
+		out **openapi_v3.Operation
+	}{
+		{item.GetGet(), &out.Get},
+		{item.GetPut(), &out.Put},
+		{item.GetPost(), &out.Post},
+		{item.GetDelete(), &out.Delete},
+		{item.GetOptions(), &out.Options},
+		{item.GetHead(), &out.Head},
+		{item.GetPatch(), &out.Patch},
+	} {
+		if op.in == nil {
+			continue
+		}
+		var err error
+		if *op.out, err = c.operation(op.in, item.GetParameters()); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// operation converts an operation. Parameters of the path are added to
+// each of its operations, because body and form parameters become parts
+// of operations' request bodies.
+func (c *v2Converter) operation(op *openapi_v2.Operation, pathParams []*openapi_v2.ParametersItem) (*openapi_v3.Operation, error) {
+	out := &openapi_v3.Operation{
+		Tags:                   op.GetTags(),
+		Summary:                op.GetSummary(),
+		Description:            op.GetDescription(),
+		ExternalDocs:           externalDocsV2ToV3(op.GetExternalDocs()),
+		OperationId:            op.GetOperationId(),
+		Deprecated:             op.GetDeprecated(),
+		Security:               securityV2ToV3(op.GetSecurity()),
+		Responses:              &openapi_v3.Responses{},
+		SpecificationExtension: extensionsV2ToV3(op.GetVendorExtension()),
+	}
+	params, err := c.parameters(pathParams, op.GetParameters())
+	if err != nil {
+		return nil, err
+	}
+	consumes := op.GetConsumes()
+	if len(consumes) == 0 {
+		consumes = c.doc.GetConsumes()
+	}
+	var form []*openapi_v2.FormDataParameterSubSchema
+	for _, p := range params {
+		if body := p.GetBodyParameter(); body != nil {
+			if len(consumes) == 0 {
+				consumes = []string{"application/json"}
+			}
+			schema, err := schemaV2ToV3(body.GetSchema())
+			if err != nil {
+				return nil, err
+			}
+			out.RequestBody = &openapi_v3.RequestBodyOrReference{
+				Oneof: &openapi_v3.RequestBodyOrReference_RequestBody{
+					RequestBody: &openapi_v3.RequestBody{
+						Description:            body.GetDescription(),
+						Required:               body.GetRequired(),
+						Content:                content(consumes, schema),
+						SpecificationExtension: extensionsV2ToV3(body.GetVendorExtension()),
+					},
+				},
+			}
+		} else if f := p.GetNonBodyParameter().GetFormDataParameterSubSchema(); f != nil {
+			form = append(form, f)
+		} else {
+			param, err := parameterV2ToV3(p.GetNonBodyParameter())
+			if err != nil {
+				return nil, err
+			}
+			out.Parameters = append(out.Parameters, &openapi_v3.ParameterOrReference{
+				Oneof: &openapi_v3.ParameterOrReference_Parameter{Parameter: param},
+			})
+		}
+	}
+	if len(form) > 0 {
+		body, err := formV2ToV3(form, consumes)
+		if err != nil {
+			return nil, err
+		}
+		out.RequestBody = &openapi_v3.RequestBodyOrReference{
+			Oneof: &openapi_v3.RequestBodyOrReference_RequestBody{RequestBody: body},
+		}
+	}
+
+	produces := op.GetProduces()
+	if len(produces) == 0 {
+		produces = c.doc.GetProduces()
+	}
+	for _, pair := range op.GetResponses().GetResponseCode() {
+		r, err := c.responseValue(pair.GetValue(), produces)
+		if err != nil {
+			return nil, err
+		}
+		if pair.GetName() == "default" {
+			out.Responses.Default = r
+		} else {
+			out.Responses.ResponseOrReference = append(out.Responses.ResponseOrReference,
+				&openapi_v3.NamedResponseOrReference{Name: pair.GetName(), Value: r})
+		}
+	}
+	out.Responses.SpecificationExtension = extensionsV2ToV3(op.GetResponses().GetVendorExtension())
+	return out, nil
+}
+
+// parameters returns the parameters of an operation and of its path with
+// references resolved. Parameters of the operation override parameters of
+// the path that have the same name and location.
+func (c *v2Converter) parameters(pathParams, opParams []*openapi_v2.ParametersItem) ([]*openapi_v2.Parameter, error) {
+	var params []*openapi_v2.Parameter
+	for _, items := range [][]*openapi_v2.ParametersItem{pathParams, opParams} {
+		for _, item := range items {
+			p := item.GetParameter()
+			if ref := item.GetJsonReference().GetXRef(); ref != "" {
+				if p = c.parameter(ref); p == nil {
+					return nil, fmt.Errorf("unresolved reference %s", ref)
+				}
+			}
+			name, in := parameterKey(p)
+			for i, existing := range params {
+				if n, l := parameterKey(existing); n == name && l == in {
+					params = append(params[:i], params[i+1:]...)
+					break
+				}
+			}
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+// parameter returns the parameter that a reference refers to, or nil.
+func (c *v2Converter) parameter(ref string) *openapi_v2.Parameter {
+	name := strings.TrimPrefix(ref, "#/parameters/")
+	for _, pair := range c.doc.GetParameters().GetAdditionalProperties() {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return nil
+}
+
+func parameterKey(p *openapi_v2.Parameter) (name, in string) {
+	if body := p.GetBodyParameter(); body != nil {
+		return body.GetName(), "body"
+	}
+	nonBody := p.GetNonBodyParameter()
+	if h := nonBody.GetHeaderParameterSubSchema(); h != nil {
+		return h.GetName(), "header"
+	}
+	if f := nonBody.GetFormDataParameterSubSchema(); f != nil {
+		return f.GetName(), "formData"
+	}
+	if q := nonBody.GetQueryParameterSubSchema(); q != nil {
+		return q.GetName(), "query"
+	}
+	return nonBody.GetPathParameterSubSchema().GetName(), "path"
+}
+
+// parameterV2ToV3 converts a path, query, or header parameter, whose type
+// and constraints become its schema.
+func parameterV2ToV3(p *openapi_v2.NonBodyParameter) (*openapi_v3.Parameter, error) {
+	var node *yaml.Node
+	var collectionFormat string
+	out := &openapi_v3.Parameter{}
+	if h := p.GetHeaderParameterSubSchema(); h != nil {
+		node, collectionFormat = h.ToRawInfo(), h.GetCollectionFormat()
+		out.Name, out.In, out.Description, out.Required = h.GetName(), "header", h.GetDescription(), h.GetRequired()
+		out.SpecificationExtension = extensionsV2ToV3(h.GetVendorExtension())
+	} else if q := p.GetQueryParameterSubSchema(); q != nil {
+		node, collectionFormat = q.ToRawInfo(), q.GetCollectionFormat()
+		out.Name, out.In, out.Description, out.Required = q.GetName(), "query", q.GetDescription(), q.GetRequired()
+		out.AllowEmptyValue = q.GetAllowEmptyValue()
+		out.SpecificationExtension = extensionsV2ToV3(q.GetVendorExtension())
+	} else if path := p.GetPathParameterSubSchema(); path != nil {
+		node, collectionFormat = path.ToRawInfo(), path.GetCollectionFormat()
+		out.Name, out.In, out.Description, out.Required = path.GetName(), "path", path.GetDescription(), true
+		out.SpecificationExtension = extensionsV2ToV3(path.GetVendorExtension())
+	} else {
+		return nil, fmt.Errorf("unsupported parameter")
+	}
+	schema, err := primitiveSchemaV2ToV3(node)
+	if err != nil {
+		return nil, err
+	}
+	out.Schema = schema
+	// The models don't write explode: false, so comma-separated query
+	// arrays can't be described and have the default style.
+	switch collectionFormat {
+	case "multi":
+		out.Style, out.Explode = "form", true
+	case "ssv":
+		out.Style = "spaceDelimited"
+	case "pipes":
+		out.Style = "pipeDelimited"
+	}
+	return out, nil
+}
+
+// formV2ToV3 converts form parameters to a request body with an object
+// schema. Forms with files are multipart forms.
+func formV2ToV3(params []*openapi_v2.FormDataParameterSubSchema, consumes []string) (*openapi_v3.RequestBody, error) {
+	object := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	properties := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	var required []string
+	multipart := containsString(consumes, "multipart/form-data")
+	for _, p := range params {
+		if p.GetType() == "file" {
+			multipart = true
+		}
+		if p.GetRequired() {
+			required = append(required, p.GetName())
+		}
+		property, err := primitiveNodeV2ToV3(p.ToRawInfo(), "description")
+		if err != nil {
+			return nil, err
+		}
+		properties.Content = append(properties.Content, scalar(p.GetName()), property)
+	}
+	setValue(object, "type", scalar("object"))
+	setValue(object, "properties", properties)
+	if len(required) > 0 {
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, name := range required {
+			list.Content = append(list.Content, scalar(name))
+		}
+		setValue(object, "required", list)
+	}
+	schema, err := openapi_v3.NewSchemaOrReference(object, newContext("schema", object))
+	if err != nil {
+		return nil, err
+	}
+	mediaType := "application/x-www-form-urlencoded"
+	if multipart {
+		mediaType = "multipart/form-data"
+	}
+	return &openapi_v3.RequestBody{Content: content([]string{mediaType}, schema)}, nil
+}
+
+// primitiveSchemaV2ToV3 returns the schema of a parameter or header from
+// the YAML form of its type and constraints.
+func primitiveSchemaV2ToV3(node *yaml.Node) (*openapi_v3.SchemaOrReference, error) {
+	node, err := primitiveNodeV2ToV3(node)
+	if err != nil {
+		return nil, err
+	}
+	return openapi_v3.NewSchemaOrReference(node, newContext("schema", node))
+}
+
+// primitiveKeys are the keys of parameters and headers that are also keys
+// of schemas.
+var primitiveKeys = []string{"type", "format", "items", "default", "maximum", "exclusiveMaximum",
+	"minimum", "exclusiveMinimum", "maxLength", "minLength", "pattern", "maxItems",
+	"minItems", "uniqueItems", "enum", "multipleOf"}
+
+// primitiveNodeV2ToV3 returns the YAML form of the schema of a parameter
+// or header, whose other fields are removed unless they are listed.
+func primitiveNodeV2ToV3(node *yaml.Node, keys ...string) (*yaml.Node, error) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid parameter")
+	}
+	keys = append(keys, primitiveKeys...)
+	return rewriteSchema(node, nil, func(m *yaml.Node) {
+		keepKeys(m, keys...)
+		fileToBinary(m)
+	}), nil
+}
+
+// fileToBinary replaces the file type of OpenAPI v2, which describes
+// uploaded files, with binary strings.
+func fileToBinary(m *yaml.Node) {
+	if t := mapValue(m, "type"); t != nil && t.Value == "file" {
+		setValue(m, "type", scalar("string"))
+		setValue(m, "format", scalar("binary"))
+	}
+}
+
+// schemaV2ToV3 converts a schema.
+func schemaV2ToV3(schema *openapi_v2.Schema) (*openapi_v3.SchemaOrReference, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	return schemaNodeV2ToV3(schema.ToRawInfo())
+}
+
+func schemaNodeV2ToV3(node *yaml.Node) (*openapi_v3.SchemaOrReference, error) {
+	node = rewriteSchema(node, v2Refs, func(m *yaml.Node) {
+		fileToBinary(m)
+		// OpenAPI v2 discriminators are property names.
+		if d := mapValue(m, "discriminator"); d != nil && d.Kind == yaml.ScalarNode {
+			discriminator := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setValue(discriminator, "propertyName", d)
+			setValue(m, "discriminator", discriminator)
+		}
+		renameKey(m, "x-nullable", "nullable")
+	})
+	return openapi_v3.NewSchemaOrReference(node, newContext("schema", node))
+}
+
+func (c *v2Converter) responseValue(r *openapi_v2.ResponseValue, produces []string) (*openapi_v3.ResponseOrReference, error) {
+	if ref := r.GetJsonReference().GetXRef(); ref != "" {
+		return &openapi_v3.ResponseOrReference{
+			Oneof: &openapi_v3.ResponseOrReference_Reference{
+				Reference: &openapi_v3.Reference{XRef: renameRef(ref, v2Refs)},
+			},
+		}, nil
+	}
+	response, err := responseV2ToV3(r.GetResponse(), produces)
+	if err != nil {
+		return nil, err
+	}
+	return &openapi_v3.ResponseOrReference{
+		Oneof: &openapi_v3.ResponseOrReference_Response{Response: response},
+	}, nil
+}
+
+// responseV2ToV3 converts a response. Responses with schemas have content
+// of each type that the operation produces.
+func responseV2ToV3(r *openapi_v2.Response, produces []string) (*openapi_v3.Response, error) {
+	out := &openapi_v3.Response{
+		Description:            r.GetDescription(),
+		SpecificationExtension: extensionsV2ToV3(r.GetVendorExtension()),
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+	if schema := r.GetSchema(); schema.GetSchema() != nil {
+		s, err := schemaV2ToV3(schema.GetSchema())
+		if err != nil {
+			return nil, err
+		}
+		out.Content = content(produces, s)
+	} else if file := schema.GetFileSchema(); file != nil {
+		s, err := schemaNodeV2ToV3(file.ToRawInfo())
+		if err != nil {
+			return nil, err
+		}
+		out.Content = content(produces, s)
+	}
+	for _, pair := range r.GetHeaders().GetAdditionalProperties() {
+		h := pair.GetValue()
+		schema, err := primitiveSchemaV2ToV3(h.ToRawInfo())
+		if err != nil {
+			return nil, err
+		}
+		if out.Headers == nil {
+			out.Headers = &openapi_v3.HeadersOrReferences{}
+		}
+		out.Headers.AdditionalProperties = append(out.Headers.AdditionalProperties, &openapi_v3.NamedHeaderOrReference{
+			Name: pair.GetName(),
+			Value: &openapi_v3.HeaderOrReference{
+				Oneof: &openapi_v3.HeaderOrReference_Header{
+					Header: &openapi_v3.Header{Description: h.GetDescription(), Schema: schema},
+				},
+			},
+		})
+	}
+	return out, nil
+}
+
+// components converts definitions, responses, and security definitions.
+// Parameters aren't converted because references to them are resolved.
+func (c *v2Converter) components() (*openapi_v3.Components, error) {
+	out := &openapi_v3.Components{}
+	for _, pair := range c.doc.GetDefinitions().GetAdditionalProperties() {
+		schema, err := schemaV2ToV3(pair.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("definitions/%s: %s", pair.GetName(), err)
+		}
+		if out.Schemas == nil {
+			out.Schemas = &openapi_v3.SchemasOrReferences{}
+		}
+		out.Schemas.AdditionalProperties = append(out.Schemas.AdditionalProperties,
+			&openapi_v3.NamedSchemaOrReference{Name: pair.GetName(), Value: schema})
+	}
+	for _, pair := range c.doc.GetResponses().GetAdditionalProperties() {
+		response, err := responseV2ToV3(pair.GetValue(), c.doc.GetProduces())
+		if err != nil {
+			return nil, fmt.Errorf("responses/%s: %s", pair.GetName(), err)
+		}
+		if out.Responses == nil {
+			out.Responses = &openapi_v3.ResponsesOrReferences{}
+		}
+		out.Responses.AdditionalProperties = append(out.Responses.AdditionalProperties, &openapi_v3.NamedResponseOrReference{
+			Name:  pair.GetName(),
+			Value: &openapi_v3.ResponseOrReference{Oneof: &openapi_v3.ResponseOrReference_Response{Response: response}},
+		})
+	}
+	for _, pair := range c.doc.GetSecurityDefinitions().GetAdditionalProperties() {
+		if out.SecuritySchemes == nil {
+			out.SecuritySchemes = &openapi_v3.SecuritySchemesOrReferences{}
+		}
+		out.SecuritySchemes.AdditionalProperties = append(out.SecuritySchemes.AdditionalProperties, &openapi_v3.NamedSecuritySchemeOrReference{
+			Name: pair.GetName(),
+			Value: &openapi_v3.SecuritySchemeOrReference{
+				Oneof: &openapi_v3.SecuritySchemeOrReference_SecurityScheme{
+					SecurityScheme: securitySchemeV2ToV3(pair.GetValue()),
+				},
+			},
+		})
+	}
+	if out.Schemas == nil && out.Responses == nil && out.SecuritySchemes == nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func securitySchemeV2ToV3(item *openapi_v2.SecurityDefinitionsItem) *openapi_v3.SecurityScheme {
+	if s := item.GetBasicAuthenticationSecurity(); s != nil {
+		return &openapi_v3.SecurityScheme{Type: "http", Scheme: "basic", Description: s.GetDescription()}
+	}
+	if s := item.GetApiKeySecurity(); s != nil {
+		return &openapi_v3.SecurityScheme{Type: "apiKey", Name: s.GetName(), In: s.GetIn(), Description: s.GetDescription()}
+	}
+	out := &openapi_v3.SecurityScheme{Type: "oauth2", Flows: &openapi_v3.OauthFlows{}}
+	if s := item.GetOauth2ImplicitSecurity(); s != nil {
+		out.Description = s.GetDescription()
+		out.Flows.Implicit = &openapi_v3.OauthFlow{AuthorizationUrl: s.GetAuthorizationUrl(), Scopes: scopesV2ToV3(s.GetScopes())}
+	} else if s := item.GetOauth2PasswordSecurity(); s != nil {
+		out.Description = s.GetDescription()
+		out.Flows.Password = &openapi_v3.OauthFlow{TokenUrl: s.GetTokenUrl(), Scopes: scopesV2ToV3(s.GetScopes())}
+	} else if s := item.GetOauth2ApplicationSecurity(); s != nil {
+		out.Description = s.GetDescription()
+		out.Flows.ClientCredentials = &openapi_v3.OauthFlow{TokenUrl: s.GetTokenUrl(), Scopes: scopesV2ToV3(s.GetScopes())}
+	} else if s := item.GetOauth2AccessCodeSecurity(); s != nil {
+		out.Description = s.GetDescription()
+		out.Flows.AuthorizationCode = &openapi_v3.OauthFlow{
+			AuthorizationUrl: s.GetAuthorizationUrl(),
+			TokenUrl:         s.GetTokenUrl(),
+			Scopes:           scopesV2ToV3(s.GetScopes()),
+		}
+	}
+	return out
+}
+
+func scopesV2ToV3(scopes *openapi_v2.Oauth2Scopes) *openapi_v3.Strings {
+	out := &openapi_v3.Strings{}
+	for _, pair := range scopes.GetAdditionalProperties() {
+		out.AdditionalProperties = append(out.AdditionalProperties,
+			&openapi_v3.NamedString{Name: pair.GetName(), Value: pair.GetValue()})
+	}
+	return out
+}
+
+func securityV2ToV3(requirements []*openapi_v2.SecurityRequirement) []*openapi_v3.SecurityRequirement {
+	var out []*openapi_v3.SecurityRequirement
+	for _, r := range requirements {
+		requirement := &openapi_v3.SecurityRequirement{}
+		for _, pair := range r.GetAdditionalProperties() {
+			requirement.AdditionalProperties = append(requirement.AdditionalProperties, &openapi_v3.NamedStringArray{
+				Name:  pair.GetName(),
+				Value: &openapi_v3.StringArray{Value: pair.GetValue().GetValue()},
+			})
+		}
+		out = append(out, requirement)
+	}
+	return out
+}
+
+func externalDocsV2ToV3(docs *openapi_v2.ExternalDocs) *openapi_v3.ExternalDocs {
+	if docs == nil {
+		return nil
+	}
+	return &openapi_v3.ExternalDocs{Description: docs.GetDescription(), Url: docs.GetUrl()}
+}
+
+func extensionsV2ToV3(extensions []*openapi_v2.NamedAny) []*openapi_v3.NamedAny {
+	var out []*openapi_v3.NamedAny
+	for _, e := range extensions {
+		out = append(out, &openapi_v3.NamedAny{
+			Name:  e.GetName(),
+			Value: &openapi_v3.Any{Value: e.GetValue().GetValue(), Yaml: e.GetValue().GetYaml()},
+		})
+	}
+	return out
+}
+
+// content returns content of several media types with the same schema.
+func content(mediaTypes []string, schema *openapi_v3.SchemaOrReference) *openapi_v3.MediaTypes {
+	out := &openapi_v3.MediaTypes{}
+	for _, mediaType := range mediaTypes {
+		out.AdditionalProperties = append(out.AdditionalProperties,
+			&openapi_v3.NamedMediaType{Name: mediaType, Value: &openapi_v3.MediaType{Schema: schema}})
+	}
+	return out
+}
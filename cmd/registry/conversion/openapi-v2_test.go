@@ -0,0 +1,231 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+)
+
+const swagger = `swagger: "2.0"
+info:
+  title: Library
+  version: 1.0.0
+host: library.example.com
+basePath: /v1
+schemes:
+- https
+consumes:
+- application/json
+produces:
+- application/json
+paths:
+  /shelves/{shelf}/books:
+    parameters:
+    - name: shelf
+      in: path
+      required: true
+      type: string
+    get:
+      operationId: listBooks
+      parameters:
+      - name: authors
+        in: query
+        type: array
+        items:
+          type: string
+        collectionFormat: multi
+      - $ref: '#/parameters/pageSize'
+      responses:
+        "200":
+          description: OK
+          schema:
+            type: array
+            items:
+              $ref: '#/definitions/Book'
+    post:
+      operationId: createBook
+      parameters:
+      - name: book
+        in: body
+        required: true
+        schema:
+          $ref: '#/definitions/Book'
+      responses:
+        "200":
+          $ref: '#/responses/Book'
+  /shelves/{shelf}/cover:
+    put:
+      operationId: uploadCover
+      consumes:
+      - multipart/form-data
+      parameters:
+      - name: shelf
+        in: path
+        required: true
+        type: string
+      - name: image
+        in: formData
+        type: file
+      responses:
+        "204":
+          description: No Content
+parameters:
+  pageSize:
+    name: pageSize
+    in: query
+    type: integer
+    format: int32
+responses:
+  Book:
+    description: A book.
+    schema:
+      $ref: '#/definitions/Book'
+definitions:
+  Book:
+    type: object
+    required:
+    - title
+    properties:
+      title:
+        type: string
+      subtitle:
+        type: string
+        x-nullable: true
+securityDefinitions:
+  apiKey:
+    type: apiKey
+    name: key
+    in: query
+security:
+- apiKey: []
+`
+
+const swaggerV3YAML = `openapi: 3.0.3
+info:
+    title: Library
+    version: 1.0.0
+servers:
+    - url: https://library.example.com/v1
+paths:
+    /shelves/{shelf}/books:
+        get:
+            operationId: listBooks
+            parameters:
+                - name: shelf
+                  in: path
+                  required: true
+                  schema:
+                    type: string
+                - name: authors
+                  in: query
+                  style: form
+                  explode: true
+                  schema:
+                    type: array
+                    items:
+                        type: string
+                - name: pageSize
+                  in: query
+                  schema:
+                    type: integer
+                    format: int32
+            responses:
+                "200":
+                    description: OK
+                    content:
+                        application/json:
+                            schema:
+                                type: array
+                                items:
+                                    $ref: '#/components/schemas/Book'
+        post:
+            operationId: createBook
+            parameters:
+                - name: shelf
+                  in: path
+                  required: true
+                  schema:
+                    type: string
+            requestBody:
+                content:
+                    application/json:
+                        schema:
+                            $ref: '#/components/schemas/Book'
+                required: true
+            responses:
+                "200":
+                    $ref: '#/components/responses/Book'
+    /shelves/{shelf}/cover:
+        put:
+            operationId: uploadCover
+            parameters:
+                - name: shelf
+                  in: path
+                  required: true
+                  schema:
+                    type: string
+            requestBody:
+                content:
+                    multipart/form-data:
+                        schema:
+                            type: object
+                            properties:
+                                image:
+                                    type: string
+                                    format: binary
+            responses:
+                "204":
+                    description: No Content
+components:
+    schemas:
+        Book:
+            required:
+                - title
+            type: object
+            properties:
+                title:
+                    type: string
+                subtitle:
+                    nullable: true
+                    type: string
+    responses:
+        Book:
+            description: A book.
+            content:
+                application/json:
+                    schema:
+                        $ref: '#/components/schemas/Book'
+    securitySchemes:
+        apiKey:
+            type: apiKey
+            name: key
+            in: query
+security:
+    - apiKey: []
+`
+
+func TestOpenAPIv2ToOpenAPIv3(t *testing.T) {
+	doc, err := openapi_v2.ParseDocument([]byte(swagger))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %s", err)
+	}
+	v3, err := OpenAPIv2ToOpenAPIv3(doc)
+	if err != nil {
+		t.Fatalf("OpenAPIv2ToOpenAPIv3() returned error: %s", err)
+	}
+	checkDocument(t, v3, swaggerV3YAML)
+}
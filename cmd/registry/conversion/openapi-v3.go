@@ -0,0 +1,682 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+	"gopkg.in/yaml.v3"
+)
+
+// v3Refs maps the prefixes of OpenAPI v3 references to those of OpenAPI v2.
+// References to parameters and request bodies aren't renamed because they
+// are resolved.
+var v3Refs = map[string]string{
+	"#/components/schemas/":   "#/definitions/",
+	"#/components/responses/": "#/responses/",
+}
+
+// OpenAPIv3ToOpenAPIv2 converts an OpenAPI v3 document to OpenAPI v2.
+// Request bodies become body or form parameters, and the host, base path,
+// and schemes are taken from the servers. Features of OpenAPI v3 that
+// OpenAPI v2 doesn't have are dropped, including cookie parameters, trace
+// operations, callbacks, links, oneOf and anyOf schemas, and bearer and
+// OpenID Connect security schemes.
+func OpenAPIv3ToOpenAPIv2(doc *openapi_v3.Document) (*openapi_v2.Document, error) {
+	c := &v3Converter{doc: doc}
+	out := &openapi_v2.Document{
+		Swagger:         "2.0",
+		Info:            infoV3ToV2(doc.GetInfo()),
+		Paths:           &openapi_v2.Paths{},
+		Security:        securityV3ToV2(doc.GetSecurity()),
+		ExternalDocs:    externalDocsV3ToV2(doc.GetExternalDocs()),
+		VendorExtension: extensionsV3ToV2(doc.GetSpecificationExtension()),
+	}
+	out.Host, out.BasePath, out.Schemes = c.location()
+	for _, tag := range doc.GetTags() {
+		out.Tags = append(out.Tags, &openapi_v2.Tag{
+			Name:            tag.GetName(),
+			Description:     tag.GetDescription(),
+			ExternalDocs:    externalDocsV3ToV2(tag.GetExternalDocs()),
+			VendorExtension: extensionsV3ToV2(tag.GetSpecificationExtension()),
+		})
+	}
+	for _, pair := range doc.GetPaths().GetPath() {
+		item, err := c.pathItem(pair.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", pair.GetName(), err)
+		}
+		out.Paths.Path = append(out.Paths.Path, &openapi_v2.NamedPathItem{Name: pair.GetName(), Value: item})
+	}
+	out.Paths.VendorExtension = extensionsV3ToV2(doc.GetPaths().GetSpecificationExtension())
+	if err := c.components(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type v3Converter struct {
+	doc *openapi_v3.Document
+}
+
+func infoV3ToV2(info *openapi_v3.Info) *openapi_v2.Info {
+	out := &openapi_v2.Info{
+		Title:           info.GetTitle(),
+		Description:     info.GetDescription(),
+		TermsOfService:  info.GetTermsOfService(),
+		Version:         info.GetVersion(),
+		VendorExtension: extensionsV3ToV2(info.GetSpecificationExtension()),
+	}
+	if contact := info.GetContact(); contact != nil {
+		out.Contact = &openapi_v2.Contact{Name: contact.GetName(), Url: contact.GetUrl(), Email: contact.GetEmail()}
+	}
+	if license := info.GetLicense(); license != nil {
+		out.License = &openapi_v2.License{Name: license.GetName(), Url: license.GetUrl()}
+	}
+	return out
+}
+
+// location returns the host, base path, and schemes of the first server.
+// The schemes of other servers with the same host and path are included.
+func (c *v3Converter) location() (host, basePath string, schemes []string) {
+	for i, server := range c.doc.GetServers() {
+		u, err := url.Parse(defaultServerURL(server))
+		if err != nil {
+			continue
+		}
+		if i == 0 {
+			host, basePath = u.Host, strings.TrimSuffix(u.Path, "/")
+		} else if u.Host != host || strings.TrimSuffix(u.Path, "/") != basePath {
+			continue
+		}
+		if u.Scheme != "" && !containsString(schemes, u.Scheme) {
+			schemes = append(schemes, u.Scheme)
+		}
+	}
+	return host, basePath, schemes
+}
+
+// defaultServerURL returns the URL of a server with its variables replaced
+// by their default values.
+func defaultServerURL(server *openapi_v3.Server) string {
+	u := server.GetUrl()
+	for _, pair := range server.GetVariables().GetAdditionalProperties() {
+		u = strings.ReplaceAll(u, "{"+pair.GetName()+"}", pair.GetValue().GetDefault())
+	}
+	return u
+}
+
+func (c *v3Converter) pathItem(item *openapi_v3.PathItem) (*openapi_v2.PathItem, error) {
+	out := &openapi_v2.PathItem{
+		XRef:            item.GetXRef(),
+		VendorExtension: extensionsV3ToV2(item.GetSpecificationExtension()),
+	}
+	params, err := c.parameters(item.GetParameters())
+	if err != nil {
+		return nil, err
+	}
+	out.Parameters = params
+	for _, op := range []struct {
+		in  *openapi_v3.Operation
+		out **openapi_v2.Operation
+	}{
+		{item.GetGet(), &out.Get},
+		{item.GetPut(), &out.Put},
+		{item.GetPost(), &out.Post},
+		{item.GetDelete(), &out.Delete},
+		{item.GetOptions(), &out.Options},
+		{item.GetHead(), &out.Head},
+		{item.GetPatch(), &out.Patch},
+	} {
+		if op.in == nil {
+			continue
+		}
+		if *op.out, err = c.operation(op.in); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (c *v3Converter) operation(op *openapi_v3.Operation) (*openapi_v2.Operation, error) {
+	out := &openapi_v2.Operation{
+		Tags:            op.GetTags(),
+		Summary:         op.GetSummary(),
+		Description:     op.GetDescription(),
+		ExternalDocs:    externalDocsV3ToV2(op.GetExternalDocs()),
+		OperationId:     op.GetOperationId(),
+		Deprecated:      op.GetDeprecated(),
+		Security:        securityV3ToV2(op.GetSecurity()),
+		Responses:       &openapi_v2.Responses{},
+		VendorExtension: extensionsV3ToV2(op.GetSpecificationExtension()),
+	}
+	params, err := c.parameters(op.GetParameters())
+	if err != nil {
+		return nil, err
+	}
+	out.Parameters = params
+	if body := c.requestBody(op.GetRequestBody()); body != nil {
+		params, consumes, err := c.bodyParameters(body)
+		if err != nil {
+			return nil, err
+		}
+		out.Parameters = append(out.Parameters, params...)
+		out.Consumes = consumes
+	}
+
+	responses := op.GetResponses()
+	var named []*openapi_v3.NamedResponseOrReference
+	if responses.GetDefault() != nil {
+		named = append(named, &openapi_v3.NamedResponseOrReference{Name: "default", Value: responses.GetDefault()})
+	}
+	named = append(named, responses.GetResponseOrReference()...)
+	for _, pair := range named {
+		r, produces, err := c.responseValue(pair.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		for _, mediaType := range produces {
+			if !containsString(out.Produces, mediaType) {
+				out.Produces = append(out.Produces, mediaType)
+			}
+		}
+		out.Responses.ResponseCode = append(out.Responses.ResponseCode,
+			&openapi_v2.NamedResponseValue{Name: pair.GetName(), Value: r})
+	}
+	out.Responses.VendorExtension = extensionsV3ToV2(responses.GetSpecificationExtension())
+	return out, nil
+}
+
+// parameters converts parameters with references resolved. Cookie
+// parameters are dropped.
+func (c *v3Converter) parameters(params []*openapi_v3.ParameterOrReference) ([]*openapi_v2.ParametersItem, error) {
+	var out []*openapi_v2.ParametersItem
+	for _, p := range params {
+		param := p.GetParameter()
+		if ref := p.GetReference().GetXRef(); ref != "" {
+			if param = c.parameter(ref); param == nil {
+				return nil, fmt.Errorf("unresolved reference %s", ref)
+			}
+		}
+		if param.GetIn() == "cookie" {
+			continue
+		}
+		converted, err := c.parameterV3ToV2(param)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %s", param.GetName(), err)
+		}
+		out = append(out, &openapi_v2.ParametersItem{
+			Oneof: &openapi_v2.ParametersItem_Parameter{Parameter: converted},
+		})
+	}
+	return out, nil
+}
+
+func (c *v3Converter) parameter(ref string) *openapi_v3.Parameter {
+	name := strings.TrimPrefix(ref, "#/components/parameters/")
+	for _, pair := range c.doc.GetComponents().GetParameters().GetAdditionalProperties() {
+		if pair.GetName() == name {
+			return pair.GetValue().GetParameter()
+		}
+	}
+	return nil
+}
+
+// parameterV3ToV2 converts a path, query, or header parameter, whose schema
+// becomes its type and constraints.
+func (c *v3Converter) parameterV3ToV2(p *openapi_v3.Parameter) (*openapi_v2.Parameter, error) {
+	node, err := c.primitiveNode(p.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	setValue(node, "name", scalar(p.GetName()))
+	setValue(node, "in", scalar(p.GetIn()))
+	if p.GetDescription() != "" {
+		setValue(node, "description", scalar(p.GetDescription()))
+	}
+	if p.GetRequired() || p.GetIn() == "path" {
+		setValue(node, "required", boolean(true))
+	}
+	if p.GetAllowEmptyValue() && p.GetIn() == "query" {
+		setValue(node, "allowEmptyValue", boolean(true))
+	}
+	if t := mapValue(node, "type"); t != nil && t.Value == "array" {
+		switch p.GetStyle() {
+		case "spaceDelimited":
+			setValue(node, "collectionFormat", scalar("ssv"))
+		case "pipeDelimited":
+			setValue(node, "collectionFormat", scalar("pipes"))
+		case "form", "":
+			// Query arrays are exploded by default.
+			if p.GetIn() == "query" {
+				setValue(node, "collectionFormat", scalar("multi"))
+			}
+		}
+	}
+	for _, e := range p.GetSpecificationExtension() {
+		setValue(node, e.GetName(), e.GetValue().ToRawInfo())
+	}
+	return openapi_v2.NewParameter(node, newContext("parameter", node))
+}
+
+// primitiveNode returns the YAML form of the type and constraints of a
+// parameter, header, or form field from its schema. References to schemas
+// are resolved, and schemas that aren't primitives or arrays are strings.
+func (c *v3Converter) primitiveNode(schema *openapi_v3.SchemaOrReference) (*yaml.Node, error) {
+	s, err := c.schema(schema)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setValue(m, "type", scalar("string"))
+		return m, nil
+	}
+	node := rewriteSchema(s.ToRawInfo(), nil, func(m *yaml.Node) {
+		keepKeys(m, primitiveKeys...)
+		if t := mapValue(m, "type"); t == nil || t.Value == "object" {
+			setValue(m, "type", scalar("string"))
+		}
+	})
+	if items := mapValue(node, "items"); items != nil {
+		if ref := mapValue(items, "$ref"); ref != nil {
+			resolved, err := c.primitiveNode(&openapi_v3.SchemaOrReference{
+				Oneof: &openapi_v3.SchemaOrReference_Reference{Reference: &openapi_v3.Reference{XRef: ref.Value}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			setValue(node, "items", resolved)
+		}
+	}
+	return node, nil
+}
+
+// schema returns a schema with a reference to a schema component resolved.
+func (c *v3Converter) schema(schema *openapi_v3.SchemaOrReference) (*openapi_v3.Schema, error) {
+	for i := 0; schema.GetReference() != nil; i++ {
+		ref := schema.GetReference().GetXRef()
+		if i > 16 {
+			return nil, fmt.Errorf("too many references from %s", ref)
+		}
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		schema = nil
+		for _, pair := range c.doc.GetComponents().GetSchemas().GetAdditionalProperties() {
+			if pair.GetName() == name {
+				schema = pair.GetValue()
+			}
+		}
+		if schema == nil {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+	}
+	return schema.GetSchema(), nil
+}
+
+func (c *v3Converter) requestBody(body *openapi_v3.RequestBodyOrReference) *openapi_v3.RequestBody {
+	ref := body.GetReference().GetXRef()
+	if ref == "" {
+		return body.GetRequestBody()
+	}
+	name := strings.TrimPrefix(ref, "#/components/requestBodies/")
+	for _, pair := range c.doc.GetComponents().GetRequestBodies().GetAdditionalProperties() {
+		if pair.GetName() == name {
+			return pair.GetValue().GetRequestBody()
+		}
+	}
+	return nil
+}
+
+// bodyParameters converts a request body to a body parameter or, if it
+// is a form, to form parameters, and returns the types that it consumes.
+func (c *v3Converter) bodyParameters(body *openapi_v3.RequestBody) ([]*openapi_v2.ParametersItem, []string, error) {
+	var consumes []string
+	for _, pair := range body.GetContent().GetAdditionalProperties() {
+		consumes = append(consumes, pair.GetName())
+	}
+	mediaType, schema := preferredContent(body.GetContent())
+	if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+		params, err := c.formParameters(schema)
+		return params, []string{mediaType}, err
+	}
+	param := &openapi_v2.BodyParameter{
+		Name:            "body",
+		In:              "body",
+		Description:     body.GetDescription(),
+		Required:        body.GetRequired(),
+		VendorExtension: extensionsV3ToV2(body.GetSpecificationExtension()),
+	}
+	if schema != nil {
+		s, err := schemaV3ToV2(schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		param.Schema = s
+	} else {
+		param.Schema = &openapi_v2.Schema{}
+	}
+	return []*openapi_v2.ParametersItem{{
+		Oneof: &openapi_v2.ParametersItem_Parameter{
+			Parameter: &openapi_v2.Parameter{
+				Oneof: &openapi_v2.Parameter_BodyParameter{BodyParameter: param},
+			},
+		},
+	}}, consumes, nil
+}
+
+// formParameters converts the properties of a form's schema to form
+// parameters. Binary strings are files.
+func (c *v3Converter) formParameters(schema *openapi_v3.SchemaOrReference) ([]*openapi_v2.ParametersItem, error) {
+	s, err := c.schema(schema)
+	if err != nil {
+		return nil, err
+	}
+	var out []*openapi_v2.ParametersItem
+	for _, pair := range s.GetProperties().GetAdditionalProperties() {
+		node, err := c.primitiveNode(pair.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if t, f := mapValue(node, "type"), mapValue(node, "format"); t != nil && t.Value == "string" && f != nil && f.Value == "binary" {
+			deleteKeys(node, "format")
+			setValue(node, "type", scalar("file"))
+		}
+		setValue(node, "name", scalar(pair.GetName()))
+		setValue(node, "in", scalar("formData"))
+		if property, err := c.schema(pair.GetValue()); err == nil && property.GetDescription() != "" {
+			setValue(node, "description", scalar(property.GetDescription()))
+		}
+		if containsString(s.GetRequired(), pair.GetName()) {
+			setValue(node, "required", boolean(true))
+		}
+		param, err := openapi_v2.NewParameter(node, newContext("parameter", node))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &openapi_v2.ParametersItem{
+			Oneof: &openapi_v2.ParametersItem_Parameter{Parameter: param},
+		})
+	}
+	return out, nil
+}
+
+// preferredContent returns the JSON content of a request or response if
+// it has JSON content and otherwise its first content.
+func preferredContent(content *openapi_v3.MediaTypes) (string, *openapi_v3.SchemaOrReference) {
+	pairs := content.GetAdditionalProperties()
+	for _, pair := range pairs {
+		if isJSON(mediaType(pair.GetName())) {
+			return pair.GetName(), pair.GetValue().GetSchema()
+		}
+	}
+	if len(pairs) == 0 {
+		return "", nil
+	}
+	return pairs[0].GetName(), pairs[0].GetValue().GetSchema()
+}
+
+func (c *v3Converter) responseValue(r *openapi_v3.ResponseOrReference) (*openapi_v2.ResponseValue, []string, error) {
+	if ref := r.GetReference().GetXRef(); ref != "" {
+		return &openapi_v2.ResponseValue{
+			Oneof: &openapi_v2.ResponseValue_JsonReference{
+				JsonReference: &openapi_v2.JsonReference{XRef: renameRef(ref, v3Refs)},
+			},
+		}, nil, nil
+	}
+	response, produces, err := c.response(r.GetResponse())
+	if err != nil {
+		return nil, nil, err
+	}
+	return &openapi_v2.ResponseValue{
+		Oneof: &openapi_v2.ResponseValue_Response{Response: response},
+	}, produces, nil
+}
+
+// response converts a response and returns the types that it produces.
+func (c *v3Converter) response(r *openapi_v3.Response) (*openapi_v2.Response, []string, error) {
+	out := &openapi_v2.Response{
+		Description:     r.GetDescription(),
+		VendorExtension: extensionsV3ToV2(r.GetSpecificationExtension()),
+	}
+	var produces []string
+	for _, pair := range r.GetContent().GetAdditionalProperties() {
+		produces = append(produces, pair.GetName())
+	}
+	if _, schema := preferredContent(r.GetContent()); schema != nil {
+		s, err := schemaV3ToV2(schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		out.Schema = &openapi_v2.SchemaItem{Oneof: &openapi_v2.SchemaItem_Schema{Schema: s}}
+	}
+	for _, pair := range r.GetHeaders().GetAdditionalProperties() {
+		h := pair.GetValue().GetHeader()
+		if h == nil {
+			continue
+		}
+		node, err := c.primitiveNode(h.GetSchema())
+		if err != nil {
+			return nil, nil, err
+		}
+		if h.GetDescription() != "" {
+			setValue(node, "description", scalar(h.GetDescription()))
+		}
+		header, err := openapi_v2.NewHeader(node, newContext("header", node))
+		if err != nil {
+			return nil, nil, err
+		}
+		if out.Headers == nil {
+			out.Headers = &openapi_v2.Headers{}
+		}
+		out.Headers.AdditionalProperties = append(out.Headers.AdditionalProperties,
+			&openapi_v2.NamedHeader{Name: pair.GetName(), Value: header})
+	}
+	return out, produces, nil
+}
+
+// schemaV3ToV2 converts a schema. Nullable schemas are marked with the
+// x-nullable extension, which many OpenAPI v2 tools support.
+func schemaV3ToV2(schema *openapi_v3.SchemaOrReference) (*openapi_v2.Schema, error) {
+	var node *yaml.Node
+	if ref := schema.GetReference(); ref != nil {
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setValue(node, "$ref", scalar(ref.GetXRef()))
+	} else {
+		node = schema.GetSchema().ToRawInfo()
+	}
+	node = rewriteSchema(node, v3Refs, func(m *yaml.Node) {
+		deleteKeys(m, "oneOf", "anyOf", "not", "writeOnly", "deprecated")
+		renameKey(m, "nullable", "x-nullable")
+		// OpenAPI v2 discriminators are property names.
+		if d := mapValue(m, "discriminator"); d != nil && d.Kind == yaml.MappingNode {
+			if name := mapValue(d, "propertyName"); name != nil {
+				setValue(m, "discriminator", name)
+			} else {
+				deleteKeys(m, "discriminator")
+			}
+		}
+	})
+	return openapi_v2.NewSchema(node, newContext("schema", node))
+}
+
+// components converts schemas, responses, and security schemes.
+// Parameters and request bodies aren't converted because references to
+// them are resolved.
+func (c *v3Converter) components(out *openapi_v2.Document) error {
+	components := c.doc.GetComponents()
+	for _, pair := range components.GetSchemas().GetAdditionalProperties() {
+		schema, err := schemaV3ToV2(pair.GetValue())
+		if err != nil {
+			return fmt.Errorf("components/schemas/%s: %s", pair.GetName(), err)
+		}
+		if out.Definitions == nil {
+			out.Definitions = &openapi_v2.Definitions{}
+		}
+		out.Definitions.AdditionalProperties = append(out.Definitions.AdditionalProperties,
+			&openapi_v2.NamedSchema{Name: pair.GetName(), Value: schema})
+	}
+	for _, pair := range components.GetResponses().GetAdditionalProperties() {
+		r := pair.GetValue().GetResponse()
+		if r == nil {
+			continue
+		}
+		response, _, err := c.response(r)
+		if err != nil {
+			return fmt.Errorf("components/responses/%s: %s", pair.GetName(), err)
+		}
+		if out.Responses == nil {
+			out.Responses = &openapi_v2.ResponseDefinitions{}
+		}
+		out.Responses.AdditionalProperties = append(out.Responses.AdditionalProperties,
+			&openapi_v2.NamedResponse{Name: pair.GetName(), Value: response})
+	}
+	for _, pair := range components.GetSecuritySchemes().GetAdditionalProperties() {
+		item := securitySchemeV3ToV2(pair.GetValue().GetSecurityScheme())
+		if item == nil {
+			continue
+		}
+		if out.SecurityDefinitions == nil {
+			out.SecurityDefinitions = &openapi_v2.SecurityDefinitions{}
+		}
+		out.SecurityDefinitions.AdditionalProperties = append(out.SecurityDefinitions.AdditionalProperties,
+			&openapi_v2.NamedSecurityDefinitionsItem{Name: pair.GetName(), Value: item})
+	}
+	return nil
+}
+
+// securitySchemeV3ToV2 converts a security scheme, or returns nil if
+// OpenAPI v2 can't describe it. Schemes with several OAuth flows are
+// described by their first flow.
+func securitySchemeV3ToV2(s *openapi_v3.SecurityScheme) *openapi_v2.SecurityDefinitionsItem {
+	switch {
+	case s.GetType() == "http" && strings.EqualFold(s.GetScheme(), "basic"):
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_BasicAuthenticationSecurity{
+				BasicAuthenticationSecurity: &openapi_v2.BasicAuthenticationSecurity{Type: "basic", Description: s.GetDescription()},
+			},
+		}
+	case s.GetType() == "apiKey" && s.GetIn() != "cookie":
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_ApiKeySecurity{
+				ApiKeySecurity: &openapi_v2.ApiKeySecurity{Type: "apiKey", Name: s.GetName(), In: s.GetIn(), Description: s.GetDescription()},
+			},
+		}
+	case s.GetType() != "oauth2":
+		return nil
+	}
+	flows := s.GetFlows()
+	if f := flows.GetImplicit(); f != nil {
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_Oauth2ImplicitSecurity{
+				Oauth2ImplicitSecurity: &openapi_v2.Oauth2ImplicitSecurity{
+					Type:             "oauth2",
+					Flow:             "implicit",
+					AuthorizationUrl: f.GetAuthorizationUrl(),
+					Scopes:           scopesV3ToV2(f.GetScopes()),
+					Description:      s.GetDescription(),
+				},
+			},
+		}
+	}
+	if f := flows.GetPassword(); f != nil {
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_Oauth2PasswordSecurity{
+				Oauth2PasswordSecurity: &openapi_v2.Oauth2PasswordSecurity{
+					Type:        "oauth2",
+					Flow:        "password",
+					TokenUrl:    f.GetTokenUrl(),
+					Scopes:      scopesV3ToV2(f.GetScopes()),
+					Description: s.GetDescription(),
+				},
+			},
+		}
+	}
+	if f := flows.GetClientCredentials(); f != nil {
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_Oauth2ApplicationSecurity{
+				Oauth2ApplicationSecurity: &openapi_v2.Oauth2ApplicationSecurity{
+					Type:        "oauth2",
+					Flow:        "application",
+					TokenUrl:    f.GetTokenUrl(),
+					Scopes:      scopesV3ToV2(f.GetScopes()),
+					Description: s.GetDescription(),
+				},
+			},
+		}
+	}
+	if f := flows.GetAuthorizationCode(); f != nil {
+		return &openapi_v2.SecurityDefinitionsItem{
+			Oneof: &openapi_v2.SecurityDefinitionsItem_Oauth2AccessCodeSecurity{
+				Oauth2AccessCodeSecurity: &openapi_v2.Oauth2AccessCodeSecurity{
+					Type:             "oauth2",
+					Flow:             "accessCode",
+					AuthorizationUrl: f.GetAuthorizationUrl(),
+					TokenUrl:         f.GetTokenUrl(),
+					Scopes:           scopesV3ToV2(f.GetScopes()),
+					Description:      s.GetDescription(),
+				},
+			},
+		}
+	}
+	return nil
+}
+
+// scopesV3ToV2 converts OAuth scopes. Note that gnostic doesn't write
+// OpenAPI v2 scopes in YAML, so they're lost when documents are written.
+func scopesV3ToV2(scopes *openapi_v3.Strings) *openapi_v2.Oauth2Scopes {
+	out := &openapi_v2.Oauth2Scopes{}
+	for _, pair := range scopes.GetAdditionalProperties() {
+		out.AdditionalProperties = append(out.AdditionalProperties,
+			&openapi_v2.NamedString{Name: pair.GetName(), Value: pair.GetValue()})
+	}
+	return out
+}
+
+func securityV3ToV2(requirements []*openapi_v3.SecurityRequirement) []*openapi_v2.SecurityRequirement {
+	var out []*openapi_v2.SecurityRequirement
+	for _, r := range requirements {
+		requirement := &openapi_v2.SecurityRequirement{}
+		for _, pair := range r.GetAdditionalProperties() {
+			requirement.AdditionalProperties = append(requirement.AdditionalProperties, &openapi_v2.NamedStringArray{
+				Name:  pair.GetName(),
+				Value: &openapi_v2.StringArray{Value: pair.GetValue().GetValue()},
+			})
+		}
+		out = append(out, requirement)
+	}
+	return out
+}
+
+func externalDocsV3ToV2(docs *openapi_v3.ExternalDocs) *openapi_v2.ExternalDocs {
+	if docs == nil {
+		return nil
+	}
+	return &openapi_v2.ExternalDocs{Description: docs.GetDescription(), Url: docs.GetUrl()}
+}
+
+func extensionsV3ToV2(extensions []*openapi_v3.NamedAny) []*openapi_v2.NamedAny {
+	var out []*openapi_v2.NamedAny
+	for _, e := range extensions {
+		out = append(out, &openapi_v2.NamedAny{
+			Name:  e.GetName(),
+			Value: &openapi_v2.Any{Value: e.GetValue().GetValue(), Yaml: e.GetValue().GetYaml()},
+		})
+	}
+	return out
+}
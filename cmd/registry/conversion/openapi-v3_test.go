@@ -0,0 +1,223 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	openapi_v2 "github.com/googleapis/gnostic/openapiv2"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+const zoo = `openapi: 3.0.3
+info:
+  title: Zoo
+  version: 2.0.0
+servers:
+- url: "{scheme}://zoo.example.com/{base}"
+  variables:
+    scheme:
+      default: https
+      enum: [https, http]
+    base:
+      default: api
+paths:
+  /animals:
+    get:
+      operationId: listAnimals
+      parameters:
+      - name: session
+        in: cookie
+        schema:
+          type: string
+      - $ref: '#/components/parameters/kinds'
+      responses:
+        "200":
+          description: OK
+          headers:
+            X-Total:
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Animal'
+            application/xml:
+              schema:
+                type: array
+                items:
+                  $ref: '#/components/schemas/Animal'
+    post:
+      operationId: createAnimal
+      requestBody:
+        $ref: '#/components/requestBodies/Animal'
+      responses:
+        "201":
+          description: Created
+    trace:
+      responses:
+        "200":
+          description: OK
+components:
+  parameters:
+    kinds:
+      name: kind
+      in: query
+      schema:
+        type: array
+        items:
+          type: string
+  requestBodies:
+    Animal:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Animal'
+  schemas:
+    Animal:
+      type: object
+      discriminator:
+        propertyName: kind
+      required:
+      - kind
+      properties:
+        kind:
+          type: string
+        name:
+          type: string
+          nullable: true
+        owner:
+          oneOf:
+          - type: string
+          - type: integer
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        authorizationCode:
+          authorizationUrl: https://zoo.example.com/auth
+          tokenUrl: https://zoo.example.com/token
+          scopes:
+            read: Read animals.
+`
+
+// zooV2YAML has no scopes because gnostic doesn't write them.
+const zooV2YAML = `swagger: "2.0"
+info:
+    title: Zoo
+    version: 2.0.0
+host: zoo.example.com
+basePath: /api
+schemes:
+    - https
+paths:
+    /animals:
+        get:
+            operationId: listAnimals
+            produces:
+                - application/json
+                - application/xml
+            parameters:
+                - in: query
+                  name: kind
+                  type: array
+                  items:
+                    type: string
+                  collectionFormat: multi
+            responses:
+                "200":
+                    description: OK
+                    schema:
+                        type: array
+                        items:
+                            $ref: '#/definitions/Animal'
+                    headers:
+                        X-Total:
+                            type: integer
+        post:
+            operationId: createAnimal
+            consumes:
+                - application/json
+            parameters:
+                - name: body
+                  in: body
+                  required: true
+                  schema:
+                    $ref: '#/definitions/Animal'
+            responses:
+                "201":
+                    description: Created
+definitions:
+    Animal:
+        required:
+            - kind
+        type: object
+        properties:
+            kind:
+                type: string
+            name:
+                type: string
+                x-nullable: true
+            owner: {}
+        discriminator: kind
+securityDefinitions:
+    oauth:
+        type: oauth2
+        flow: accessCode
+        scopes: {}
+        authorizationUrl: https://zoo.example.com/auth
+        tokenUrl: https://zoo.example.com/token
+`
+
+func TestOpenAPIv3ToOpenAPIv2(t *testing.T) {
+	doc, err := openapi_v3.ParseDocument([]byte(zoo))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %s", err)
+	}
+	v2, err := OpenAPIv3ToOpenAPIv2(doc)
+	if err != nil {
+		t.Fatalf("OpenAPIv3ToOpenAPIv2() returned error: %s", err)
+	}
+	b, err := v2.YAMLValue("")
+	if err != nil {
+		t.Fatalf("YAMLValue() returned error: %s", err)
+	}
+	if diff := cmp.Diff(zooV2YAML, string(b)); diff != "" {
+		t.Errorf("unexpected document (-want +got):\n%s", diff)
+	}
+	if _, err := openapi_v2.ParseDocument(b); err != nil {
+		t.Errorf("ParseDocument() returned error: %s", err)
+	}
+}
+
+func TestOpenAPIRoundTrip(t *testing.T) {
+	doc, err := openapi_v3.ParseDocument([]byte(swaggerV3YAML))
+	if err != nil {
+		t.Fatalf("ParseDocument() returned error: %s", err)
+	}
+	v2, err := OpenAPIv3ToOpenAPIv2(doc)
+	if err != nil {
+		t.Fatalf("OpenAPIv3ToOpenAPIv2() returned error: %s", err)
+	}
+	v3, err := OpenAPIv2ToOpenAPIv3(v2)
+	if err != nil {
+		t.Fatalf("OpenAPIv2ToOpenAPIv3() returned error: %s", err)
+	}
+	checkDocument(t, v3, swaggerV3YAML)
+}
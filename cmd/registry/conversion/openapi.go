@@ -0,0 +1,406 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conversion converts descriptions of APIs between formats.
+package conversion
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+// An exchange is an HTTP request and the responses to it, which are read
+// from a recording or a collection of requests and from which an operation
+// is inferred.
+type exchange struct {
+	summary     string
+	description string
+	method      string
+	server      string
+	// path is a template such as /shelves/{shelf}.
+	path       string
+	pathParams []param
+	query      []param
+	headers    []param
+	body       *body
+	responses  []*response
+}
+
+type param struct {
+	name        string
+	value       string
+	description string
+	file        bool // a multipart form field that is a file
+}
+
+type body struct {
+	mediaType string
+	text      string
+	form      []param
+}
+
+type response struct {
+	status      int
+	description string
+	mediaType   string
+	text        string
+}
+
+// A builder merges exchanges into an OpenAPI v3 document. Exchanges with the
+// same method and path are merged into one operation. Bodies are described
+// by schemas that are inferred from them, but bodies and parameter values
+// aren't included as examples because recordings can contain credentials
+// and personal data. For the same reason, HAR files are redacted with
+// RedactHAR before they are stored with specs.
+type builder struct {
+	servers    []string
+	operations map[string]*operation
+}
+
+type operation struct {
+	path        string
+	method      string
+	summary     string
+	description string
+	params      []*parameter
+	bodies      map[string]*schema
+	responses   map[string]*responseContent
+}
+
+type parameter struct {
+	name        string
+	in          string
+	description string
+	schema      *schema
+}
+
+type responseContent struct {
+	description string
+	content     map[string]*schema
+}
+
+func newBuilder() *builder {
+	return &builder{operations: make(map[string]*operation)}
+}
+
+// add merges an exchange into the document. Exchanges with methods that
+// OpenAPI doesn't support are ignored.
+func (b *builder) add(e *exchange) {
+	method := strings.ToUpper(e.method)
+	if !supportedMethod(method) {
+		return
+	}
+	if e.server != "" && !containsString(b.servers, e.server) {
+		b.servers = append(b.servers, e.server)
+	}
+	path := e.path
+	if path == "" {
+		path = "/"
+	}
+	key := method + " " + path
+	op, ok := b.operations[key]
+	if !ok {
+		op = &operation{
+			path:      path,
+			method:    method,
+			bodies:    make(map[string]*schema),
+			responses: make(map[string]*responseContent),
+		}
+		b.operations[key] = op
+	}
+	if op.summary == "" {
+		op.summary = e.summary
+	}
+	if op.description == "" {
+		op.description = e.description
+	}
+
+	// Every name in the path template is a parameter, described by the
+	// exchange's path parameter of that name if there is one.
+	for _, m := range templateVariable.FindAllStringSubmatch(path, -1) {
+		p := param{name: m[1]}
+		for _, pathParam := range e.pathParams {
+			if pathParam.name == p.name {
+				p = pathParam
+			}
+		}
+		op.param("path", p)
+	}
+	for _, p := range e.query {
+		op.param("query", p)
+	}
+	for _, p := range e.headers {
+		if !ignoredHeader(p.name) {
+			op.param("header", p)
+		}
+	}
+	if e.body != nil {
+		if mediaType, s := bodySchema(e.body); mediaType != "" {
+			op.bodies[mediaType] = mergeSchemas(op.bodies[mediaType], s)
+		}
+	}
+	for _, r := range e.responses {
+		op.response(r)
+	}
+}
+
+func (op *operation) param(in string, p param) {
+	for _, existing := range op.params {
+		if existing.in == in && existing.name == p.name {
+			existing.schema = mergeSchemas(existing.schema, schemaForString(p.value))
+			return
+		}
+	}
+	op.params = append(op.params, &parameter{
+		name:        p.name,
+		in:          in,
+		description: p.description,
+		schema:      schemaForString(p.value),
+	})
+}
+
+func (op *operation) response(r *response) {
+	code := "default"
+	if r.status > 0 {
+		code = strconv.Itoa(r.status)
+	}
+	content, ok := op.responses[code]
+	if !ok {
+		content = &responseContent{description: r.description, content: make(map[string]*schema)}
+		if content.description == "" {
+			content.description = http.StatusText(r.status)
+		}
+		if content.description == "" {
+			content.description = "Response"
+		}
+		op.responses[code] = content
+	}
+	if mediaType, s := bodySchema(&body{mediaType: r.mediaType, text: r.text}); mediaType != "" {
+		content.content[mediaType] = mergeSchemas(content.content[mediaType], s)
+	}
+}
+
+// bodySchema returns the media type and schema of a body, or an empty media
+// type if the body is empty.
+func bodySchema(b *body) (string, *schema) {
+	mediaType := mediaType(b.mediaType)
+	if len(b.form) > 0 {
+		if mediaType == "" {
+			mediaType = "application/x-www-form-urlencoded"
+		}
+		s := &schema{typ: "object", properties: make(map[string]*schema)}
+		for _, field := range b.form {
+			if field.file {
+				s.properties[field.name] = &schema{typ: "string", format: "binary"}
+			} else {
+				s.properties[field.name] = schemaForString(field.value)
+			}
+		}
+		return mediaType, s
+	}
+	if strings.TrimSpace(b.text) == "" {
+		return "", nil
+	}
+	if mediaType == "" && looksLikeJSON(b.text) {
+		mediaType = "application/json"
+	} else if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	if isJSON(mediaType) {
+		if s := schemaForJSON(b.text); s != nil {
+			return mediaType, s
+		}
+	}
+	return mediaType, &schema{typ: "string"}
+}
+
+func supportedMethod(method string) bool {
+	switch method {
+	case "GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE":
+		return true
+	default:
+		return false
+	}
+}
+
+// ignoredHeader returns true for headers that are set by HTTP clients or
+// that are described in other ways, which aren't described as parameters.
+func ignoredHeader(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "accept", "accept-encoding", "accept-language", "authorization", "cache-control",
+		"connection", "content-length", "content-type", "cookie", "dnt", "host",
+		"if-modified-since", "if-none-match", "origin", "postman-token", "pragma",
+		"priority", "referer", "te", "upgrade-insecure-requests", "user-agent":
+		return true
+	}
+	return strings.HasPrefix(name, "sec-") || strings.HasPrefix(name, ":")
+}
+
+// templateVariable matches the names in braces in path and server URL templates.
+var templateVariable = regexp.MustCompile(`{([^{}]+)}`)
+
+// document returns an OpenAPI v3 document that describes the exchanges
+// that were added.
+func (b *builder) document(title, description, version string) *openapi_v3.Document {
+	if version == "" {
+		version = "1.0.0"
+	}
+	doc := &openapi_v3.Document{
+		Openapi: "3.0.3",
+		Info: &openapi_v3.Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: &openapi_v3.Paths{},
+	}
+	for _, url := range b.servers {
+		server := &openapi_v3.Server{Url: url}
+		for _, m := range templateVariable.FindAllStringSubmatch(url, -1) {
+			if server.Variables == nil {
+				server.Variables = &openapi_v3.ServerVariables{}
+			}
+			server.Variables.AdditionalProperties = append(server.Variables.AdditionalProperties,
+				&openapi_v3.NamedServerVariable{Name: m[1], Value: &openapi_v3.ServerVariable{Default: ""}})
+		}
+		doc.Servers = append(doc.Servers, server)
+	}
+
+	items := make(map[string]*openapi_v3.PathItem)
+	for _, op := range b.operations {
+		item, ok := items[op.path]
+		if !ok {
+			item = &openapi_v3.PathItem{}
+			items[op.path] = item
+		}
+		switch op.method {
+		case "GET":
+			item.Get = op.openAPI()
+		case "PUT":
+			item.Put = op.openAPI()
+		case "POST":
+			item.Post = op.openAPI()
+		case "DELETE":
+			item.Delete = op.openAPI()
+		case "OPTIONS":
+			item.Options = op.openAPI()
+		case "HEAD":
+			item.Head = op.openAPI()
+		case "PATCH":
+			item.Patch = op.openAPI()
+		case "TRACE":
+			item.Trace = op.openAPI()
+		}
+	}
+	paths := make([]string, 0, len(items))
+	for path := range items {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		doc.Paths.Path = append(doc.Paths.Path, &openapi_v3.NamedPathItem{Name: path, Value: items[path]})
+	}
+	return doc
+}
+
+func (op *operation) openAPI() *openapi_v3.Operation {
+	out := &openapi_v3.Operation{
+		Summary:     op.summary,
+		Description: op.description,
+		Responses:   &openapi_v3.Responses{},
+	}
+	for _, p := range op.params {
+		out.Parameters = append(out.Parameters, &openapi_v3.ParameterOrReference{
+			Oneof: &openapi_v3.ParameterOrReference_Parameter{
+				Parameter: &openapi_v3.Parameter{
+					Name:        p.name,
+					In:          p.in,
+					Description: p.description,
+					Required:    p.in == "path",
+					Schema:      p.schema.openAPI(),
+				},
+			},
+		})
+	}
+	if len(op.bodies) > 0 {
+		out.RequestBody = &openapi_v3.RequestBodyOrReference{
+			Oneof: &openapi_v3.RequestBodyOrReference_RequestBody{
+				RequestBody: &openapi_v3.RequestBody{Content: mediaTypes(op.bodies)},
+			},
+		}
+	}
+
+	codes := make([]string, 0, len(op.responses))
+	for code := range op.responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		r := &openapi_v3.ResponseOrReference{
+			Oneof: &openapi_v3.ResponseOrReference_Response{
+				Response: &openapi_v3.Response{
+					Description: op.responses[code].description,
+					Content:     mediaTypes(op.responses[code].content),
+				},
+			},
+		}
+		if code == "default" {
+			out.Responses.Default = r
+		} else {
+			out.Responses.ResponseOrReference = append(out.Responses.ResponseOrReference,
+				&openapi_v3.NamedResponseOrReference{Name: code, Value: r})
+		}
+	}
+	// Responses are required, so operations without recorded responses
+	// have a default response.
+	if len(codes) == 0 {
+		out.Responses.Default = &openapi_v3.ResponseOrReference{
+			Oneof: &openapi_v3.ResponseOrReference_Response{
+				Response: &openapi_v3.Response{Description: "Response"},
+			},
+		}
+	}
+	return out
+}
+
+func mediaTypes(content map[string]*schema) *openapi_v3.MediaTypes {
+	if len(content) == 0 {
+		return nil
+	}
+	out := &openapi_v3.MediaTypes{}
+	for _, name := range sortedNames(content) {
+		mediaType := &openapi_v3.MediaType{}
+		if s := content[name]; s != nil {
+			mediaType.Schema = s.openAPI()
+		}
+		out.AdditionalProperties = append(out.AdditionalProperties,
+			&openapi_v3.NamedMediaType{Name: name, Value: mediaType})
+	}
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
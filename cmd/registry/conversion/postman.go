@@ -0,0 +1,418 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+// PostmanMimeType is the MIME type of Postman v2.1 collections.
+const PostmanMimeType = "application/vnd.postman.collection+json;version=2.1"
+
+// PostmanToOpenAPI converts a Postman v2.1 collection to an OpenAPI v3
+// document. Each request in the collection and its folders is an operation,
+// and the saved responses of requests are the responses of operations.
+// Path segments such as :shelf and {{shelf}} are path parameters, and
+// collection variables in server URLs are replaced by their values or,
+// if they have none, described as server variables.
+func PostmanToOpenAPI(b []byte) (*openapi_v3.Document, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(b, &collection); err != nil {
+		return nil, err
+	}
+	if collection.Info.Name == "" {
+		return nil, fmt.Errorf("not a Postman collection: info.name is missing")
+	}
+	variables := make(map[string]string)
+	for _, v := range collection.Variable {
+		if v.Value != "" {
+			variables[v.Key] = string(v.Value)
+		}
+	}
+
+	builder := newBuilder()
+	var add func(items []*postmanItem)
+	add = func(items []*postmanItem) {
+		for _, item := range items {
+			if item.Request != nil {
+				builder.add(item.exchange(variables))
+			}
+			add(item.Item)
+		}
+	}
+	add(collection.Item)
+	return builder.document(collection.Info.Name, string(collection.Info.Description),
+		collection.Info.Version.String()), nil
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name        string             `json:"name"`
+		Description postmanDescription `json:"description"`
+		Version     postmanVersion     `json:"version"`
+	} `json:"info"`
+	Item     []*postmanItem `json:"item"`
+	Variable []postmanPair  `json:"variable"`
+}
+
+// A postmanItem is a request or a folder of items.
+type postmanItem struct {
+	Name        string             `json:"name"`
+	Description postmanDescription `json:"description"`
+	Item        []*postmanItem     `json:"item"`
+	Request     *postmanRequest    `json:"request"`
+	Response    []*postmanResponse `json:"response"`
+}
+
+type postmanRequest struct {
+	Method      string             `json:"method"`
+	URL         postmanURL         `json:"url"`
+	Header      postmanPairs       `json:"header"`
+	Body        *postmanBody       `json:"body"`
+	Description postmanDescription `json:"description"`
+}
+
+// UnmarshalJSON reads a request, which can be a URL to GET.
+func (r *postmanRequest) UnmarshalJSON(b []byte) error {
+	var url string
+	if err := json.Unmarshal(b, &url); err == nil {
+		*r = postmanRequest{Method: "GET", URL: postmanURL{Raw: url}}
+		return nil
+	}
+	type request postmanRequest
+	return json.Unmarshal(b, (*request)(r))
+}
+
+type postmanURL struct {
+	Raw      string          `json:"raw"`
+	Protocol string          `json:"protocol"`
+	Host     json.RawMessage `json:"host"`
+	Port     string          `json:"port"`
+	Path     json.RawMessage `json:"path"`
+	Query    []postmanPair   `json:"query"`
+	Variable []postmanPair   `json:"variable"`
+}
+
+// UnmarshalJSON reads a URL, which can be a string.
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		*u = postmanURL{Raw: raw}
+		return nil
+	}
+	type url postmanURL
+	return json.Unmarshal(b, (*url)(u))
+}
+
+// postmanPair is a header, query parameter, variable, or form field.
+type postmanPair struct {
+	Key         string             `json:"key"`
+	Value       postmanValue       `json:"value"`
+	Description postmanDescription `json:"description"`
+	Disabled    bool               `json:"disabled"`
+	Type        string             `json:"type"`
+}
+
+// postmanValue is the value of a pair, which can be any JSON value.
+type postmanValue string
+
+func (v *postmanValue) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = postmanValue(s)
+	} else if string(b) != "null" {
+		*v = postmanValue(b)
+	}
+	return nil
+}
+
+// postmanPairs are headers, which can also be a string that isn't read.
+type postmanPairs []postmanPair
+
+func (p *postmanPairs) UnmarshalJSON(b []byte) error {
+	var pairs []postmanPair
+	if err := json.Unmarshal(b, &pairs); err == nil {
+		*p = pairs
+	}
+	return nil
+}
+
+type postmanBody struct {
+	Mode       string        `json:"mode"`
+	Raw        string        `json:"raw"`
+	URLEncoded []postmanPair `json:"urlencoded"`
+	FormData   []postmanPair `json:"formdata"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanResponse struct {
+	Name            string       `json:"name"`
+	Code            int          `json:"code"`
+	Status          string       `json:"status"`
+	Header          postmanPairs `json:"header"`
+	Body            string       `json:"body"`
+	PreviewLanguage string       `json:"_postman_previewlanguage"`
+}
+
+// postmanDescription is a string or an object with the string as its content.
+type postmanDescription string
+
+func (d *postmanDescription) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*d = postmanDescription(s)
+		return nil
+	}
+	var v struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*d = postmanDescription(v.Content)
+	return nil
+}
+
+// postmanVersion is a string or an object with major, minor, and patch numbers.
+type postmanVersion string
+
+func (v *postmanVersion) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = postmanVersion(s)
+		return nil
+	}
+	var version struct {
+		Major, Minor, Patch int
+	}
+	if err := json.Unmarshal(b, &version); err != nil {
+		return err
+	}
+	*v = postmanVersion(fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch))
+	return nil
+}
+
+func (v postmanVersion) String() string {
+	return string(v)
+}
+
+func (item *postmanItem) exchange(variables map[string]string) *exchange {
+	r := item.Request
+	server, segments, query := r.URL.parts()
+	e := &exchange{
+		summary:     item.Name,
+		description: string(r.Description),
+		method:      r.Method,
+		server:      serverURL(server, variables),
+		query:       query,
+	}
+	if e.method == "" {
+		e.method = "GET"
+	}
+	if e.description == "" {
+		e.description = string(item.Description)
+	}
+
+	var path []string
+	for _, segment := range segments {
+		if name := pathVariable(segment); name != "" {
+			segment = "{" + name + "}"
+		}
+		path = append(path, segment)
+	}
+	e.path = "/" + strings.Join(path, "/")
+	for _, v := range r.URL.Variable {
+		e.pathParams = append(e.pathParams, param{name: v.Key, value: string(v.Value), description: string(v.Description)})
+	}
+
+	contentType := ""
+	for _, h := range r.Header {
+		if h.Disabled {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = string(h.Value)
+		}
+		e.headers = append(e.headers, param{name: h.Key, value: string(h.Value), description: string(h.Description)})
+	}
+	if r.Body != nil {
+		e.body = r.Body.body(contentType)
+	}
+
+	for _, saved := range item.Response {
+		response := &response{status: saved.Code, description: saved.Status, text: saved.Body}
+		for _, h := range saved.Header {
+			if strings.EqualFold(h.Key, "Content-Type") {
+				response.mediaType = string(h.Value)
+			}
+		}
+		if response.mediaType == "" && saved.PreviewLanguage == "json" {
+			response.mediaType = "application/json"
+		}
+		e.responses = append(e.responses, response)
+	}
+	return e
+}
+
+// parts returns the server, path segments, and query parameters of a URL.
+func (u *postmanURL) parts() (server string, segments []string, query []param) {
+	var host, path []string
+	if json.Unmarshal(u.Host, &host) != nil {
+		var s string
+		if json.Unmarshal(u.Host, &s) == nil && s != "" {
+			host = []string{s}
+		}
+	}
+	if json.Unmarshal(u.Path, &path) != nil {
+		var s string
+		if json.Unmarshal(u.Path, &s) == nil {
+			path = strings.Split(s, "/")
+		}
+	}
+	if len(host) == 0 && len(path) == 0 {
+		// Only the raw URL is known.
+		server, segments, query = parseRawURL(u.Raw)
+		return server, segments, query
+	}
+
+	server = strings.Join(host, ".")
+	if u.Port != "" {
+		server += ":" + u.Port
+	}
+	if u.Protocol != "" {
+		server = u.Protocol + "://" + server
+	}
+	for _, segment := range path {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, param{name: q.Key, value: string(q.Value), description: string(q.Description)})
+		}
+	}
+	return server, segments, query
+}
+
+// parseRawURL splits a URL such as {{baseUrl}}/shelves/:shelf?view=full.
+func parseRawURL(raw string) (server string, segments []string, query []param) {
+	if i := strings.Index(raw, "#"); i >= 0 {
+		raw = raw[:i]
+	}
+	if i := strings.Index(raw, "?"); i >= 0 {
+		for _, pair := range strings.Split(raw[i+1:], "&") {
+			if pair == "" {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			p := param{name: kv[0]}
+			if len(kv) == 2 {
+				p.value = kv[1]
+			}
+			query = append(query, p)
+		}
+		raw = raw[:i]
+	}
+	rest := raw
+	if i := strings.Index(rest, "://"); i >= 0 {
+		rest = rest[i+3:]
+	}
+	path := ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		path = rest[i:]
+		raw = strings.TrimSuffix(raw, path)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return raw, segments, query
+}
+
+var postmanVariable = regexp.MustCompile(`{{([^{}]+)}}`)
+
+// pathVariable returns the name of the path parameter that a segment such as
+// :shelf or {{shelf}} is, or "" if the segment is literal.
+func pathVariable(segment string) string {
+	if strings.HasPrefix(segment, ":") {
+		return segment[1:]
+	}
+	if m := postmanVariable.FindStringSubmatch(segment); m != nil && m[0] == segment {
+		return m[1]
+	}
+	return ""
+}
+
+// serverURL replaces the variables in a server URL with their values, or
+// with OpenAPI server variables if they don't have values. Servers without
+// a scheme use HTTPS.
+func serverURL(server string, variables map[string]string) string {
+	server = postmanVariable.ReplaceAllStringFunc(server, func(v string) string {
+		name := strings.Trim(v, "{}")
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return "{" + name + "}"
+	})
+	server = strings.TrimSuffix(server, "/")
+	if server != "" && !strings.Contains(server, "://") && !strings.HasPrefix(server, "{") {
+		server = "https://" + server
+	}
+	return server
+}
+
+func (b *postmanBody) body(contentType string) *body {
+	switch b.Mode {
+	case "raw":
+		if contentType == "" {
+			switch b.Options.Raw.Language {
+			case "json":
+				contentType = "application/json"
+			case "xml":
+				contentType = "application/xml"
+			case "text":
+				contentType = "text/plain"
+			}
+		}
+		return &body{mediaType: contentType, text: b.Raw}
+	case "urlencoded":
+		return &body{mediaType: "application/x-www-form-urlencoded", form: formFields(b.URLEncoded)}
+	case "formdata":
+		return &body{mediaType: "multipart/form-data", form: formFields(b.FormData)}
+	default:
+		return nil
+	}
+}
+
+func formFields(pairs []postmanPair) []param {
+	var fields []param
+	for _, p := range pairs {
+		if !p.Disabled {
+			fields = append(fields, param{name: p.Key, value: string(p.Value), file: p.Type == "file"})
+		}
+	}
+	return fields
+}
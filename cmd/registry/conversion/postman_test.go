@@ -0,0 +1,219 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+const collection = `{
+	"info": {
+		"name": "Library",
+		"description": {"content": "A library of books."},
+		"version": {"major": 1, "minor": 2, "patch": 0},
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"variable": [{"key": "baseUrl", "value": "https://library.example.com/v1"}],
+	"item": [
+		{
+			"name": "Shelves",
+			"item": [
+				{
+					"name": "Get a shelf",
+					"request": {
+						"method": "GET",
+						"header": [
+							{"key": "Accept", "value": "application/json"},
+							{"key": "X-Request-Id", "value": "abc", "description": "Identifies the request."}
+						],
+						"url": {
+							"raw": "{{baseUrl}}/shelves/:shelf?view=full",
+							"host": ["{{baseUrl}}"],
+							"path": ["shelves", ":shelf"],
+							"query": [{"key": "view", "value": "full"}, {"key": "debug", "value": "true", "disabled": true}],
+							"variable": [{"key": "shelf", "value": "42", "description": "The shelf ID."}]
+						}
+					},
+					"response": [
+						{
+							"name": "Found",
+							"code": 200,
+							"status": "OK",
+							"header": [{"key": "Content-Type", "value": "application/json; charset=utf-8"}],
+							"body": "{\"name\": \"shelves/42\", \"books\": [{\"title\": \"Dune\", \"pages\": 412}], \"created\": \"2021-06-01T10:00:00Z\"}"
+						},
+						{
+							"name": "Missing",
+							"code": 404,
+							"status": "Not Found",
+							"_postman_previewlanguage": "json",
+							"body": "{\"error\": \"not found\"}"
+						}
+					]
+				}
+			]
+		},
+		{
+			"name": "Add a book",
+			"request": {
+				"method": "POST",
+				"url": "{{baseUrl}}/shelves/{{shelf}}/books",
+				"body": {"mode": "raw", "raw": "{\"title\": \"Emma\", \"price\": 9.5}", "options": {"raw": {"language": "json"}}}
+			}
+		},
+		{
+			"name": "Upload a cover",
+			"request": {
+				"method": "PUT",
+				"url": "{{baseUrl}}/covers",
+				"body": {"mode": "formdata", "formdata": [{"key": "title", "value": "Emma"}, {"key": "image", "type": "file", "src": "cover.png"}]}
+			}
+		}
+	]
+}`
+
+const collectionYAML = `openapi: 3.0.3
+info:
+    title: Library
+    description: A library of books.
+    version: 1.2.0
+servers:
+    - url: https://library.example.com/v1
+paths:
+    /covers:
+        put:
+            summary: Upload a cover
+            requestBody:
+                content:
+                    multipart/form-data:
+                        schema:
+                            type: object
+                            properties:
+                                image:
+                                    type: string
+                                    format: binary
+                                title:
+                                    type: string
+            responses:
+                default:
+                    description: Response
+    /shelves/{shelf}:
+        get:
+            summary: Get a shelf
+            parameters:
+                - name: shelf
+                  in: path
+                  description: The shelf ID.
+                  required: true
+                  schema:
+                    type: integer
+                - name: view
+                  in: query
+                  schema:
+                    type: string
+                - name: X-Request-Id
+                  in: header
+                  description: Identifies the request.
+                  schema:
+                    type: string
+            responses:
+                "200":
+                    description: OK
+                    content:
+                        application/json:
+                            schema:
+                                type: object
+                                properties:
+                                    books:
+                                        type: array
+                                        items:
+                                            type: object
+                                            properties:
+                                                pages:
+                                                    type: integer
+                                                title:
+                                                    type: string
+                                    created:
+                                        type: string
+                                        format: date-time
+                                    name:
+                                        type: string
+                "404":
+                    description: Not Found
+                    content:
+                        application/json:
+                            schema:
+                                type: object
+                                properties:
+                                    error:
+                                        type: string
+    /shelves/{shelf}/books:
+        post:
+            summary: Add a book
+            parameters:
+                - name: shelf
+                  in: path
+                  required: true
+                  schema:
+                    type: string
+            requestBody:
+                content:
+                    application/json:
+                        schema:
+                            type: object
+                            properties:
+                                price:
+                                    type: number
+                                title:
+                                    type: string
+            responses:
+                default:
+                    description: Response
+`
+
+// checkDocument checks that a document is serialized as want and that the
+// serialized document can be read as OpenAPI v3.
+func checkDocument(t *testing.T, doc *openapi_v3.Document, want string) {
+	t.Helper()
+	b, err := doc.YAMLValue("")
+	if err != nil {
+		t.Fatalf("YAMLValue() returned error: %s", err)
+	}
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Errorf("unexpected document (-want +got):\n%s", diff)
+	}
+	if _, err := openapi_v3.ParseDocument(b); err != nil {
+		t.Errorf("ParseDocument() returned error: %s", err)
+	}
+}
+
+func TestPostmanToOpenAPI(t *testing.T) {
+	doc, err := PostmanToOpenAPI([]byte(collection))
+	if err != nil {
+		t.Fatalf("PostmanToOpenAPI() returned error: %s", err)
+	}
+	checkDocument(t, doc, collectionYAML)
+}
+
+func TestPostmanToOpenAPIErrors(t *testing.T) {
+	for _, input := range []string{`not json`, `{"log": {"entries": []}}`} {
+		if _, err := PostmanToOpenAPI([]byte(input)); err == nil {
+			t.Errorf("PostmanToOpenAPI(%s) succeeded, want error", input)
+		}
+	}
+}
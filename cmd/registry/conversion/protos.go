@@ -0,0 +1,778 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+	protoparser "github.com/yoheimuta/go-protoparser/v4"
+	"github.com/yoheimuta/go-protoparser/v4/parser"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// ProtosToOpenAPIv3 converts the methods of the services in a set of proto
+// files that have google.api.http annotations to an OpenAPI v3 document.
+// Files are named by their paths. Requests and responses are described by
+// schemas of their JSON forms, and fields of requests that aren't in paths
+// or bodies are query parameters. Methods without HTTP annotations are
+// left out, as are types that are defined in files that aren't in the set,
+// which are described by schemas without types.
+func ProtosToOpenAPIv3(files map[string][]byte) (*openapi_v3.Document, error) {
+	c := &protoConverter{
+		messages: make(map[string]*protoMessage),
+		enums:    make(map[string][]string),
+		schemas:  make(map[string]bool),
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		if strings.HasSuffix(path, ".proto") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		p, err := protoparser.Parse(
+			bytes.NewReader(files[path]),
+			protoparser.WithPermissive(true),
+			protoparser.WithFilename(path),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		c.addFile(p)
+	}
+	return c.document()
+}
+
+type protoConverter struct {
+	messages map[string]*protoMessage // by full name
+	enums    map[string][]string      // values by full name
+	services []*protoService
+	packages []string
+	schemas  map[string]bool // full names of messages that are referenced
+}
+
+type protoMessage struct {
+	name        string // full name
+	description string
+	fields      []*protoField
+}
+
+type protoField struct {
+	name        string // JSON name
+	protoName   string
+	typ         string // full name of a message or an enum, or the name of a scalar
+	scope       string // the scope in which typ is resolved
+	repeated    bool
+	mapValue    bool // the field is a map with string keys and values of type typ
+	required    bool
+	description string
+}
+
+type protoService struct {
+	name    string
+	pkg     string
+	host    string
+	methods []*protoMethod
+}
+
+type protoMethod struct {
+	name        string
+	description string
+	request     string // full name after types are resolved
+	response    string
+	rule        *annotations.HttpRule
+}
+
+func (c *protoConverter) addFile(p *parser.Proto) {
+	pkg := ""
+	for _, x := range p.ProtoBody {
+		if x, ok := x.(*parser.Package); ok {
+			pkg = x.Name
+		}
+	}
+	if !containsString(c.packages, pkg) {
+		c.packages = append(c.packages, pkg)
+	}
+	// Types are resolved after all files are read, because they can be
+	// defined in files that are read later.
+	for _, x := range p.ProtoBody {
+		switch x := x.(type) {
+		case *parser.Message:
+			c.addMessage(pkg, x)
+		case *parser.Enum:
+			c.addEnum(pkg, x)
+		case *parser.Service:
+			c.addService(pkg, x)
+		}
+	}
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (c *protoConverter) addMessage(scope string, m *parser.Message) {
+	message := &protoMessage{
+		name:        qualify(scope, m.MessageName),
+		description: commentText(m.Comments),
+	}
+	c.messages[message.name] = message
+	for _, x := range m.MessageBody {
+		switch x := x.(type) {
+		case *parser.Field:
+			f := newProtoField(x.FieldName, x.Type, x.FieldOptions, x.Comments)
+			f.repeated = x.IsRepeated
+			message.fields = append(message.fields, f)
+		case *parser.MapField:
+			f := newProtoField(x.MapName, x.Type, x.FieldOptions, x.Comments)
+			f.mapValue = true
+			message.fields = append(message.fields, f)
+		case *parser.Oneof:
+			for _, o := range x.OneofFields {
+				message.fields = append(message.fields, newProtoField(o.FieldName, o.Type, o.FieldOptions, o.Comments))
+			}
+		case *parser.Message:
+			c.addMessage(message.name, x)
+		case *parser.Enum:
+			c.addEnum(message.name, x)
+		}
+	}
+	for _, f := range message.fields {
+		f.scope = message.name
+	}
+}
+
+func newProtoField(name, typ string, options []*parser.FieldOption, comments []*parser.Comment) *protoField {
+	f := &protoField{
+		name:        jsonFieldName(name),
+		protoName:   name,
+		typ:         typ,
+		description: commentText(comments),
+	}
+	for _, o := range options {
+		switch o.OptionName {
+		case "json_name":
+			f.name = strings.Trim(o.Constant, `"`)
+		case "(google.api.field_behavior)":
+			f.required = f.required || o.Constant == "REQUIRED"
+		}
+	}
+	return f
+}
+
+// jsonFieldName returns the JSON name of a field, which is its name in
+// lower camel case.
+func jsonFieldName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (c *protoConverter) addEnum(scope string, e *parser.Enum) {
+	var values []string
+	for _, x := range e.EnumBody {
+		if f, ok := x.(*parser.EnumField); ok {
+			values = append(values, f.Ident)
+		}
+	}
+	c.enums[qualify(scope, e.EnumName)] = values
+}
+
+func (c *protoConverter) addService(pkg string, s *parser.Service) {
+	service := &protoService{name: s.ServiceName, pkg: pkg}
+	for _, x := range s.ServiceBody {
+		switch x := x.(type) {
+		case *parser.Option:
+			if x.OptionName == "(google.api.default_host)" {
+				service.host = strings.Trim(x.Constant, `"`)
+			}
+		case *parser.RPC:
+			rule, err := httpRule(x.Options)
+			if rule == nil || err != nil {
+				continue
+			}
+			service.methods = append(service.methods, &protoMethod{
+				name:        x.RPCName,
+				description: commentText(x.Comments),
+				request:     x.RPCRequest.MessageType,
+				response:    x.RPCResponse.MessageType,
+				rule:        rule,
+			})
+		}
+	}
+	c.services = append(c.services, service)
+}
+
+// httpRule returns the google.api.http annotation of a method, or nil if
+// it doesn't have one.
+func httpRule(options []*parser.Option) (*annotations.HttpRule, error) {
+	var text []string
+	for _, o := range options {
+		if o.OptionName == "(google.api.http)" {
+			text = append(text, strings.TrimSuffix(strings.TrimPrefix(o.Constant, "{"), "}"))
+		} else if field := strings.TrimPrefix(o.OptionName, "(google.api.http)."); field != o.OptionName {
+			text = append(text, field+":"+o.Constant)
+		}
+	}
+	if len(text) == 0 {
+		return nil, nil
+	}
+	rule := &annotations.HttpRule{}
+	if err := prototext.Unmarshal([]byte(strings.Join(text, "\n")), rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// internalComment matches comments that are for API producers, which are
+// written in (-- and --).
+var internalComment = regexp.MustCompile(`(?s)\s*\(--.*?--\)`)
+
+// commentText returns the text of the comments before an element without
+// internal comments.
+func commentText(comments []*parser.Comment) string {
+	var lines []string
+	for _, c := range comments {
+		for _, line := range c.Lines() {
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+	}
+	text := internalComment.ReplaceAllString(strings.Join(lines, "\n"), "")
+	return strings.TrimSpace(text)
+}
+
+// resolve returns the full name of a type that is named in a scope, following
+// the scoping rules of protobuf: names are looked up in the scope and then in
+// each enclosing scope. Scalars and unknown types are returned unchanged.
+func (c *protoConverter) resolve(scope, name string) string {
+	if strings.HasPrefix(name, ".") {
+		return strings.TrimPrefix(name, ".")
+	}
+	for {
+		candidate := qualify(scope, name)
+		if _, ok := c.messages[candidate]; ok {
+			return candidate
+		}
+		if _, ok := c.enums[candidate]; ok {
+			return candidate
+		}
+		if scope == "" {
+			return name
+		}
+		if j := strings.LastIndex(scope, "."); j >= 0 {
+			scope = scope[:j]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+func (c *protoConverter) document() (*openapi_v3.Document, error) {
+	for _, m := range c.messages {
+		for _, f := range m.fields {
+			f.typ = c.resolve(f.scope, f.typ)
+		}
+	}
+	sort.Strings(c.packages)
+	doc := &openapi_v3.Document{
+		Openapi: "3.0.3",
+		Info:    &openapi_v3.Info{Version: "1.0.0"},
+		Paths:   &openapi_v3.Paths{},
+	}
+	var services []string
+	items := make(map[string]*openapi_v3.PathItem)
+	for _, s := range c.services {
+		if len(s.methods) == 0 {
+			continue
+		}
+		services = append(services, s.name)
+		if s.host != "" {
+			server := &openapi_v3.Server{Url: "https://" + s.host}
+			if !containsServer(doc.Servers, server.Url) {
+				doc.Servers = append(doc.Servers, server)
+			}
+		}
+		for _, m := range s.methods {
+			m.request, m.response = c.resolve(s.pkg, m.request), c.resolve(s.pkg, m.response)
+			rules := append([]*annotations.HttpRule{m.rule}, m.rule.GetAdditionalBindings()...)
+			for i, rule := range rules {
+				method, template := httpPattern(rule)
+				if method == "" {
+					continue
+				}
+				operationID := s.name + "_" + m.name
+				if i > 0 {
+					operationID += fmt.Sprint(i + 1)
+				}
+				path, op, err := c.operation(s, m, rule, template, operationID)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %s", s.name, m.name, err)
+				}
+				item, ok := items[path]
+				if !ok {
+					item = &openapi_v3.PathItem{}
+					items[path] = item
+				}
+				setOperation(item, method, op)
+			}
+		}
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no methods with HTTP annotations")
+	}
+	doc.Info.Title = strings.Join(c.packages, ", ")
+	if len(c.packages) == 1 && c.packages[0] != "" {
+		parts := strings.Split(c.packages[0], ".")
+		if last := parts[len(parts)-1]; len(parts) > 1 && protoVersion.MatchString(last) {
+			doc.Info.Version = last
+		}
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = strings.Join(services, ", ")
+	}
+	doc.Info.Description = "Converted from the services " + strings.Join(services, ", ") + "."
+
+	paths := make([]string, 0, len(items))
+	for path := range items {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		doc.Paths.Path = append(doc.Paths.Path, &openapi_v3.NamedPathItem{Name: path, Value: items[path]})
+	}
+	doc.Components = c.components()
+	return doc, nil
+}
+
+var protoVersion = regexp.MustCompile(`^v[0-9]+[a-z0-9]*$`)
+
+func containsServer(servers []*openapi_v3.Server, url string) bool {
+	for _, s := range servers {
+		if s.Url == url {
+			return true
+		}
+	}
+	return false
+}
+
+func httpPattern(rule *annotations.HttpRule) (method, template string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return "GET", p.Get
+	case *annotations.HttpRule_Put:
+		return "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		return "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		return "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		return "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+func setOperation(item *openapi_v3.PathItem, method string, op *openapi_v3.Operation) {
+	switch method {
+	case "GET":
+		item.Get = op
+	case "PUT":
+		item.Put = op
+	case "POST":
+		item.Post = op
+	case "DELETE":
+		item.Delete = op
+	case "PATCH":
+		item.Patch = op
+	case "OPTIONS":
+		item.Options = op
+	case "HEAD":
+		item.Head = op
+	case "TRACE":
+		item.Trace = op
+	}
+}
+
+// httpPathVariable matches the variables of path templates, such as
+// {name=shelves/*} and {shelf.name}.
+var httpPathVariable = regexp.MustCompile(`{([^{}=]+)(=[^{}]*)?}`)
+
+// operation returns the path and the operation of an HTTP binding of a method.
+func (c *protoConverter) operation(s *protoService, m *protoMethod, rule *annotations.HttpRule, template, operationID string) (string, *openapi_v3.Operation, error) {
+	summary, description := splitDescription(m.description)
+	op := &openapi_v3.Operation{
+		Tags:        []string{s.name},
+		Summary:     summary,
+		Description: description,
+		OperationId: operationID,
+		Responses:   &openapi_v3.Responses{},
+	}
+	used := make(map[string]bool)
+	path := httpPathVariable.ReplaceAllStringFunc(template, func(v string) string {
+		return "{" + httpPathVariable.FindStringSubmatch(v)[1] + "}"
+	})
+	for _, match := range httpPathVariable.FindAllStringSubmatch(template, -1) {
+		fieldPath := match[1]
+		used[strings.Split(fieldPath, ".")[0]] = true
+		param := &openapi_v3.Parameter{Name: fieldPath, In: "path", Required: true}
+		if f := c.fieldAtPath(m.request, fieldPath); f != nil {
+			param.Description = f.description
+			param.Schema = c.fieldSchema(f)
+		} else {
+			param.Schema = typeSchema("string", "")
+		}
+		if pattern := strings.TrimPrefix(match[2], "="); pattern != "" && pattern != "*" {
+			param.Description = strings.TrimSpace(param.Description + "\n\nFormat: " + pattern)
+		}
+		op.Parameters = append(op.Parameters, &openapi_v3.ParameterOrReference{
+			Oneof: &openapi_v3.ParameterOrReference_Parameter{Parameter: param},
+		})
+	}
+
+	// Fields that aren't in the path or the body are query parameters.
+	switch body := rule.GetBody(); body {
+	case "*":
+		op.RequestBody = requestBody(c.messageSchema(m.request), "")
+	case "":
+	default:
+		f := c.fieldAtPath(m.request, body)
+		if f == nil {
+			return "", nil, fmt.Errorf("unknown body field %s", body)
+		}
+		used[f.protoName] = true
+		op.RequestBody = requestBody(c.fieldSchema(f), f.description)
+	}
+	if rule.GetBody() != "*" {
+		for _, f := range c.messages[m.request].fieldsOrNil() {
+			if used[f.protoName] || !c.isQueryParameter(f) {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &openapi_v3.ParameterOrReference{
+				Oneof: &openapi_v3.ParameterOrReference_Parameter{
+					Parameter: &openapi_v3.Parameter{
+						Name:        f.name,
+						In:          "query",
+						Description: f.description,
+						Schema:      c.fieldSchema(f),
+					},
+				},
+			})
+		}
+	}
+
+	schema := c.messageSchema(m.response)
+	if field := rule.GetResponseBody(); field != "" {
+		f := c.fieldAtPath(m.response, field)
+		if f == nil {
+			return "", nil, fmt.Errorf("unknown response body field %s", field)
+		}
+		schema = c.fieldSchema(f)
+	}
+	op.Responses.ResponseOrReference = append(op.Responses.ResponseOrReference, &openapi_v3.NamedResponseOrReference{
+		Name: "200",
+		Value: &openapi_v3.ResponseOrReference{
+			Oneof: &openapi_v3.ResponseOrReference_Response{
+				Response: &openapi_v3.Response{
+					Description: "OK",
+					Content:     content([]string{"application/json"}, schema),
+				},
+			},
+		},
+	})
+	return path, op, nil
+}
+
+// splitDescription returns the first sentence of a description as a
+// summary, and the rest of the description.
+func splitDescription(text string) (summary, description string) {
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		return strings.Join(strings.Fields(text[:i]), " "), strings.TrimSpace(text[i+2:])
+	}
+	return strings.Join(strings.Fields(text), " "), ""
+}
+
+func (m *protoMessage) fieldsOrNil() []*protoField {
+	if m == nil {
+		return nil
+	}
+	return m.fields
+}
+
+// fieldAtPath returns the field at a path of field names, such as
+// shelf.name, or nil if there isn't one.
+func (c *protoConverter) fieldAtPath(message, path string) *protoField {
+	var field *protoField
+	for _, name := range strings.Split(path, ".") {
+		m := c.messages[message]
+		if m == nil {
+			return nil
+		}
+		field = nil
+		for _, f := range m.fields {
+			if f.protoName == name {
+				field = f
+			}
+		}
+		if field == nil {
+			return nil
+		}
+		message = field.typ
+	}
+	return field
+}
+
+// isQueryParameter returns true for fields that can be query parameters,
+// which are fields of scalar and enum types, lists of them, and
+// well-known types with string forms.
+func (c *protoConverter) isQueryParameter(f *protoField) bool {
+	if f.mapValue {
+		return false
+	}
+	if _, ok := c.messages[f.typ]; ok {
+		return false
+	}
+	switch f.typ {
+	case "google.protobuf.Struct", "google.protobuf.Value", "google.protobuf.ListValue",
+		"google.protobuf.Any", "google.protobuf.Empty":
+		return false
+	}
+	return true
+}
+
+func requestBody(schema *openapi_v3.SchemaOrReference, description string) *openapi_v3.RequestBodyOrReference {
+	return &openapi_v3.RequestBodyOrReference{
+		Oneof: &openapi_v3.RequestBodyOrReference_RequestBody{
+			RequestBody: &openapi_v3.RequestBody{
+				Description: description,
+				Content:     content([]string{"application/json"}, schema),
+				Required:    true,
+			},
+		},
+	}
+}
+
+func (c *protoConverter) fieldSchema(f *protoField) *openapi_v3.SchemaOrReference {
+	s := c.typeSchema(f.typ)
+	if f.repeated {
+		s = &openapi_v3.SchemaOrReference{
+			Oneof: &openapi_v3.SchemaOrReference_Schema{
+				Schema: &openapi_v3.Schema{
+					Type:  "array",
+					Items: &openapi_v3.ItemsItem{SchemaOrReference: []*openapi_v3.SchemaOrReference{s}},
+				},
+			},
+		}
+	} else if f.mapValue {
+		s = &openapi_v3.SchemaOrReference{
+			Oneof: &openapi_v3.SchemaOrReference_Schema{
+				Schema: &openapi_v3.Schema{
+					Type: "object",
+					AdditionalProperties: &openapi_v3.AdditionalPropertiesItem{
+						Oneof: &openapi_v3.AdditionalPropertiesItem_SchemaOrReference{SchemaOrReference: s},
+					},
+				},
+			},
+		}
+	}
+	return s
+}
+
+// propertySchema returns the schema of a field of a message, which has the
+// field's description.
+func (c *protoConverter) propertySchema(f *protoField) *openapi_v3.SchemaOrReference {
+	s := c.fieldSchema(f)
+	if f.description == "" {
+		return s
+	}
+	if schema := s.GetSchema(); schema != nil {
+		schema.Description = f.description
+		return s
+	}
+	// Siblings of references are ignored, so descriptions of fields of
+	// message types are added with allOf.
+	return &openapi_v3.SchemaOrReference{
+		Oneof: &openapi_v3.SchemaOrReference_Schema{
+			Schema: &openapi_v3.Schema{
+				Description: f.description,
+				AllOf:       []*openapi_v3.SchemaOrReference{s},
+			},
+		},
+	}
+}
+
+// protoScalars maps the scalar types of protobuf to the types and formats
+// of their JSON forms. 64-bit integers are strings in JSON.
+var protoScalars = map[string][2]string{
+	"double":   {"number", "double"},
+	"float":    {"number", "float"},
+	"int32":    {"integer", "int32"},
+	"sint32":   {"integer", "int32"},
+	"sfixed32": {"integer", "int32"},
+	"uint32":   {"integer", "int64"},
+	"fixed32":  {"integer", "int64"},
+	"int64":    {"string", "int64"},
+	"sint64":   {"string", "int64"},
+	"sfixed64": {"string", "int64"},
+	"uint64":   {"string", "uint64"},
+	"fixed64":  {"string", "uint64"},
+	"bool":     {"boolean", ""},
+	"string":   {"string", ""},
+	"bytes":    {"string", "byte"},
+
+	"google.protobuf.Timestamp":   {"string", "date-time"},
+	"google.protobuf.Duration":    {"string", ""},
+	"google.protobuf.FieldMask":   {"string", "field-mask"},
+	"google.protobuf.DoubleValue": {"number", "double"},
+	"google.protobuf.FloatValue":  {"number", "float"},
+	"google.protobuf.Int32Value":  {"integer", "int32"},
+	"google.protobuf.UInt32Value": {"integer", "int64"},
+	"google.protobuf.Int64Value":  {"string", "int64"},
+	"google.protobuf.UInt64Value": {"string", "uint64"},
+	"google.protobuf.BoolValue":   {"boolean", ""},
+	"google.protobuf.StringValue": {"string", ""},
+	"google.protobuf.BytesValue":  {"string", "byte"},
+	"google.protobuf.Struct":      {"object", ""},
+	"google.protobuf.Empty":       {"object", ""},
+	"google.protobuf.Any":         {"object", ""},
+	"google.protobuf.ListValue":   {"array", ""},
+}
+
+func (c *protoConverter) typeSchema(typ string) *openapi_v3.SchemaOrReference {
+	if scalar, ok := protoScalars[typ]; ok {
+		s := typeSchema(scalar[0], scalar[1])
+		if scalar[0] == "array" {
+			s.GetSchema().Items = &openapi_v3.ItemsItem{SchemaOrReference: []*openapi_v3.SchemaOrReference{typeSchema("", "")}}
+		}
+		return s
+	}
+	if values, ok := c.enums[typ]; ok {
+		s := typeSchema("string", "")
+		for _, v := range values {
+			s.GetSchema().Enum = append(s.GetSchema().Enum, &openapi_v3.Any{Yaml: v})
+		}
+		return s
+	}
+	if _, ok := c.messages[typ]; ok {
+		return c.messageSchema(typ)
+	}
+	// google.protobuf.Value and types that aren't defined in the files
+	// can have any form.
+	return typeSchema("", "")
+}
+
+func typeSchema(typ, format string) *openapi_v3.SchemaOrReference {
+	return &openapi_v3.SchemaOrReference{
+		Oneof: &openapi_v3.SchemaOrReference_Schema{Schema: &openapi_v3.Schema{Type: typ, Format: format}},
+	}
+}
+
+// messageSchema returns a reference to the schema of a message, which is
+// added to the components of the document.
+func (c *protoConverter) messageSchema(name string) *openapi_v3.SchemaOrReference {
+	if _, ok := c.messages[name]; !ok {
+		return c.typeSchema(name)
+	}
+	c.schemas[name] = true
+	return &openapi_v3.SchemaOrReference{
+		Oneof: &openapi_v3.SchemaOrReference_Reference{
+			Reference: &openapi_v3.Reference{XRef: "#/components/schemas/" + c.schemaName(name)},
+		},
+	}
+}
+
+// schemaName returns the name of the schema of a message, which is its name
+// without its package unless messages in different packages have that name.
+func (c *protoConverter) schemaName(message string) string {
+	short := c.shortName(message)
+	for name := range c.messages {
+		if name != message && c.shortName(name) == short {
+			return message
+		}
+	}
+	return short
+}
+
+func (c *protoConverter) shortName(message string) string {
+	for _, pkg := range c.packages {
+		if pkg != "" && strings.HasPrefix(message, pkg+".") {
+			return strings.TrimPrefix(message, pkg+".")
+		}
+	}
+	return message
+}
+
+// components returns the schemas of the messages that are referenced,
+// including messages that are referenced by the schemas of others.
+func (c *protoConverter) components() *openapi_v3.Components {
+	schemas := make(map[string]*openapi_v3.SchemaOrReference)
+	for done := false; !done; {
+		done = true
+		for name := range c.schemas {
+			if _, ok := schemas[name]; ok {
+				continue
+			}
+			done = false
+			m := c.messages[name]
+			s := &openapi_v3.Schema{Type: "object", Description: m.description}
+			for _, f := range m.fields {
+				if s.Properties == nil {
+					s.Properties = &openapi_v3.Properties{}
+				}
+				s.Properties.AdditionalProperties = append(s.Properties.AdditionalProperties,
+					&openapi_v3.NamedSchemaOrReference{Name: f.name, Value: c.propertySchema(f)})
+				if f.required {
+					s.Required = append(s.Required, f.name)
+				}
+			}
+			schemas[name] = &openapi_v3.SchemaOrReference{Oneof: &openapi_v3.SchemaOrReference_Schema{Schema: s}}
+		}
+	}
+	if len(schemas) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return c.schemaName(names[i]) < c.schemaName(names[j]) })
+	out := &openapi_v3.Components{Schemas: &openapi_v3.SchemasOrReferences{}}
+	for _, name := range names {
+		out.Schemas.AdditionalProperties = append(out.Schemas.AdditionalProperties,
+			&openapi_v3.NamedSchemaOrReference{Name: c.schemaName(name), Value: schemas[name]})
+	}
+	return out
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import "testing"
+
+const libraryProto = `syntax = "proto3";
+
+package example.library.v1;
+
+import "google/api/annotations.proto";
+import "google/api/client.proto";
+import "google/api/field_behavior.proto";
+import "google/protobuf/timestamp.proto";
+
+service Library {
+  option (google.api.default_host) = "library.example.com";
+
+  // Gets a book.
+  //
+  // Returns NOT_FOUND if the book does not exist.
+  // (-- api-linter: core::0131=disabled --)
+  rpc GetBook(GetBookRequest) returns (Book) {
+    option (google.api.http) = {
+      get: "/v1/{name=shelves/*/books/*}"
+    };
+  }
+
+  // Updates a book.
+  rpc UpdateBook(UpdateBookRequest) returns (Book) {
+    option (google.api.http) = {
+      patch: "/v1/{book.name=shelves/*/books/*}"
+      body: "book"
+      additional_bindings {
+        put: "/v1/{book.name=shelves/*/books/*}"
+        body: "*"
+      }
+    };
+  }
+}
+
+// A book.
+message Book {
+  // The kind of a book.
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    FICTION = 1;
+    NONFICTION = 2;
+  }
+
+  // The resource name of the book.
+  string name = 1;
+
+  // The title of the book.
+  string title = 2 [(google.api.field_behavior) = REQUIRED];
+
+  Kind kind = 3;
+
+  repeated string authors = 4;
+
+  map<string, int64> ratings = 5;
+
+  google.protobuf.Timestamp publish_time = 6 [json_name = "published"];
+
+  // Pages of the book.
+  repeated Page pages = 7;
+}
+
+message Page {
+  int32 number = 1;
+}
+
+message GetBookRequest {
+  // The name of the book.
+  string name = 1;
+
+  // The view of the book.
+  Book.Kind view = 2;
+}
+
+message UpdateBookRequest {
+  Book book = 1;
+
+  bool allow_missing = 2;
+}
+`
+
+const libraryYAML = `openapi: 3.0.3
+info:
+    title: example.library.v1
+    description: Converted from the services Library.
+    version: v1
+servers:
+    - url: https://library.example.com
+paths:
+    /v1/{book.name}:
+        put:
+            tags:
+                - Library
+            summary: Updates a book.
+            operationId: Library_UpdateBook2
+            parameters:
+                - name: book.name
+                  in: path
+                  description: |-
+                    The resource name of the book.
+
+                    Format: shelves/*/books/*
+                  required: true
+                  schema:
+                    type: string
+            requestBody:
+                content:
+                    application/json:
+                        schema:
+                            $ref: '#/components/schemas/UpdateBookRequest'
+                required: true
+            responses:
+                "200":
+                    description: OK
+                    content:
+                        application/json:
+                            schema:
+                                $ref: '#/components/schemas/Book'
+        patch:
+            tags:
+                - Library
+            summary: Updates a book.
+            operationId: Library_UpdateBook
+            parameters:
+                - name: book.name
+                  in: path
+                  description: |-
+                    The resource name of the book.
+
+                    Format: shelves/*/books/*
+                  required: true
+                  schema:
+                    type: string
+                - name: allowMissing
+                  in: query
+                  schema:
+                    type: boolean
+            requestBody:
+                content:
+                    application/json:
+                        schema:
+                            $ref: '#/components/schemas/Book'
+                required: true
+            responses:
+                "200":
+                    description: OK
+                    content:
+                        application/json:
+                            schema:
+                                $ref: '#/components/schemas/Book'
+    /v1/{name}:
+        get:
+            tags:
+                - Library
+            summary: Gets a book.
+            description: Returns NOT_FOUND if the book does not exist.
+            operationId: Library_GetBook
+            parameters:
+                - name: name
+                  in: path
+                  description: |-
+                    The name of the book.
+
+                    Format: shelves/*/books/*
+                  required: true
+                  schema:
+                    type: string
+                - name: view
+                  in: query
+                  description: The view of the book.
+                  schema:
+                    enum:
+                        - KIND_UNSPECIFIED
+                        - FICTION
+                        - NONFICTION
+                    type: string
+            responses:
+                "200":
+                    description: OK
+                    content:
+                        application/json:
+                            schema:
+                                $ref: '#/components/schemas/Book'
+components:
+    schemas:
+        Book:
+            required:
+                - title
+            type: object
+            properties:
+                name:
+                    type: string
+                    description: The resource name of the book.
+                title:
+                    type: string
+                    description: The title of the book.
+                kind:
+                    enum:
+                        - KIND_UNSPECIFIED
+                        - FICTION
+                        - NONFICTION
+                    type: string
+                authors:
+                    type: array
+                    items:
+                        type: string
+                ratings:
+                    type: object
+                    additionalProperties:
+                        type: string
+                        format: int64
+                published:
+                    type: string
+                    format: date-time
+                pages:
+                    type: array
+                    items:
+                        $ref: '#/components/schemas/Page'
+                    description: Pages of the book.
+            description: A book.
+        Page:
+            type: object
+            properties:
+                number:
+                    type: integer
+                    format: int32
+        UpdateBookRequest:
+            type: object
+            properties:
+                book:
+                    $ref: '#/components/schemas/Book'
+                allowMissing:
+                    type: boolean
+`
+
+func TestProtosToOpenAPIv3(t *testing.T) {
+	doc, err := ProtosToOpenAPIv3(map[string][]byte{
+		"example/library/v1/library.proto": []byte(libraryProto),
+	})
+	if err != nil {
+		t.Fatalf("ProtosToOpenAPIv3() returned error: %s", err)
+	}
+	checkDocument(t, doc, libraryYAML)
+}
+
+func TestProtosToOpenAPIv3Errors(t *testing.T) {
+	for _, files := range []map[string][]byte{
+		{},
+		{"bad.proto": []byte("message {")},
+	} {
+		if _, err := ProtosToOpenAPIv3(files); err == nil {
+			t.Errorf("ProtosToOpenAPIv3(%v) succeeded, want error", files)
+		}
+	}
+}
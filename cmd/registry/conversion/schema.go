@@ -0,0 +1,193 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	openapi_v3 "github.com/googleapis/gnostic/openapiv3"
+)
+
+// A schema is a JSON schema that is inferred from example values. Schemas
+// inferred from several examples of the same value are merged.
+type schema struct {
+	typ        string
+	format     string
+	nullable   bool
+	mixed      bool // values have different types, so the schema has no type
+	items      *schema
+	properties map[string]*schema
+}
+
+// schemaForJSON returns the schema of a JSON document, or nil if text isn't JSON.
+func schemaForJSON(text string) *schema {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil
+	}
+	return schemaForValue(value)
+}
+
+// schemaForValue returns the schema of a value decoded from JSON with numbers
+// decoded as json.Number.
+func schemaForValue(value interface{}) *schema {
+	switch v := value.(type) {
+	case nil:
+		return &schema{nullable: true}
+	case bool:
+		return &schema{typ: "boolean"}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &schema{typ: "integer"}
+		}
+		return &schema{typ: "number"}
+	case string:
+		s := &schema{typ: "string"}
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			s.format = "date-time"
+		}
+		return s
+	case []interface{}:
+		s := &schema{typ: "array"}
+		for _, item := range v {
+			s.items = mergeSchemas(s.items, schemaForValue(item))
+		}
+		return s
+	case map[string]interface{}:
+		s := &schema{typ: "object", properties: make(map[string]*schema)}
+		for name, property := range v {
+			s.properties[name] = schemaForValue(property)
+		}
+		return s
+	default:
+		return &schema{}
+	}
+}
+
+// schemaForString returns the schema of a value of a parameter or form field.
+func schemaForString(value string) *schema {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return &schema{typ: "integer"}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return &schema{typ: "number"}
+	}
+	if value == "true" || value == "false" {
+		return &schema{typ: "boolean"}
+	}
+	return &schema{typ: "string"}
+}
+
+// mergeSchemas returns a schema for values that match either of two schemas,
+// either of which can be nil. Values of different types are described by a
+// schema without a type, except that integers and numbers are numbers.
+func mergeSchemas(a, b *schema) *schema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	merged := &schema{nullable: a.nullable || b.nullable}
+	switch {
+	case a.mixed || b.mixed:
+		merged.mixed = true
+	case a.typ == "":
+		merged.typ, merged.format = b.typ, b.format
+	case b.typ == "":
+		merged.typ, merged.format = a.typ, a.format
+	case a.typ == b.typ:
+		merged.typ = a.typ
+		if a.format == b.format {
+			merged.format = a.format
+		}
+	case a.typ == "integer" && b.typ == "number", a.typ == "number" && b.typ == "integer":
+		merged.typ = "number"
+	default:
+		merged.mixed = true
+	}
+	if merged.typ == "array" {
+		merged.items = mergeSchemas(a.items, b.items)
+	}
+	if merged.typ == "object" {
+		merged.properties = make(map[string]*schema)
+		for name, property := range a.properties {
+			merged.properties[name] = property
+		}
+		for name, property := range b.properties {
+			merged.properties[name] = mergeSchemas(merged.properties[name], property)
+		}
+	}
+	return merged
+}
+
+// openAPI returns the OpenAPI v3 form of a schema.
+func (s *schema) openAPI() *openapi_v3.SchemaOrReference {
+	out := &openapi_v3.Schema{
+		Type:     s.typ,
+		Format:   s.format,
+		Nullable: s.nullable,
+	}
+	if s.typ == "array" {
+		items := s.items
+		if items == nil {
+			items = &schema{}
+		}
+		out.Items = &openapi_v3.ItemsItem{SchemaOrReference: []*openapi_v3.SchemaOrReference{items.openAPI()}}
+	}
+	if len(s.properties) > 0 {
+		out.Properties = &openapi_v3.Properties{}
+		for _, name := range sortedNames(s.properties) {
+			out.Properties.AdditionalProperties = append(out.Properties.AdditionalProperties,
+				&openapi_v3.NamedSchemaOrReference{Name: name, Value: s.properties[name].openAPI()})
+		}
+	}
+	return &openapi_v3.SchemaOrReference{Oneof: &openapi_v3.SchemaOrReference_Schema{Schema: out}}
+}
+
+func sortedNames(properties map[string]*schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isJSON returns true for media types of JSON documents, such as
+// application/json and application/problem+json.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// mediaType returns a content type without parameters such as charset.
+func mediaType(contentType string) string {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// looksLikeJSON returns true if text appears to be a JSON object or array.
+func looksLikeJSON(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[")
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaForJSON(t *testing.T) {
+	tests := []struct {
+		desc string
+		text string
+		want *schema
+	}{
+		{"not JSON", `{"a":`, nil},
+		{"two documents", `{} {}`, nil},
+		{"integer", `1`, &schema{typ: "integer"}},
+		{"number", `1.5`, &schema{typ: "number"}},
+		{"date-time", `"2021-06-01T10:00:00Z"`, &schema{typ: "string", format: "date-time"}},
+		{"null", `null`, &schema{nullable: true}},
+		{"numbers", `[1, 2.5]`, &schema{typ: "array", items: &schema{typ: "number"}}},
+		{"mixed", `[1, "a"]`, &schema{typ: "array", items: &schema{mixed: true}}},
+		{"empty array", `[]`, &schema{typ: "array"}},
+		{
+			"objects",
+			`[{"a": 1, "b": null}, {"b": "x", "c": true}]`,
+			&schema{typ: "array", items: &schema{typ: "object", properties: map[string]*schema{
+				"a": {typ: "integer"},
+				"b": {typ: "string", nullable: true},
+				"c": {typ: "boolean"},
+			}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got := schemaForJSON(test.text)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(schema{})); diff != "" {
+				t.Errorf("schemaForJSON(%s) returned unexpected schema (-want +got):\n%s", test.text, diff)
+			}
+		})
+	}
+}
+
+func TestMergeSchemasFormats(t *testing.T) {
+	a := &schema{typ: "string", format: "date-time"}
+	b := &schema{typ: "string"}
+	if got := mergeSchemas(a, b); got.typ != "string" || got.format != "" {
+		t.Errorf("mergeSchemas() returned %+v, want a string without a format", got)
+	}
+	if got := mergeSchemas(a, a); got.format != "date-time" {
+		t.Errorf("mergeSchemas() returned %+v, want a date-time", got)
+	}
+}
+
+func TestSchemaForString(t *testing.T) {
+	for value, want := range map[string]string{
+		"42":    "integer",
+		"-1.5":  "number",
+		"true":  "boolean",
+		"":      "string",
+		"hello": "string",
+	} {
+		if got := schemaForString(value).typ; got != want {
+			t.Errorf("schemaForString(%q) returned type %q, want %q", value, got, want)
+		}
+	}
+}
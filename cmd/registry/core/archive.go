@@ -0,0 +1,645 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/gapic"
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/gateway"
+	"github.com/apigee/registry/server/names"
+	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// ArchiveFormat identifies the layout of project archives.
+const ArchiveFormat = "apigee-registry-archive/v1"
+
+// archiveManifest is the name of the file that describes an archive's contents.
+const archiveManifest = "manifest.json"
+
+// Kinds of archive entries.
+const (
+	archiveProject    = "Project"
+	archiveApi        = "Api"
+	archiveVersion    = "ApiVersion"
+	archiveRevision   = "ApiSpecRevision"
+	archiveDeployment = "ApiDeploymentRevision"
+	archiveArtifact   = "Artifact"
+)
+
+// ArchiveManifest describes the resources in a project archive.
+// Entries are listed in the order in which they must be recreated.
+type ArchiveManifest struct {
+	Format     string         `json:"format"`
+	Project    string         `json:"project"`
+	CreateTime time.Time      `json:"createTime"`
+	Entries    []ArchiveEntry `json:"entries"`
+}
+
+// ArchiveEntry describes a single resource in a project archive.
+type ArchiveEntry struct {
+	// Kind is the type of the resource.
+	Kind string `json:"kind"`
+	// Name is the name of the resource relative to its project.
+	// Spec and deployment revisions are named with their original revision IDs.
+	Name string `json:"name"`
+	// Resource is the JSON representation of the resource.
+	Resource json.RawMessage `json:"resource"`
+	// Contents is the path of the resource's contents in the archive.
+	Contents string `json:"contents,omitempty"`
+}
+
+// archiveWriter writes resources to a zip archive.
+type archiveWriter struct {
+	zip      *zip.Writer
+	project  string
+	manifest ArchiveManifest
+}
+
+// ExportArchive writes a project and all of its resources to a zip archive.
+func ExportArchive(ctx context.Context, client *gapic.RegistryClient, project names.Project, w io.Writer) error {
+	a := &archiveWriter{
+		zip:     zip.NewWriter(w),
+		project: project.String(),
+		manifest: ArchiveManifest{
+			Format:     ArchiveFormat,
+			Project:    project.ProjectID,
+			CreateTime: time.Now().UTC(),
+		},
+	}
+
+	p, err := client.GetProject(ctx, &rpc.GetProjectRequest{Name: project.String()})
+	if err != nil {
+		return err
+	}
+	if err := a.add(archiveProject, p, nil); err != nil {
+		return err
+	}
+	if err := a.addArtifacts(ctx, client, p.GetName()); err != nil {
+		return err
+	}
+
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	var apis []string
+	check(ListAPIs(ctx, client, names.ProjectRegexp().FindStringSubmatch(p.GetName()), "", func(api *rpc.Api) {
+		apis = append(apis, api.GetName())
+		check(a.add(archiveApi, api, nil))
+		check(a.addArtifacts(ctx, client, api.GetName()))
+		check(ListVersions(ctx, client, names.ApiRegexp().FindStringSubmatch(api.GetName()), "", func(version *rpc.ApiVersion) {
+			check(a.add(archiveVersion, version, nil))
+			check(a.addArtifacts(ctx, client, version.GetName()))
+			check(ListSpecs(ctx, client, names.VersionRegexp().FindStringSubmatch(version.GetName()), "", func(spec *rpc.ApiSpec) {
+				check(a.addRevisions(ctx, client, spec))
+				check(a.addArtifacts(ctx, client, spec.GetName()))
+			}))
+		}))
+	}))
+	// Deployments refer to spec revisions, so they follow all of the specs.
+	for _, api := range apis {
+		check(ListDeployments(ctx, client, names.ApiRegexp().FindStringSubmatch(api), "", func(deployment *rpc.ApiDeployment) {
+			check(a.addDeploymentRevisions(ctx, client, deployment))
+		}))
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	f, err := a.zip.Create(archiveManifest)
+	if err != nil {
+		return err
+	}
+	e := json.NewEncoder(f)
+	e.SetIndent("", "  ")
+	if err := e.Encode(a.manifest); err != nil {
+		return err
+	}
+	return a.zip.Close()
+}
+
+// addRevisions adds all revisions of a spec, oldest first.
+func (a *archiveWriter) addRevisions(ctx context.Context, client *gapic.RegistryClient, spec *rpc.ApiSpec) error {
+	var revisions []*rpc.ApiSpec
+	if err := ListSpecRevisions(ctx, client, names.SpecRegexp().FindStringSubmatch(spec.GetName()), "", func(revision *rpc.ApiSpec) {
+		revisions = append(revisions, revision)
+	}); err != nil {
+		return err
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		contents, err := storedBytesForSpec(ctx, client, revision)
+		if err != nil {
+			return err
+		}
+		if err := a.add(archiveRevision, revision, contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedBytesForSpec returns the contents of a spec revision as they are stored,
+// so that archived contents match the revision's MIME type and hash.
+// Gzipped contents are requested without unzipping them, and they are
+// zipped again if the server unzips them anyway.
+func storedBytesForSpec(ctx context.Context, client *gapic.RegistryClient, spec *rpc.ApiSpec) ([]byte, error) {
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, gateway.AcceptEncodingKey, "gzip")
+	contents, err := client.GetApiSpecContents(ctx,
+		&rpc.GetApiSpecContentsRequest{Name: fmt.Sprintf("%s/contents", spec.GetName())},
+		gax.WithGRPCOptions(grpc.Header(&header)))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(spec.GetMimeType(), "+gzip") {
+		return contents.GetData(), nil
+	}
+	for _, encoding := range header.Get(gateway.ContentEncodingKey) {
+		if encoding == "gzip" {
+			return contents.GetData(), nil
+		}
+	}
+	return GZippedBytes(contents.GetData())
+}
+
+// addDeploymentRevisions adds all revisions of a deployment, oldest first.
+func (a *archiveWriter) addDeploymentRevisions(ctx context.Context, client *gapic.RegistryClient, deployment *rpc.ApiDeployment) error {
+	var revisions []*rpc.ApiDeployment
+	if err := ListDeploymentRevisions(ctx, client, names.DeploymentRegexp().FindStringSubmatch(deployment.GetName()), "", func(revision *rpc.ApiDeployment) {
+		revisions = append(revisions, revision)
+	}); err != nil {
+		return err
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if err := a.add(archiveDeployment, revisions[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addArtifacts adds the artifacts that are direct children of a resource.
+func (a *archiveWriter) addArtifacts(ctx context.Context, client *gapic.RegistryClient, parent string) error {
+	it := client.ListArtifacts(ctx, &rpc.ListArtifactsRequest{Parent: parent})
+	for {
+		artifact, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		contents, err := client.GetArtifactContents(ctx, &rpc.GetArtifactContentsRequest{
+			Name: fmt.Sprintf("%s/contents", artifact.GetName()),
+		})
+		if err != nil {
+			return err
+		}
+		if err := a.add(archiveArtifact, artifact, contents.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+// add writes a resource and its contents to the archive.
+func (a *archiveWriter) add(kind string, m proto.Message, contents []byte) error {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	entry := ArchiveEntry{
+		Kind:     kind,
+		Name:     strings.TrimPrefix(strings.TrimPrefix(nameOf(m), a.project), "/"),
+		Resource: b,
+	}
+	if contents != nil {
+		entry.Contents = fmt.Sprintf("contents/%06d", len(a.manifest.Entries))
+		f, err := a.zip.Create(entry.Contents)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(contents); err != nil {
+			return err
+		}
+	}
+	a.manifest.Entries = append(a.manifest.Entries, entry)
+	return nil
+}
+
+func nameOf(m proto.Message) string {
+	f := m.ProtoReflect().Descriptor().Fields().ByName("name")
+	if f == nil {
+		return ""
+	}
+	return m.ProtoReflect().Get(f).String()
+}
+
+// archiveReader recreates resources from a zip archive.
+type archiveReader struct {
+	client  *gapic.RegistryClient
+	files   map[string]*zip.File
+	project string // name of the target project
+	source  string // name of the archived project
+
+	// Names of imported spec revisions, keyed by their archived names.
+	revisions map[string]string
+
+	// State of the spec whose revisions are being imported.
+	spec     string
+	existing []*rpc.ApiSpec // existing revisions of the spec, oldest first
+	position int            // number of existing revisions matched so far
+
+	// State of the deployment whose revisions are being imported.
+	deployment          string
+	existingDeployments []*rpc.ApiDeployment // existing revisions of the deployment, oldest first
+	deploymentPosition  int                  // number of existing revisions matched so far
+}
+
+// ImportArchive recreates the resources in a project archive. If projectID
+// is not empty, resources are imported into that project instead of the
+// archived one. Imports are idempotent: resources that already exist are
+// updated, and spec and deployment revisions that were imported previously are skipped,
+// so a failed import can be resumed by running it again.
+func ImportArchive(ctx context.Context, client *gapic.RegistryClient, r *zip.Reader, projectID string) error {
+	a := &archiveReader{
+		client:    client,
+		files:     make(map[string]*zip.File),
+		revisions: make(map[string]string),
+	}
+	for _, f := range r.File {
+		a.files[f.Name] = f
+	}
+
+	b, err := a.read(archiveManifest)
+	if err != nil {
+		return err
+	}
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return fmt.Errorf("invalid archive manifest: %s", err)
+	}
+	if manifest.Format != ArchiveFormat {
+		return fmt.Errorf("unsupported archive format %q, expected %q", manifest.Format, ArchiveFormat)
+	}
+	if projectID == "" {
+		projectID = manifest.Project
+	}
+	a.project = names.Project{ProjectID: projectID}.String()
+	a.source = names.Project{ProjectID: manifest.Project}.String()
+
+	for _, entry := range manifest.Entries {
+		if err := a.importEntry(ctx, entry); err != nil {
+			return fmt.Errorf("failed to import %s %q: %s", entry.Kind, entry.Name, err)
+		}
+	}
+	return nil
+}
+
+func (a *archiveReader) read(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("archive is missing %q", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// name returns the name of an archived resource in the target project.
+func (a *archiveReader) name(relative string) string {
+	if relative == "" {
+		return a.project
+	}
+	return a.project + "/" + relative
+}
+
+func (a *archiveReader) importEntry(ctx context.Context, entry ArchiveEntry) error {
+	var contents []byte
+	if entry.Contents != "" {
+		var err error
+		if contents, err = a.read(entry.Contents); err != nil {
+			return err
+		}
+	}
+
+	switch entry.Kind {
+	case archiveProject:
+		m := &rpc.Project{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		return a.importProject(ctx, m)
+	case archiveApi:
+		m := &rpc.Api{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		m.Name = a.name(entry.Name)
+		if strings.HasPrefix(m.RecommendedVersion, a.source+"/") {
+			m.RecommendedVersion = a.project + strings.TrimPrefix(m.RecommendedVersion, a.source)
+		}
+		return a.importApi(ctx, m)
+	case archiveVersion:
+		m := &rpc.ApiVersion{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		m.Name = a.name(entry.Name)
+		return a.importVersion(ctx, m)
+	case archiveRevision:
+		m := &rpc.ApiSpec{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		m.Name = a.name(strings.SplitN(entry.Name, "@", 2)[0])
+		m.Contents = contents
+		revision, err := a.importRevision(ctx, m)
+		if err != nil {
+			return err
+		}
+		a.revisions[entry.Name] = revision
+		return nil
+	case archiveDeployment:
+		m := &rpc.ApiDeployment{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		m.Name = a.name(strings.SplitN(entry.Name, "@", 2)[0])
+		m.ApiSpecRevision = a.specRevision(m.GetApiSpecRevision())
+		return a.importDeploymentRevision(ctx, m)
+	case archiveArtifact:
+		m := &rpc.Artifact{}
+		if err := protojson.Unmarshal(entry.Resource, m); err != nil {
+			return err
+		}
+		m.Name = a.name(entry.Name)
+		m.Contents = contents
+		return SetArtifact(ctx, a.client, m)
+	default:
+		return fmt.Errorf("unknown kind")
+	}
+}
+
+func (a *archiveReader) importProject(ctx context.Context, m *rpc.Project) error {
+	project := &rpc.Project{
+		Name:        a.project,
+		DisplayName: m.GetDisplayName(),
+		Description: m.GetDescription(),
+	}
+	_, err := a.client.CreateProject(ctx, &rpc.CreateProjectRequest{
+		ProjectId: strings.TrimPrefix(a.project, "projects/"),
+		Project:   project,
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	_, err = a.client.UpdateProject(ctx, &rpc.UpdateProjectRequest{
+		Project:    project,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name", "description"}},
+	})
+	return err
+}
+
+func (a *archiveReader) importApi(ctx context.Context, m *rpc.Api) error {
+	name, err := names.ParseApi(m.GetName())
+	if err != nil {
+		return err
+	}
+	_, err = a.client.CreateApi(ctx, &rpc.CreateApiRequest{
+		Parent: name.Project().String(),
+		ApiId:  name.ApiID,
+		Api:    m,
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	_, err = a.client.UpdateApi(ctx, &rpc.UpdateApiRequest{
+		Api: m,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{
+			"display_name", "description", "availability", "recommended_version", "labels", "annotations",
+		}},
+	})
+	return err
+}
+
+func (a *archiveReader) importVersion(ctx context.Context, m *rpc.ApiVersion) error {
+	name, err := names.ParseVersion(m.GetName())
+	if err != nil {
+		return err
+	}
+	_, err = a.client.CreateApiVersion(ctx, &rpc.CreateApiVersionRequest{
+		Parent:       name.Api().String(),
+		ApiVersionId: name.VersionID,
+		ApiVersion:   m,
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	_, err = a.client.UpdateApiVersion(ctx, &rpc.UpdateApiVersionRequest{
+		ApiVersion: m,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{
+			"display_name", "description", "state", "labels", "annotations",
+		}},
+	})
+	return err
+}
+
+// importRevision recreates a spec revision. Revisions of a spec are archived
+// oldest first, so each one is applied as an update of the previous one.
+// Revisions that match existing revisions of the spec are skipped.
+// It returns the name of the imported revision.
+func (a *archiveReader) importRevision(ctx context.Context, m *rpc.ApiSpec) (string, error) {
+	if m.GetName() != a.spec {
+		if err := a.startSpec(ctx, m.GetName()); err != nil {
+			return "", err
+		}
+	}
+
+	var revisionID string
+	if a.position < len(a.existing) {
+		existing := a.existing[a.position]
+		if existing.GetHash() != m.GetHash() {
+			return "", fmt.Errorf("existing revision %s does not match the archive", existing.GetName())
+		}
+		a.position++
+		revisionID = existing.GetRevisionId()
+	} else {
+		resp, err := a.client.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+			ApiSpec: &rpc.ApiSpec{
+				Name:        m.GetName(),
+				Filename:    m.GetFilename(),
+				Description: m.GetDescription(),
+				MimeType:    m.GetMimeType(),
+				SourceUri:   m.GetSourceUri(),
+				Contents:    m.GetContents(),
+				Labels:      m.GetLabels(),
+				Annotations: m.GetAnnotations(),
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{
+				"filename", "description", "mime_type", "source_uri", "contents", "labels", "annotations",
+			}},
+			AllowMissing: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		revisionID = resp.GetRevisionId()
+		log.Printf("imported %s@%s", m.GetName(), revisionID)
+	}
+
+	for _, tag := range m.GetRevisionTags() {
+		if _, err := a.client.TagApiSpecRevision(ctx, &rpc.TagApiSpecRevisionRequest{
+			Name: fmt.Sprintf("%s@%s", m.GetName(), revisionID),
+			Tag:  tag,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s@%s", m.GetName(), revisionID), nil
+}
+
+// startSpec loads the existing revisions of a spec before its archived revisions are imported.
+func (a *archiveReader) startSpec(ctx context.Context, name string) error {
+	a.spec = name
+	a.existing = nil
+	a.position = 0
+
+	err := ListSpecRevisions(ctx, a.client, names.SpecRegexp().FindStringSubmatch(name), "", func(revision *rpc.ApiSpec) {
+		a.existing = append([]*rpc.ApiSpec{revision}, a.existing...)
+	})
+	if NotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// specRevision returns the name in the target project of a spec revision
+// that an archived deployment refers to. Revisions that were imported are
+// renamed with their new revision IDs.
+func (a *archiveReader) specRevision(name string) string {
+	if !strings.HasPrefix(name, a.source+"/") {
+		return name
+	}
+	relative := strings.TrimPrefix(name, a.source+"/")
+	if revision, ok := a.revisions[relative]; ok {
+		return revision
+	}
+	return a.name(relative)
+}
+
+// importDeploymentRevision recreates a deployment revision. Like spec revisions,
+// deployment revisions are archived oldest first and applied as updates, and
+// revisions that match existing revisions of the deployment are skipped.
+func (a *archiveReader) importDeploymentRevision(ctx context.Context, m *rpc.ApiDeployment) error {
+	if m.GetName() != a.deployment {
+		if err := a.startDeployment(ctx, m.GetName()); err != nil {
+			return err
+		}
+	}
+
+	var revisionID string
+	if a.deploymentPosition < len(a.existingDeployments) {
+		existing := a.existingDeployments[a.deploymentPosition]
+		if !sameDeploymentRevision(existing, m) {
+			return fmt.Errorf("existing revision %s does not match the archive", existing.GetName())
+		}
+		a.deploymentPosition++
+		revisionID = existing.GetRevisionId()
+	} else {
+		resp, err := a.client.UpdateApiDeployment(ctx, &rpc.UpdateApiDeploymentRequest{
+			ApiDeployment: &rpc.ApiDeployment{
+				Name:               m.GetName(),
+				DisplayName:        m.GetDisplayName(),
+				Description:        m.GetDescription(),
+				ApiSpecRevision:    m.GetApiSpecRevision(),
+				EndpointUri:        m.GetEndpointUri(),
+				ExternalChannelUri: m.GetExternalChannelUri(),
+				IntendedAudience:   m.GetIntendedAudience(),
+				AccessGuidance:     m.GetAccessGuidance(),
+				Environment:        m.GetEnvironment(),
+				Gateway:            m.GetGateway(),
+				Labels:             m.GetLabels(),
+				Annotations:        m.GetAnnotations(),
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{
+				"display_name", "description", "api_spec_revision", "endpoint_uri", "external_channel_uri",
+				"intended_audience", "access_guidance", "environment", "gateway", "labels", "annotations",
+			}},
+			AllowMissing: true,
+		})
+		if err != nil {
+			return err
+		}
+		revisionID = resp.GetRevisionId()
+		log.Printf("imported %s@%s", m.GetName(), revisionID)
+	}
+
+	for _, tag := range m.GetRevisionTags() {
+		if _, err := a.client.TagApiDeploymentRevision(ctx, &rpc.TagApiDeploymentRevisionRequest{
+			Name: fmt.Sprintf("%s@%s", m.GetName(), revisionID),
+			Tag:  tag,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameDeploymentRevision reports whether two deployment revisions have the
+// same values for the fields that distinguish revisions.
+func sameDeploymentRevision(a, b *rpc.ApiDeployment) bool {
+	return a.GetApiSpecRevision() == b.GetApiSpecRevision() &&
+		a.GetEndpointUri() == b.GetEndpointUri() &&
+		a.GetEnvironment() == b.GetEnvironment() &&
+		a.GetGateway() == b.GetGateway()
+}
+
+// startDeployment loads the existing revisions of a deployment before its archived revisions are imported.
+func (a *archiveReader) startDeployment(ctx context.Context, name string) error {
+	a.deployment = name
+	a.existingDeployments = nil
+	a.deploymentPosition = 0
+
+	err := ListDeploymentRevisions(ctx, a.client, names.DeploymentRegexp().FindStringSubmatch(name), "", func(revision *rpc.ApiDeployment) {
+		a.existingDeployments = append([]*rpc.ApiDeployment{revision}, a.existingDeployments...)
+	})
+	if NotFound(err) {
+		return nil
+	}
+	return err
+}
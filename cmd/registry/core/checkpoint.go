@@ -0,0 +1,144 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Checkpoint records the resources that a command has finished, so that
+// the command can skip them if it is interrupted and run again. Each line
+// of a checkpoint file holds the hash of a resource's contents and its name.
+type Checkpoint struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	done  map[string]string
+}
+
+// OpenCheckpoint reads a checkpoint file, creating it if it doesn't exist.
+func OpenCheckpoint(path string) (*Checkpoint, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpoint{path: path, file: file, done: make(map[string]string)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Lines that were cut short by an interruption are ignored.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			c.done[fields[1]] = fields[0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Len returns the number of resources that are recorded.
+func (c *Checkpoint) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.done)
+}
+
+// Done returns true if a resource was recorded with the same hash.
+func (c *Checkpoint) Done(name, hash string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	h, ok := c.done[name]
+	return ok && h == checkpointHash(hash)
+}
+
+// Record records that a resource is finished.
+func (c *Checkpoint) Record(name, hash string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	hash = checkpointHash(hash)
+	c.done[name] = hash
+	_, err := fmt.Fprintf(c.file, "%s %s\n", hash, name)
+	return err
+}
+
+// checkpointHash represents empty hashes, which are the hashes of empty
+// contents, so that they can be written as fields.
+func checkpointHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	return hash
+}
+
+// Close closes a checkpoint file so that a later run can resume from it.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// Remove closes and deletes a checkpoint file after a command finishes.
+func (c *Checkpoint) Remove() error {
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(c.path)
+}
+
+// ReadRecord reads the names of the resources that a finished command
+// handled, one per line. A missing file is read as an empty record.
+func ReadRecord(path string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return names, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Fields(string(b)) {
+		names[name] = true
+	}
+	return names, nil
+}
+
+// WriteRecord replaces the record of the resources that a command handled,
+// so that a later run can find the resources that it no longer handles.
+func WriteRecord(path string, names map[string]bool) error {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// Records are replaced by renaming so that interruptions don't leave
+	// partial records.
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strings.Join(sorted, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
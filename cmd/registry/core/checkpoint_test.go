@@ -0,0 +1,105 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uploads", "checkpoint")
+	c, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint() returned error: %s", err)
+	}
+	for _, r := range []struct{ name, hash string }{{"a", "1"}, {"b", "2"}, {"a", "3"}, {"empty", ""}} {
+		if err := c.Record(r.name, r.hash); err != nil {
+			t.Fatalf("Record(%q, %q) returned error: %s", r.name, r.hash, err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() returned error: %s", err)
+	}
+
+	// Simulate an interruption while a line was being written.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Setup: OpenFile() returned error: %s", err)
+	}
+	f.WriteString("4")
+	f.Close()
+
+	c, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint() returned error: %s", err)
+	}
+	tests := []struct {
+		name, hash string
+		want       bool
+	}{
+		{"a", "3", true},
+		{"a", "1", false},
+		{"b", "2", true},
+		{"empty", "", true},
+		{"c", "4", false},
+	}
+	for _, test := range tests {
+		if got := c.Done(test.name, test.hash); got != test.want {
+			t.Errorf("Done(%q, %q) returned %t, want %t", test.name, test.hash, got, test.want)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len() returned %d, want 3", c.Len())
+	}
+
+	if err := c.Remove(); err != nil {
+		t.Fatalf("Remove() returned error: %s", err)
+	}
+	if _, err := ioutil.ReadFile(path); !os.IsNotExist(err) {
+		t.Errorf("Remove() didn't delete %s", path)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uploads", "record")
+	names, err := ReadRecord(path)
+	if err != nil {
+		t.Fatalf("ReadRecord() returned error: %s", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("ReadRecord() of a missing file returned %v, want no names", names)
+	}
+
+	for _, want := range []map[string]bool{
+		{"projects/p/apis/a/versions/v/specs/b.yaml": true, "projects/p/apis/a/versions/v/specs/a.yaml": true},
+		{"projects/p/apis/a/versions/v/specs/a.yaml": true},
+	} {
+		if err := WriteRecord(path, want); err != nil {
+			t.Fatalf("WriteRecord() returned error: %s", err)
+		}
+		got, err := ReadRecord(path)
+		if err != nil {
+			t.Fatalf("ReadRecord() returned error: %s", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ReadRecord() returned unexpected names (-want +got):\n%s", diff)
+		}
+	}
+}
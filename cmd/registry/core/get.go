@@ -102,6 +102,23 @@ func GetSpec(ctx context.Context,
 	return spec, nil
 }
 
+func GetDeployment(ctx context.Context,
+	client *gapic.RegistryClient,
+	segments []string,
+	handler DeploymentHandler) (*rpc.ApiDeployment, error) {
+	request := &rpc.GetApiDeploymentRequest{
+		Name: "projects/" + segments[1] + "/apis/" + segments[2] + "/deployments/" + segments[3] + segments[4],
+	}
+	deployment, err := client.GetApiDeployment(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if handler != nil {
+		handler(deployment)
+	}
+	return deployment, nil
+}
+
 func GetArtifact(ctx context.Context,
 	client *gapic.RegistryClient,
 	segments []string,
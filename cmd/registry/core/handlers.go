@@ -22,4 +22,6 @@ type ProjectHandler func(*rpc.Project)
 type ApiHandler func(*rpc.Api)
 type VersionHandler func(*rpc.ApiVersion)
 type SpecHandler func(*rpc.ApiSpec)
+type DeploymentHandler func(*rpc.ApiDeployment)
 type ArtifactHandler func(*rpc.Artifact)
+type AuditEventHandler func(*rpc.AuditEvent)
@@ -16,6 +16,7 @@ package core
 
 import (
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
@@ -26,10 +27,23 @@ import (
 	yaml "gopkg.in/yaml.v3"
 )
 
-func lintFileForOpenAPIWithGnostic(path string, root string) (*rpc.LintFile, error) {
+// lintFileForOpenAPIWithGnostic runs gnostic on a spec in a temporary
+// directory, where gnostic reads the spec and writes its results.
+func lintFileForOpenAPIWithGnostic(path string, spec []byte) (*rpc.LintFile, error) {
+	// create a tmp directory
+	root, err := ioutil.TempDir("", "registry-openapi-")
+	if err != nil {
+		return nil, err
+	}
+	// whenever we finish, delete the tmp directory
+	defer os.RemoveAll(root)
+	// write the file to the temp directory
+	if err := ioutil.WriteFile(filepath.Join(root, path), spec, 0644); err != nil {
+		return nil, err
+	}
 	cmd := exec.Command("gnostic", path, "--linter-out=.")
 	cmd.Dir = root
-	_, err := cmd.CombinedOutput()
+	_, err = cmd.CombinedOutput()
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,33 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/apigee/registry/cmd/registry/rules"
+	"github.com/apigee/registry/rpc"
+)
+
+// lintFileForOpenAPIWithRules checks a spec against a ruleset in the format
+// of Spectral rulesets, or against the default ruleset if none is given.
+func lintFileForOpenAPIWithRules(spec []byte, ruleset *rules.Ruleset) (*rpc.LintFile, error) {
+	if ruleset == nil {
+		var err error
+		ruleset, err = rules.DefaultRuleset()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ruleset.Lint(spec)
+}
@@ -16,37 +16,29 @@ package core
 
 import (
 	"errors"
-	"io/ioutil"
-	"os"
 	"path/filepath"
 
+	"github.com/apigee/registry/cmd/registry/rules"
 	"github.com/apigee/registry/rpc"
 )
 
 // NewLintFromOpenAPI runs the API linter and returns the results.
-func NewLintFromOpenAPI(name string, spec []byte, linter string) (*rpc.Lint, error) {
-	// create a tmp directory
-	root, err := ioutil.TempDir("", "registry-openapi-")
-	if err != nil {
-		return nil, err
-	}
+// The rules linter checks specs against a ruleset, or against the
+// default ruleset if the ruleset is nil.
+func NewLintFromOpenAPI(name string, spec []byte, linter string, ruleset *rules.Ruleset) (*rpc.Lint, error) {
 	name = filepath.Base(name)
-	// whenever we finish, delete the tmp directory
-	defer os.RemoveAll(root)
-	// write the file to the temp directory
-	err = ioutil.WriteFile(filepath.Join(root, name), spec, 0644)
-	if err != nil {
-		return nil, err
-	}
 	// run the linter on the spec
 	var lintFile *rpc.LintFile
+	var err error
 	switch linter {
 	case "":
 		err = errors.New("unspecified linter")
 	case "gnostic":
-		lintFile, err = lintFileForOpenAPIWithGnostic(name, root)
+		lintFile, err = lintFileForOpenAPIWithGnostic(name, spec)
+	case "rules":
+		lintFile, err = lintFileForOpenAPIWithRules(spec, ruleset)
 	case "spectral":
-		lintFile, err = lintFileForOpenAPIWithSpectral(name, root)
+		err = errors.New(`the spectral linter was replaced by the "rules" linter, which checks Spectral rulesets without running Spectral`)
 	default:
 		err = errors.New("unknown linter: " + linter)
 	}
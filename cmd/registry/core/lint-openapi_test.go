@@ -0,0 +1,49 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+const lintSpec = `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+paths: {}
+`
+
+func TestNewLintFromOpenAPI(t *testing.T) {
+	lint, err := NewLintFromOpenAPI("projects/p/apis/a/versions/v/specs/openapi.yaml", []byte(lintSpec), "rules", nil)
+	if err != nil {
+		t.Fatalf("NewLintFromOpenAPI() returned error: %s", err)
+	}
+	if lint.GetName() != "openapi.yaml" || len(lint.GetFiles()) != 1 {
+		t.Errorf("NewLintFromOpenAPI() returned %q with %d files, want openapi.yaml with 1 file", lint.GetName(), len(lint.GetFiles()))
+	}
+	if len(lint.GetFiles()[0].GetProblems()) == 0 {
+		t.Errorf("NewLintFromOpenAPI() found no problems, want problems of the default ruleset")
+	}
+
+	for _, linter := range []string{"", "spectral", "unknown"} {
+		if _, err := NewLintFromOpenAPI("openapi.yaml", []byte(lintSpec), linter, nil); err == nil {
+			t.Errorf("NewLintFromOpenAPI() with linter %q succeeded, want error", linter)
+		}
+	}
+	if _, err := NewLintFromOpenAPI("openapi.yaml", []byte(lintSpec), "spectral", nil); !strings.Contains(err.Error(), `"rules"`) {
+		t.Errorf("NewLintFromOpenAPI() with linter spectral returned %q, want the name of its replacement", err)
+	}
+}
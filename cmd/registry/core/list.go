@@ -20,6 +20,7 @@ import (
 
 	"github.com/apigee/registry/gapic"
 	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
 	"google.golang.org/api/iterator"
 )
 
@@ -120,6 +121,12 @@ func ListSpecs(ctx context.Context,
 	if filter != "" {
 		request.Filter = filter
 	}
+	// A revision ID or tag selects that revision of each matching spec.
+	// Specs without the revision or tag are skipped.
+	revision := ""
+	if len(segments) > 6 {
+		revision = segments[6]
+	}
 	it := client.ListApiSpecs(ctx, request)
 	for {
 		spec, err := it.Next()
@@ -128,6 +135,16 @@ func ListSpecs(ctx context.Context,
 		} else if err != nil {
 			return err
 		}
+		if revision != "" {
+			spec, err = client.GetApiSpec(ctx, &rpc.GetApiSpecRequest{
+				Name: spec.GetName() + "@" + revision,
+			})
+			if NotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
 		handler(spec)
 	}
 	return nil
@@ -157,6 +174,73 @@ func ListSpecRevisions(ctx context.Context,
 	return nil
 }
 
+func ListDeployments(ctx context.Context,
+	client *gapic.RegistryClient,
+	segments []string,
+	filterFlag string,
+	handler DeploymentHandler) error {
+	request := &rpc.ListApiDeploymentsRequest{
+		Parent: "projects/" + segments[1] + "/apis/" + segments[2],
+	}
+	filter := filterFlag
+	if len(segments) > 3 && segments[3] != "-" {
+		filter = "deployment_id == '" + segments[3] + "'"
+	}
+	if filter != "" {
+		request.Filter = filter
+	}
+	// A revision ID or tag selects that revision of each matching deployment.
+	// Deployments without the revision or tag are skipped.
+	revision := ""
+	if len(segments) > 5 {
+		revision = segments[5]
+	}
+	it := client.ListApiDeployments(ctx, request)
+	for {
+		deployment, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return err
+		}
+		if revision != "" {
+			deployment, err = client.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{
+				Name: deployment.GetName() + "@" + revision,
+			})
+			if NotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+		handler(deployment)
+	}
+	return nil
+}
+
+func ListDeploymentRevisions(ctx context.Context,
+	client *gapic.RegistryClient,
+	segments []string,
+	filterFlag string,
+	handler DeploymentHandler) error {
+	request := &rpc.ListApiDeploymentRevisionsRequest{
+		Name: "projects/" + segments[1] +
+			"/apis/" + segments[2] +
+			"/deployments/" + segments[3],
+	}
+	it := client.ListApiDeploymentRevisions(ctx, request)
+	for {
+		deployment, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return err
+		}
+		handler(deployment)
+	}
+	return nil
+}
+
 func ListArtifacts(ctx context.Context,
 	client *gapic.RegistryClient,
 	segments []string,
@@ -238,3 +322,25 @@ func ListArtifactsForParent(ctx context.Context,
 	}
 	return nil
 }
+
+func ListAuditEvents(ctx context.Context,
+	client *gapic.RegistryClient,
+	project names.Project,
+	filterFlag string,
+	handler AuditEventHandler) error {
+	request := &rpc.ListAuditEventsRequest{
+		Parent: project.String(),
+		Filter: filterFlag,
+	}
+	it := client.ListAuditEvents(ctx, request)
+	for {
+		event, err := it.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return err
+		}
+		handler(event)
+	}
+	return nil
+}
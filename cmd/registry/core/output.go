@@ -0,0 +1,263 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"text/template"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// OutputFormats describes the values of the --output flag.
+const OutputFormats = "name, json, jsonl, yaml, table[=COLUMNS], or template=TEMPLATE"
+
+// Columns of tables when none are selected.
+var defaultColumns = []string{"name", "createTime"}
+
+// Printer writes resources in an output format. Fields are named as in
+// the JSON encoding of resources, e.g. "createTime"; table columns can
+// also be given in snake case and can select map entries like "labels.team".
+// Printers can be used by concurrent tasks.
+type Printer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	format  string
+	list    bool
+	columns []string
+	tmpl    *template.Template
+	table   *tabwriter.Writer
+	header  bool
+	values  [][]byte
+}
+
+// NewPrinter returns a printer for an output format. Printers for lists
+// write JSON and YAML arrays, while other printers write single values.
+func NewPrinter(w io.Writer, output string, list bool) (*Printer, error) {
+	p := &Printer{w: w, list: list}
+	format, arg := output, ""
+	if i := strings.Index(output, "="); i >= 0 {
+		format, arg = output[:i], output[i+1:]
+	}
+
+	switch format {
+	case "name", "json", "jsonl", "yaml":
+		if arg != "" {
+			return nil, fmt.Errorf("invalid output %q: %s takes no arguments", output, format)
+		}
+	case "table":
+		p.columns = defaultColumns
+		if arg != "" {
+			p.columns = strings.Split(arg, ",")
+		}
+		p.table = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	case "template", "go-template":
+		format = "template"
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output %q: %s", output, err)
+		}
+		p.tmpl = tmpl
+	default:
+		return nil, fmt.Errorf("invalid output %q: must be one of %s", output, OutputFormats)
+	}
+	p.format = format
+	return p, nil
+}
+
+// Print writes a message.
+func (p *Printer) Print(message proto.Message) error {
+	b, err := protojson.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return p.print(b)
+}
+
+// PrintValue writes a value that isn't a message, such as a computed result.
+func (p *Printer) PrintValue(value map[string]interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return p.print(b)
+}
+
+func (p *Printer) print(b []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.format {
+	case "json", "yaml":
+		// Values are written together by Flush.
+		p.values = append(p.values, b)
+		return nil
+	case "jsonl":
+		var line bytes.Buffer
+		if err := json.Compact(&line, b); err != nil {
+			return err
+		}
+		line.WriteByte('\n')
+		_, err := p.w.Write(line.Bytes())
+		return err
+	}
+
+	var v map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	switch p.format {
+	case "name":
+		_, err := fmt.Fprintln(p.w, formatValue(lookup(v, "name")))
+		return err
+	case "table":
+		if !p.header {
+			header := make([]string, len(p.columns))
+			for i, c := range p.columns {
+				header[i] = columnHeader(c)
+			}
+			fmt.Fprintln(p.table, strings.Join(header, "\t"))
+			p.header = true
+		}
+		row := make([]string, len(p.columns))
+		for i, c := range p.columns {
+			row[i] = formatValue(lookup(v, c))
+		}
+		_, err := fmt.Fprintln(p.table, strings.Join(row, "\t"))
+		return err
+	case "template":
+		var out bytes.Buffer
+		if err := p.tmpl.Execute(&out, v); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		_, err := p.w.Write(out.Bytes())
+		return err
+	}
+	return nil
+}
+
+// Flush writes buffered values. It must be called after all values are printed.
+func (p *Printer) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.format {
+	case "table":
+		return p.table.Flush()
+	case "json", "yaml":
+	default:
+		return nil
+	}
+
+	var b []byte
+	if p.list {
+		b = append([]byte("["), bytes.Join(p.values, []byte(","))...)
+		b = append(b, ']')
+	} else if len(p.values) > 0 {
+		b = p.values[len(p.values)-1]
+	} else {
+		return nil
+	}
+	p.values = nil
+
+	if p.format == "yaml" {
+		y, err := yaml.JSONToYAML(b)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(y)
+		return err
+	}
+
+	// Indenting preserves the field order of the protojson encoding.
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
+
+// lookup returns the value of a dotted field path, e.g. "labels.team".
+// Path elements may be given in snake case.
+func lookup(v interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok := m[key]; ok {
+			v = value
+		} else {
+			v = m[lowerCamelCase(key)]
+		}
+	}
+	return v
+}
+
+// formatValue formats a value for a single line of output.
+func formatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(x)
+		return string(b)
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
+func lowerCamelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// columnHeader returns the header of a table column, e.g. CREATE_TIME for createTime.
+func columnHeader(column string) string {
+	var b strings.Builder
+	for i, r := range column {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		if r == '.' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestPrinter(t *testing.T) {
+	resources := []map[string]interface{}{
+		{"name": "projects/p/apis/a", "createTime": "2021-06-01T00:00:00Z", "labels": map[string]interface{}{"team": "payments"}},
+		{"name": "projects/p/apis/b", "sizeBytes": 1000000},
+	}
+
+	tests := []struct {
+		output string
+		list   bool
+		want   string
+	}{
+		{"name", true, "projects/p/apis/a\nprojects/p/apis/b\n"},
+		{"jsonl", true, `{"createTime":"2021-06-01T00:00:00Z","labels":{"team":"payments"},"name":"projects/p/apis/a"}` + "\n" +
+			`{"name":"projects/p/apis/b","sizeBytes":1000000}` + "\n"},
+		{"json", false, "{\n  \"name\": \"projects/p/apis/b\",\n  \"sizeBytes\": 1000000\n}\n"},
+		{"json", true, "[\n  {\n    \"createTime\": \"2021-06-01T00:00:00Z\",\n    \"labels\": {\n      \"team\": \"payments\"\n    },\n    \"name\": \"projects/p/apis/a\"\n  },\n" +
+			"  {\n    \"name\": \"projects/p/apis/b\",\n    \"sizeBytes\": 1000000\n  }\n]\n"},
+		{"yaml", true, "- createTime: \"2021-06-01T00:00:00Z\"\n  labels:\n    team: payments\n  name: projects/p/apis/a\n- name: projects/p/apis/b\n  sizeBytes: 1000000\n"},
+		{"table", true, "NAME               CREATE_TIME\nprojects/p/apis/a  2021-06-01T00:00:00Z\nprojects/p/apis/b  \n"},
+		{"table=name,labels.team,size_bytes", true, "NAME               LABELS_TEAM  SIZE_BYTES\nprojects/p/apis/a  payments     \nprojects/p/apis/b               1000000\n"},
+		{`template={{.name}}{{with .labels}} {{.team}}{{end}}`, true, "projects/p/apis/a payments\nprojects/p/apis/b\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, test.output, test.list)
+			if err != nil {
+				t.Fatalf("NewPrinter(%q) returned error: %s", test.output, err)
+			}
+			for _, r := range resources {
+				if test.output == "table" || test.output == "yaml" {
+					// Values and messages are printed the same way.
+					if err := p.PrintValue(r); err != nil {
+						t.Fatalf("PrintValue() returned error: %s", err)
+					}
+					continue
+				}
+				s, err := structpb.NewStruct(r)
+				if err != nil {
+					t.Fatalf("Setup: NewStruct() returned error: %s", err)
+				}
+				if err := p.Print(s); err != nil {
+					t.Fatalf("Print() returned error: %s", err)
+				}
+			}
+			if err := p.Flush(); err != nil {
+				t.Fatalf("Flush() returned error: %s", err)
+			}
+			if diff := cmp.Diff(test.want, out.String()); diff != "" {
+				t.Errorf("printed unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPrinterErrors(t *testing.T) {
+	for _, output := range []string{"", "xml", "json=x", "template={{.name"} {
+		if _, err := NewPrinter(&bytes.Buffer{}, output, true); err == nil {
+			t.Errorf("NewPrinter(%q) succeeded, want error", output)
+		}
+	}
+}
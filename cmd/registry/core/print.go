@@ -18,11 +18,13 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apigee/registry/rpc"
 	metrics "github.com/googleapis/gnostic/metrics"
 	openapiv2 "github.com/googleapis/gnostic/openapiv2"
 	openapiv3 "github.com/googleapis/gnostic/openapiv3"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
@@ -67,6 +69,14 @@ func PrintSpecContents(message *rpc.ApiSpec) {
 	os.Stdout.Write(contents)
 }
 
+func PrintDeployment(deployment *rpc.ApiDeployment) {
+	fmt.Println(deployment.Name)
+}
+
+func PrintDeploymentDetail(message *rpc.ApiDeployment) {
+	PrintMessage(message)
+}
+
 func PrintArtifact(artifact *rpc.Artifact) {
 	fmt.Println(artifact.Name)
 }
@@ -104,6 +114,20 @@ func PrintArtifactContents(artifact *rpc.Artifact) {
 	}
 }
 
+func PrintAuditEvent(event *rpc.AuditEvent) {
+	result := codes.Code(event.GetCode()).String()
+	fmt.Printf("%s %s %s %s %s\n",
+		event.GetEventTime().AsTime().Format(time.RFC3339),
+		event.GetPrincipal(),
+		event.GetMethod(),
+		event.GetResource(),
+		result)
+}
+
+func PrintAuditEventDetail(event *rpc.AuditEvent) {
+	PrintMessage(event)
+}
+
 func PrintMessage(message proto.Message) {
 	fmt.Println(protojson.Format(message))
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/apigee/registry/connection"
 	"github.com/apigee/registry/rpc"
@@ -37,6 +38,16 @@ func ResourceNameOfSpec(segments []string) string {
 	return ""
 }
 
+// SpecArtifactParent returns the name of the spec that stores artifacts
+// computed from a spec or from one of its revisions or tags.
+// Artifacts belong to specs rather than to individual revisions.
+func SpecArtifactParent(name string) string {
+	if i := strings.Index(name, "@"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
 func GetBytesForSpec(ctx context.Context, client connection.Client, spec *rpc.ApiSpec) ([]byte, error) {
 	request := &rpc.GetApiSpecContentsRequest{Name: fmt.Sprintf("%s/contents", spec.GetName())}
 	contents, err := client.GetApiSpecContents(ctx, request)
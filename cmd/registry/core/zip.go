@@ -19,10 +19,13 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // UnzipArchiveToPath will decompress a zip archive, writing all files and folders
@@ -94,6 +97,63 @@ func ZipArchiveOfPath(path, prefix string) (buf bytes.Buffer, err error) {
 	return buf, nil
 }
 
+// ZipArchiveOfFiles writes files, which map names to contents, into a zip
+// archive. Files are written in name order, so archives of the same files
+// are identical.
+func ZipArchiveOfFiles(files map[string][]byte) (buf bytes.Buffer, err error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zipWriter := zip.NewWriter(&buf)
+	for _, name := range names {
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: zipModTime,
+		})
+		if err != nil {
+			return buf, err
+		}
+		if _, err := writer.Write(files[name]); err != nil {
+			return buf, err
+		}
+	}
+	return buf, zipWriter.Close()
+}
+
+// UnzipArchiveToFiles reads the files in a zip archive into a map from
+// their names to their contents.
+func UnzipArchiveToFiles(b []byte) (map[string][]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = contents
+	}
+	return files, nil
+}
+
+// zipModTime is the modification time of files in archives, the earliest
+// time that zip files can represent.
+var zipModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func addFileToZip(zipWriter *zip.Writer, filename, prefix string) error {
 	fileToZip, err := os.Open(filename)
 	if err != nil {
@@ -113,6 +173,9 @@ func addFileToZip(zipWriter *zip.Writer, filename, prefix string) error {
 	// to preserve the folder structure we can overwrite this with the full path.
 	name := strings.TrimPrefix(filename, prefix)
 	header.Name = name
+	// Archives of the same files are identical, regardless of when the files
+	// were last modified, so that their hashes can be compared.
+	header.Modified = zipModTime
 	// Set to Deflate to gain better compression
 	// see http://golang.org/pkg/archive/zip/#pkg-constants
 	header.Method = zip.Deflate
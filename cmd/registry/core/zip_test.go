@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZipArchiveOfFiles(t *testing.T) {
+	files := map[string][]byte{
+		"a/b.proto": []byte("syntax = \"proto3\";"),
+		"c.txt":     []byte("c"),
+		"empty":     {},
+	}
+	buf, err := ZipArchiveOfFiles(files)
+	if err != nil {
+		t.Fatalf("ZipArchiveOfFiles() returned error: %s", err)
+	}
+	got, err := UnzipArchiveToFiles(buf.Bytes())
+	if err != nil {
+		t.Fatalf("UnzipArchiveToFiles() returned error: %s", err)
+	}
+	if diff := cmp.Diff(files, got); diff != "" {
+		t.Errorf("unexpected files (-want +got):\n%s", diff)
+	}
+	if _, err := UnzipArchiveToFiles([]byte("not a zip")); err == nil {
+		t.Errorf("UnzipArchiveToFiles() succeeded on invalid input, want error")
+	}
+}
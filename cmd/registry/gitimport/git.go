@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Commit is a commit and the files that it added or modified.
+type Commit struct {
+	SHA    string
+	Author string
+	Time   time.Time
+	Files  []string
+}
+
+// Log returns the commits of a repository's current branch, oldest first.
+// Merge commits, which repeat the changes of the commits that they merge,
+// are omitted.
+func Log(ctx context.Context, repo string) ([]*Commit, error) {
+	out, err := git(ctx, repo, "log", "--reverse", "--no-merges", "--no-renames",
+		"--format=%x00%H%x09%an <%ae>%x09%aI", "--name-status", "--diff-filter=AMT")
+	if err != nil {
+		return nil, err
+	}
+	commits := make([]*Commit, 0)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		header := strings.SplitN(lines[0], "\t", 3)
+		if len(header) != 3 {
+			return nil, fmt.Errorf("unexpected git log output %q", lines[0])
+		}
+		t, err := time.Parse(time.RFC3339, header[2])
+		if err != nil {
+			return nil, err
+		}
+		c := &Commit{SHA: header[0], Author: header[1], Time: t}
+		for _, line := range lines[1:] {
+			// Lines hold a status and a path separated by a tab.
+			if fields := strings.SplitN(line, "\t", 2); len(fields) == 2 {
+				c.Files = append(c.Files, fields[1])
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// Show returns the contents of a file at a commit.
+func Show(ctx context.Context, repo, sha, path string) ([]byte, error) {
+	return git(ctx, repo, "show", sha+":"+path)
+}
+
+func git(ctx context.Context, repo string, args ...string) ([]byte, error) {
+	// Paths are printed as they are, rather than quoted, so that they can be used in commands.
+	args = append([]string{"-C", repo, "-c", "core.quotePath=false"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s: %s", args[4], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
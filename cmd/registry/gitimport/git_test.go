@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	ctx := context.Background()
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ada", "GIT_AUTHOR_EMAIL=ada@example.com",
+			"GIT_COMMITTER_NAME=Ada", "GIT_COMMITTER_EMAIL=ada@example.com",
+			"GIT_AUTHOR_DATE=2021-06-01T12:00:00Z", "GIT_COMMITTER_DATE=2021-06-01T12:00:00Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Setup: git %v failed: %s\n%s", args, err, out)
+		}
+	}
+	write := func(path, contents string) {
+		t.Helper()
+		path = filepath.Join(repo, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Setup: MkdirAll() returned error: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("Setup: WriteFile() returned error: %s", err)
+		}
+	}
+
+	run("init", "-q")
+	write("apis/petstore/v1/openapi.yaml", "openapi: 3.0.0")
+	write("README.md", "specs")
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	write("apis/petstore/v1/openapi.yaml", "openapi: 3.0.1")
+	run("rm", "-q", "README.md")
+	run("add", ".")
+	run("commit", "-q", "-m", "second")
+
+	commits, err := Log(ctx, repo)
+	if err != nil {
+		t.Fatalf("Log() returned error: %s", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Log() returned %d commits, want 2", len(commits))
+	}
+	if diff := cmp.Diff([]string{"README.md", "apis/petstore/v1/openapi.yaml"}, commits[0].Files); diff != "" {
+		t.Errorf("Log() returned unexpected files of the first commit (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"apis/petstore/v1/openapi.yaml"}, commits[1].Files); diff != "" {
+		t.Errorf("Log() returned unexpected files of the second commit (-want +got):\n%s", diff)
+	}
+	if c := commits[0]; c.Author != "Ada <ada@example.com>" || c.Time.Year() != 2021 || len(c.SHA) != 40 {
+		t.Errorf("Log() returned unexpected commit %+v", c)
+	}
+
+	contents, err := Show(ctx, repo, commits[0].SHA, "apis/petstore/v1/openapi.yaml")
+	if err != nil || string(contents) != "openapi: 3.0.0" {
+		t.Errorf("Show() returned %q, %v, want %q", contents, err, "openapi: 3.0.0")
+	}
+	if _, err := Show(ctx, repo, commits[1].SHA, "README.md"); err == nil {
+		t.Errorf("Show() succeeded for a deleted file, want error")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/cmd/registry/core"
+	"github.com/apigee/registry/connection"
+	"github.com/apigee/registry/rpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Annotations of the spec revisions that are created for commits.
+const (
+	CommitAnnotation = "git-commit"
+	AuthorAnnotation = "git-author"
+	TimeAnnotation   = "git-time"
+)
+
+// Summary counts the results of an import.
+type Summary struct {
+	Specs     int // specs that are mapped to files in the repository
+	Revisions int // revisions that were imported
+	Unchanged int // specs whose latest commit was already imported
+	Diverged  int // specs whose last imported commit isn't in the history, e.g. after a rebase
+}
+
+// revision is a version of a file that is imported as a spec revision.
+type revision struct {
+	commit *Commit
+	path   string
+}
+
+// Import creates a spec revision for each commit that changed a mapped file,
+// in the order of the commits. Each revision is annotated with its commit,
+// and specs are imported from the commit after the commit of their current
+// revision, so running Import again imports only new commits. Specs whose
+// current revision's commit is no longer in the history are reported and
+// skipped, rather than imported again from the first commit.
+func Import(ctx context.Context, client connection.Client, projectID, repo string, mapping *Mapping) (*Summary, error) {
+	commits, err := Log(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]*Target)
+	history := make(map[string][]revision)
+	for _, c := range commits {
+		for _, path := range c.Files {
+			t, ok := mapping.Match(path)
+			if !ok {
+				continue
+			}
+			name := t.Name(projectID)
+			if h := history[name]; len(h) > 0 && h[len(h)-1].commit == c {
+				log.Printf("%s: %s and %s map to %s, using %s", c.SHA, h[len(h)-1].path, path, name, path)
+				history[name] = h[:len(h)-1]
+			}
+			targets[name] = t
+			history[name] = append(history[name], revision{commit: c, path: path})
+		}
+	}
+
+	summary := &Summary{Specs: len(targets)}
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		imported, err := importSpec(ctx, client, projectID, repo, targets[name], name, history[name])
+		if err == errDiverged {
+			log.Printf("%s: %s", name, err)
+			summary.Diverged++
+			continue
+		} else if err != nil {
+			return summary, fmt.Errorf("%s: %s", name, err)
+		}
+		if imported == 0 {
+			summary.Unchanged++
+		}
+		summary.Revisions += imported
+	}
+	return summary, nil
+}
+
+var errDiverged = errors.New("the commit of the current revision is not in the history of the mapped files; " +
+	"delete the spec or its " + CommitAnnotation + " annotation to import its history again")
+
+// importSpec imports the revisions of a spec that are newer than its
+// current revision and returns the number that were imported.
+func importSpec(ctx context.Context, client connection.Client, projectID, repo string, t *Target, name string, revisions []revision) (int, error) {
+	spec, err := client.GetApiSpec(ctx, &rpc.GetApiSpecRequest{Name: name})
+	exists := err == nil
+	if err != nil && !core.NotFound(err) {
+		return 0, err
+	}
+	if revisions, err = unimported(revisions, spec.GetAnnotations()[CommitAnnotation]); err != nil {
+		return 0, err
+	}
+
+	// Commit annotations are merged with the spec's other annotations.
+	annotations := make(map[string]string)
+	for k, v := range spec.GetAnnotations() {
+		annotations[k] = v
+	}
+
+	for _, r := range revisions {
+		contents, err := Show(ctx, repo, r.commit.SHA, r.path)
+		if err != nil {
+			return 0, err
+		}
+		if strings.Contains(t.MimeType, "+gzip") {
+			if contents, err = core.GZippedBytes(contents); err != nil {
+				return 0, err
+			}
+		}
+		annotations[CommitAnnotation] = r.commit.SHA
+		annotations[AuthorAnnotation] = r.commit.Author
+		annotations[TimeAnnotation] = r.commit.Time.UTC().Format(time.RFC3339)
+		spec := &rpc.ApiSpec{
+			Name:        name,
+			Filename:    t.SpecID,
+			MimeType:    t.MimeType,
+			Contents:    contents,
+			Annotations: annotations,
+		}
+		if exists {
+			_, err = client.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+				ApiSpec:    spec,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"contents", "mime_type", "annotations"}},
+			})
+		} else {
+			err = createSpec(ctx, client, projectID, t, spec)
+			exists = true
+		}
+		if err != nil {
+			return 0, err
+		}
+		log.Printf("imported %s from %s", name, r.commit.SHA)
+	}
+	return len(revisions), nil
+}
+
+// unimported returns the revisions after the one for the last imported commit,
+// or all revisions if none have been imported.
+func unimported(revisions []revision, last string) ([]revision, error) {
+	if last == "" {
+		return revisions, nil
+	}
+	for i, r := range revisions {
+		if r.commit.SHA == last {
+			return revisions[i+1:], nil
+		}
+	}
+	return nil, errDiverged
+}
+
+// createSpec creates a spec and any of its parents that don't exist.
+func createSpec(ctx context.Context, client connection.Client, projectID string, t *Target, spec *rpc.ApiSpec) error {
+	api := fmt.Sprintf("projects/%s/apis/%s", projectID, t.ApiID)
+	version := fmt.Sprintf("%s/versions/%s", api, t.VersionID)
+	if _, err := client.CreateApi(ctx, &rpc.CreateApiRequest{
+		Parent: fmt.Sprintf("projects/%s", projectID),
+		ApiId:  t.ApiID,
+		Api:    &rpc.Api{DisplayName: t.ApiID},
+	}); err != nil && status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	if _, err := client.CreateApiVersion(ctx, &rpc.CreateApiVersionRequest{
+		Parent:       api,
+		ApiVersionId: t.VersionID,
+		ApiVersion:   &rpc.ApiVersion{},
+	}); err != nil && status.Code(err) != codes.AlreadyExists {
+		return err
+	}
+	_, err := client.CreateApiSpec(ctx, &rpc.CreateApiSpecRequest{
+		Parent:    version,
+		ApiSpecId: t.SpecID,
+		ApiSpec:   spec,
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"testing"
+)
+
+func TestUnimported(t *testing.T) {
+	revisions := []revision{
+		{commit: &Commit{SHA: "a"}},
+		{commit: &Commit{SHA: "b"}},
+		{commit: &Commit{SHA: "c"}},
+	}
+	tests := []struct {
+		desc string
+		last string
+		want int
+		err  error
+	}{
+		{"nothing imported", "", 3, nil},
+		{"partly imported", "a", 2, nil},
+		{"all imported", "c", 0, nil},
+		{"rewritten history", "x", 0, errDiverged},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := unimported(revisions, test.last)
+			if err != test.err {
+				t.Fatalf("unimported(%q) returned error %v, want %v", test.last, err, test.err)
+			}
+			if len(got) != test.want {
+				t.Errorf("unimported(%q) returned %d revisions, want %d", test.last, len(got), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping maps paths of files in a repository to specs. For example,
+//
+//	mappings:
+//	- path: apis/{api}/{version}/openapi.yaml
+//	  mime_type: application/x.openapi+gzip;version=3.0.0
+//	- path: google/{group}/{api}/{version}/discovery.json
+//	  api: "{group}-{api}"
+//	  mime_type: application/x.discovery+gzip
+//
+// maps apis/petstore/v1/openapi.yaml to apis/petstore/versions/v1/specs/openapi.yaml.
+type Mapping struct {
+	Rules []*Rule `yaml:"mappings"`
+}
+
+// Rule maps paths that match a pattern to a spec. Patterns can include
+// {placeholders} that match parts of a path segment, and the IDs of specs
+// and their parents can be templates that refer to placeholders. API and
+// version IDs are {api} and {version} and spec IDs are file names by default.
+// Contents are compressed when MIME types include "+gzip".
+type Rule struct {
+	Path     string `yaml:"path"`
+	Api      string `yaml:"api"`
+	Version  string `yaml:"version"`
+	Spec     string `yaml:"spec"`
+	MimeType string `yaml:"mime_type"`
+
+	pattern *regexp.Regexp
+}
+
+// Target is a spec that a file is mapped to.
+type Target struct {
+	ApiID     string
+	VersionID string
+	SpecID    string
+	MimeType  string
+}
+
+// Name returns the name of the target spec in a project.
+func (t *Target) Name(projectID string) string {
+	return fmt.Sprintf("projects/%s/apis/%s/versions/%s/specs/%s", projectID, t.ApiID, t.VersionID, t.SpecID)
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// ReadMapping reads a mapping file.
+func ReadMapping(filename string) (*Mapping, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mapping{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	if err := m.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return m, nil
+}
+
+func (m *Mapping) compile() error {
+	if len(m.Rules) == 0 {
+		return fmt.Errorf("no mappings")
+	}
+	for _, r := range m.Rules {
+		if r.Path == "" || r.MimeType == "" {
+			return fmt.Errorf("mappings must have a path and a mime_type")
+		}
+		if r.Api == "" {
+			r.Api = "{api}"
+		}
+		if r.Version == "" {
+			r.Version = "{version}"
+		}
+
+		names := make(map[string]bool)
+		expr := "^"
+		last := 0
+		for _, loc := range placeholder.FindAllStringSubmatchIndex(r.Path, -1) {
+			name := r.Path[loc[2]:loc[3]]
+			if names[name] {
+				return fmt.Errorf("%s: {%s} appears more than once", r.Path, name)
+			}
+			names[name] = true
+			expr += regexp.QuoteMeta(r.Path[last:loc[0]]) + "(?P<" + name + ">[^/]+)"
+			last = loc[1]
+		}
+		expr += regexp.QuoteMeta(r.Path[last:]) + "$"
+		r.pattern = regexp.MustCompile(expr)
+
+		for _, template := range []string{r.Api, r.Version, r.Spec} {
+			for _, m := range placeholder.FindAllStringSubmatch(template, -1) {
+				if !names[m[1]] {
+					return fmt.Errorf("%s: %s refers to {%s}, which isn't in the path", r.Path, template, m[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns the spec that a path is mapped to by the first rule that
+// matches it.
+func (m *Mapping) Match(filename string) (*Target, bool) {
+	for _, r := range m.Rules {
+		match := r.pattern.FindStringSubmatch(filename)
+		if match == nil {
+			continue
+		}
+		values := make(map[string]string)
+		for i, name := range r.pattern.SubexpNames() {
+			if name != "" {
+				values[name] = match[i]
+			}
+		}
+		expand := func(template string) string {
+			return placeholder.ReplaceAllStringFunc(template, func(s string) string {
+				return values[strings.Trim(s, "{}")]
+			})
+		}
+		t := &Target{
+			ApiID:     expand(r.Api),
+			VersionID: expand(r.Version),
+			SpecID:    expand(r.Spec),
+			MimeType:  r.MimeType,
+		}
+		if t.SpecID == "" {
+			t.SpecID = path.Base(filename)
+		}
+		return t, true
+	}
+	return nil, false
+}
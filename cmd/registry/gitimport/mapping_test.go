@@ -0,0 +1,96 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitimport
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func readTestMapping(t *testing.T, contents string) (*Mapping, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("Setup: WriteFile() returned error: %s", err)
+	}
+	return ReadMapping(path)
+}
+
+func TestMappingMatch(t *testing.T) {
+	m, err := readTestMapping(t, `
+mappings:
+- path: apis/{api}/{version}/openapi.yaml
+  mime_type: application/x.openapi+gzip;version=3.0.0
+- path: google/{group}/{api}/{version}/{name}.json
+  api: "{group}-{api}"
+  spec: "{name}-discovery.json"
+  mime_type: application/x.discovery+gzip
+- path: "protos/{api}.v{version}.proto"
+  mime_type: text/plain
+`)
+	if err != nil {
+		t.Fatalf("ReadMapping() returned error: %s", err)
+	}
+
+	tests := []struct {
+		path string
+		want *Target
+	}{
+		{"apis/petstore/v1/openapi.yaml", &Target{"petstore", "v1", "openapi.yaml", "application/x.openapi+gzip;version=3.0.0"}},
+		{"google/cloud/pubsub/v1/rest.json", &Target{"cloud-pubsub", "v1", "rest-discovery.json", "application/x.discovery+gzip"}},
+		{"protos/library.v2.proto", &Target{"library", "2", "library.v2.proto", "text/plain"}},
+		{"apis/petstore/v1/extra/openapi.yaml", nil},
+		{"apis/petstore/v1/openapi.yml", nil},
+		{"README.md", nil},
+	}
+	for _, test := range tests {
+		got, ok := m.Match(test.path)
+		if ok != (test.want != nil) {
+			t.Errorf("Match(%q) returned %t, want %t", test.path, ok, test.want != nil)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Match(%q) returned unexpected target (-want +got):\n%s", test.path, diff)
+		}
+	}
+
+	target, _ := m.Match("apis/petstore/v1/openapi.yaml")
+	if got, want := target.Name("demo"), "projects/demo/apis/petstore/versions/v1/specs/openapi.yaml"; got != want {
+		t.Errorf("Name() returned %q, want %q", got, want)
+	}
+}
+
+func TestReadMappingErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		contents string
+	}{
+		{"empty", ""},
+		{"no mime type", "mappings:\n- path: apis/{api}/{version}/openapi.yaml\n"},
+		{"unknown placeholder", "mappings:\n- path: apis/{api}/openapi.yaml\n  mime_type: text/plain\n"},
+		{"repeated placeholder", "mappings:\n- path: '{api}/{version}/{api}.yaml'\n  mime_type: text/plain\n"},
+		{"invalid yaml", "mappings: ["},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if _, err := readTestMapping(t, test.contents); err == nil {
+				t.Errorf("ReadMapping() succeeded, want error")
+			}
+		})
+	}
+}
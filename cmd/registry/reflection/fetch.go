@@ -0,0 +1,234 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reflection reconstructs the proto files of gRPC services from
+// servers that support gRPC server reflection.
+package reflection
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// An API is the services of a server that are in the same proto package.
+type API struct {
+	Package  string
+	Services []string
+	// Files maps the names of the proto files that define the services and
+	// their dependencies to their reconstructed sources. Well-known types
+	// (google/protobuf/*.proto) are left out because protoc includes them.
+	Files map[string][]byte
+}
+
+// Fetch returns the APIs of the services that a server exposes with its
+// reflection service. The reflection service itself isn't included.
+func Fetch(ctx context.Context, conn grpc.ClientConnInterface) ([]*API, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	f := &fetcher{stream: stream, raw: make(map[string][]byte)}
+
+	response, err := f.request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the files that define services, then their dependencies.
+	serviceFiles := make(map[string]string)
+	for _, service := range response.GetListServicesResponse().GetService() {
+		name := service.GetName()
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		file, err := f.fileContainingSymbol(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		serviceFiles[name] = file
+	}
+	for i := 0; i < len(f.files); i++ {
+		for _, dependency := range f.files[i].GetDependency() {
+			if _, ok := f.raw[dependency]; ok {
+				continue
+			}
+			if err := f.fileByFilename(dependency); err != nil {
+				return nil, fmt.Errorf("%s: %s", dependency, err)
+			}
+		}
+	}
+
+	files, err := f.resolve()
+	if err != nil {
+		return nil, err
+	}
+	p := newPrinter(files)
+
+	apis := make(map[string]*API)
+	for service, file := range serviceFiles {
+		pkg := files[file].GetPackage()
+		api, ok := apis[pkg]
+		if !ok {
+			api = &API{Package: pkg, Files: make(map[string][]byte)}
+			apis[pkg] = api
+		}
+		api.Services = append(api.Services, service)
+		if err := p.addSources(api.Files, file); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*API, 0, len(apis))
+	for _, api := range apis {
+		sort.Strings(api.Services)
+		result = append(result, api)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Package < result[j].Package
+	})
+	return result, nil
+}
+
+// fetcher sends requests to a reflection service and collects the file
+// descriptors that it returns.
+type fetcher struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	// raw holds the serialized descriptors of files by name.
+	raw   map[string][]byte
+	files []*descriptorpb.FileDescriptorProto
+}
+
+func (f *fetcher) request(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := f.stream.Send(req); err != nil {
+		return nil, err
+	}
+	response, err := f.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := response.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return response, nil
+}
+
+// fileContainingSymbol fetches the file that defines a symbol and returns its name.
+func (f *fetcher) fileContainingSymbol(symbol string) (string, error) {
+	names, err := f.fetch(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		return "", err
+	}
+	// The file that defines the symbol comes first, then optionally its dependencies.
+	return names[0], nil
+}
+
+func (f *fetcher) fileByFilename(name string) error {
+	names, err := f.fetch(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+	})
+	if err != nil {
+		return err
+	}
+	if names[0] != name {
+		return fmt.Errorf("requested %s, received %s", name, names[0])
+	}
+	return nil
+}
+
+// fetch sends a request for files and records the files that it returns,
+// returning their names.
+func (f *fetcher) fetch(req *rpb.ServerReflectionRequest) ([]string, error) {
+	response, err := f.request(req)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, b := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, file); err != nil {
+			return nil, err
+		}
+		names = append(names, file.GetName())
+		if _, ok := f.raw[file.GetName()]; ok {
+			continue
+		}
+		f.raw[file.GetName()] = b
+		f.files = append(f.files, file)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no files in response")
+	}
+	return names, nil
+}
+
+// resolve returns the fetched files by name. Custom options are usually
+// unknown to this program, so descriptors are read again with extension
+// types built from the fetched files to preserve their options.
+func (f *fetcher) resolve() (map[string]*descriptorpb.FileDescriptorProto, error) {
+	registry, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: f.files})
+	if err != nil {
+		return nil, err
+	}
+	types := new(protoregistry.Types)
+	registry.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = registerExtensions(types, fd.Extensions(), fd.Messages())
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	for name, b := range f.raw {
+		file := &descriptorpb.FileDescriptorProto{}
+		if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(b, file); err != nil {
+			return nil, err
+		}
+		files[name] = file
+	}
+	return files, nil
+}
+
+func registerExtensions(types *protoregistry.Types, extensions protoreflect.ExtensionDescriptors, messages protoreflect.MessageDescriptors) error {
+	for i := 0; i < extensions.Len(); i++ {
+		if err := types.RegisterExtension(dynamicpb.NewExtensionType(extensions.Get(i))); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < messages.Len(); i++ {
+		m := messages.Get(i)
+		if err := registerExtensions(types, m.Extensions(), m.Messages()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
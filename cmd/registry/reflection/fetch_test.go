@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reflection
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	protoparser "github.com/yoheimuta/go-protoparser/v4"
+	library "google.golang.org/genproto/googleapis/example/library/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve starts an in-process server with the services that register
+// registers and returns a connection to it.
+func serve(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestFetch(t *testing.T) {
+	conn := serve(t, func(server *grpc.Server) {
+		library.RegisterLibraryServiceServer(server, &library.UnimplementedLibraryServiceServer{})
+		healthpb.RegisterHealthServer(server, health.NewServer())
+		reflection.Register(server)
+	})
+	apis, err := Fetch(context.Background(), conn)
+	if err != nil {
+		t.Fatalf("Fetch() returned error: %s", err)
+	}
+	if len(apis) != 2 {
+		t.Fatalf("Fetch() returned %d APIs, want 2", len(apis))
+	}
+
+	api := apis[0]
+	if api.Package != "google.example.library.v1" || !cmp.Equal(api.Services, []string{"google.example.library.v1.LibraryService"}) {
+		t.Errorf("Fetch() returned API %s with services %v, want the library example", api.Package, api.Services)
+	}
+	var names []string
+	for name, source := range api.Files {
+		names = append(names, name)
+		if _, err := protoparser.Parse(bytes.NewReader(source)); err != nil {
+			t.Errorf("source of %s doesn't parse: %s\n%s", name, err, source)
+		}
+	}
+	sort.Strings(names)
+	want := []string{
+		"google/api/annotations.proto",
+		"google/api/client.proto",
+		"google/api/field_behavior.proto",
+		"google/api/http.proto",
+		"google/api/resource.proto",
+		"google/example/library/v1/library.proto",
+	}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("Fetch() returned unexpected files (-want +got):\n%s", diff)
+	}
+
+	// Options of imported extensions are kept.
+	source := string(api.Files["google/example/library/v1/library.proto"])
+	for _, line := range []string{
+		`import "google/protobuf/empty.proto";`,
+		`  option (google.api.default_host) = "library-example.googleapis.com";`,
+		`  rpc DeleteShelf(DeleteShelfRequest) returns (google.protobuf.Empty) {`,
+		`    option (google.api.http) = { post: "/v1/shelves" body: "shelf" };`,
+		`  Shelf shelf = 1 [(google.api.field_behavior) = REQUIRED];`,
+	} {
+		if !strings.Contains(source, line+"\n") {
+			t.Errorf("library.proto doesn't contain %q:\n%s", line, source)
+		}
+	}
+
+	if api := apis[1]; api.Package != "grpc.health.v1" || len(api.Files) != 1 {
+		t.Errorf("Fetch() returned API %s with %d files, want grpc.health.v1 with 1 file", api.Package, len(api.Files))
+	}
+}
+
+func TestFetchNoReflection(t *testing.T) {
+	conn := serve(t, func(server *grpc.Server) {
+		healthpb.RegisterHealthServer(server, health.NewServer())
+	})
+	if _, err := Fetch(context.Background(), conn); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Fetch() returned %v, want Unimplemented", err)
+	}
+}
+
+func TestFetchUnresponsive(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	// The server accepts reflection streams but never answers them.
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		<-stream.Context().Done()
+		return stream.Context().Err()
+	}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("Dial() returned error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := Fetch(ctx, conn); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Fetch() returned %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,684 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reflection
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// maxFieldNumber is the largest field number, which ranges write as "max".
+	maxFieldNumber = 1<<29 - 1
+	// maxEnumNumber is the largest enum value, which ranges write as "max".
+	maxEnumNumber = math.MaxInt32
+)
+
+// printer writes the proto source of file descriptors. Descriptors don't
+// record comments or the order of declarations, so sources declare services,
+// then messages, enums, and extensions.
+type printer struct {
+	files map[string]*descriptorpb.FileDescriptorProto
+	// symbols holds the fully-qualified names of everything declared in
+	// files, which is used to write type names as briefly as possible.
+	symbols map[string]bool
+
+	// State of the file being written.
+	buf     bytes.Buffer
+	pkg     string
+	proto3  bool
+	depth   int
+	started bool // something was written in the current block
+	blank   bool // a blank line is written before the next line
+}
+
+func newPrinter(files map[string]*descriptorpb.FileDescriptorProto) *printer {
+	p := &printer{files: files, symbols: make(map[string]bool)}
+	for _, file := range files {
+		pkg := file.GetPackage()
+		for s := pkg; s != ""; s = parent(s) {
+			p.symbols[s] = true
+		}
+		p.addMessageSymbols(pkg, file.GetMessageType())
+		p.addEnumSymbols(pkg, file.GetEnumType())
+		p.addFieldSymbols(pkg, file.GetExtension())
+		for _, service := range file.GetService() {
+			p.symbols[join(pkg, service.GetName())] = true
+		}
+	}
+	return p
+}
+
+func (p *printer) addMessageSymbols(scope string, messages []*descriptorpb.DescriptorProto) {
+	for _, m := range messages {
+		name := join(scope, m.GetName())
+		p.symbols[name] = true
+		p.addFieldSymbols(name, m.GetField())
+		p.addFieldSymbols(name, m.GetExtension())
+		for _, o := range m.GetOneofDecl() {
+			p.symbols[join(name, o.GetName())] = true
+		}
+		p.addMessageSymbols(name, m.GetNestedType())
+		p.addEnumSymbols(name, m.GetEnumType())
+	}
+}
+
+func (p *printer) addEnumSymbols(scope string, enums []*descriptorpb.EnumDescriptorProto) {
+	for _, e := range enums {
+		p.symbols[join(scope, e.GetName())] = true
+		// Enum values are declared in the scope that encloses their enum.
+		for _, v := range e.GetValue() {
+			p.symbols[join(scope, v.GetName())] = true
+		}
+	}
+}
+
+func (p *printer) addFieldSymbols(scope string, fields []*descriptorpb.FieldDescriptorProto) {
+	for _, f := range fields {
+		p.symbols[join(scope, f.GetName())] = true
+	}
+}
+
+// addSources adds the source of a file and of its dependencies to sources,
+// leaving out well-known types.
+func (p *printer) addSources(sources map[string][]byte, name string) error {
+	if _, ok := sources[name]; ok || strings.HasPrefix(name, "google/protobuf/") {
+		return nil
+	}
+	file, ok := p.files[name]
+	if !ok {
+		return fmt.Errorf("missing file %s", name)
+	}
+	sources[name] = p.source(file)
+	for _, dependency := range file.GetDependency() {
+		if err := p.addSources(sources, dependency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// source returns the proto source of a file.
+func (p *printer) source(file *descriptorpb.FileDescriptorProto) []byte {
+	p.buf.Reset()
+	p.pkg = file.GetPackage()
+	p.proto3 = file.GetSyntax() == "proto3"
+	p.depth, p.started, p.blank = 0, false, false
+
+	syntax := file.GetSyntax()
+	if syntax == "" {
+		syntax = "proto2"
+	}
+	p.print("syntax = %s;", quote(syntax, false))
+	if p.pkg != "" {
+		p.section()
+		p.print("package %s;", p.pkg)
+	}
+	p.section()
+	for i, dependency := range file.GetDependency() {
+		modifier := ""
+		if contains(file.GetPublicDependency(), i) {
+			modifier = "public "
+		} else if contains(file.GetWeakDependency(), i) {
+			modifier = "weak "
+		}
+		p.print("import %s%s;", modifier, quote(dependency, false))
+	}
+	p.section()
+	p.options(file.GetOptions(), p.pkg)
+
+	for _, s := range file.GetService() {
+		p.section()
+		p.service(s)
+	}
+	implicit := implicitTypes(p.pkg, file.GetExtension(), file.GetMessageType())
+	for _, m := range file.GetMessageType() {
+		if implicit[join(p.pkg, m.GetName())] == nil {
+			p.section()
+			p.message(m, p.pkg)
+		}
+	}
+	for _, e := range file.GetEnumType() {
+		p.section()
+		p.enum(e, p.pkg)
+	}
+	p.extensions(file.GetExtension(), p.pkg, implicit)
+	return append([]byte(nil), p.buf.Bytes()...)
+}
+
+// print writes a line at the current depth.
+func (p *printer) print(format string, args ...interface{}) {
+	if p.blank {
+		p.buf.WriteString("\n")
+		p.blank = false
+	}
+	p.buf.WriteString(strings.Repeat("  ", p.depth))
+	fmt.Fprintf(&p.buf, format, args...)
+	p.buf.WriteString("\n")
+	p.started = true
+}
+
+// section separates what is written next from what was written before in
+// the current block with a blank line.
+func (p *printer) section() {
+	p.blank = p.started
+}
+
+// open starts a block.
+func (p *printer) open(format string, args ...interface{}) {
+	p.print(format+" {", args...)
+	p.depth++
+	p.started = false
+}
+
+// close ends a block.
+func (p *printer) close() {
+	p.depth--
+	p.blank = false
+	p.print("}")
+}
+
+func (p *printer) service(s *descriptorpb.ServiceDescriptorProto) {
+	scope := join(p.pkg, s.GetName())
+	p.open("service %s", s.GetName())
+	p.options(s.GetOptions(), scope)
+	p.section()
+	for _, m := range s.GetMethod() {
+		signature := fmt.Sprintf("rpc %s(%s%s) returns (%s%s)", m.GetName(),
+			streaming(m.GetClientStreaming()), p.typeName(m.GetInputType(), scope),
+			streaming(m.GetServerStreaming()), p.typeName(m.GetOutputType(), scope))
+		options := p.optionStatements(m.GetOptions(), scope)
+		if len(options) == 0 {
+			p.print("%s;", signature)
+			continue
+		}
+		p.open("%s", signature)
+		for _, option := range options {
+			p.print("%s", option)
+		}
+		p.close()
+	}
+	p.close()
+}
+
+func streaming(stream bool) string {
+	if stream {
+		return "stream "
+	}
+	return ""
+}
+
+func (p *printer) message(m *descriptorpb.DescriptorProto, scope string) {
+	p.open("message %s", m.GetName())
+	p.messageBody(m, join(scope, m.GetName()))
+	p.close()
+}
+
+func (p *printer) messageBody(m *descriptorpb.DescriptorProto, scope string) {
+	p.options(m.GetOptions(), scope)
+	fields := append(append([]*descriptorpb.FieldDescriptorProto{}, m.GetField()...), m.GetExtension()...)
+	implicit := implicitTypes(scope, fields, m.GetNestedType())
+
+	p.section()
+	oneofs := make(map[int32]bool)
+	for _, f := range m.GetField() {
+		if f.OneofIndex == nil || f.GetProto3Optional() {
+			p.field(f, scope, implicit, false)
+			continue
+		}
+		// Fields of a oneof are written together where the first one is declared.
+		i := f.GetOneofIndex()
+		if oneofs[i] {
+			continue
+		}
+		oneofs[i] = true
+		p.open("oneof %s", m.GetOneofDecl()[i].GetName())
+		p.options(m.GetOneofDecl()[i].GetOptions(), scope)
+		p.section()
+		for _, f := range m.GetField() {
+			if f.OneofIndex != nil && f.GetOneofIndex() == i {
+				p.field(f, scope, implicit, true)
+			}
+		}
+		p.close()
+	}
+
+	for _, n := range m.GetNestedType() {
+		if implicit[join(scope, n.GetName())] == nil {
+			p.section()
+			p.message(n, scope)
+		}
+	}
+	for _, e := range m.GetEnumType() {
+		p.section()
+		p.enum(e, scope)
+	}
+	p.extensions(m.GetExtension(), scope, implicit)
+
+	p.section()
+	for _, r := range m.GetExtensionRange() {
+		p.print("extensions %s%s;", numberRange(r.GetStart(), r.GetEnd()-1, maxFieldNumber),
+			p.inlineOptions(r.GetOptions(), scope))
+	}
+	var reserved []string
+	for _, r := range m.GetReservedRange() {
+		reserved = append(reserved, numberRange(r.GetStart(), r.GetEnd()-1, maxFieldNumber))
+	}
+	p.reserved(reserved, m.GetReservedName())
+}
+
+// implicitTypes returns the messages that are declared by fields rather than
+// by message declarations: the entries of map fields and the types of groups.
+func implicitTypes(scope string, fields []*descriptorpb.FieldDescriptorProto, messages []*descriptorpb.DescriptorProto) map[string]*descriptorpb.DescriptorProto {
+	implicit := make(map[string]*descriptorpb.DescriptorProto)
+	for _, f := range fields {
+		name := strings.TrimPrefix(f.GetTypeName(), ".")
+		for _, m := range messages {
+			if join(scope, m.GetName()) != name {
+				continue
+			}
+			if f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP || m.GetOptions().GetMapEntry() {
+				implicit[name] = m
+			}
+		}
+	}
+	return implicit
+}
+
+func (p *printer) field(f *descriptorpb.FieldDescriptorProto, scope string, implicit map[string]*descriptorpb.DescriptorProto, inOneof bool) {
+	label := ""
+	switch {
+	case inOneof:
+	case f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		label = "repeated "
+	case f.GetProto3Optional():
+		label = "optional "
+	case p.proto3:
+	case f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED:
+		label = "required "
+	default:
+		label = "optional "
+	}
+
+	var options []string
+	if f.JsonName != nil && f.GetJsonName() != jsonName(f.GetName()) {
+		options = append(options, "json_name = "+quote(f.GetJsonName(), false))
+	}
+	if f.DefaultValue != nil {
+		options = append(options, "default = "+defaultValue(f))
+	}
+	inline := p.inlineOptions(f.GetOptions(), scope, options...)
+
+	entry := implicit[strings.TrimPrefix(f.GetTypeName(), ".")]
+	switch {
+	case entry != nil && f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		p.open("%sgroup %s = %d%s", label, entry.GetName(), f.GetNumber(), inline)
+		p.messageBody(entry, join(scope, entry.GetName()))
+		p.close()
+	case entry != nil && len(entry.GetField()) == 2:
+		p.print("map<%s, %s> %s = %d%s;", p.fieldType(entry.GetField()[0], scope), p.fieldType(entry.GetField()[1], scope),
+			f.GetName(), f.GetNumber(), inline)
+	default:
+		p.print("%s%s %s = %d%s;", label, p.fieldType(f, scope), f.GetName(), f.GetNumber(), inline)
+	}
+}
+
+func (p *printer) fieldType(f *descriptorpb.FieldDescriptorProto, scope string) string {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return p.typeName(f.GetTypeName(), scope)
+	default:
+		// Scalar types are written as their names without the TYPE_ prefix, e.g. TYPE_INT32 is int32.
+		return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+	}
+}
+
+// jsonName returns the JSON name that protoc gives a field by default.
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, c := range name {
+		switch {
+		case c == '_':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(c))
+			upper = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func defaultValue(f *descriptorpb.FieldDescriptorProto) string {
+	switch f.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return quote(f.GetDefaultValue(), false)
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		// Default values of bytes fields are already escaped.
+		return `"` + f.GetDefaultValue() + `"`
+	default:
+		return f.GetDefaultValue()
+	}
+}
+
+// extensions writes extension fields, declaring consecutive extensions of
+// the same message in one block.
+func (p *printer) extensions(fields []*descriptorpb.FieldDescriptorProto, scope string, implicit map[string]*descriptorpb.DescriptorProto) {
+	for i := 0; i < len(fields); {
+		extendee := fields[i].GetExtendee()
+		p.section()
+		p.open("extend %s", p.typeName(extendee, scope))
+		for ; i < len(fields) && fields[i].GetExtendee() == extendee; i++ {
+			p.field(fields[i], scope, implicit, false)
+		}
+		p.close()
+	}
+}
+
+func (p *printer) enum(e *descriptorpb.EnumDescriptorProto, scope string) {
+	p.open("enum %s", e.GetName())
+	p.options(e.GetOptions(), join(scope, e.GetName()))
+	p.section()
+	for _, v := range e.GetValue() {
+		p.print("%s = %d%s;", v.GetName(), v.GetNumber(), p.inlineOptions(v.GetOptions(), scope))
+	}
+	var reserved []string
+	for _, r := range e.GetReservedRange() {
+		reserved = append(reserved, numberRange(r.GetStart(), r.GetEnd(), maxEnumNumber))
+	}
+	p.reserved(reserved, e.GetReservedName())
+	p.close()
+}
+
+func (p *printer) reserved(ranges, names []string) {
+	p.section()
+	if len(ranges) > 0 {
+		p.print("reserved %s;", strings.Join(ranges, ", "))
+	}
+	if len(names) > 0 {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = quote(name, false)
+		}
+		p.print("reserved %s;", strings.Join(quoted, ", "))
+	}
+}
+
+// numberRange formats an inclusive range of field or enum numbers.
+func numberRange(start, end, max int32) string {
+	switch {
+	case start == end:
+		return strconv.Itoa(int(start))
+	case end == max:
+		return fmt.Sprintf("%d to max", start)
+	default:
+		return fmt.Sprintf("%d to %d", start, end)
+	}
+}
+
+// options writes option statements for the options that are set in an
+// options message such as descriptorpb.FileOptions.
+func (p *printer) options(options proto.Message, scope string) {
+	for _, option := range p.optionStatements(options, scope) {
+		p.print("%s", option)
+	}
+}
+
+func (p *printer) optionStatements(options proto.Message, scope string) []string {
+	var statements []string
+	p.rangeOptions(options, scope, func(name, value string) {
+		statements = append(statements, fmt.Sprintf("option %s = %s;", name, value))
+	})
+	return statements
+}
+
+// inlineOptions formats options that are written in brackets after fields
+// and enum values, preceded by any options that aren't in the options message.
+func (p *printer) inlineOptions(options proto.Message, scope string, extra ...string) string {
+	p.rangeOptions(options, scope, func(name, value string) {
+		extra = append(extra, name+" = "+value)
+	})
+	if len(extra) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(extra, ", ") + "]"
+}
+
+// rangeOptions calls f with the name and value of each option that is set,
+// in field number order. Repeated options are set once for each value.
+func (p *printer) rangeOptions(options proto.Message, scope string, f func(name, value string)) {
+	for _, field := range setFields(options.ProtoReflect()) {
+		name := string(field.fd.Name())
+		if field.fd.IsExtension() {
+			name = "(" + p.typeName(string(field.fd.FullName()), scope) + ")"
+		}
+		if field.fd.IsList() {
+			list := field.v.List()
+			for i := 0; i < list.Len(); i++ {
+				f(name, formatValue(field.fd, list.Get(i)))
+			}
+			continue
+		}
+		f(name, formatValue(field.fd, field.v))
+	}
+}
+
+type setField struct {
+	fd protoreflect.FieldDescriptor
+	v  protoreflect.Value
+}
+
+// setFields returns the fields that are set in a message in field number order.
+func setFields(m protoreflect.Message) []setField {
+	var fields []setField
+	if !m.IsValid() {
+		return fields
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fields = append(fields, setField{fd: fd, v: v})
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].fd.Number() < fields[j].fd.Number()
+	})
+	return fields
+}
+
+// formatValue formats a single value of a field in the text format that
+// option values are written in.
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(v.Bool())
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.StringKind:
+		return quote(v.String(), false)
+	case protoreflect.BytesKind:
+		return quote(string(v.Bytes()), true)
+	case protoreflect.FloatKind:
+		return formatFloat(v.Float(), 32)
+	case protoreflect.DoubleKind:
+		return formatFloat(v.Float(), 64)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return formatMessage(v.Message())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func formatFloat(f float64, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+}
+
+// formatMessage formats a message value. protoc's text format isn't used
+// because its output deliberately varies, which would change the hashes of
+// uploaded specs.
+func formatMessage(m protoreflect.Message) string {
+	var entries []string
+	add := func(name string, fd protoreflect.FieldDescriptor, v protoreflect.Value) {
+		if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			entries = append(entries, name+" "+formatValue(fd, v))
+		} else {
+			entries = append(entries, name+": "+formatValue(fd, v))
+		}
+	}
+	for _, field := range setFields(m) {
+		fd := field.fd
+		name := string(fd.Name())
+		switch {
+		case fd.IsExtension():
+			name = "[" + string(fd.FullName()) + "]"
+		case fd.Kind() == protoreflect.GroupKind:
+			name = string(fd.Message().Name())
+		}
+		switch {
+		case fd.IsMap():
+			// Map entries are written as messages with key and value fields.
+			var keys []protoreflect.MapKey
+			field.v.Map().Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, k)
+				return true
+			})
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, k := range keys {
+				entries = append(entries, fmt.Sprintf("%s { key: %s value: %s }", name,
+					formatValue(fd.MapKey(), k.Value()), formatValue(fd.MapValue(), field.v.Map().Get(k))))
+			}
+		case fd.IsList():
+			list := field.v.List()
+			for i := 0; i < list.Len(); i++ {
+				add(name, fd, list.Get(i))
+			}
+		default:
+			add(name, fd, field.v)
+		}
+	}
+	if len(entries) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(entries, " ") + " }"
+}
+
+// quote returns a string literal. Bytes values are escaped so that they
+// aren't read as UTF-8.
+func quote(s string, bytes bool) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c < 0x20 || c == 0x7f || (bytes && c >= 0x80):
+			fmt.Fprintf(&b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// typeName returns the briefest name that refers to a fully-qualified name
+// from a scope. Names in the package of the file are written relative to
+// the package, and other names are written in full, with a leading dot if
+// that is needed to keep a name in the scope from hiding them.
+func (p *printer) typeName(name, scope string) string {
+	name = strings.TrimPrefix(name, ".")
+	if p.pkg == "" || strings.HasPrefix(name, p.pkg+".") {
+		parts := strings.Split(strings.TrimPrefix(name, p.pkg+"."), ".")
+		for i := len(parts) - 1; i >= 0; i-- {
+			relative := strings.Join(parts[i:], ".")
+			if p.resolve(relative, scope) == name {
+				return relative
+			}
+		}
+	}
+	if p.resolve(name, scope) == name {
+		return name
+	}
+	return "." + name
+}
+
+// resolve returns the fully-qualified name that a relative name refers to
+// from a scope, following protoc: the first part of the name is looked up
+// in the scope, then in each enclosing scope.
+func (p *printer) resolve(name, scope string) string {
+	first := strings.SplitN(name, ".", 2)[0]
+	for s := scope; ; s = parent(s) {
+		if p.symbols[join(s, first)] {
+			return join(s, name)
+		}
+		if s == "" {
+			return ""
+		}
+	}
+}
+
+func join(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func parent(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+func contains(indexes []int32, i int) bool {
+	for _, index := range indexes {
+		if int(index) == i {
+			return true
+		}
+	}
+	return false
+}
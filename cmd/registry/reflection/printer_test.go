@@ -0,0 +1,176 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reflection
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		desc string
+		file string
+		want string
+	}{
+		{
+			desc: "proto2",
+			file: `
+				name: "example/v1/example.proto"
+				package: "example.v1"
+				dependency: ["example/v1/common.proto"]
+				public_dependency: [0]
+				message_type {
+					name: "Shelf"
+					field { name: "id" number: 1 label: LABEL_REQUIRED type: TYPE_INT64 json_name: "id" }
+					field { name: "title" number: 2 label: LABEL_OPTIONAL type: TYPE_STRING default_value: "A \"shelf\"" json_name: "name" }
+					field { name: "kind" number: 3 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".example.v1.Kind" default_value: "FICTION" }
+					field { name: "result" number: 4 label: LABEL_REPEATED type: TYPE_GROUP type_name: ".example.v1.Shelf.Result" }
+					field { name: "old" number: 5 label: LABEL_OPTIONAL type: TYPE_BOOL options { deprecated: true } }
+					field { name: "example" number: 7 label: LABEL_OPTIONAL type: TYPE_INT32 }
+					nested_type {
+						name: "Result"
+						field { name: "url" number: 6 label: LABEL_OPTIONAL type: TYPE_STRING }
+					}
+					nested_type {
+						name: "Kind"
+						field { name: "kind" number: 1 label: LABEL_OPTIONAL type: TYPE_ENUM type_name: ".example.v1.Kind" }
+					}
+					extension_range { start: 100 end: 200 }
+					extension_range { start: 1000 end: 536870912 }
+					reserved_range { start: 8 end: 9 }
+					reserved_range { start: 10 end: 13 }
+					reserved_name: ["author"]
+				}
+				enum_type {
+					name: "Kind"
+					options { allow_alias: true }
+					value { name: "FICTION" number: 0 }
+					value { name: "NOVEL" number: 0 }
+					value { name: "POETRY" number: 1 options { deprecated: true } }
+					reserved_range { start: 5 end: 2147483647 }
+				}
+				extension { name: "color" number: 100 label: LABEL_OPTIONAL type: TYPE_STRING extendee: ".example.v1.Shelf" }
+			`,
+			want: `syntax = "proto2";
+
+package example.v1;
+
+import public "example/v1/common.proto";
+
+message Shelf {
+  required int64 id = 1;
+  optional string title = 2 [json_name = "name", default = "A \"shelf\""];
+  optional .example.v1.Kind kind = 3 [default = FICTION];
+  repeated group Result = 4 {
+    optional string url = 6;
+  }
+  optional bool old = 5 [deprecated = true];
+  optional int32 example = 7;
+
+  message Kind {
+    optional .example.v1.Kind kind = 1;
+  }
+
+  extensions 100 to 199;
+  extensions 1000 to max;
+
+  reserved 8, 10 to 12;
+  reserved "author";
+}
+
+enum Kind {
+  option allow_alias = true;
+
+  FICTION = 0;
+  NOVEL = 0;
+  POETRY = 1 [deprecated = true];
+
+  reserved 5 to max;
+}
+
+extend Shelf {
+  optional string color = 100;
+}
+`,
+		},
+		{
+			desc: "proto3",
+			file: `
+				name: "example.proto"
+				syntax: "proto3"
+				service {
+					name: "Echo"
+					method { name: "Chat" input_type: ".Message" output_type: ".Message" client_streaming: true server_streaming: true }
+				}
+				message_type {
+					name: "Message"
+					field { name: "text" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING oneof_index: 0 }
+					field { name: "data" number: 2 label: LABEL_OPTIONAL type: TYPE_BYTES oneof_index: 0 }
+					field { name: "count" number: 3 label: LABEL_OPTIONAL type: TYPE_INT32 oneof_index: 1 proto3_optional: true }
+					field { name: "tags" number: 4 label: LABEL_REPEATED type: TYPE_MESSAGE type_name: ".Message.TagsEntry" }
+					nested_type {
+						name: "TagsEntry"
+						field { name: "key" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+						field { name: "value" number: 2 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".Message" }
+						options { map_entry: true }
+					}
+					oneof_decl { name: "body" }
+					oneof_decl { name: "_count" }
+				}
+			`,
+			want: `syntax = "proto3";
+
+service Echo {
+  rpc Chat(stream Message) returns (stream Message);
+}
+
+message Message {
+  oneof body {
+    string text = 1;
+    bytes data = 2;
+  }
+  optional int32 count = 3;
+  map<string, Message> tags = 4;
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := prototext.Unmarshal([]byte(test.file), file); err != nil {
+				t.Fatalf("Unmarshal() returned error: %s", err)
+			}
+			p := newPrinter(map[string]*descriptorpb.FileDescriptorProto{file.GetName(): file})
+			if diff := cmp.Diff(test.want, string(p.source(file))); diff != "" {
+				t.Errorf("source() returned unexpected source (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("a\"b\\\n\x01é", false), `"a\"b\\\n\001é"`; got != want {
+		t.Errorf("quote() returned %s, want %s", got, want)
+	}
+	if got, want := quote("é", true), `"\303\251"`; got != want {
+		t.Errorf("quote() returned %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+// defaultRuleset has the rules of Spectral's OpenAPI ruleset that can be
+// written with paths and the built-in functions, with the same IDs so that
+// results can be compared with earlier results from Spectral.
+const defaultRuleset = `
+documentationUrl: https://meta.stoplight.io/docs/spectral/docs/reference/openapi-rules.md
+rules:
+  info-contact:
+    description: Info object must have a "contact" object.
+    given: $.info
+    then:
+      field: contact
+      function: truthy
+  info-description:
+    description: Info object must have a "description" field.
+    given: $.info
+    then:
+      field: description
+      function: truthy
+  no-eval-in-markdown:
+    description: Markdown descriptions must not have "eval(".
+    given:
+    - $..description
+    - $..title
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: eval\(
+  no-script-tags-in-markdown:
+    description: Markdown descriptions must not have "<script>" tags.
+    given: $..description
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: <script
+  openapi-tags:
+    description: OpenAPI object must have non-empty "tags" array.
+    given: $
+    then:
+      field: tags
+      function: truthy
+  operation-description:
+    description: Operation must have a "description" field.
+    given: $.paths[*]['get','put','post','delete','options','head','patch','trace']
+    then:
+      field: description
+      function: truthy
+  operation-operationId:
+    description: Operation must have an "operationId" field.
+    given: $.paths[*]['get','put','post','delete','options','head','patch','trace']
+    then:
+      field: operationId
+      function: truthy
+  operation-operationId-valid-in-url:
+    description: OperationId must not contain characters that are invalid when used in URL.
+    given: $.paths[*]['get','put','post','delete','options','head','patch','trace'].operationId
+    then:
+      function: pattern
+      functionOptions:
+        match: ^[A-Za-z0-9-._~:/?#\[\]@!$&'()*+,;=]*$
+  operation-tags:
+    description: Operation must have non-empty "tags" array.
+    given: $.paths[*]['get','put','post','delete','options','head','patch','trace']
+    then:
+      field: tags
+      function: truthy
+  parameter-description:
+    description: Parameter objects must have a "description" field.
+    given:
+    - $.paths[*].parameters[?(@.in)]
+    - $.paths[*]['get','put','post','delete','options','head','patch','trace'].parameters[?(@.in)]
+    then:
+      field: description
+      function: truthy
+  path-keys-no-trailing-slash:
+    description: Path must not end with a slash.
+    given: $.paths
+    then:
+      field: '@key'
+      function: pattern
+      functionOptions:
+        notMatch: .+\/$
+  path-not-include-query:
+    description: Path must not include a query string.
+    given: $.paths
+    then:
+      field: '@key'
+      function: pattern
+      functionOptions:
+        notMatch: \?
+  tag-description:
+    description: Tag object must have a "description" field.
+    given: $.tags[*]
+    then:
+      field: description
+      function: truthy
+  oas2-api-host:
+    description: OpenAPI "host" must be present and non-empty string.
+    formats: [oas2]
+    given: $
+    then:
+      field: host
+      function: truthy
+  oas2-api-schemes:
+    description: OpenAPI host "schemes" must be present and non-empty array.
+    formats: [oas2]
+    given: $
+    then:
+      field: schemes
+      function: truthy
+  oas2-host-not-example:
+    description: Host URL must not point at example.com.
+    formats: [oas2]
+    given: $.host
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: example\.com
+  oas2-host-trailing-slash:
+    description: Server URL must not have a trailing slash.
+    formats: [oas2]
+    given: $.host
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: /$
+  oas3-api-servers:
+    description: OpenAPI "servers" must be present and non-empty array.
+    formats: [oas3]
+    given: $
+    then:
+      field: servers
+      function: truthy
+  oas3-server-not-example.com:
+    description: Server URL must not point at example.com.
+    formats: [oas3]
+    given: $.servers[*].url
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: example\.com
+  oas3-server-trailing-slash:
+    description: Server URL must not have a trailing slash.
+    formats: [oas3]
+    given: $.servers[*].url
+    then:
+      function: pattern
+      functionOptions:
+        notMatch: ./$
+`
@@ -0,0 +1,273 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// An expression filters the nodes selected by a path step.
+type expression interface {
+	evaluate(m match) bool
+}
+
+type or []expression
+
+func (e or) evaluate(m match) bool {
+	for _, x := range e {
+		if x.evaluate(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type and []expression
+
+func (e and) evaluate(m match) bool {
+	for _, x := range e {
+		if !x.evaluate(m) {
+			return false
+		}
+	}
+	return true
+}
+
+type not struct{ x expression }
+
+func (e not) evaluate(m match) bool {
+	return !e.x.evaluate(m)
+}
+
+// An operand is @property, the key or index of a node, or @ or @.name,
+// a node or the value of one of its keys.
+type operand struct {
+	property bool
+	field    string
+}
+
+// value returns the node that an operand refers to, or nil if it doesn't
+// exist.
+func (o operand) value(m match) *yaml.Node {
+	if o.property {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.property()}
+	}
+	if o.field == "" {
+		return m.node
+	}
+	if f, ok := field(m, o.field); ok {
+		return f.node
+	}
+	return nil
+}
+
+// exists is true if an operand refers to a truthy value.
+type exists struct{ o operand }
+
+func (e exists) evaluate(m match) bool {
+	return truthy(e.o.value(m))
+}
+
+// compare is true if an operand refers to a scalar that equals, or for
+// !=, doesn't equal a literal.
+type compare struct {
+	o       operand
+	equal   bool
+	literal string
+}
+
+func (e compare) evaluate(m match) bool {
+	v := e.o.value(m)
+	matches := v != nil && v.Kind == yaml.ScalarNode && v.Value == e.literal
+	return matches == e.equal
+}
+
+// parseExpression parses the expression of a filter, such as
+// "@.in == 'query' || @.in == 'path'".
+func parseExpression(s string) (expression, error) {
+	p := &expressionParser{tokens: tokenize(s)}
+	e, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %s", s, err)
+	}
+	if p.more() {
+		return nil, fmt.Errorf("filter %q: unexpected %q", s, p.peek())
+	}
+	return e, nil
+}
+
+type expressionParser struct {
+	tokens []string
+}
+
+func (p *expressionParser) more() bool {
+	return len(p.tokens) > 0
+}
+
+func (p *expressionParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *expressionParser) next() string {
+	t := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+func (p *expressionParser) or() (expression, error) {
+	var terms or
+	for {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+		if p.peek() != "||" {
+			break
+		}
+		p.next()
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *expressionParser) and() (expression, error) {
+	var terms and
+	for {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, e)
+		if p.peek() != "&&" {
+			break
+		}
+		p.next()
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *expressionParser) unary() (expression, error) {
+	switch t := p.next(); {
+	case t == "!":
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	case t == "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return e, nil
+	case t == "@property":
+		return p.comparison(operand{property: true})
+	case t == "@":
+		return p.comparison(operand{})
+	case strings.HasPrefix(t, "@."):
+		return p.comparison(operand{field: t[2:]})
+	case t == "":
+		return nil, fmt.Errorf("unexpected end")
+	default:
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+}
+
+func (p *expressionParser) comparison(o operand) (expression, error) {
+	var equal bool
+	switch p.peek() {
+	case "==", "===":
+		equal = true
+	case "!=", "!==":
+		equal = false
+	default:
+		return exists{o}, nil
+	}
+	p.next()
+	t := p.next()
+	if t == "" {
+		return nil, fmt.Errorf("missing value to compare")
+	}
+	literal, ok := unquote(t)
+	if !ok {
+		literal = t
+	}
+	return compare{o: o, equal: equal, literal: literal}, nil
+}
+
+// tokenize splits an expression into operators, parentheses, quoted
+// strings, and words such as @.name and numbers.
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		case strings.ContainsRune("=!&|", rune(c)):
+			j := i
+			for j < len(s) && strings.ContainsRune("=!&|", rune(s[j])) {
+				j++
+			}
+			// A ! before an operand negates it.
+			if s[i:j] == "!" || (c == '!' && j-i > 1 && s[i+1] == '!') {
+				j = i + 1
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t()'\"=!&|", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
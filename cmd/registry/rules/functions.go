@@ -0,0 +1,217 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A target is a value that a rule checks. Its node is nil if the value
+// doesn't exist.
+type target struct {
+	property string
+	node     *yaml.Node
+}
+
+// A check returns a description of how a target breaks a rule, or an empty
+// string if it doesn't.
+type check func(t target) string
+
+// A function makes a check from the functionOptions of a rule.
+type function func(options map[string]interface{}) (check, error)
+
+var functions = map[string]function{
+	"truthy":      truthyFunction,
+	"falsy":       falsyFunction,
+	"defined":     definedFunction,
+	"undefined":   undefinedFunction,
+	"pattern":     patternFunction,
+	"enumeration": enumerationFunction,
+	"casing":      casingFunction,
+}
+
+func truthyFunction(map[string]interface{}) (check, error) {
+	return func(t target) string {
+		if !truthy(t.node) {
+			return fmt.Sprintf("%q property must be truthy", t.property)
+		}
+		return ""
+	}, nil
+}
+
+func falsyFunction(map[string]interface{}) (check, error) {
+	return func(t target) string {
+		if truthy(t.node) {
+			return fmt.Sprintf("%q property must be falsy", t.property)
+		}
+		return ""
+	}, nil
+}
+
+func definedFunction(map[string]interface{}) (check, error) {
+	return func(t target) string {
+		if t.node == nil {
+			return fmt.Sprintf("%q property must be defined", t.property)
+		}
+		return ""
+	}, nil
+}
+
+func undefinedFunction(map[string]interface{}) (check, error) {
+	return func(t target) string {
+		if t.node != nil {
+			return fmt.Sprintf("%q property must not be defined", t.property)
+		}
+		return ""
+	}, nil
+}
+
+// patternFunction checks that strings match a regular expression (match)
+// and don't match another one (notMatch). Expressions can be written as
+// /expression/flags, as in JavaScript, where the only flag is i.
+func patternFunction(options map[string]interface{}) (check, error) {
+	var match, notMatch *regexp.Regexp
+	for name, v := range options {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("pattern option %s must be a string", name)
+		}
+		re, err := compilePattern(s)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "match":
+			match = re
+		case "notMatch":
+			notMatch = re
+		default:
+			return nil, fmt.Errorf("unknown pattern option %s", name)
+		}
+	}
+	if match == nil && notMatch == nil {
+		return nil, fmt.Errorf("pattern requires a match or notMatch option")
+	}
+	return func(t target) string {
+		if !isScalar(t.node) {
+			return ""
+		}
+		if match != nil && !match.MatchString(t.node.Value) {
+			return fmt.Sprintf("%q must match the pattern %q", t.node.Value, match.String())
+		}
+		if notMatch != nil && notMatch.MatchString(t.node.Value) {
+			return fmt.Sprintf("%q must not match the pattern %q", t.node.Value, notMatch.String())
+		}
+		return ""
+	}, nil
+}
+
+func compilePattern(s string) (*regexp.Regexp, error) {
+	if i := strings.LastIndex(s, "/"); strings.HasPrefix(s, "/") && i > 0 {
+		switch flags := s[i+1:]; flags {
+		case "":
+			s = s[1:i]
+		case "i":
+			s = "(?i)" + s[1:i]
+		default:
+			return nil, fmt.Errorf("unsupported pattern flags %q", flags)
+		}
+	}
+	return regexp.Compile(s)
+}
+
+// enumerationFunction checks that scalars are one of a list of values.
+func enumerationFunction(options map[string]interface{}) (check, error) {
+	values, ok := options["values"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("enumeration requires a list of values")
+	}
+	allowed := make(map[string]bool)
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		s := fmt.Sprint(v)
+		allowed[s] = true
+		quoted[i] = strconv.Quote(s)
+	}
+	return func(t target) string {
+		if !isScalar(t.node) || allowed[t.node.Value] {
+			return ""
+		}
+		return fmt.Sprintf("%q must be equal to one of the allowed values: %s",
+			t.node.Value, strings.Join(quoted, ", "))
+	}, nil
+}
+
+var casings = map[string]string{
+	"flat":   `^[a-z][a-z{d}]*$`,
+	"camel":  `^[a-z][a-z{d}]*(?:[A-Z{d}][a-z{d}]*)*$`,
+	"pascal": `^[A-Z][a-z{d}]*(?:[A-Z{d}][a-z{d}]*)*$`,
+	"kebab":  `^[a-z][a-z{d}]*(?:-[a-z{d}]+)*$`,
+	"cobol":  `^[A-Z][A-Z{d}]*(?:-[A-Z{d}]+)*$`,
+	"snake":  `^[a-z][a-z{d}]*(?:_[a-z{d}]+)*$`,
+	"macro":  `^[A-Z][A-Z{d}]*(?:_[A-Z{d}]+)*$`,
+}
+
+// casingFunction checks that strings are in a case (type), such as camel
+// or snake, that can forbid digits (disallowDigits).
+func casingFunction(options map[string]interface{}) (check, error) {
+	casing, _ := options["type"].(string)
+	pattern, ok := casings[casing]
+	if !ok {
+		return nil, fmt.Errorf("casing requires a type (flat, camel, pascal, kebab, cobol, snake, or macro)")
+	}
+	digits := "0-9"
+	if disallow, _ := options["disallowDigits"].(bool); disallow {
+		digits = ""
+	}
+	re := regexp.MustCompile(strings.ReplaceAll(pattern, "{d}", digits))
+	return func(t target) string {
+		if !isScalar(t.node) || re.MatchString(t.node.Value) {
+			return ""
+		}
+		return fmt.Sprintf("%q must be %s case", t.node.Value, casing)
+	}, nil
+}
+
+func isScalar(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.ScalarNode
+}
+
+// truthy is true for values that JavaScript considers true: values that
+// exist and aren't null, false, zero, or empty strings.
+func truthy(n *yaml.Node) bool {
+	if n == nil {
+		return false
+	}
+	if n.Kind != yaml.ScalarNode {
+		return true
+	}
+	switch n.ShortTag() {
+	case "!!null":
+		return false
+	case "!!bool":
+		b, _ := strconv.ParseBool(n.Value)
+		return b
+	case "!!int", "!!float":
+		f, err := strconv.ParseFloat(n.Value, 64)
+		return err != nil || f != 0
+	}
+	return n.Value != ""
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/apigee/registry/rpc"
+	"gopkg.in/yaml.v3"
+)
+
+// Lint checks an OpenAPI v2 or v3 document, in YAML or JSON, against the
+// rules of a ruleset.
+func (r *Ruleset) Lint(b []byte) (*rpc.LintFile, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	format := documentFormat(&doc)
+	if format == "" {
+		return nil, errors.New("not an OpenAPI v2 or v3 document")
+	}
+	src := newSource(b)
+	problems := make([]*rpc.LintProblem, 0)
+	for _, id := range r.ruleIDs() {
+		rule := r.Rules[id]
+		if !rule.appliesTo(format) {
+			continue
+		}
+		// Paths can select the same values more than once.
+		reported := make(map[string]bool)
+		for _, p := range rule.paths {
+			for _, m := range p.evaluate(&doc) {
+				for i, then := range rule.Then {
+					for _, t := range targets(m, then.Field) {
+						message := rule.checks[i](target{property: t.property(), node: t.node})
+						if message == "" {
+							continue
+						}
+						key := strings.Join(t.path, "\x00") + "\x00" + message
+						if reported[key] {
+							continue
+						}
+						reported[key] = true
+						// Missing values are reported at the values that
+						// should contain them.
+						location := t
+						if t.node == nil {
+							location = m
+						}
+						problems = append(problems, &rpc.LintProblem{
+							Message:    rule.message(message, t),
+							RuleId:     id,
+							RuleDocUri: r.documentationURL(id),
+							Location:   src.location(location),
+						})
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i].Location.StartPosition, problems[j].Location.StartPosition
+		if a.LineNumber != b.LineNumber {
+			return a.LineNumber < b.LineNumber
+		}
+		return a.ColumnNumber < b.ColumnNumber
+	})
+	return &rpc.LintFile{Problems: problems}, nil
+}
+
+// targets returns the values that a then clause checks in a matched value:
+// the value itself, one of its fields (with a nil node if the field doesn't
+// exist), or for "@key", its keys.
+func targets(m match, name string) []match {
+	switch name {
+	case "":
+		return []match{m}
+	case "@key":
+		var keys []match
+		for _, c := range children(m) {
+			if c.key != nil {
+				c.node = c.key
+				keys = append(keys, c)
+			}
+		}
+		return keys
+	}
+	if f, ok := field(m, name); ok {
+		return []match{f}
+	}
+	return []match{{path: appendPath(m.path, name), index: -1}}
+}
+
+// documentFormat returns "oas2" or "oas3" for OpenAPI documents.
+func documentFormat(doc *yaml.Node) string {
+	root := match{node: resolve(doc), index: -1}
+	if v, ok := field(root, "swagger"); ok && v.node.Value == "2.0" {
+		return "oas2"
+	}
+	if v, ok := field(root, "openapi"); ok && strings.HasPrefix(v.node.Value, "3.") {
+		return "oas3"
+	}
+	return ""
+}
+
+// message returns the message of a rule, or its description if it has no
+// message, or the description of the problem if it has neither. Messages
+// can include the placeholders {{error}}, {{description}}, {{property}},
+// {{value}}, and {{path}}.
+func (r *Rule) message(problem string, t match) string {
+	message := r.Message
+	if message == "" {
+		message = r.Description
+	}
+	if message == "" {
+		return problem
+	}
+	value := ""
+	if isScalar(t.node) {
+		value = t.node.Value
+	}
+	return strings.NewReplacer(
+		"{{error}}", problem,
+		"{{description}}", r.Description,
+		"{{property}}", t.property(),
+		"{{value}}", value,
+		"{{path}}", strings.Join(t.path, "."),
+	).Replace(message)
+}
+
+func (r *Ruleset) documentationURL(id string) string {
+	if url := r.Rules[id].DocumentationURL; url != "" {
+		return url
+	}
+	if r.DocumentationURL != "" {
+		return r.DocumentationURL + "#" + id
+	}
+	return ""
+}
+
+// A source finds the positions of nodes in the text of a document.
+type source struct {
+	lines [][]rune
+}
+
+func newSource(b []byte) *source {
+	s := &source{}
+	for _, line := range strings.Split(string(b), "\n") {
+		s.lines = append(s.lines, []rune(strings.TrimSuffix(line, "\r")))
+	}
+	return s
+}
+
+// location returns the range of a matched value, starting with its key.
+// Positions are one-based, and end positions are the last characters of
+// values.
+func (s *source) location(m match) *rpc.LintLocation {
+	start := m.node
+	if m.key != nil && m.key != m.node {
+		start = m.key
+	}
+	line, column := s.end(m.node)
+	return &rpc.LintLocation{
+		StartPosition: &rpc.LintPosition{
+			LineNumber:   int32(start.Line),
+			ColumnNumber: int32(start.Column),
+		},
+		EndPosition: &rpc.LintPosition{
+			LineNumber:   int32(line),
+			ColumnNumber: int32(column),
+		},
+	}
+}
+
+// end returns the position of the last character of a node.
+func (s *source) end(n *yaml.Node) (int, int) {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			if n.Style&yaml.FlowStyle != 0 {
+				return s.closing(n.Line, n.Column, n.Kind)
+			}
+			return n.Line, n.Column
+		}
+		line, column := s.end(n.Content[len(n.Content)-1])
+		if n.Style&yaml.FlowStyle != 0 {
+			return s.closing(line, column, n.Kind)
+		}
+		return line, column
+	case yaml.AliasNode:
+		return n.Line, n.Column + len([]rune(n.Value))
+	case yaml.ScalarNode:
+		switch {
+		case n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+			return s.closingQuote(n.Line, n.Column)
+		case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			value := strings.TrimRight(n.Value, "\n")
+			if value == "" {
+				return n.Line, n.Column
+			}
+			line := n.Line + strings.Count(value, "\n") + 1
+			return line, s.lineLength(line)
+		default:
+			return n.Line, n.Column + len([]rune(n.Value)) - 1
+		}
+	}
+	return n.Line, n.Column
+}
+
+func (s *source) lineLength(line int) int {
+	if line < 1 || line > len(s.lines) {
+		return 0
+	}
+	trimmed := strings.TrimRight(string(s.lines[line-1]), " \t")
+	return len([]rune(trimmed))
+}
+
+// closingQuote returns the position of the quote that closes the quote at
+// a position.
+func (s *source) closingQuote(line, column int) (int, int) {
+	if line < 1 || line > len(s.lines) || column < 1 || column > len(s.lines[line-1]) {
+		return line, column
+	}
+	quote := s.lines[line-1][column-1]
+	l, c := line, column
+	for l <= len(s.lines) {
+		text := s.lines[l-1]
+		for ; c < len(text); c++ {
+			switch {
+			case quote == '"' && text[c] == '\\':
+				c++
+			case quote == '\'' && text[c] == '\'' && c+1 < len(text) && text[c+1] == '\'':
+				c++
+			case text[c] == quote:
+				return l, c + 1
+			}
+		}
+		l, c = l+1, 0
+	}
+	return line, column
+}
+
+// closing returns the position of the bracket that closes a flow mapping
+// or sequence after a position.
+func (s *source) closing(line, column int, kind yaml.Kind) (int, int) {
+	bracket := '}'
+	if kind == yaml.SequenceNode {
+		bracket = ']'
+	}
+	l, c := line, column
+	for l <= len(s.lines) {
+		text := s.lines[l-1]
+		for ; c < len(text); c++ {
+			if text[c] == bracket {
+				return l, c + 1
+			}
+		}
+		l, c = l+1, 0
+	}
+	return line, column
+}
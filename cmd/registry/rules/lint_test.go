@@ -0,0 +1,208 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/google/go-cmp/cmp"
+)
+
+const petstoreV3 = `openapi: 3.0.0
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+- url: https://example.com/
+paths:
+  /pets/:
+    parameters:
+    - name: limit
+      in: query
+    get:
+      operationId: list pets
+      description: |
+        Lists pets.
+        Calls eval(x).
+      tags: [pets]
+      responses: {}
+tags:
+- name: pets
+  x-owner: {team: "pets", "on call": [alice, bob]}
+`
+
+const petstoreV2 = `{
+  "swagger": "2.0",
+  "info": {"title": "Pets", "version": "1.0.0", "description": "Pets.",
+    "contact": {"name": "Pets"}},
+  "host": "pets.example.com/",
+  "tags": [{"name": "pets", "description": "Pets."}],
+  "paths": {
+    "/pets?all": {
+      "get": {
+        "operationId": "listPets",
+        "description": "Lists \"all\" pets.",
+        "tags": ["pets"],
+        "responses": {}
+      }
+    }
+  }
+}`
+
+// problem describes a problem as "rule-id start-end message".
+func problem(p *rpc.LintProblem) string {
+	start, end := p.GetLocation().GetStartPosition(), p.GetLocation().GetEndPosition()
+	return fmt.Sprintf("%s %d:%d-%d:%d %s", p.GetRuleId(),
+		start.GetLineNumber(), start.GetColumnNumber(),
+		end.GetLineNumber(), end.GetColumnNumber(), p.GetMessage())
+}
+
+func checkProblems(t *testing.T, r *Ruleset, doc string, want []string) {
+	t.Helper()
+	file, err := r.Lint([]byte(doc))
+	if err != nil {
+		t.Fatalf("Lint() returned error: %s", err)
+	}
+	got := make([]string, 0)
+	for _, p := range file.GetProblems() {
+		got = append(got, problem(p))
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected problems (-want +got):\n%s", diff)
+	}
+}
+
+func TestDefaultRuleset(t *testing.T) {
+	r, err := DefaultRuleset()
+	if err != nil {
+		t.Fatalf("DefaultRuleset() returned error: %s", err)
+	}
+	checkProblems(t, r, petstoreV3, []string{
+		`info-contact 2:1-4:16 Info object must have a "contact" object.`,
+		`info-description 2:1-4:16 Info object must have a "description" field.`,
+		`oas3-server-not-example.com 6:3-6:27 Server URL must not point at example.com.`,
+		`oas3-server-trailing-slash 6:3-6:27 Server URL must not have a trailing slash.`,
+		`path-keys-no-trailing-slash 8:3-8:8 Path must not end with a slash.`,
+		`parameter-description 10:7-11:15 Parameter objects must have a "description" field.`,
+		`operation-operationId-valid-in-url 13:7-13:28 OperationId must not contain characters that are invalid when used in URL.`,
+		`no-eval-in-markdown 14:7-16:22 Markdown descriptions must not have "eval(".`,
+		`tag-description 20:3-21:50 Tag object must have a "description" field.`,
+	})
+	checkProblems(t, r, petstoreV2, []string{
+		`oas2-api-schemes 1:1-17:1 OpenAPI host "schemes" must be present and non-empty array.`,
+		`oas2-host-not-example 5:3-5:29 Host URL must not point at example.com.`,
+		`oas2-host-trailing-slash 5:3-5:29 Server URL must not have a trailing slash.`,
+		`path-not-include-query 8:5-8:15 Path must not include a query string.`,
+	})
+}
+
+func TestDocumentationURL(t *testing.T) {
+	r, err := DefaultRuleset()
+	if err != nil {
+		t.Fatalf("DefaultRuleset() returned error: %s", err)
+	}
+	file, err := r.Lint([]byte(petstoreV3))
+	if err != nil {
+		t.Fatalf("Lint() returned error: %s", err)
+	}
+	want := "https://meta.stoplight.io/docs/spectral/docs/reference/openapi-rules.md#info-contact"
+	if got := file.GetProblems()[0].GetRuleDocUri(); got != want {
+		t.Errorf("RuleDocUri is %q, want %q", got, want)
+	}
+}
+
+const customRuleset = `
+documentationUrl: https://example.com/rules
+rules:
+  operation-id-casing:
+    message: "{{property}} at {{path}} is {{value}}: {{error}}"
+    given: $.paths[*][*].operationId
+    then:
+      function: casing
+      functionOptions:
+        type: camel
+        disallowDigits: true
+  parameter-in:
+    description: Parameters must be in paths or queries.
+    given: $..parameters[*]
+    then:
+      field: in
+      function: enumeration
+      functionOptions:
+        values: [path, query]
+  info-version:
+    given: $.info
+    then:
+    - field: version
+      function: pattern
+      functionOptions:
+        match: /^V?\d+\.\d+\.\d+$/i
+    - field: x-draft
+      function: falsy
+    - field: x-legacy
+      function: undefined
+    - field: license
+      function: defined
+`
+
+func TestCustomRuleset(t *testing.T) {
+	r, err := ParseRuleset([]byte(customRuleset))
+	if err != nil {
+		t.Fatalf("ParseRuleset() returned error: %s", err)
+	}
+	checkProblems(t, r, `swagger: "2.0"
+info:
+  version: v1
+  x-draft: yes
+  x-legacy: false
+paths:
+  /pets:
+    get:
+      operationId: list_pets2
+      parameters:
+      - in: header
+      - in: query
+    put:
+      operationId: putPet
+`, []string{
+		`info-version 2:1-5:17 "license" property must be defined`,
+		`info-version 3:3-3:13 "v1" must match the pattern "(?i)^V?\\d+\\.\\d+\\.\\d+$"`,
+		`info-version 4:3-4:14 "x-draft" property must be falsy`,
+		`info-version 5:3-5:17 "x-legacy" property must not be defined`,
+		`operation-id-casing 9:7-9:29 operationId at paths./pets.get.operationId is list_pets2: "list_pets2" must be camel case`,
+		`parameter-in 11:9-11:18 Parameters must be in paths or queries.`,
+	})
+	file, err := r.Lint([]byte("openapi: 3.0.0\ninfo: {version: 1.0.0, license: {}}\n"))
+	if err != nil {
+		t.Fatalf("Lint() returned error: %s", err)
+	}
+	if len(file.GetProblems()) != 0 {
+		t.Errorf("Lint() returned unexpected problems: %v", file.GetProblems())
+	}
+}
+
+func TestLintErrors(t *testing.T) {
+	r, err := DefaultRuleset()
+	if err != nil {
+		t.Fatalf("DefaultRuleset() returned error: %s", err)
+	}
+	for _, doc := range []string{"", "a: [", "swagger: '1.2'", "kind: Deployment"} {
+		if _, err := r.Lint([]byte(doc)); err == nil {
+			t.Errorf("Lint(%q) succeeded, want error", doc)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A path selects nodes of a YAML document with a subset of JSONPath:
+// $ is the document, .name and ['name'] select values of mapping keys,
+// .* and [*] select all values, [0] selects a sequence item, ['a','b']
+// selects the values of several keys, .. selects descendants rather than
+// children (e.g. $..description), and [?(...)] filters values with
+// expressions like @.in == 'query', @property != 'x', !@.deprecated,
+// && and ||.
+type path []step
+
+type step struct {
+	descendants bool
+	wildcard    bool
+	names       []string
+	indexes     []int
+	filter      expression
+}
+
+// A match is a node selected by a path.
+type match struct {
+	path  []string   // keys and indexes from the document root
+	key   *yaml.Node // the mapping key of the node, if it has one
+	index int        // the index of the node in a sequence, or -1
+	node  *yaml.Node
+}
+
+// property is the last key or index of a match's path.
+func (m match) property() string {
+	if len(m.path) == 0 {
+		return ""
+	}
+	return m.path[len(m.path)-1]
+}
+
+func parsePath(s string) (path, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("path %q doesn't start with $", s)
+	}
+	var p path
+	for i := 1; i < len(s); {
+		var st step
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			st.descendants = true
+			i += 2
+		case s[i] == '.':
+			i++
+		case s[i] == '[':
+		default:
+			return nil, fmt.Errorf("path %q has unexpected %q at %d", s, s[i], i)
+		}
+		if i < len(s) && s[i] == '[' {
+			n, err := parseBracket(s[i:], &st)
+			if err != nil {
+				return nil, fmt.Errorf("path %q: %s", s, err)
+			}
+			i += n
+		} else {
+			j := i
+			for j < len(s) && s[j] != '.' && s[j] != '[' {
+				j++
+			}
+			switch name := s[i:j]; name {
+			case "":
+				return nil, fmt.Errorf("path %q has an empty name at %d", s, i)
+			case "*":
+				st.wildcard = true
+			default:
+				st.names = []string{name}
+			}
+			i = j
+		}
+		p = append(p, st)
+	}
+	return p, nil
+}
+
+// parseBracket parses a bracketed selector at the start of s into a step
+// and returns the length of the selector.
+func parseBracket(s string, st *step) (int, error) {
+	end := closingBracket(s)
+	if end < 0 {
+		return 0, fmt.Errorf("unterminated %q", s)
+	}
+	inner := strings.TrimSpace(s[1:end])
+	switch {
+	case inner == "*":
+		st.wildcard = true
+	case strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")"):
+		e, err := parseExpression(inner[2 : len(inner)-1])
+		if err != nil {
+			return 0, err
+		}
+		st.filter = e
+	default:
+		for _, item := range splitUnquoted(inner, ',') {
+			item = strings.TrimSpace(item)
+			if name, ok := unquote(item); ok {
+				st.names = append(st.names, name)
+			} else if n, err := strconv.Atoi(item); err == nil {
+				st.indexes = append(st.indexes, n)
+			} else {
+				return 0, fmt.Errorf("invalid selector %q", item)
+			}
+		}
+	}
+	return end + 1, nil
+}
+
+// closingBracket returns the index of the bracket that closes the bracket
+// at the start of s, ignoring brackets in quotes and in parentheses.
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitUnquoted splits s at separators that aren't in quotes.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote returns the contents of a single- or double-quoted string.
+func unquote(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	return strings.NewReplacer(`\'`, `'`, `\"`, `"`, `\\`, `\`).Replace(s[1 : len(s)-1]), true
+}
+
+// evaluate returns the nodes of a document that a path selects.
+func (p path) evaluate(doc *yaml.Node) []match {
+	matches := []match{{index: -1, node: resolve(doc)}}
+	for _, st := range p {
+		var next []match
+		for _, m := range matches {
+			var candidates []match
+			if st.descendants {
+				candidates = descendants(m)
+			} else {
+				candidates = children(m)
+			}
+			for _, c := range candidates {
+				if st.selects(c) {
+					next = append(next, c)
+				}
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+func (st step) selects(m match) bool {
+	switch {
+	case st.wildcard:
+		return true
+	case st.filter != nil:
+		return st.filter.evaluate(m)
+	case m.key != nil:
+		for _, name := range st.names {
+			if m.key.Value == name {
+				return true
+			}
+		}
+	case m.index >= 0:
+		for _, index := range st.indexes {
+			if m.index == index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// children returns the values of a mapping or the items of a sequence.
+func children(m match) []match {
+	var result []match
+	if m.node == nil {
+		return nil
+	}
+	switch m.node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(m.node.Content); i += 2 {
+			key := m.node.Content[i]
+			result = append(result, match{
+				path:  appendPath(m.path, key.Value),
+				key:   key,
+				index: -1,
+				node:  resolve(m.node.Content[i+1]),
+			})
+		}
+	case yaml.SequenceNode:
+		for i, item := range m.node.Content {
+			result = append(result, match{
+				path:  appendPath(m.path, strconv.Itoa(i)),
+				index: i,
+				node:  resolve(item),
+			})
+		}
+	}
+	return result
+}
+
+// descendants returns the children of a node, their children, and so on.
+func descendants(m match) []match {
+	var result []match
+	for _, c := range children(m) {
+		result = append(result, c)
+		result = append(result, descendants(c)...)
+	}
+	return result
+}
+
+// field returns the value of a key, or of a sequence of keys separated by
+// dots, in a mapping.
+func field(m match, name string) (match, bool) {
+	for _, key := range strings.Split(name, ".") {
+		found := false
+		for _, c := range children(m) {
+			if c.key != nil && c.key.Value == key {
+				m, found = c, true
+				break
+			}
+		}
+		if !found {
+			return match{}, false
+		}
+	}
+	return m, true
+}
+
+func appendPath(p []string, s string) []string {
+	result := make([]string, len(p), len(p)+1)
+	copy(result, p)
+	return append(result, s)
+}
+
+// resolve returns the content of document nodes and the targets of aliases.
+func resolve(n *yaml.Node) *yaml.Node {
+	for n != nil {
+		switch {
+		case n.Kind == yaml.DocumentNode && len(n.Content) > 0:
+			n = n.Content[0]
+		case n.Kind == yaml.AliasNode && n.Alias != nil:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+const pathDocument = `
+paths:
+  /pets:
+    parameters:
+    - name: limit
+      in: query
+      description: How many pets to list.
+    get:
+      description: Lists pets.
+      parameters:
+      - name: id
+        in: path
+        required: true
+      - $ref: '#/parameters/kind'
+    x-internal: true
+  /pets/{id}:
+    delete:
+      deprecated: true
+parameters:
+  kind:
+    name: kind
+    in: query
+`
+
+func TestPathEvaluate(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(pathDocument), &doc); err != nil {
+		t.Fatalf("Unmarshal() returned error: %s", err)
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"$", []string{""}},
+		{"$.paths", []string{"paths"}},
+		{"$.paths[*]", []string{"paths./pets", "paths./pets/{id}"}},
+		{"$.paths.*.get", []string{"paths./pets.get"}},
+		{"$['paths']['/pets/{id}']", []string{"paths./pets/{id}"}},
+		{"$.paths[*]['get','delete']", []string{"paths./pets.get", "paths./pets/{id}.delete"}},
+		{"$.paths['/pets'].get.parameters[1]", []string{"paths./pets.get.parameters.1"}},
+		{"$..description", []string{"paths./pets.parameters.0.description", "paths./pets.get.description"}},
+		{"$..parameters[?(@.in)]", []string{"paths./pets.parameters.0", "paths./pets.get.parameters.0", "parameters.kind"}},
+		{"$..[?(@.in == 'query')].name", []string{"paths./pets.parameters.0.name", "parameters.kind.name"}},
+		{"$..[?(@.in == 'path' && @.required)]", []string{"paths./pets.get.parameters.0"}},
+		{"$.paths[*][?(@property != 'parameters' && !@.deprecated)]", []string{"paths./pets.get", "paths./pets.x-internal"}},
+		{"$.paths[*][?(@.deprecated === true || @property === 'get')]", []string{"paths./pets.get", "paths./pets/{id}.delete"}},
+		{"$.missing[*]", nil},
+	}
+	for _, test := range tests {
+		p, err := parsePath(test.path)
+		if err != nil {
+			t.Errorf("parsePath(%q) returned error: %s", test.path, err)
+			continue
+		}
+		var got []string
+		for _, m := range p.evaluate(&doc) {
+			got = append(got, strings.Join(m.path, "."))
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s selected unexpected values (-want +got):\n%s", test.path, diff)
+		}
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, s := range []string{
+		"paths",
+		"$.",
+		"$paths",
+		"$.paths[",
+		"$.paths[x]",
+		"$.paths[?(@.in ==)]",
+		"$.paths[?(@.in == 'x' 'y')]",
+		"$.paths[?(in)]",
+	} {
+		if _, err := parsePath(s); err == nil {
+			t.Errorf("parsePath(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules checks OpenAPI documents against rulesets that are written
+// in the YAML format of Spectral rulesets, without running Spectral.
+package rules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRulesetName is the name that rulesets use to extend the default
+// ruleset, the name of Spectral's similar ruleset.
+const DefaultRulesetName = "spectral:oas"
+
+// A Ruleset is a set of rules, named by their IDs. A ruleset can extend
+// the default ruleset, adding rules and changing or turning off (with
+// "rule-id: off") the default rules.
+type Ruleset struct {
+	Extends          stringList       `yaml:"extends"`
+	DocumentationURL string           `yaml:"documentationUrl"`
+	Rules            map[string]*Rule `yaml:"rules"`
+}
+
+// A Rule applies the functions of its then clauses to the values that its
+// given paths select, or to fields of those values.
+type Rule struct {
+	Description      string     `yaml:"description"`
+	Message          string     `yaml:"message"`
+	Severity         string     `yaml:"severity"`
+	Formats          stringList `yaml:"formats"`
+	Given            stringList `yaml:"given"`
+	Then             thenList   `yaml:"then"`
+	DocumentationURL string     `yaml:"documentationUrl"`
+
+	paths  []path
+	checks []check
+}
+
+// A Then names a function that checks a field of the values that a rule
+// selects, the values themselves if the field is empty, or their keys if
+// the field is "@key".
+type Then struct {
+	Field           string                 `yaml:"field"`
+	Function        string                 `yaml:"function"`
+	FunctionOptions map[string]interface{} `yaml:"functionOptions"`
+}
+
+// Severities of rules. Lint results don't record severities, but rules
+// with the severity "off" aren't checked.
+var severities = map[string]bool{"error": true, "warn": true, "info": true, "hint": true, "off": true}
+
+// Formats of documents.
+var formats = map[string]bool{"oas2": true, "oas3": true}
+
+// UnmarshalYAML reads a rule, or the severity of a rule that is written
+// alone, as in "rule-id: off" or "rule-id: false".
+func (r *Rule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		switch node.Value {
+		case "false":
+			r.Severity = "off"
+		case "true":
+		default:
+			r.Severity = node.Value
+		}
+		return nil
+	}
+	type plain Rule
+	return node.Decode((*plain)(r))
+}
+
+// isOverride is true for rules that only change the severity of the rules
+// of extended rulesets.
+func (r *Rule) isOverride() bool {
+	return len(r.Given) == 0 && len(r.Then) == 0
+}
+
+type stringList []string
+
+// UnmarshalYAML reads a string or a list of strings.
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(l))
+}
+
+type thenList []*Then
+
+// UnmarshalYAML reads a then clause or a list of them.
+func (l *thenList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		t := &Then{}
+		if err := node.Decode(t); err != nil {
+			return err
+		}
+		*l = thenList{t}
+		return nil
+	}
+	return node.Decode((*[]*Then)(l))
+}
+
+// DefaultRuleset returns the default ruleset.
+func DefaultRuleset() (*Ruleset, error) {
+	return ParseRuleset([]byte(defaultRuleset))
+}
+
+// ReadRuleset reads a ruleset from a YAML file.
+func ReadRuleset(filename string) (*Ruleset, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseRuleset(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return r, nil
+}
+
+// ParseRuleset parses a YAML ruleset.
+func ParseRuleset(b []byte) (*Ruleset, error) {
+	r := &Ruleset{}
+	if err := yaml.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	if len(r.Extends) > 0 {
+		if err := r.extend(); err != nil {
+			return nil, err
+		}
+	}
+	for id, rule := range r.Rules {
+		if rule == nil {
+			return nil, fmt.Errorf("rule %s is empty", id)
+		}
+		if rule.isOverride() {
+			return nil, fmt.Errorf("rule %s has no given paths or then clauses", id)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rule %s: %s", id, err)
+		}
+	}
+	return r, nil
+}
+
+// extend adds the rules of the default ruleset that a ruleset doesn't
+// define and applies the severities of rules that override them.
+func (r *Ruleset) extend() error {
+	for _, name := range r.Extends {
+		if name != DefaultRulesetName {
+			return fmt.Errorf("can't extend unknown ruleset %q", name)
+		}
+	}
+	base, err := DefaultRuleset()
+	if err != nil {
+		return err
+	}
+	if r.Rules == nil {
+		r.Rules = make(map[string]*Rule)
+	}
+	for id, rule := range base.Rules {
+		if rule.DocumentationURL == "" && base.DocumentationURL != "" {
+			rule.DocumentationURL = base.DocumentationURL + "#" + id
+		}
+		override, ok := r.Rules[id]
+		if !ok {
+			r.Rules[id] = rule
+		} else if override != nil && override.isOverride() {
+			if override.Severity != "" {
+				rule.Severity = override.Severity
+			}
+			r.Rules[id] = rule
+		}
+	}
+	return nil
+}
+
+// compile parses the paths, formats, and functions of a rule.
+func (r *Rule) compile() error {
+	if r.Severity == "" {
+		r.Severity = "warn"
+	}
+	if !severities[r.Severity] {
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	for _, f := range r.Formats {
+		if !formats[f] {
+			return fmt.Errorf("unknown format %q", f)
+		}
+	}
+	if len(r.Given) == 0 {
+		return fmt.Errorf("no given paths")
+	}
+	if len(r.Then) == 0 {
+		return fmt.Errorf("no then clauses")
+	}
+	r.paths, r.checks = nil, nil
+	for _, given := range r.Given {
+		p, err := parsePath(given)
+		if err != nil {
+			return err
+		}
+		r.paths = append(r.paths, p)
+	}
+	for _, then := range r.Then {
+		f, ok := functions[then.Function]
+		if !ok {
+			return fmt.Errorf("unknown function %q", then.Function)
+		}
+		c, err := f(then.FunctionOptions)
+		if err != nil {
+			return err
+		}
+		r.checks = append(r.checks, c)
+	}
+	return nil
+}
+
+// appliesTo is true if a rule is on and applies to documents of a format.
+func (r *Rule) appliesTo(format string) bool {
+	if r.Severity == "off" {
+		return false
+	}
+	if len(r.Formats) == 0 {
+		return true
+	}
+	for _, f := range r.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleIDs returns the IDs of a ruleset's rules in order.
+func (r *Ruleset) ruleIDs() []string {
+	ids := make([]string, 0, len(r.Rules))
+	for id := range r.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtendedRuleset(t *testing.T) {
+	r, err := ParseRuleset([]byte(`
+extends: spectral:oas
+documentationUrl: https://example.com/rules
+rules:
+  info-contact: off
+  info-description: false
+  oas3-server-trailing-slash: error
+  operation-tags:
+    given: $.paths[*][*]
+    then:
+      field: tags
+      function: defined
+`))
+	if err != nil {
+		t.Fatalf("ParseRuleset() returned error: %s", err)
+	}
+	checkProblems(t, r, `openapi: 3.0.0
+info:
+  title: Pets
+servers:
+- url: https://pets.example.org/
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      description: Lists pets.
+      tags: []
+tags:
+- name: pets
+  description: Pets.
+`, []string{
+		`oas3-server-trailing-slash 5:3-5:32 Server URL must not have a trailing slash.`,
+	})
+	if got := r.documentationURL("oas3-server-trailing-slash"); got != "https://meta.stoplight.io/docs/spectral/docs/reference/openapi-rules.md#oas3-server-trailing-slash" {
+		t.Errorf("extended rules have unexpected documentation %q", got)
+	}
+	if got := r.documentationURL("operation-tags"); got != "https://example.com/rules#operation-tags" {
+		t.Errorf("rules have unexpected documentation %q", got)
+	}
+}
+
+func TestReadRuleset(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ruleset.yaml")
+	if err := ioutil.WriteFile(filename, []byte("extends: [spectral:oas]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() returned error: %s", err)
+	}
+	r, err := ReadRuleset(filename)
+	if err != nil {
+		t.Fatalf("ReadRuleset() returned error: %s", err)
+	}
+	d, err := DefaultRuleset()
+	if err != nil {
+		t.Fatalf("DefaultRuleset() returned error: %s", err)
+	}
+	if len(r.Rules) != len(d.Rules) {
+		t.Errorf("ReadRuleset() returned %d rules, want %d", len(r.Rules), len(d.Rules))
+	}
+	if _, err := ReadRuleset(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("ReadRuleset() succeeded for a missing file, want error")
+	}
+}
+
+func TestParseRulesetErrors(t *testing.T) {
+	for _, ruleset := range []string{
+		`rules: [`,
+		`extends: spectral:asyncapi`,
+		`rules: {r: off}`,
+		`rules: {r: }`,
+		`rules: {r: {given: $, then: {function: truthy}, severity: fatal}}`,
+		`rules: {r: {given: $, then: {function: truthy}, formats: [oas4]}}`,
+		`rules: {r: {then: {function: truthy}}}`,
+		`rules: {r: {given: $}}`,
+		`rules: {r: {given: info, then: {function: truthy}}}`,
+		`rules: {r: {given: $, then: {function: schema}}}`,
+		`rules: {r: {given: $, then: {function: pattern}}}`,
+		`rules: {r: {given: $, then: {function: pattern, functionOptions: {match: "("}}}}`,
+		`rules: {r: {given: $, then: {function: pattern, functionOptions: {match: /a/g}}}}`,
+		`rules: {r: {given: $, then: {function: pattern, functionOptions: {matches: a}}}}`,
+		`rules: {r: {given: $, then: {function: enumeration, functionOptions: {values: a}}}}`,
+		`rules: {r: {given: $, then: {function: casing, functionOptions: {type: title}}}}`,
+	} {
+		if _, err := ParseRuleset([]byte(ruleset)); err == nil {
+			t.Errorf("ParseRuleset(%q) succeeded, want error", ruleset)
+		}
+	}
+}
@@ -16,14 +16,20 @@ package connection
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"os"
-	"strconv"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/apigee/registry/gapic"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 )
 
 // Client is a client of the Registry API
@@ -31,21 +37,35 @@ type Client = *gapic.RegistryClient
 
 // Settings configure the client.
 type Settings struct {
-	Address  string // service address
-	Insecure bool   // if true, connect over HTTP
-	Token    string // bearer token
+	Address      string        // service address
+	Insecure     bool          // if true, connect over HTTP
+	Token        string        // bearer token
+	TokenCommand string        // shell command that prints a bearer token, used if Token is empty
+	CAFile       string        // PEM file of CA certificates used to verify the server
+	CertFile     string        // PEM file containing a client certificate for mutual TLS
+	KeyFile      string        // PEM file containing the client certificate's key
+	ServerName   string        // overrides the server name used to verify the server certificate
+	Project      string        // default project of commands that take one
+	Timeout      time.Duration // deadline of calls that don't have one, including retries, if nonzero
+
+	Retries            int           // retries of idempotent calls that fail with UNAVAILABLE or RESOURCE_EXHAUSTED
+	RetryDelay         time.Duration // delay before the first retry, DefaultRetryDelay if zero
+	Keepalive          time.Duration // interval of keepalive pings, if nonzero (at least 10s)
+	MaxMessageSize     int           // maximum size in bytes of messages sent and received, if nonzero
+	MaxConcurrentCalls int           // maximum number of calls in progress at once, if nonzero
 }
 
-// NewClient creates a new GAPIC client using environment variable settings.
+// NewClient creates a new GAPIC client using the settings of the selected
+// profile and environment variables.
 func NewClient(ctx context.Context) (Client, error) {
-	var settings Settings
-	settings.Address = os.Getenv("APG_REGISTRY_ADDRESS")
+	settings, err := ActiveSettings()
+	if err != nil {
+		return nil, err
+	}
 	if settings.Address == "" {
-		return nil, fmt.Errorf("rpc error: APG_REGISTRY_ADDRESS must be set")
+		return nil, fmt.Errorf("rpc error: APG_REGISTRY_ADDRESS must be set or a profile must be selected with \"registry config use\"")
 	}
-	settings.Insecure, _ = strconv.ParseBool(os.Getenv("APG_REGISTRY_INSECURE"))
-	settings.Token = os.Getenv("APG_REGISTRY_TOKEN")
-	return NewClientWithSettings(ctx, &settings)
+	return NewClientWithSettings(ctx, settings)
 }
 
 // NewClientWithSettings creates a GAPIC client with specified settings.
@@ -54,20 +74,149 @@ func NewClientWithSettings(ctx context.Context, settings *Settings) (Client, err
 	if settings.Address == "" {
 		return nil, fmt.Errorf("rpc error: address must be set")
 	}
+	token, err := settings.token()
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := settings.dialOptions()
 	opts = append(opts, option.WithEndpoint(settings.Address))
+	if !settings.Insecure && !settings.customTLS() {
+		// The client dials its own connection, which sends the token.
+		for _, o := range dialOpts {
+			opts = append(opts, option.WithGRPCDialOption(o))
+		}
+		if token != "" {
+			opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(
+				&oauth2.Token{
+					AccessToken: token,
+					TokenType:   "Bearer",
+				})))
+		}
+		return gapic.NewRegistryClient(ctx, opts...)
+	}
+	// Connections that are dialed here ignore token sources, so they
+	// send the token themselves.
 	if settings.Insecure {
-		conn, err := grpc.Dial(settings.Address, grpc.WithInsecure())
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	} else {
+		config, err := settings.tlsConfig()
 		if err != nil {
 			return nil, err
 		}
-		opts = append(opts, option.WithGRPCConn(conn))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	}
+	if token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken{
+			token:      token,
+			requireTLS: !settings.Insecure,
+		}))
 	}
-	if settings.Token != "" {
-		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: settings.Token,
-				TokenType:   "Bearer",
-			})))
+	conn, err := grpc.DialContext(ctx, settings.Address, dialOpts...)
+	if err != nil {
+		return nil, err
 	}
+	opts = append(opts, option.WithGRPCConn(conn))
 	return gapic.NewRegistryClient(ctx, opts...)
 }
+
+// bearerToken sends a token with each call. Unlike oauth.NewOauthAccess,
+// it can send tokens over insecure connections, which are used to reach
+// local servers.
+type bearerToken struct {
+	token      string
+	requireTLS bool
+}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return t.requireTLS
+}
+
+// token returns the bearer token, running the token command if needed.
+func (settings *Settings) token() (string, error) {
+	if settings.Token != "" || settings.TokenCommand == "" {
+		return settings.Token, nil
+	}
+	out, err := exec.Command("sh", "-c", settings.TokenCommand).Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %s", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// dialOptions returns the options of connections that apply deadlines,
+// retries, and limits to calls.
+func (settings *Settings) dialOptions() []grpc.DialOption {
+	// Deadlines are set first so that they include retries, and calls are
+	// limited last so that calls waiting to retry don't hold their slots.
+	var interceptors []grpc.UnaryClientInterceptor
+	if settings.Timeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor(settings.Timeout))
+	}
+	if settings.Retries > 0 {
+		delay := settings.RetryDelay
+		if delay <= 0 {
+			delay = DefaultRetryDelay
+		}
+		interceptors = append(interceptors, retryInterceptor(settings.Retries, delay))
+	}
+	if settings.MaxConcurrentCalls > 0 {
+		interceptors = append(interceptors, limitInterceptor(settings.MaxConcurrentCalls))
+	}
+
+	var opts []grpc.DialOption
+	if len(interceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(interceptors...))
+	}
+	if settings.Keepalive > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                settings.Keepalive,
+			PermitWithoutStream: true,
+		}))
+	}
+	if settings.MaxMessageSize > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(settings.MaxMessageSize),
+			grpc.MaxCallSendMsgSize(settings.MaxMessageSize),
+		))
+	}
+	return opts
+}
+
+// customTLS returns true if the settings require a TLS configuration
+// other than the system defaults.
+func (settings *Settings) customTLS() bool {
+	return settings.CAFile != "" || settings.CertFile != "" || settings.KeyFile != "" || settings.ServerName != ""
+}
+
+// tlsConfig builds a TLS configuration from the settings.
+func (settings *Settings) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: settings.ServerName,
+	}
+	if settings.CAFile != "" {
+		b, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in CA file %q", settings.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if (settings.CertFile == "") != (settings.KeyFile == "") {
+		return nil, fmt.Errorf("client certificate and key files must be set together")
+	}
+	if settings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %s", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
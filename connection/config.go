@@ -0,0 +1,231 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named set of client settings.
+type Profile struct {
+	Address      string        `yaml:"address,omitempty"`
+	Insecure     bool          `yaml:"insecure,omitempty"`
+	Token        string        `yaml:"token,omitempty"`
+	TokenCommand string        `yaml:"token_command,omitempty"`
+	CAFile       string        `yaml:"ca_file,omitempty"`
+	CertFile     string        `yaml:"cert_file,omitempty"`
+	KeyFile      string        `yaml:"key_file,omitempty"`
+	ServerName   string        `yaml:"server_name,omitempty"`
+	Project      string        `yaml:"project,omitempty"`
+	Timeout      time.Duration `yaml:"timeout,omitempty"`
+
+	Retries            *int          `yaml:"retries,omitempty"`
+	RetryDelay         time.Duration `yaml:"retry_delay,omitempty"`
+	Keepalive          time.Duration `yaml:"keepalive,omitempty"`
+	MaxMessageSize     int           `yaml:"max_message_size,omitempty"`
+	MaxConcurrentCalls int           `yaml:"max_concurrent_calls,omitempty"`
+}
+
+// Config holds client profiles and the name of the profile in use.
+type Config struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// ConfigPath returns the path of the client configuration file, which is
+// APG_REGISTRY_CONFIG if it is set and registry/config.yaml in the user's
+// configuration directory (e.g. ~/.config) otherwise.
+func ConfigPath() (string, error) {
+	if path := os.Getenv("APG_REGISTRY_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "registry", "config.yaml"), nil
+}
+
+// ReadConfig reads a configuration file. A missing file is read as an
+// empty configuration.
+func ReadConfig(path string) (*Config, error) {
+	config := &Config{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, config); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+	return config, nil
+}
+
+// Write writes a configuration file. Profiles can hold tokens, so the file
+// is readable only by its owner.
+func (c *Config) Write(path string) error {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// ProfileNames returns the names of the profiles in order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveSettings returns the settings of the selected profile, which is
+// APG_REGISTRY_PROFILE if it is set and the configuration's current profile
+// otherwise. Environment variables override profile settings, and failed
+// idempotent calls are retried DefaultRetries times unless a profile or
+// APG_REGISTRY_RETRIES sets another number. The credentials of the current
+// profile aren't used when APG_REGISTRY_ADDRESS names another server.
+func ActiveSettings() (*Settings, error) {
+	settings := &Settings{Retries: DefaultRetries}
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	config, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	selected := os.Getenv("APG_REGISTRY_PROFILE")
+	name := selected
+	if name == "" {
+		name = config.Current
+	}
+	if name != "" {
+		profile, ok := config.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q is not in %s", name, path)
+		}
+		settings.apply(profile)
+		// Profiles' credentials are only sent to their own servers unless
+		// the profiles are selected explicitly.
+		if address := os.Getenv("APG_REGISTRY_ADDRESS"); selected == "" && address != "" && address != profile.Address {
+			settings.clearCredentials()
+		}
+	}
+	if err := settings.applyEnv(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (settings *Settings) apply(p *Profile) {
+	settings.Address = p.Address
+	settings.Insecure = p.Insecure
+	settings.Token = p.Token
+	settings.TokenCommand = p.TokenCommand
+	settings.CAFile = p.CAFile
+	settings.CertFile = p.CertFile
+	settings.KeyFile = p.KeyFile
+	settings.ServerName = p.ServerName
+	settings.Project = p.Project
+	settings.Timeout = p.Timeout
+	if p.Retries != nil {
+		settings.Retries = *p.Retries
+	}
+	settings.RetryDelay = p.RetryDelay
+	settings.Keepalive = p.Keepalive
+	settings.MaxMessageSize = p.MaxMessageSize
+	settings.MaxConcurrentCalls = p.MaxConcurrentCalls
+}
+
+// clearCredentials removes the settings that authenticate clients and
+// servers to each other.
+func (settings *Settings) clearCredentials() {
+	settings.Token = ""
+	settings.TokenCommand = ""
+	settings.CAFile = ""
+	settings.CertFile = ""
+	settings.KeyFile = ""
+	settings.ServerName = ""
+}
+
+// applyEnv overrides settings with the environment variables that are set.
+func (settings *Settings) applyEnv() error {
+	vars := map[string]*string{
+		"APG_REGISTRY_ADDRESS":       &settings.Address,
+		"APG_REGISTRY_TOKEN":         &settings.Token,
+		"APG_REGISTRY_TOKEN_COMMAND": &settings.TokenCommand,
+		"APG_REGISTRY_CA_FILE":       &settings.CAFile,
+		"APG_REGISTRY_CERT_FILE":     &settings.CertFile,
+		"APG_REGISTRY_KEY_FILE":      &settings.KeyFile,
+		"APG_REGISTRY_SERVER_NAME":   &settings.ServerName,
+		"APG_REGISTRY_PROJECT":       &settings.Project,
+	}
+	for name, value := range vars {
+		if v := os.Getenv(name); v != "" {
+			*value = v
+		}
+	}
+	// A token command set in the environment replaces a profile's token.
+	if os.Getenv("APG_REGISTRY_TOKEN_COMMAND") != "" && os.Getenv("APG_REGISTRY_TOKEN") == "" {
+		settings.Token = ""
+	}
+	if v := os.Getenv("APG_REGISTRY_INSECURE"); v != "" {
+		settings.Insecure, _ = strconv.ParseBool(v)
+	}
+	durations := map[string]*time.Duration{
+		"APG_REGISTRY_TIMEOUT":     &settings.Timeout,
+		"APG_REGISTRY_RETRY_DELAY": &settings.RetryDelay,
+		"APG_REGISTRY_KEEPALIVE":   &settings.Keepalive,
+	}
+	for name, value := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", name, err)
+			}
+			*value = d
+		}
+	}
+	ints := map[string]*int{
+		"APG_REGISTRY_RETRIES":              &settings.Retries,
+		"APG_REGISTRY_MAX_MESSAGE_SIZE":     &settings.MaxMessageSize,
+		"APG_REGISTRY_MAX_CONCURRENT_CALLS": &settings.MaxConcurrentCalls,
+	}
+	for name, value := range ints {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", name, err)
+			}
+			*value = n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// setenv sets environment variables for the duration of a test.
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for name, value := range env {
+		old, ok := os.LookupEnv(name)
+		os.Setenv(name, value)
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+func TestActiveSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry", "config.yaml")
+	noRetries := 0
+	config := &Config{
+		Current: "local",
+		Profiles: map[string]*Profile{
+			"local": {Address: "localhost:8080", Insecure: true, Project: "demo", Token: "local-token", CAFile: "ca.pem"},
+			"prod": {Address: "registry.example.com:443", TokenCommand: "echo secret", Timeout: 30 * time.Second,
+				Retries: &noRetries, Keepalive: time.Minute, MaxConcurrentCalls: 8},
+		},
+	}
+	if err := config.Write(path); err != nil {
+		t.Fatalf("Write() returned error: %s", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Write() created %s with mode %v, want 0600", path, info.Mode())
+	}
+	if got, err := ReadConfig(path); err != nil || !cmp.Equal(config, got) {
+		t.Errorf("ReadConfig() returned %+v, %v, want %+v", got, err, config)
+	}
+
+	tests := []struct {
+		desc string
+		env  map[string]string
+		want *Settings
+	}{
+		{
+			desc: "current profile",
+			want: &Settings{Address: "localhost:8080", Insecure: true, Project: "demo", Token: "local-token", CAFile: "ca.pem",
+				Retries: DefaultRetries},
+		},
+		{
+			desc: "current profile with its address",
+			env:  map[string]string{"APG_REGISTRY_ADDRESS": "localhost:8080"},
+			want: &Settings{Address: "localhost:8080", Insecure: true, Project: "demo", Token: "local-token", CAFile: "ca.pem",
+				Retries: DefaultRetries},
+		},
+		{
+			desc: "current profile with another address",
+			env:  map[string]string{"APG_REGISTRY_ADDRESS": "attacker.example.com:443"},
+			want: &Settings{Address: "attacker.example.com:443", Insecure: true, Project: "demo", Retries: DefaultRetries},
+		},
+		{
+			desc: "selected profile with another address",
+			env:  map[string]string{"APG_REGISTRY_PROFILE": "prod", "APG_REGISTRY_ADDRESS": "localhost:9999"},
+			want: &Settings{Address: "localhost:9999", TokenCommand: "echo secret", Timeout: 30 * time.Second,
+				Keepalive: time.Minute, MaxConcurrentCalls: 8},
+		},
+		{
+			desc: "selected profile",
+			env:  map[string]string{"APG_REGISTRY_PROFILE": "prod"},
+			want: &Settings{Address: "registry.example.com:443", TokenCommand: "echo secret", Timeout: 30 * time.Second,
+				Keepalive: time.Minute, MaxConcurrentCalls: 8},
+		},
+		{
+			desc: "environment overrides",
+			env: map[string]string{
+				"APG_REGISTRY_ADDRESS":  "localhost:9999",
+				"APG_REGISTRY_INSECURE": "false",
+				"APG_REGISTRY_TOKEN":    "t",
+				"APG_REGISTRY_TIMEOUT":  "5s",
+				"APG_REGISTRY_RETRIES":  "10",
+			},
+			want: &Settings{Address: "localhost:9999", Token: "t", Project: "demo", Timeout: 5 * time.Second, Retries: 10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			env := map[string]string{"APG_REGISTRY_CONFIG": path}
+			for _, name := range []string{"APG_REGISTRY_PROFILE", "APG_REGISTRY_ADDRESS", "APG_REGISTRY_INSECURE",
+				"APG_REGISTRY_TOKEN", "APG_REGISTRY_TOKEN_COMMAND", "APG_REGISTRY_PROJECT", "APG_REGISTRY_TIMEOUT",
+				"APG_REGISTRY_CA_FILE", "APG_REGISTRY_CERT_FILE", "APG_REGISTRY_KEY_FILE", "APG_REGISTRY_SERVER_NAME",
+				"APG_REGISTRY_RETRIES", "APG_REGISTRY_RETRY_DELAY", "APG_REGISTRY_KEEPALIVE",
+				"APG_REGISTRY_MAX_MESSAGE_SIZE", "APG_REGISTRY_MAX_CONCURRENT_CALLS"} {
+				env[name] = ""
+			}
+			for name, value := range test.env {
+				env[name] = value
+			}
+			setenv(t, env)
+
+			got, err := ActiveSettings()
+			if err != nil {
+				t.Fatalf("ActiveSettings() returned error: %s", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ActiveSettings() returned unexpected settings (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	setenv(t, map[string]string{"APG_REGISTRY_CONFIG": path, "APG_REGISTRY_PROFILE": "missing"})
+	if _, err := ActiveSettings(); err == nil {
+		t.Errorf("ActiveSettings() succeeded with a missing profile, want error")
+	}
+}
+
+func TestSettingsToken(t *testing.T) {
+	settings := &Settings{TokenCommand: "echo secret"}
+	if token, err := settings.token(); err != nil || token != "secret" {
+		t.Errorf("token() returned %q, %v, want %q", token, err, "secret")
+	}
+	settings = &Settings{TokenCommand: "exit 1"}
+	if _, err := settings.token(); err == nil {
+		t.Errorf("token() succeeded with a failing command, want error")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// failingInvoker fails with code the first failures times that it is called.
+func failingInvoker(failures int, code codes.Code, calls *int) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls <= failures {
+			return status.Error(code, "failed")
+		}
+		return nil
+	}
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	tests := []struct {
+		desc      string
+		method    string
+		failures  int
+		code      codes.Code
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{"retried get", "/google.cloud.apigee.registry.v1.Registry/GetApi", 2, codes.Unavailable, 3, codes.OK},
+		{"retried list", "/google.cloud.apigee.registry.v1.Registry/ListApis", 1, codes.ResourceExhausted, 2, codes.OK},
+		{"too many failures", "/google.cloud.apigee.registry.v1.Registry/GetApi", 5, codes.Unavailable, 4, codes.Unavailable},
+		{"not idempotent", "/google.cloud.apigee.registry.v1.Registry/CreateApi", 1, codes.Unavailable, 1, codes.Unavailable},
+		{"not retryable", "/google.cloud.apigee.registry.v1.Registry/GetApi", 1, codes.NotFound, 1, codes.NotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			calls := 0
+			interceptor := retryInterceptor(3, time.Millisecond)
+			err := interceptor(context.Background(), test.method, nil, nil, nil, failingInvoker(test.failures, test.code, &calls))
+			if status.Code(err) != test.wantCode {
+				t.Errorf("retryInterceptor() returned %s, want %s", status.Code(err), test.wantCode)
+			}
+			if calls != test.wantCalls {
+				t.Errorf("retryInterceptor() made %d calls, want %d", calls, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryInterceptorCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	interceptor := retryInterceptor(3, time.Hour)
+	err := interceptor(ctx, "/google.cloud.apigee.registry.v1.Registry/GetApi", nil, nil, nil, failingInvoker(5, codes.Unavailable, &calls))
+	if status.Code(err) != codes.Unavailable || calls != 1 {
+		t.Errorf("retryInterceptor() returned %v after %d calls, want the first error", err, calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, max := range []time.Duration{100, 200, 400, 800} {
+		if d := backoff(100, attempt); d < max/2 || d > max {
+			t.Errorf("backoff(100, %d) returned %d, want between %d and %d", attempt, d, max/2, max)
+		}
+	}
+	if d := backoff(time.Second, 20); d > maxRetryDelay {
+		t.Errorf("backoff(1s, 20) returned %s, want at most %s", d, maxRetryDelay)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "rate limited").WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(3 * time.Second),
+	})
+	if err != nil {
+		t.Fatalf("Setup: failed to add details: %s", err)
+	}
+	if d := retryDelay(st.Err(), time.Millisecond, 0); d != 3*time.Second {
+		t.Errorf("retryDelay() returned %s for an error with RetryInfo, want %s", d, 3*time.Second)
+	}
+	if d := retryDelay(status.Error(codes.Unavailable, "unavailable"), 100, 0); d < 50 || d > 100 {
+		t.Errorf("retryDelay() returned %d for an error without RetryInfo, want between 50 and 100", d)
+	}
+}
+
+func TestTimeoutInterceptor(t *testing.T) {
+	interceptor := timeoutInterceptor(time.Minute)
+	var deadline time.Time
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}
+	if err := interceptor(context.Background(), "/m", nil, nil, nil, invoker); err != nil || deadline.IsZero() {
+		t.Errorf("timeoutInterceptor() didn't set a deadline")
+	}
+	want := time.Now().Add(time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), want)
+	defer cancel()
+	if err := interceptor(ctx, "/m", nil, nil, nil, invoker); err != nil || !deadline.Equal(want) {
+		t.Errorf("timeoutInterceptor() replaced an existing deadline")
+	}
+}
+
+func TestLimitInterceptor(t *testing.T) {
+	const limit = 2
+	interceptor := limitInterceptor(limit)
+	var active, peak int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := interceptor(context.Background(), "/m", nil, nil, nil, invoker); err != nil {
+				t.Errorf("limitInterceptor() returned error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if peak > limit {
+		t.Errorf("limitInterceptor() allowed %d calls at once, want at most %d", peak, limit)
+	}
+}
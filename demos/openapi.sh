@@ -39,6 +39,10 @@ registry upload bulk openapi \
 	--project_id openapi ~/Desktop/openapi-directory/APIs \
 	--base_uri https://github.com/APIs-guru/openapi-directory/blob/$COMMIT/APIs 
 
+# Running the same upload again only updates specs whose files changed, and
+# resumes where it stopped if it was interrupted. Add --prune to also delete
+# OpenAPI specs whose files were removed from the directory.
+
 # The openapi project was automatically created. Here we'll use an
 # update-project call to set a few properties of the project.
 apg registry update-project \
@@ -68,7 +68,13 @@ func (s *RegistryServer) CreateApi(ctx context.Context, req *rpc.CreateApiReques
 		return nil, invalidArgumentError(err)
 	}
 
-	if err := db.SaveApi(ctx, api); err != nil {
+	// The quota is checked in the transaction that saves the API (see checkCount).
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkApiQuota(ctx, db, parent); err != nil {
+			return err
+		}
+		return db.SaveApi(ctx, api)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -160,6 +166,7 @@ func (s *RegistryServer) ListApis(ctx context.Context, req *rpc.ListApisRequest)
 	listing, err := db.ListApis(ctx, parent, dao.PageOptions{
 		Size:   req.GetPageSize(),
 		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
 		Token:  req.GetPageToken(),
 	})
 	if err != nil {
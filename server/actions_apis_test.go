@@ -551,6 +551,30 @@ func TestListApisResponseCodes(t *testing.T) {
 			},
 			want: codes.InvalidArgument,
 		},
+		{
+			desc: "unknown order_by field",
+			req: &rpc.ListApisRequest{
+				Parent:  "projects/-",
+				OrderBy: "popularity",
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "order_by map field",
+			req: &rpc.ListApisRequest{
+				Parent:  "projects/-",
+				OrderBy: "labels",
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "invalid order_by direction",
+			req: &rpc.ListApisRequest{
+				Parent:  "projects/-",
+				OrderBy: "display_name up",
+			},
+			want: codes.InvalidArgument,
+		},
 	}
 
 	for _, test := range tests {
@@ -565,6 +589,73 @@ func TestListApisResponseCodes(t *testing.T) {
 	}
 }
 
+func TestListApisOrdering(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedApis(ctx, t, server,
+		&rpc.Api{Name: "projects/my-project/apis/a", DisplayName: "Bravo", Availability: "GA"},
+		&rpc.Api{Name: "projects/my-project/apis/b", DisplayName: "Alpha", Availability: "GA"},
+		&rpc.Api{Name: "projects/my-project/apis/c", DisplayName: "Charlie", Availability: "Preview"},
+		&rpc.Api{Name: "projects/my-project/apis/d", DisplayName: "Alpha", Availability: "Preview"},
+	)
+
+	tests := []struct {
+		orderBy string
+		want    []string
+	}{
+		{"display_name", []string{"b", "d", "a", "c"}},
+		{"display_name desc", []string{"c", "a", "b", "d"}},
+		{"availability desc, display_name", []string{"d", "c", "b", "a"}},
+		{"name desc", []string{"d", "c", "b", "a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.orderBy, func(t *testing.T) {
+			// List one API per page to check that ordering is stable across pages.
+			req := &rpc.ListApisRequest{
+				Parent:   "projects/my-project",
+				PageSize: 1,
+				OrderBy:  test.orderBy,
+			}
+			got := make([]string, 0)
+			for {
+				resp, err := server.ListApis(ctx, req)
+				if err != nil {
+					t.Fatalf("ListApis(%+v) returned error: %s", req, err)
+				}
+				for _, api := range resp.GetApis() {
+					got = append(got, strings.TrimPrefix(api.GetName(), "projects/my-project/apis/"))
+				}
+				if resp.GetNextPageToken() == "" {
+					break
+				}
+				req.PageToken = resp.GetNextPageToken()
+			}
+
+			if !cmp.Equal(test.want, got) {
+				t.Errorf("ListApis(order_by=%q) returned unexpected diff (-want +got):\n%s", test.orderBy, cmp.Diff(test.want, got))
+			}
+		})
+	}
+
+	t.Run("order_by changed between pages", func(t *testing.T) {
+		req := &rpc.ListApisRequest{
+			Parent:   "projects/my-project",
+			PageSize: 1,
+			OrderBy:  "display_name",
+		}
+		resp, err := server.ListApis(ctx, req)
+		if err != nil {
+			t.Fatalf("ListApis(%+v) returned error: %s", req, err)
+		}
+		req.PageToken = resp.GetNextPageToken()
+		req.OrderBy = "display_name desc"
+		if _, err := server.ListApis(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListApis(%+v) returned status code %q, want %q: %v", req, status.Code(err), codes.InvalidArgument, err)
+		}
+	})
+}
+
 func TestListApisSequence(t *testing.T) {
 	ctx := context.Background()
 	server := defaultTestServer(t)
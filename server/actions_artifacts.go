@@ -98,12 +98,21 @@ func (s *RegistryServer) CreateArtifact(ctx context.Context, req *rpc.CreateArti
 		}
 	}
 
+	// Quotas are checked in the transaction that saves the artifact (see checkCount).
+	project := names.Project{ProjectID: name.ProjectID()}
 	artifact := models.NewArtifact(name, req.GetArtifact())
-	if err := db.SaveArtifact(ctx, artifact); err != nil {
-		return nil, err
-	}
-
-	if err := db.SaveArtifactContents(ctx, artifact, req.Artifact.GetContents()); err != nil {
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkArtifactQuota(ctx, db, project); err != nil {
+			return err
+		}
+		if err := s.checkContentsQuota(ctx, db, project, int64(len(req.Artifact.GetContents())), 0); err != nil {
+			return err
+		}
+		if err := db.SaveArtifact(ctx, artifact); err != nil {
+			return err
+		}
+		return db.SaveArtifactContents(ctx, artifact, req.Artifact.GetContents())
+	}); err != nil {
 		return nil, err
 	}
 
@@ -157,17 +166,17 @@ func (s *RegistryServer) GetArtifact(ctx context.Context, req *rpc.GetArtifactRe
 		return nil, invalidArgumentError(err)
 	}
 
-	artifact, err := db.GetArtifact(ctx, name)
+	v, err := newView(&rpc.Artifact{}, req.GetView(), req.GetReadMask())
 	if err != nil {
 		return nil, err
 	}
 
-	message, err := artifact.BasicMessage()
+	artifact, err := db.GetArtifact(ctx, name)
 	if err != nil {
-		return nil, internalError(err)
+		return nil, err
 	}
 
-	return message, nil
+	return v.artifactMessage(ctx, db, artifact)
 }
 
 // GetArtifactContents handles the corresponding API request.
@@ -189,15 +198,18 @@ func (s *RegistryServer) GetArtifactContents(ctx context.Context, req *rpc.GetAr
 		return nil, err
 	}
 
-	blob, err := db.GetArtifactContents(ctx, name)
-	if err != nil {
-		return nil, err
-	}
-
-	return &httpbody.HttpBody{
-		ContentType: artifact.MimeType,
-		Data:        blob.Contents,
-	}, nil
+	return s.contentsResponse(ctx, contents{
+		key:      name.String(),
+		hash:     artifact.Hash,
+		mimeType: artifact.MimeType,
+		load: func() ([]byte, error) {
+			blob, err := db.GetArtifactContents(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			return blob.Contents, nil
+		},
+	})
 }
 
 // ListArtifacts handles the corresponding API request.
@@ -222,30 +234,40 @@ func (s *RegistryServer) ListArtifacts(ctx context.Context, req *rpc.ListArtifac
 		return nil, invalidArgumentError(err)
 	}
 
+	v, err := newView(&rpc.Artifact{}, req.GetView(), req.GetReadMask())
+	if err != nil {
+		return nil, err
+	}
+	v = v.forList()
+
 	var listing dao.ArtifactList
 	switch parent := parent.(type) {
 	case names.Project:
 		listing, err = db.ListProjectArtifacts(ctx, parent, dao.PageOptions{
 			Size:   req.GetPageSize(),
 			Filter: req.GetFilter(),
+			Order:  req.GetOrderBy(),
 			Token:  req.GetPageToken(),
 		})
 	case names.Api:
 		listing, err = db.ListApiArtifacts(ctx, parent, dao.PageOptions{
 			Size:   req.GetPageSize(),
 			Filter: req.GetFilter(),
+			Order:  req.GetOrderBy(),
 			Token:  req.GetPageToken(),
 		})
 	case names.Version:
 		listing, err = db.ListVersionArtifacts(ctx, parent, dao.PageOptions{
 			Size:   req.GetPageSize(),
 			Filter: req.GetFilter(),
+			Order:  req.GetOrderBy(),
 			Token:  req.GetPageToken(),
 		})
 	case names.Spec:
 		listing, err = db.ListSpecArtifacts(ctx, parent, dao.PageOptions{
 			Size:   req.GetPageSize(),
 			Filter: req.GetFilter(),
+			Order:  req.GetOrderBy(),
 			Token:  req.GetPageToken(),
 		})
 	}
@@ -259,9 +281,9 @@ func (s *RegistryServer) ListArtifacts(ctx context.Context, req *rpc.ListArtifac
 	}
 
 	for i, artifact := range listing.Artifacts {
-		response.Artifacts[i], err = artifact.BasicMessage()
+		response.Artifacts[i], err = v.artifactMessage(ctx, db, &artifact)
 		if err != nil {
-			return nil, internalError(err)
+			return nil, err
 		}
 	}
 
@@ -283,19 +305,28 @@ func (s *RegistryServer) ReplaceArtifact(ctx context.Context, req *rpc.ReplaceAr
 	}
 
 	// Replacement should only succeed on artifacts that currently exist.
-	if _, err := db.GetArtifact(ctx, name); err != nil {
+	existing, err := db.GetArtifact(ctx, name)
+	if err != nil {
 		return nil, err
 	}
 
+	project := names.Project{ProjectID: name.ProjectID()}
 	artifact := models.NewArtifact(name, req.GetArtifact())
-	if err := db.SaveArtifact(ctx, artifact); err != nil {
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkContentsQuota(ctx, db, project, int64(len(req.Artifact.GetContents())), int64(existing.SizeInBytes)); err != nil {
+			return err
+		}
+		if err := db.SaveArtifact(ctx, artifact); err != nil {
+			return err
+		}
+		if err := db.SaveArtifactContents(ctx, artifact, req.Artifact.GetContents()); err != nil {
+			return internalError(err)
+		}
+		return nil
+	}); err != nil {
 		return nil, err
 	}
 
-	if err := db.SaveArtifactContents(ctx, artifact, req.Artifact.GetContents()); err != nil {
-		return nil, internalError(err)
-	}
-
 	s.notify(rpc.Notification_UPDATED, name.String())
 	return artifact.BasicMessage()
 }
@@ -27,6 +27,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 var (
@@ -324,6 +325,38 @@ func TestGetArtifact(t *testing.T) {
 			},
 			want: basicArtifact,
 		},
+		{
+			desc: "full view",
+			seed: fullArtifact,
+			req: &rpc.GetArtifactRequest{
+				Name: fullArtifact.Name,
+				View: rpc.View_FULL,
+			},
+			want: fullArtifact,
+		},
+		{
+			desc: "read mask",
+			seed: fullArtifact,
+			req: &rpc.GetArtifactRequest{
+				Name:     fullArtifact.Name,
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "mime_type"}},
+			},
+			want: &rpc.Artifact{
+				Name:     fullArtifact.Name,
+				MimeType: fullArtifact.MimeType,
+			},
+		},
+		{
+			desc: "read mask including contents",
+			seed: fullArtifact,
+			req: &rpc.GetArtifactRequest{
+				Name:     fullArtifact.Name,
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"contents"}},
+			},
+			want: &rpc.Artifact{
+				Contents: fullArtifact.Contents,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -372,6 +405,15 @@ func TestGetArtifactResponseCodes(t *testing.T) {
 			},
 			want: codes.OK,
 		},
+		{
+			desc: "invalid read mask",
+			seed: &rpc.Artifact{Name: "projects/my-project/artifacts/my-artifact"},
+			req: &rpc.GetArtifactRequest{
+				Name:     "projects/my-project/artifacts/my-artifact",
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"this field does not exist"}},
+			},
+			want: codes.InvalidArgument,
+		},
 	}
 
 	for _, test := range tests {
@@ -577,6 +619,30 @@ func TestListArtifacts(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "full view",
+			seed: []*rpc.Artifact{fullArtifact},
+			req: &rpc.ListArtifactsRequest{
+				Parent: "projects/my-project/apis/my-api/versions/v1",
+				View:   rpc.View_FULL,
+			},
+			want: &rpc.ListArtifactsResponse{
+				Artifacts: []*rpc.Artifact{fullArtifact},
+			},
+		},
+		{
+			desc: "read mask",
+			seed: []*rpc.Artifact{fullArtifact},
+			req: &rpc.ListArtifactsRequest{
+				Parent:   "projects/my-project/apis/my-api/versions/v1",
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "hash"}},
+			},
+			want: &rpc.ListArtifactsResponse{
+				Artifacts: []*rpc.Artifact{
+					{Name: fullArtifact.Name, Hash: fullArtifact.Hash},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,71 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/names"
+)
+
+// ListAuditEvents handles the corresponding API request.
+func (s *RegistryServer) ListAuditEvents(ctx context.Context, req *rpc.ListAuditEventsRequest) (*rpc.ListAuditEventsResponse, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetPageSize() < 0 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid page_size %d: must not be negative", req.GetPageSize()))
+	} else if req.GetPageSize() > 1000 {
+		req.PageSize = 1000
+	} else if req.GetPageSize() == 0 {
+		req.PageSize = 50
+	}
+
+	parent, err := names.ParseProject(req.GetParent())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	listing, err := db.ListAuditEvents(ctx, parent, dao.PageOptions{
+		Size:   req.GetPageSize(),
+		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
+		Token:  req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &rpc.ListAuditEventsResponse{
+		AuditEvents:   make([]*rpc.AuditEvent, len(listing.AuditEvents)),
+		NextPageToken: listing.Token,
+	}
+
+	for i, event := range listing.AuditEvents {
+		response.AuditEvents[i], err = event.Message()
+		if err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/auth"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// audited calls a method through the audit interceptor.
+func audited(ctx context.Context, s *RegistryServer, method string, req interface{}, call func(context.Context, interface{}) (interface{}, error)) error {
+	info := &grpc.UnaryServerInfo{FullMethod: registryServicePrefix + method}
+	_, err := s.auditHandler(ctx, req, info, call)
+	return err
+}
+
+func TestAuditEvents(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), "someone@example.com")
+	server := defaultTestServer(t)
+	seedProjects(ctx, t, server, &rpc.Project{Name: "projects/my-project"})
+
+	createReq := &rpc.CreateApiRequest{
+		Parent: "projects/my-project",
+		ApiId:  "my-api",
+		Api:    &rpc.Api{},
+	}
+	if err := audited(ctx, server, "CreateApi", createReq, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.CreateApi(ctx, req.(*rpc.CreateApiRequest))
+	}); err != nil {
+		t.Fatalf("CreateApi(%+v) returned error: %s", createReq, err)
+	}
+
+	updateReq := &rpc.UpdateApiRequest{
+		Api:        &rpc.Api{Name: "projects/my-project/apis/my-api", DisplayName: "My API"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name"}},
+	}
+	if err := audited(ctx, server, "UpdateApi", updateReq, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.UpdateApi(ctx, req.(*rpc.UpdateApiRequest))
+	}); err != nil {
+		t.Fatalf("UpdateApi(%+v) returned error: %s", updateReq, err)
+	}
+
+	getReq := &rpc.GetApiRequest{Name: "projects/my-project/apis/my-api"}
+	if err := audited(ctx, server, "GetApi", getReq, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.GetApi(ctx, req.(*rpc.GetApiRequest))
+	}); err != nil {
+		t.Fatalf("GetApi(%+v) returned error: %s", getReq, err)
+	}
+
+	deleteReq := &rpc.DeleteApiRequest{Name: "projects/my-project/apis/missing"}
+	if err := audited(ctx, server, "DeleteApi", deleteReq, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return server.DeleteApi(ctx, req.(*rpc.DeleteApiRequest))
+	}); err == nil {
+		t.Fatalf("DeleteApi(%+v) succeeded, expected error", deleteReq)
+	}
+
+	want := []*rpc.AuditEvent{
+		{
+			Method:    "CreateApi",
+			Resource:  "projects/my-project/apis/my-api",
+			Principal: "someone@example.com",
+		},
+		{
+			Method:     "UpdateApi",
+			Resource:   "projects/my-project/apis/my-api",
+			Principal:  "someone@example.com",
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name"}},
+		},
+		{
+			Method:    "DeleteApi",
+			Resource:  "projects/my-project/apis/missing",
+			Principal: "someone@example.com",
+			Code:      int32(codes.NotFound),
+		},
+	}
+
+	listReq := &rpc.ListAuditEventsRequest{Parent: "projects/my-project"}
+	got, err := server.ListAuditEvents(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListAuditEvents(%+v) returned error: %s", listReq, err)
+	}
+
+	opts := cmp.Options{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(new(rpc.AuditEvent), "name", "event_time", "request", "message"),
+	}
+	if !cmp.Equal(want, got.GetAuditEvents(), opts) {
+		t.Errorf("ListAuditEvents(%+v) returned unexpected diff (-want +got):\n%s", listReq, cmp.Diff(want, got.GetAuditEvents(), opts))
+	}
+
+	for _, e := range got.GetAuditEvents() {
+		if !strings.HasPrefix(e.GetName(), "projects/my-project/auditEvents/") {
+			t.Errorf("ListAuditEvents(%+v) returned unexpected name %q", listReq, e.GetName())
+		}
+		if !strings.Contains(e.GetRequest(), "my-api") && e.GetMethod() != "DeleteApi" {
+			t.Errorf("ListAuditEvents(%+v) returned unexpected request summary %q", listReq, e.GetRequest())
+		}
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		req := &rpc.ListAuditEventsRequest{
+			Parent: "projects/-",
+			Filter: "method == 'UpdateApi' && principal.endsWith('@example.com')",
+		}
+		got, err := server.ListAuditEvents(ctx, req)
+		if err != nil {
+			t.Fatalf("ListAuditEvents(%+v) returned error: %s", req, err)
+		}
+		if len(got.GetAuditEvents()) != 1 {
+			t.Errorf("ListAuditEvents(%+v) returned %d events, expected 1", req, len(got.GetAuditEvents()))
+		}
+	})
+}
+
+func TestAuditDeniedCalls(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedProjects(ctx, t, server, &rpc.Project{Name: "projects/my-project"})
+
+	req := &rpc.DeleteApiRequest{Name: "projects/my-project/apis/my-api"}
+	method := registryServicePrefix + "DeleteApi"
+	server.recordDeniedCall(ctx, method, req, status.Error(codes.Unauthenticated, "missing credentials"))
+	server.recordDeniedCall(auth.NewContext(ctx, auth.Anonymous), method, req, status.Error(codes.PermissionDenied, "denied"))
+	server.recordDeniedCall(auth.NewContext(ctx, "someone@example.com"), method, req, status.Error(codes.PermissionDenied, "denied"))
+
+	want := []*rpc.AuditEvent{
+		{
+			Method:    "DeleteApi",
+			Resource:  "projects/my-project/apis/my-api",
+			Principal: "someone@example.com",
+			Code:      int32(codes.PermissionDenied),
+		},
+	}
+
+	listReq := &rpc.ListAuditEventsRequest{Parent: "projects/my-project"}
+	got, err := server.ListAuditEvents(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListAuditEvents(%+v) returned error: %s", listReq, err)
+	}
+
+	opts := cmp.Options{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(new(rpc.AuditEvent), "name", "event_time", "request", "message"),
+	}
+	if !cmp.Equal(want, got.GetAuditEvents(), opts) {
+		t.Errorf("ListAuditEvents(%+v) returned unexpected diff (-want +got):\n%s", listReq, cmp.Diff(want, got.GetAuditEvents(), opts))
+	}
+}
+
+func TestAuditOperations(t *testing.T) {
+	ctx := auth.NewContext(context.Background(), "someone@example.com")
+	server := defaultTestServer(t)
+	seedProjects(ctx, t, server, &rpc.Project{Name: "projects/my-project"})
+
+	req := &rpc.DeleteProjectRequest{Name: "projects/my-project"}
+	var op *longrunning.Operation
+	if err := audited(ctx, server, "DeleteProject", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		var err error
+		op, err = server.DeleteProject(ctx, req.(*rpc.DeleteProjectRequest))
+		return op, err
+	}); err != nil {
+		t.Fatalf("DeleteProject(%+v) returned error: %s", req, err)
+	}
+	waitOperation(ctx, t, server, op)
+	// Events are recorded after operations are saved as done.
+	server.operations.wait()
+
+	want := []*rpc.AuditEvent{
+		{
+			Method:    "DeleteProject",
+			Resource:  "projects/my-project",
+			Principal: "someone@example.com",
+		},
+	}
+
+	listReq := &rpc.ListAuditEventsRequest{Parent: "projects/my-project"}
+	got, err := server.ListAuditEvents(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListAuditEvents(%+v) returned error: %s", listReq, err)
+	}
+
+	opts := cmp.Options{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(new(rpc.AuditEvent), "name", "event_time", "request", "message"),
+	}
+	if !cmp.Equal(want, got.GetAuditEvents(), opts) {
+		t.Errorf("ListAuditEvents(%+v) returned unexpected diff (-want +got):\n%s", listReq, cmp.Diff(want, got.GetAuditEvents(), opts))
+	}
+}
+
+func TestSummarizeRequest(t *testing.T) {
+	req := &rpc.CreateApiSpecRequest{
+		Parent: "projects/p/apis/a/versions/v",
+		ApiSpec: &rpc.ApiSpec{
+			MimeType: "application/x.openapi;version=3",
+			Contents: []byte("openapi: 3.0.0"),
+		},
+	}
+	summary := summarizeRequest(req)
+	if strings.Contains(summary, "contents") {
+		t.Errorf("summarizeRequest(%+v) returned %q, expected contents to be removed", req, summary)
+	}
+	if !strings.Contains(summary, "x.openapi") {
+		t.Errorf("summarizeRequest(%+v) returned %q, expected mime type", req, summary)
+	}
+	if len(req.GetApiSpec().GetContents()) == 0 {
+		t.Errorf("summarizeRequest(%+v) modified the request", req)
+	}
+}
+
+func TestAuditPrincipal(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+	spoofed := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-authz-user", "admin@example.com"))
+	tests := []struct {
+		desc string
+		ctx  context.Context
+		want string
+	}{
+		{"authenticated", auth.NewContext(spoofed, "someone@example.com"), "someone@example.com"},
+		{"unauthenticated with header", peer.NewContext(spoofed, &peer.Peer{Addr: addr}), "anonymous (10.0.0.1:1234)"},
+		{"unauthenticated without peer", spoofed, "anonymous"},
+	}
+	for _, test := range tests {
+		if got := principal(test.ctx); got != test.want {
+			t.Errorf("principal() for %s returned %q, expected %q", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// ListApiDeploymentRevisions handles the corresponding API request.
+func (s *RegistryServer) ListApiDeploymentRevisions(ctx context.Context, req *rpc.ListApiDeploymentRevisionsRequest) (*rpc.ListApiDeploymentRevisionsResponse, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetPageSize() < 0 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid page_size %d: must not be negative", req.GetPageSize()))
+	} else if req.GetPageSize() > 1000 {
+		req.PageSize = 1000
+	} else if req.GetPageSize() == 0 {
+		req.PageSize = 50
+	}
+
+	parent, err := names.ParseDeployment(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	listing, err := db.ListDeploymentRevisions(ctx, parent, dao.PageOptions{
+		Size:  req.GetPageSize(),
+		Order: req.GetOrderBy(),
+		Token: req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := db.GetDeploymentRevisionTags(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &rpc.ListApiDeploymentRevisionsResponse{
+		ApiDeployments: make([]*rpc.ApiDeployment, len(listing.Deployments)),
+		NextPageToken:  listing.Token,
+	}
+
+	for i, deployment := range listing.Deployments {
+		response.ApiDeployments[i], err = deployment.Message(deployment.RevisionName())
+		if err != nil {
+			return nil, internalError(err)
+		}
+		response.ApiDeployments[i].RevisionTags = tags[deployment.RevisionName()]
+	}
+
+	return response, nil
+}
+
+// DeleteApiDeploymentRevision handles the corresponding API request.
+func (s *RegistryServer) DeleteApiDeploymentRevision(ctx context.Context, req *rpc.DeleteApiDeploymentRevisionRequest) (*empty.Empty, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	name, err := names.ParseDeploymentRevision(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	revision, err := db.GetDeploymentRevision(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the retrieved deployment revision name, which has a non-tag revision ID.
+	// This is necessary to ensure the actual revision is deleted.
+	name, err = names.ParseDeploymentRevision(revision.RevisionName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	if err := db.DeleteDeploymentRevision(ctx, name); err != nil {
+		return nil, internalError(err)
+	}
+
+	s.notify(rpc.Notification_DELETED, name.String())
+	return &empty.Empty{}, nil
+}
+
+// TagApiDeploymentRevision handles the corresponding API request.
+func (s *RegistryServer) TagApiDeploymentRevision(ctx context.Context, req *rpc.TagApiDeploymentRevisionRequest) (*rpc.ApiDeployment, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetTag() == "" {
+		return nil, invalidArgumentError(fmt.Errorf("invalid tag %q, must not be empty", req.GetTag()))
+	} else if len(req.GetTag()) > 40 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid tag %q, must be 40 characters or less", req.GetTag()))
+	}
+
+	// Parse the requested deployment revision name, which may include a tag name.
+	name, err := names.ParseDeploymentRevision(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	revision, err := db.GetDeploymentRevision(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the retrieved deployment revision name, which has a non-tag revision ID.
+	// This is necessary to ensure the new tag is associated with a revision ID, not another tag.
+	name, err = names.ParseDeploymentRevision(revision.RevisionName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	tag := models.NewDeploymentRevisionTag(name, req.GetTag())
+	if err := db.SaveDeploymentRevisionTag(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	message, err := revision.Message(tag.String())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.notify(rpc.Notification_UPDATED, name.String())
+	return message, nil
+}
+
+// RollbackApiDeployment handles the corresponding API request.
+func (s *RegistryServer) RollbackApiDeployment(ctx context.Context, req *rpc.RollbackApiDeploymentRequest) (*rpc.ApiDeployment, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetRevisionId() == "" {
+		return nil, invalidArgumentError(fmt.Errorf("invalid revision ID %q, must not be empty", req.GetRevisionId()))
+	}
+
+	parent, err := names.ParseDeployment(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	// Get the target deployment revision to use as a base for the new rollback revision.
+	target, err := db.GetDeploymentRevision(ctx, parent.Revision(req.GetRevisionId()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Save a new rollback revision based on the target revision.
+	rollback := target.NewRevision()
+	if err := db.SaveDeploymentRevision(ctx, rollback); err != nil {
+		return nil, err
+	}
+
+	message, err := rollback.Message(rollback.RevisionName())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.notify(rpc.Notification_CREATED, rollback.RevisionName())
+	return message, nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// CreateApiDeployment handles the corresponding API request.
+func (s *RegistryServer) CreateApiDeployment(ctx context.Context, req *rpc.CreateApiDeploymentRequest) (*rpc.ApiDeployment, error) {
+	parent, err := names.ParseApi(req.GetParent())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	if req.GetApiDeployment() == nil {
+		return nil, invalidArgumentError(fmt.Errorf("invalid api_deployment %+v: body must be provided", req.GetApiDeployment()))
+	}
+
+	name := parent.Deployment(req.GetApiDeploymentId())
+	if name.DeploymentID == "" {
+		name.DeploymentID = names.GenerateID()
+	}
+
+	return s.createDeployment(ctx, name, req.GetApiDeployment())
+}
+
+func (s *RegistryServer) createDeployment(ctx context.Context, name names.Deployment, body *rpc.ApiDeployment) (*rpc.ApiDeployment, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if _, err := db.GetDeployment(ctx, name); err == nil {
+		return nil, alreadyExistsError(fmt.Errorf("API deployment %q already exists", name))
+	} else if !isNotFound(err) {
+		return nil, err
+	}
+
+	if err := name.Validate(); err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	if err := validateApiSpecRevision(body.GetApiSpecRevision()); err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	// Creation should only succeed when the parent exists.
+	if _, err := db.GetApi(ctx, name.Api()); err != nil {
+		return nil, err
+	}
+
+	deployment, err := models.NewDeployment(name, body)
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	if err := db.SaveDeploymentRevision(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	message, err := deployment.Message(name.String())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.notify(rpc.Notification_CREATED, deployment.RevisionName())
+	return message, nil
+}
+
+// validateApiSpecRevision returns an error if a deployment's spec reference is not a spec revision name.
+func validateApiSpecRevision(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := names.ParseSpecRevision(name); err != nil {
+		return fmt.Errorf("invalid api_spec_revision %q: must be a spec name including a revision ID", name)
+	}
+	return nil
+}
+
+// DeleteApiDeployment handles the corresponding API request.
+func (s *RegistryServer) DeleteApiDeployment(ctx context.Context, req *rpc.DeleteApiDeploymentRequest) (*empty.Empty, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	name, err := names.ParseDeployment(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	// Deletion should only succeed on API deployments that currently exist.
+	if _, err := db.GetDeployment(ctx, name); err != nil {
+		return nil, err
+	}
+
+	if err := db.DeleteDeployment(ctx, name); err != nil {
+		return nil, err
+	}
+
+	s.notify(rpc.Notification_DELETED, name.String())
+	return &empty.Empty{}, nil
+}
+
+// GetApiDeployment handles the corresponding API request.
+func (s *RegistryServer) GetApiDeployment(ctx context.Context, req *rpc.GetApiDeploymentRequest) (*rpc.ApiDeployment, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	var deployment *models.Deployment
+	if name, err := names.ParseDeployment(req.GetName()); err == nil {
+		if deployment, err = db.GetDeployment(ctx, name); err != nil {
+			return nil, err
+		}
+	} else if name, err := names.ParseDeploymentRevision(req.GetName()); err == nil {
+		if deployment, err = db.GetDeploymentRevision(ctx, name); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, invalidArgumentError(fmt.Errorf("invalid resource name %q, must be an API deployment or revision", req.GetName()))
+	}
+
+	message, err := deployment.Message(req.GetName())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return message, nil
+}
+
+// ListApiDeployments handles the corresponding API request.
+func (s *RegistryServer) ListApiDeployments(ctx context.Context, req *rpc.ListApiDeploymentsRequest) (*rpc.ListApiDeploymentsResponse, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetPageSize() < 0 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid page_size %d: must not be negative", req.GetPageSize()))
+	} else if req.GetPageSize() > 1000 {
+		req.PageSize = 1000
+	} else if req.GetPageSize() == 0 {
+		req.PageSize = 50
+	}
+
+	parent, err := names.ParseApi(req.GetParent())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	listing, err := db.ListDeployments(ctx, parent, dao.PageOptions{
+		Size:   req.GetPageSize(),
+		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
+		Token:  req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &rpc.ListApiDeploymentsResponse{
+		ApiDeployments: make([]*rpc.ApiDeployment, len(listing.Deployments)),
+		NextPageToken:  listing.Token,
+	}
+
+	for i, deployment := range listing.Deployments {
+		response.ApiDeployments[i], err = deployment.Message(deployment.Name())
+		if err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	return response, nil
+}
+
+// UpdateApiDeployment handles the corresponding API request.
+func (s *RegistryServer) UpdateApiDeployment(ctx context.Context, req *rpc.UpdateApiDeploymentRequest) (*rpc.ApiDeployment, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetApiDeployment() == nil {
+		return nil, invalidArgumentError(fmt.Errorf("invalid api_deployment %+v: body must be provided", req.GetApiDeployment()))
+	} else if err := models.ValidateMask(req.GetApiDeployment(), req.GetUpdateMask()); err != nil {
+		return nil, invalidArgumentError(fmt.Errorf("invalid update_mask %v: %s", req.GetUpdateMask(), err))
+	}
+
+	name, err := names.ParseDeployment(req.ApiDeployment.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	deployment, err := db.GetDeployment(ctx, name)
+	if req.GetAllowMissing() && isNotFound(err) {
+		return s.createDeployment(ctx, name, req.GetApiDeployment())
+	} else if err != nil {
+		return nil, err
+	}
+
+	mask := models.ExpandMask(req.GetApiDeployment(), req.GetUpdateMask())
+	for _, field := range mask.GetPaths() {
+		if field == "api_spec_revision" {
+			if err := validateApiSpecRevision(req.ApiDeployment.GetApiSpecRevision()); err != nil {
+				return nil, invalidArgumentError(err)
+			}
+		}
+	}
+
+	// Apply the update to the deployment - possibly changing the revision ID.
+	if err := deployment.Update(req.GetApiDeployment(), mask); err != nil {
+		return nil, internalError(err)
+	}
+
+	// Save the updated/current deployment. This creates a new revision or updates the previous one.
+	if err := db.SaveDeploymentRevision(ctx, deployment); err != nil {
+		return nil, err
+	}
+
+	message, err := deployment.Message(name.String())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.notify(rpc.Notification_UPDATED, deployment.RevisionName())
+	return message, nil
+}
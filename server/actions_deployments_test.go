@@ -0,0 +1,321 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+var fullDeployment = &rpc.ApiDeployment{
+	Name:               "projects/my-project/apis/my-api/deployments/prod",
+	DisplayName:        "Production",
+	Description:        "Serves production traffic",
+	ApiSpecRevision:    "projects/my-project/apis/my-api/versions/v1/specs/openapi.yaml@1234abcd",
+	EndpointUri:        "https://api.example.com",
+	ExternalChannelUri: "https://developers.example.com",
+	IntendedAudience:   "Partners",
+	AccessGuidance:     "Request a key from the portal",
+	Environment:        "prod",
+	Gateway:            "envoy",
+	Labels:             map[string]string{"tier": "gold"},
+	Annotations:        map[string]string{"owner": "api-team"},
+}
+
+func seedDeployments(ctx context.Context, t *testing.T, s *RegistryServer, deployments ...*rpc.ApiDeployment) {
+	t.Helper()
+
+	for _, deployment := range deployments {
+		name, err := names.ParseDeployment(deployment.Name)
+		if err != nil {
+			t.Fatalf("Setup/Seeding: ParseDeployment(%q) returned error: %s", deployment.Name, err)
+		}
+
+		seedApis(ctx, t, s, &rpc.Api{
+			Name: name.Api().String(),
+		})
+
+		req := &rpc.UpdateApiDeploymentRequest{
+			ApiDeployment: deployment,
+			AllowMissing:  true,
+		}
+
+		switch _, err := s.UpdateApiDeployment(ctx, req); status.Code(err) {
+		case codes.OK, codes.AlreadyExists:
+			// ApiDeployment is now ready for use in test.
+		default:
+			t.Fatalf("Setup/Seeding: UpdateApiDeployment(%+v) returned error: %s", req, err)
+		}
+	}
+}
+
+func TestCreateApiDeployment(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedApis(ctx, t, server, &rpc.Api{Name: "projects/my-project/apis/my-api"})
+
+	req := &rpc.CreateApiDeploymentRequest{
+		Parent:          "projects/my-project/apis/my-api",
+		ApiDeploymentId: "prod",
+		ApiDeployment:   fullDeployment,
+	}
+
+	got, err := server.CreateApiDeployment(ctx, req)
+	if err != nil {
+		t.Fatalf("CreateApiDeployment(%+v) returned error: %s", req, err)
+	}
+
+	opts := cmp.Options{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(fullDeployment, "revision_id", "create_time", "revision_create_time", "revision_update_time"),
+	}
+	if !cmp.Equal(fullDeployment, got, opts) {
+		t.Errorf("CreateApiDeployment(%+v) returned unexpected diff (-want +got):\n%s", req, cmp.Diff(fullDeployment, got, opts))
+	}
+
+	getReq := &rpc.GetApiDeploymentRequest{Name: got.GetName()}
+	stored, err := server.GetApiDeployment(ctx, getReq)
+	if err != nil {
+		t.Fatalf("GetApiDeployment(%+v) returned error: %s", getReq, err)
+	}
+	if !cmp.Equal(got, stored, protocmp.Transform()) {
+		t.Errorf("GetApiDeployment(%+v) returned unexpected diff (-want +got):\n%s", getReq, cmp.Diff(got, stored, protocmp.Transform()))
+	}
+}
+
+func TestCreateApiDeploymentResponseCodes(t *testing.T) {
+	tests := []struct {
+		desc string
+		seed *rpc.Api
+		req  *rpc.CreateApiDeploymentRequest
+		want codes.Code
+	}{
+		{
+			desc: "parent not found",
+			seed: &rpc.Api{Name: "projects/my-project/apis/my-api"},
+			req: &rpc.CreateApiDeploymentRequest{
+				Parent:        "projects/my-project/apis/other-api",
+				ApiDeployment: &rpc.ApiDeployment{},
+			},
+			want: codes.NotFound,
+		},
+		{
+			desc: "missing resource body",
+			seed: &rpc.Api{Name: "projects/my-project/apis/my-api"},
+			req: &rpc.CreateApiDeploymentRequest{
+				Parent: "projects/my-project/apis/my-api",
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "spec reference without revision",
+			seed: &rpc.Api{Name: "projects/my-project/apis/my-api"},
+			req: &rpc.CreateApiDeploymentRequest{
+				Parent: "projects/my-project/apis/my-api",
+				ApiDeployment: &rpc.ApiDeployment{
+					ApiSpecRevision: "projects/my-project/apis/my-api/versions/v1/specs/openapi.yaml",
+				},
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "invalid identifier",
+			seed: &rpc.Api{Name: "projects/my-project/apis/my-api"},
+			req: &rpc.CreateApiDeploymentRequest{
+				Parent:          "projects/my-project/apis/my-api",
+				ApiDeploymentId: "this-identifier-is-invalid-because-it-exceeds-the-eighty-character-maximum-length",
+				ApiDeployment:   &rpc.ApiDeployment{},
+			},
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			server := defaultTestServer(t)
+			seedApis(ctx, t, server, test.seed)
+
+			if _, err := server.CreateApiDeployment(ctx, test.req); status.Code(err) != test.want {
+				t.Errorf("CreateApiDeployment(%+v) returned status code %q, want %q: %v", test.req, status.Code(err), test.want, err)
+			}
+		})
+	}
+}
+
+func TestListApiDeployments(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedDeployments(ctx, t, server,
+		&rpc.ApiDeployment{Name: "projects/my-project/apis/my-api/deployments/prod", Environment: "prod"},
+		&rpc.ApiDeployment{Name: "projects/my-project/apis/my-api/deployments/staging", Environment: "staging"},
+		&rpc.ApiDeployment{Name: "projects/my-project/apis/other-api/deployments/prod", Environment: "prod"},
+	)
+
+	tests := []struct {
+		desc string
+		req  *rpc.ListApiDeploymentsRequest
+		want []string
+	}{
+		{
+			desc: "single api",
+			req:  &rpc.ListApiDeploymentsRequest{Parent: "projects/my-project/apis/my-api"},
+			want: []string{
+				"projects/my-project/apis/my-api/deployments/prod",
+				"projects/my-project/apis/my-api/deployments/staging",
+			},
+		},
+		{
+			desc: "across apis with filter",
+			req: &rpc.ListApiDeploymentsRequest{
+				Parent: "projects/my-project/apis/-",
+				Filter: "environment == 'prod'",
+			},
+			want: []string{
+				"projects/my-project/apis/my-api/deployments/prod",
+				"projects/my-project/apis/other-api/deployments/prod",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := server.ListApiDeployments(ctx, test.req)
+			if err != nil {
+				t.Fatalf("ListApiDeployments(%+v) returned error: %s", test.req, err)
+			}
+
+			gotNames := make([]string, len(got.GetApiDeployments()))
+			for i, d := range got.GetApiDeployments() {
+				gotNames[i] = d.GetName()
+			}
+			if !cmp.Equal(test.want, gotNames) {
+				t.Errorf("ListApiDeployments(%+v) returned unexpected diff (-want +got):\n%s", test.req, cmp.Diff(test.want, gotNames))
+			}
+		})
+	}
+}
+
+func TestUpdateApiDeploymentRevisions(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedDeployments(ctx, t, server, fullDeployment)
+
+	original, err := server.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{Name: fullDeployment.GetName()})
+	if err != nil {
+		t.Fatalf("Setup: GetApiDeployment() returned error: %s", err)
+	}
+
+	// Metadata changes update the current revision.
+	req := &rpc.UpdateApiDeploymentRequest{
+		ApiDeployment: &rpc.ApiDeployment{Name: fullDeployment.GetName(), Description: "Updated"},
+		UpdateMask:    &fieldmaskpb.FieldMask{Paths: []string{"description"}},
+	}
+	got, err := server.UpdateApiDeployment(ctx, req)
+	if err != nil {
+		t.Fatalf("UpdateApiDeployment(%+v) returned error: %s", req, err)
+	}
+	if got.GetRevisionId() != original.GetRevisionId() {
+		t.Errorf("UpdateApiDeployment(%+v) changed revision ID from %q to %q", req, original.GetRevisionId(), got.GetRevisionId())
+	}
+
+	// Endpoint changes create a new revision.
+	req = &rpc.UpdateApiDeploymentRequest{
+		ApiDeployment: &rpc.ApiDeployment{Name: fullDeployment.GetName(), EndpointUri: "https://api2.example.com"},
+		UpdateMask:    &fieldmaskpb.FieldMask{Paths: []string{"endpoint_uri"}},
+	}
+	revised, err := server.UpdateApiDeployment(ctx, req)
+	if err != nil {
+		t.Fatalf("UpdateApiDeployment(%+v) returned error: %s", req, err)
+	}
+	if revised.GetRevisionId() == original.GetRevisionId() {
+		t.Errorf("UpdateApiDeployment(%+v) kept revision ID %q, want a new revision", req, revised.GetRevisionId())
+	}
+
+	listReq := &rpc.ListApiDeploymentRevisionsRequest{Name: fullDeployment.GetName()}
+	listing, err := server.ListApiDeploymentRevisions(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListApiDeploymentRevisions(%+v) returned error: %s", listReq, err)
+	}
+	if len(listing.GetApiDeployments()) != 2 {
+		t.Fatalf("ListApiDeploymentRevisions(%+v) returned %d revisions, want 2", listReq, len(listing.GetApiDeployments()))
+	}
+
+	// Tags resolve to the tagged revision.
+	tagReq := &rpc.TagApiDeploymentRevisionRequest{
+		Name: fullDeployment.GetName() + "@" + original.GetRevisionId(),
+		Tag:  "stable",
+	}
+	if _, err := server.TagApiDeploymentRevision(ctx, tagReq); err != nil {
+		t.Fatalf("TagApiDeploymentRevision(%+v) returned error: %s", tagReq, err)
+	}
+	tagged, err := server.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{Name: fullDeployment.GetName() + "@stable"})
+	if err != nil {
+		t.Fatalf("GetApiDeployment() of tag returned error: %s", err)
+	}
+	if tagged.GetEndpointUri() != fullDeployment.GetEndpointUri() {
+		t.Errorf("GetApiDeployment() of tag returned endpoint %q, want %q", tagged.GetEndpointUri(), fullDeployment.GetEndpointUri())
+	}
+
+	// Rollbacks create a new revision from the target revision.
+	rollbackReq := &rpc.RollbackApiDeploymentRequest{Name: fullDeployment.GetName(), RevisionId: "stable"}
+	rollback, err := server.RollbackApiDeployment(ctx, rollbackReq)
+	if err != nil {
+		t.Fatalf("RollbackApiDeployment(%+v) returned error: %s", rollbackReq, err)
+	}
+	current, err := server.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{Name: fullDeployment.GetName()})
+	if err != nil {
+		t.Fatalf("GetApiDeployment() returned error: %s", err)
+	}
+	if current.GetRevisionId() != rollback.GetRevisionId() || current.GetEndpointUri() != fullDeployment.GetEndpointUri() {
+		t.Errorf("GetApiDeployment() after rollback returned %+v, want revision %q with endpoint %q", current, rollback.GetRevisionId(), fullDeployment.GetEndpointUri())
+	}
+
+	// Deleted revisions can no longer be retrieved.
+	deleteReq := &rpc.DeleteApiDeploymentRevisionRequest{Name: fullDeployment.GetName() + "@" + revised.GetRevisionId()}
+	if _, err := server.DeleteApiDeploymentRevision(ctx, deleteReq); err != nil {
+		t.Fatalf("DeleteApiDeploymentRevision(%+v) returned error: %s", deleteReq, err)
+	}
+	if _, err := server.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{Name: deleteReq.GetName()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetApiDeployment() of deleted revision returned status code %q, want %q: %v", status.Code(err), codes.NotFound, err)
+	}
+}
+
+func TestDeleteApiDeployment(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedDeployments(ctx, t, server, fullDeployment)
+
+	req := &rpc.DeleteApiDeploymentRequest{Name: fullDeployment.GetName()}
+	if _, err := server.DeleteApiDeployment(ctx, req); err != nil {
+		t.Fatalf("DeleteApiDeployment(%+v) returned error: %s", req, err)
+	}
+
+	if _, err := server.GetApiDeployment(ctx, &rpc.GetApiDeploymentRequest{Name: req.GetName()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetApiDeployment(%q) returned status code %q, want %q: %v", req.GetName(), status.Code(err), codes.NotFound, err)
+	}
+
+	if _, err := server.DeleteApiDeployment(ctx, req); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteApiDeployment(%+v) of deleted deployment returned status code %q, want %q: %v", req, status.Code(err), codes.NotFound, err)
+	}
+}
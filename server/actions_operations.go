@@ -0,0 +1,209 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxOperationWait is the longest time that WaitOperation waits for an operation.
+	maxOperationWait = time.Minute
+	// operationPollInterval is how often WaitOperation checks whether an operation is done.
+	operationPollInterval = 100 * time.Millisecond
+)
+
+// ListOperations handles the corresponding API request.
+// The name in the request is the parent project of the operations.
+func (s *RegistryServer) ListOperations(ctx context.Context, req *longrunning.ListOperationsRequest) (*longrunning.ListOperationsResponse, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetPageSize() < 0 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid page_size %d: must not be negative", req.GetPageSize()))
+	} else if req.GetPageSize() > 1000 {
+		req.PageSize = 1000
+	} else if req.GetPageSize() == 0 {
+		req.PageSize = 50
+	}
+
+	// Names that include the operations collection are also accepted.
+	parent, err := names.ParseProject(strings.TrimSuffix(req.GetName(), "/operations"))
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	listing, err := db.ListOperations(ctx, parent, dao.PageOptions{
+		Size:   req.GetPageSize(),
+		Filter: req.GetFilter(),
+		Token:  req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &longrunning.ListOperationsResponse{
+		Operations:    make([]*longrunning.Operation, len(listing.Operations)),
+		NextPageToken: listing.Token,
+	}
+
+	for i, op := range listing.Operations {
+		response.Operations[i], err = op.Message()
+		if err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	return response, nil
+}
+
+// GetOperation handles the corresponding API request.
+func (s *RegistryServer) GetOperation(ctx context.Context, req *longrunning.GetOperationRequest) (*longrunning.Operation, error) {
+	name, err := names.ParseOperation(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	return s.getOperation(ctx, name)
+}
+
+func (s *RegistryServer) getOperation(ctx context.Context, name names.Operation) (*longrunning.Operation, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	op, err := db.GetOperation(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := op.Message()
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	return message, nil
+}
+
+// DeleteOperation handles the corresponding API request.
+// Deleting an operation discards its result but does not cancel it.
+func (s *RegistryServer) DeleteOperation(ctx context.Context, req *longrunning.DeleteOperationRequest) (*empty.Empty, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	name, err := names.ParseOperation(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	// Deletion should only succeed on operations that currently exist.
+	if _, err := db.GetOperation(ctx, name); err != nil {
+		return nil, err
+	}
+
+	if err := db.DeleteOperation(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// CancelOperation handles the corresponding API request.
+// Cancellation is best-effort: operations that have already finished are
+// unchanged, and work that was done before cancellation is not undone.
+func (s *RegistryServer) CancelOperation(ctx context.Context, req *longrunning.CancelOperationRequest) (*empty.Empty, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	name, err := names.ParseOperation(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	op, err := db.GetOperation(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !op.Done && !op.CancelRequested {
+		op.CancelRequested = true
+		if err := db.SaveOperation(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+
+	s.operations.cancel(name.String())
+	return &empty.Empty{}, nil
+}
+
+// WaitOperation handles the corresponding API request.
+// It returns the latest state of the operation when the operation finishes or
+// the timeout expires, whichever happens first.
+func (s *RegistryServer) WaitOperation(ctx context.Context, req *longrunning.WaitOperationRequest) (*longrunning.Operation, error) {
+	name, err := names.ParseOperation(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	timeout := maxOperationWait
+	if req.GetTimeout() != nil {
+		if err := req.GetTimeout().CheckValid(); err != nil {
+			return nil, invalidArgumentError(fmt.Errorf("invalid timeout: %s", err))
+		} else if t := req.GetTimeout().AsDuration(); t < 0 {
+			return nil, invalidArgumentError(fmt.Errorf("invalid timeout %s: must not be negative", t))
+		} else if t < timeout {
+			timeout = t
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		op, err := s.getOperation(ctx, name)
+		if err != nil || op.GetDone() || !time.Now().Before(deadline) {
+			return op, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, status.Error(codes.Canceled, ctx.Err().Error())
+		case <-time.After(operationPollInterval):
+		}
+	}
+}
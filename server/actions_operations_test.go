@@ -0,0 +1,289 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// waitOperation waits for an operation to finish and returns its final state.
+func waitOperation(ctx context.Context, t *testing.T, s *RegistryServer, op *longrunning.Operation) *longrunning.Operation {
+	t.Helper()
+	req := &longrunning.WaitOperationRequest{Name: op.GetName()}
+	op, err := s.WaitOperation(ctx, req)
+	if err != nil {
+		t.Fatalf("WaitOperation(%+v) returned error: %s", req, err)
+	} else if !op.GetDone() {
+		t.Fatalf("WaitOperation(%+v) returned unfinished operation %+v", req, op)
+	}
+	return op
+}
+
+// seedOperation saves an operation without running it.
+func seedOperation(ctx context.Context, t *testing.T, s *RegistryServer, op *models.Operation) {
+	t.Helper()
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		t.Fatalf("Setup: Failed to create storage client: %s", err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+	if err := db.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("Setup: SaveOperation(%+v) returned error: %s", op, err)
+	}
+}
+
+func TestDeleteProjectOperation(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedProjects(ctx, t, server, &rpc.Project{Name: "projects/my-project"})
+
+	op, err := server.DeleteProject(ctx, &rpc.DeleteProjectRequest{Name: "projects/my-project"})
+	if err != nil {
+		t.Fatalf("DeleteProject() returned error: %s", err)
+	}
+
+	metadata := new(rpc.OperationMetadata)
+	if err := op.GetMetadata().UnmarshalTo(metadata); err != nil {
+		t.Fatalf("DeleteProject() returned operation with invalid metadata: %s", err)
+	} else if metadata.GetTarget() != "projects/my-project" || metadata.GetVerb() != "delete" {
+		t.Errorf("DeleteProject() returned operation with unexpected metadata %+v", metadata)
+	}
+
+	op = waitOperation(ctx, t, server, op)
+	if op.GetError() != nil {
+		t.Fatalf("Operation failed with error %+v", op.GetError())
+	} else if !op.GetResponse().MessageIs(new(emptypb.Empty)) {
+		t.Errorf("Operation returned response %+v, expected Empty", op.GetResponse())
+	}
+
+	t.Run("GetOperation", func(t *testing.T) {
+		got, err := server.GetOperation(ctx, &longrunning.GetOperationRequest{Name: op.GetName()})
+		if err != nil {
+			t.Fatalf("GetOperation() returned error: %s", err)
+		}
+		if !proto.Equal(got, op) {
+			t.Errorf("GetOperation() returned %+v, expected %+v", got, op)
+		}
+	})
+
+	t.Run("ListOperations", func(t *testing.T) {
+		for _, req := range []*longrunning.ListOperationsRequest{
+			{Name: "projects/my-project"},
+			{Name: "projects/my-project/operations", Filter: "done && verb == 'delete'"},
+			{Name: "projects/-"},
+		} {
+			got, err := server.ListOperations(ctx, req)
+			if err != nil {
+				t.Fatalf("ListOperations(%+v) returned error: %s", req, err)
+			}
+			if len(got.GetOperations()) != 1 || got.GetOperations()[0].GetName() != op.GetName() {
+				t.Errorf("ListOperations(%+v) returned %+v, expected only %q", req, got.GetOperations(), op.GetName())
+			}
+		}
+
+		got, err := server.ListOperations(ctx, &longrunning.ListOperationsRequest{Name: "projects/my-project", Filter: "!done"})
+		if err != nil {
+			t.Fatalf("ListOperations() returned error: %s", err)
+		}
+		if len(got.GetOperations()) != 0 {
+			t.Errorf("ListOperations() returned %+v, expected no unfinished operations", got.GetOperations())
+		}
+	})
+
+	t.Run("DeleteOperation", func(t *testing.T) {
+		if _, err := server.DeleteOperation(ctx, &longrunning.DeleteOperationRequest{Name: op.GetName()}); err != nil {
+			t.Fatalf("DeleteOperation() returned error: %s", err)
+		}
+		if _, err := server.GetOperation(ctx, &longrunning.GetOperationRequest{Name: op.GetName()}); status.Code(err) != codes.NotFound {
+			t.Errorf("GetOperation() returned status code %q, want %q: %v", status.Code(err), codes.NotFound, err)
+		}
+	})
+}
+
+func TestCancelOperation(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+
+	op, err := models.NewOperation(names.Project{ProjectID: "my-project"}, "wait", "projects/my-project", &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Setup: NewOperation() returned error: %s", err)
+	}
+	started, err := server.startOperation(ctx, op, func(ctx context.Context) (proto.Message, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Setup: startOperation() returned error: %s", err)
+	}
+
+	// The operation runs until it is cancelled.
+	req := &longrunning.WaitOperationRequest{Name: started.GetName(), Timeout: durationpb.New(10 * time.Millisecond)}
+	if got, err := server.WaitOperation(ctx, req); err != nil {
+		t.Fatalf("WaitOperation(%+v) returned error: %s", req, err)
+	} else if got.GetDone() {
+		t.Fatalf("WaitOperation(%+v) returned finished operation %+v, expected timeout", req, got)
+	}
+
+	if _, err := server.CancelOperation(ctx, &longrunning.CancelOperationRequest{Name: started.GetName()}); err != nil {
+		t.Fatalf("CancelOperation() returned error: %s", err)
+	}
+
+	got := waitOperation(ctx, t, server, started)
+	if codes.Code(got.GetError().GetCode()) != codes.Canceled {
+		t.Errorf("Cancelled operation finished with %+v, expected code %q", got.GetResult(), codes.Canceled)
+	}
+}
+
+func TestResumeOperations(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	project := names.Project{ProjectID: "my-project"}
+	seedProjects(ctx, t, server, &rpc.Project{Name: project.String()})
+
+	// Save operations as if the server stopped while they were running.
+	deletion, err := models.NewOperation(project, "delete", project.String(), &rpc.DeleteProjectRequest{Name: project.String()})
+	if err != nil {
+		t.Fatalf("Setup: NewOperation() returned error: %s", err)
+	}
+	seedOperation(ctx, t, server, deletion)
+	unknown, err := models.NewOperation(project, "wait", project.String(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Setup: NewOperation() returned error: %s", err)
+	}
+	seedOperation(ctx, t, server, unknown)
+	leased, err := models.NewOperation(project, "wait", project.String(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Setup: NewOperation() returned error: %s", err)
+	}
+	leased.Lease("another-server", time.Now().Add(time.Hour))
+	seedOperation(ctx, t, server, leased)
+
+	if err := server.resumeOperations(ctx); err != nil {
+		t.Fatalf("resumeOperations() returned error: %s", err)
+	}
+
+	op := waitOperation(ctx, t, server, &longrunning.Operation{Name: deletion.Name()})
+	if op.GetError() != nil {
+		t.Errorf("Resumed deletion failed with error %+v", op.GetError())
+	}
+	if _, err := server.GetProject(ctx, &rpc.GetProjectRequest{Name: project.String()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetProject() returned status code %q, want %q: %v", status.Code(err), codes.NotFound, err)
+	}
+
+	op = waitOperation(ctx, t, server, &longrunning.Operation{Name: unknown.Name()})
+	if codes.Code(op.GetError().GetCode()) != codes.Aborted {
+		t.Errorf("Operation that cannot be resumed finished with %+v, expected code %q", op.GetResult(), codes.Aborted)
+	}
+
+	// Operations leased by running servers are left to them.
+	if op, err := server.GetOperation(ctx, &longrunning.GetOperationRequest{Name: leased.Name()}); err != nil {
+		t.Errorf("GetOperation() returned error: %s", err)
+	} else if op.GetDone() {
+		t.Errorf("Operation leased by another server finished with %+v, expected it to be left running", op.GetResult())
+	}
+}
+
+func TestOperationResponseCodes(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+
+	tests := []struct {
+		desc string
+		call func() error
+		want codes.Code
+	}{
+		{
+			desc: "get invalid name",
+			call: func() error {
+				_, err := server.GetOperation(ctx, &longrunning.GetOperationRequest{Name: "operations/123"})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "get missing operation",
+			call: func() error {
+				_, err := server.GetOperation(ctx, &longrunning.GetOperationRequest{Name: "projects/p/operations/missing"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			desc: "cancel missing operation",
+			call: func() error {
+				_, err := server.CancelOperation(ctx, &longrunning.CancelOperationRequest{Name: "projects/p/operations/missing"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			desc: "delete missing operation",
+			call: func() error {
+				_, err := server.DeleteOperation(ctx, &longrunning.DeleteOperationRequest{Name: "projects/p/operations/missing"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			desc: "wait with negative timeout",
+			call: func() error {
+				_, err := server.WaitOperation(ctx, &longrunning.WaitOperationRequest{
+					Name:    "projects/p/operations/missing",
+					Timeout: durationpb.New(-time.Second),
+				})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "list with negative page size",
+			call: func() error {
+				_, err := server.ListOperations(ctx, &longrunning.ListOperationsRequest{Name: "projects/p", PageSize: -1})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "list with invalid filter",
+			call: func() error {
+				_, err := server.ListOperations(ctx, &longrunning.ListOperationsRequest{Name: "projects/p", Filter: "done ==="})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := test.call(); status.Code(err) != test.want {
+				t.Errorf("returned status code %q, want %q: %v", status.Code(err), test.want, err)
+			}
+		})
+	}
+}
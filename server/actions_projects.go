@@ -23,6 +23,8 @@ import (
 	"github.com/apigee/registry/server/models"
 	"github.com/apigee/registry/server/names"
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/protobuf/proto"
 )
 
 // CreateProject handles the corresponding API request.
@@ -68,7 +70,8 @@ func (s *RegistryServer) CreateProject(ctx context.Context, req *rpc.CreateProje
 }
 
 // DeleteProject handles the corresponding API request.
-func (s *RegistryServer) DeleteProject(ctx context.Context, req *rpc.DeleteProjectRequest) (*empty.Empty, error) {
+// Projects are deleted by long-running operations.
+func (s *RegistryServer) DeleteProject(ctx context.Context, req *rpc.DeleteProjectRequest) (*longrunning.Operation, error) {
 	client, err := s.getStorageClient(ctx)
 	if err != nil {
 		return nil, unavailableError(err)
@@ -86,12 +89,32 @@ func (s *RegistryServer) DeleteProject(ctx context.Context, req *rpc.DeleteProje
 		return nil, err
 	}
 
-	if err := db.DeleteProject(ctx, name); err != nil {
-		return nil, err
+	op, err := models.NewOperation(name, "delete", name.String(), req)
+	if err != nil {
+		return nil, internalError(err)
 	}
 
-	s.notify(rpc.Notification_DELETED, name.String())
-	return &empty.Empty{}, nil
+	return s.startOperation(ctx, op, s.deleteProjectOperation(name))
+}
+
+// deleteProjectOperation returns a function that deletes a project.
+// Deletion can be safely repeated if it is interrupted.
+func (s *RegistryServer) deleteProjectOperation(name names.Project) operationFunc {
+	return func(ctx context.Context) (proto.Message, error) {
+		client, err := s.getStorageClient(ctx)
+		if err != nil {
+			return nil, unavailableError(err)
+		}
+		defer s.releaseStorageClient(client)
+		db := dao.NewDAO(client)
+
+		if err := db.DeleteProject(ctx, name); err != nil {
+			return nil, err
+		}
+
+		s.notify(rpc.Notification_DELETED, name.String())
+		return &empty.Empty{}, nil
+	}
 }
 
 // GetProject handles the corresponding API request.
@@ -141,6 +164,7 @@ func (s *RegistryServer) ListProjects(ctx context.Context, req *rpc.ListProjects
 	listing, err := db.ListProjects(ctx, dao.PageOptions{
 		Size:   req.GetPageSize(),
 		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
 		Token:  req.GetPageToken(),
 	})
 	if err != nil {
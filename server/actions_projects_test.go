@@ -758,9 +758,11 @@ func TestDeleteProject(t *testing.T) {
 			server := defaultTestServer(t)
 			seedProjects(ctx, t, server, test.seed)
 
-			if _, err := server.DeleteProject(ctx, test.req); err != nil {
+			op, err := server.DeleteProject(ctx, test.req)
+			if err != nil {
 				t.Fatalf("DeleteProject(%+v) returned error: %s", test.req, err)
 			}
+			waitOperation(ctx, t, server, op)
 
 			t.Run("GetProject", func(t *testing.T) {
 				req := &rpc.GetProjectRequest{
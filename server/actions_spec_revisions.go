@@ -49,12 +49,18 @@ func (s *RegistryServer) ListApiSpecRevisions(ctx context.Context, req *rpc.List
 
 	listing, err := db.ListSpecRevisions(ctx, parent, dao.PageOptions{
 		Size:  req.GetPageSize(),
+		Order: req.GetOrderBy(),
 		Token: req.GetPageToken(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	tags, err := db.GetSpecRevisionTags(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
 	response := &rpc.ListApiSpecRevisionsResponse{
 		ApiSpecs:      make([]*rpc.ApiSpec, len(listing.Specs)),
 		NextPageToken: listing.Token,
@@ -65,6 +71,7 @@ func (s *RegistryServer) ListApiSpecRevisions(ctx context.Context, req *rpc.List
 		if err != nil {
 			return nil, internalError(err)
 		}
+		response.ApiSpecs[i].RevisionTags = tags[spec.RevisionName()]
 	}
 
 	return response, nil
@@ -137,8 +144,10 @@ func (s *RegistryServer) TagApiSpecRevision(ctx context.Context, req *rpc.TagApi
 		return nil, invalidArgumentError(err)
 	}
 
+	// Moves of existing tags are recorded along with the revision the tag was moved from.
 	tag := models.NewSpecRevisionTag(name, req.GetTag())
-	if err := db.SaveSpecRevisionTag(ctx, tag); err != nil {
+	previous, err := db.GetSpecRevisionTag(ctx, name.Spec().Revision(tag.Tag))
+	if err != nil && !isNotFound(err) {
 		return nil, err
 	}
 
@@ -147,10 +156,90 @@ func (s *RegistryServer) TagApiSpecRevision(ctx context.Context, req *rpc.TagApi
 		return nil, internalError(err)
 	}
 
+	if previous != nil && previous.RevisionID == tag.RevisionID {
+		return message, nil
+	}
+
+	event := models.NewSpecRevisionTagEvent(tag, "", principal(ctx))
+	if previous != nil {
+		tag.CreateTime = previous.CreateTime
+		event.PreviousRevisionID = previous.RevisionID
+	}
+
+	if err := db.SaveSpecRevisionTag(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	if err := db.SaveSpecRevisionTagEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
 	s.notify(rpc.Notification_UPDATED, name.String())
 	return message, nil
 }
 
+// ListApiSpecRevisionTags handles the corresponding API request.
+func (s *RegistryServer) ListApiSpecRevisionTags(ctx context.Context, req *rpc.ListApiSpecRevisionTagsRequest) (*rpc.ListApiSpecRevisionTagsResponse, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	if req.GetPageSize() < 0 {
+		return nil, invalidArgumentError(fmt.Errorf("invalid page_size %d: must not be negative", req.GetPageSize()))
+	} else if req.GetPageSize() > 1000 {
+		req.PageSize = 1000
+	} else if req.GetPageSize() == 0 {
+		req.PageSize = 50
+	}
+
+	parent, err := names.ParseSpec(req.GetName())
+	if err != nil {
+		return nil, invalidArgumentError(err)
+	}
+
+	opts := dao.PageOptions{
+		Size:   req.GetPageSize(),
+		Filter: req.GetFilter(),
+		Token:  req.GetPageToken(),
+	}
+
+	response := new(rpc.ListApiSpecRevisionTagsResponse)
+	if req.GetHistory() {
+		listing, err := db.ListSpecRevisionTagEvents(ctx, parent, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		response.NextPageToken = listing.Token
+		response.Tags = make([]*rpc.ApiSpecRevisionTag, len(listing.Events))
+		for i, event := range listing.Events {
+			response.Tags[i], err = event.Message()
+			if err != nil {
+				return nil, internalError(err)
+			}
+		}
+	} else {
+		listing, err := db.ListSpecRevisionTags(ctx, parent, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		response.NextPageToken = listing.Token
+		response.Tags = make([]*rpc.ApiSpecRevisionTag, len(listing.Tags))
+		for i, tag := range listing.Tags {
+			response.Tags[i], err = tag.Message()
+			if err != nil {
+				return nil, internalError(err)
+			}
+		}
+	}
+
+	return response, nil
+}
+
 // RollbackApiSpec handles the corresponding API request.
 func (s *RegistryServer) RollbackApiSpec(ctx context.Context, req *rpc.RollbackApiSpecRequest) (*rpc.ApiSpec, error) {
 	client, err := s.getStorageClient(ctx)
@@ -176,20 +265,28 @@ func (s *RegistryServer) RollbackApiSpec(ctx context.Context, req *rpc.RollbackA
 		return nil, err
 	}
 
-	// Save a new rollback revision based on the target revision.
-	rollback := target.NewRevision()
-	if err := db.SaveSpecRevision(ctx, rollback); err != nil {
-		return nil, err
-	}
-
 	blob, err := db.GetSpecRevisionContents(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 
-	// Save a new copy of the target revision blob for the rollback revision.
-	blob.RevisionID = name.RevisionID
-	if err := db.SaveSpecRevisionContents(ctx, rollback, blob.Contents); err != nil {
+	// Save a new rollback revision based on the target revision. Quotas are
+	// checked in the transaction that saves it (see checkCount).
+	rollback := target.NewRevision()
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkRevisionQuota(ctx, db, parent); err != nil {
+			return err
+		}
+		if err := s.checkContentsQuota(ctx, db, parent.Project(), int64(target.SizeInBytes), 0); err != nil {
+			return err
+		}
+		if err := db.SaveSpecRevision(ctx, rollback); err != nil {
+			return err
+		}
+		// Save a new copy of the target revision blob for the rollback revision.
+		blob.RevisionID = name.RevisionID
+		return db.SaveSpecRevisionContents(ctx, rollback, blob.Contents)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -17,6 +17,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/apigee/registry/rpc"
@@ -134,6 +135,29 @@ func TestTagApiSpecRevision(t *testing.T) {
 		})
 	})
 
+	t.Run("ListApiSpecRevisions", func(t *testing.T) {
+		req := &rpc.ListApiSpecRevisionsRequest{
+			Name: revision.GetName(),
+		}
+
+		got, err := server.ListApiSpecRevisions(ctx, req)
+		if err != nil {
+			t.Fatalf("ListApiSpecRevisions(%+v) returned error: %s", req, err)
+		}
+
+		for _, spec := range got.GetApiSpecs() {
+			if spec.GetRevisionId() != revision.GetRevisionId() {
+				continue
+			}
+			want := []string{"my-second-tag", "my-tag"}
+			tags := append([]string{}, spec.GetRevisionTags()...)
+			sort.Strings(tags)
+			if !cmp.Equal(want, tags) {
+				t.Errorf("ListApiSpecRevisions(%+v) returned unexpected tags (-want +got):\n%s", req, cmp.Diff(want, tags))
+			}
+		}
+	})
+
 	t.Run("DeleteApiSpecRevision", func(t *testing.T) {
 		req := &rpc.DeleteApiSpecRevisionRequest{
 			Name: got.GetName(),
@@ -392,6 +416,132 @@ func TestListApiSpecRevisions(t *testing.T) {
 	})
 }
 
+func TestListApiSpecRevisionTags(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedVersions(ctx, t, server, &rpc.ApiVersion{
+		Name: "projects/my-project/apis/my-api/versions/v1",
+	})
+
+	createReq := &rpc.CreateApiSpecRequest{
+		Parent:    "projects/my-project/apis/my-api/versions/v1",
+		ApiSpecId: "my-spec",
+		ApiSpec:   &rpc.ApiSpec{},
+	}
+
+	first, err := server.CreateApiSpec(ctx, createReq)
+	if err != nil {
+		t.Fatalf("Setup: CreateApiSpec(%+v) returned error: %s", createReq, err)
+	}
+
+	updateReq := &rpc.UpdateApiSpecRequest{
+		ApiSpec: &rpc.ApiSpec{
+			Name:     first.GetName(),
+			Contents: specContents,
+		},
+	}
+
+	second, err := server.UpdateApiSpec(ctx, updateReq)
+	if err != nil {
+		t.Fatalf("Setup: UpdateApiSpec(%+v) returned error: %s", updateReq, err)
+	}
+
+	// Tag the first revision, move the tag to the second, and tag the second again.
+	for _, req := range []*rpc.TagApiSpecRevisionRequest{
+		{Name: fmt.Sprintf("%s@%s", first.GetName(), first.GetRevisionId()), Tag: "production"},
+		{Name: fmt.Sprintf("%s@%s", second.GetName(), second.GetRevisionId()), Tag: "production"},
+		{Name: fmt.Sprintf("%s@%s", second.GetName(), second.GetRevisionId()), Tag: "production"},
+		{Name: fmt.Sprintf("%s@%s", second.GetName(), second.GetRevisionId()), Tag: "latest"},
+	} {
+		if _, err := server.TagApiSpecRevision(ctx, req); err != nil {
+			t.Fatalf("Setup: TagApiSpecRevision(%+v) returned error: %s", req, err)
+		}
+	}
+
+	opts := cmp.Options{
+		protocmp.Transform(),
+		protocmp.IgnoreFields(new(rpc.ApiSpecRevisionTag), "create_time", "update_time"),
+	}
+
+	tests := []struct {
+		desc string
+		req  *rpc.ListApiSpecRevisionTagsRequest
+		want []*rpc.ApiSpecRevisionTag
+	}{
+		{
+			desc: "current tags",
+			req: &rpc.ListApiSpecRevisionTagsRequest{
+				Name: first.GetName(),
+			},
+			want: []*rpc.ApiSpecRevisionTag{
+				{Name: first.GetName() + "@latest", Tag: "latest", RevisionId: second.GetRevisionId()},
+				{Name: first.GetName() + "@production", Tag: "production", RevisionId: second.GetRevisionId()},
+			},
+		},
+		{
+			desc: "tag history",
+			req: &rpc.ListApiSpecRevisionTagsRequest{
+				Name:    first.GetName(),
+				History: true,
+			},
+			want: []*rpc.ApiSpecRevisionTag{
+				{Name: first.GetName() + "@latest", Tag: "latest", RevisionId: second.GetRevisionId()},
+				{Name: first.GetName() + "@production", Tag: "production", RevisionId: second.GetRevisionId(), PreviousRevisionId: first.GetRevisionId()},
+				{Name: first.GetName() + "@production", Tag: "production", RevisionId: first.GetRevisionId()},
+			},
+		},
+		{
+			desc: "filtered tag history",
+			req: &rpc.ListApiSpecRevisionTagsRequest{
+				Name:    first.GetName(),
+				History: true,
+				Filter:  "tag == 'production' && previous_revision_id != ''",
+			},
+			want: []*rpc.ApiSpecRevisionTag{
+				{Name: first.GetName() + "@production", Tag: "production", RevisionId: second.GetRevisionId(), PreviousRevisionId: first.GetRevisionId()},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := server.ListApiSpecRevisionTags(ctx, test.req)
+			if err != nil {
+				t.Fatalf("ListApiSpecRevisionTags(%+v) returned error: %s", test.req, err)
+			}
+
+			if !cmp.Equal(test.want, got.GetTags(), opts) {
+				t.Errorf("ListApiSpecRevisionTags(%+v) returned unexpected diff (-want +got):\n%s", test.req, cmp.Diff(test.want, got.GetTags(), opts))
+			}
+		})
+	}
+
+	t.Run("GetApiSpecContents", func(t *testing.T) {
+		req := &rpc.GetApiSpecContentsRequest{
+			Name: first.GetName() + "@production/contents",
+		}
+
+		got, err := server.GetApiSpecContents(ctx, req)
+		if err != nil {
+			t.Fatalf("GetApiSpecContents(%+v) returned error: %s", req, err)
+		}
+
+		if !cmp.Equal(specContents, got.GetData()) {
+			t.Errorf("GetApiSpecContents(%+v) returned unexpected contents", req)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		req := &rpc.ListApiSpecRevisionTagsRequest{
+			Name: first.GetName() + "@production",
+		}
+
+		if _, err := server.ListApiSpecRevisionTags(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListApiSpecRevisionTags(%+v) returned status code %q, want %q: %v", req, status.Code(err), codes.InvalidArgument, err)
+		}
+	})
+}
+
 func TestUpdateApiSpecRevisions(t *testing.T) {
 	ctx := context.Background()
 	server := defaultTestServer(t)
@@ -28,8 +28,6 @@ import (
 	"github.com/apigee/registry/server/names"
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/genproto/googleapis/api/httpbody"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
@@ -80,11 +78,19 @@ func (s *RegistryServer) createSpec(ctx context.Context, name names.Spec, body *
 		return nil, invalidArgumentError(err)
 	}
 
-	if err := db.SaveSpecRevision(ctx, spec); err != nil {
-		return nil, err
-	}
-
-	if err := db.SaveSpecRevisionContents(ctx, spec, body.GetContents()); err != nil {
+	// Quotas are checked in the transaction that saves the spec (see checkCount).
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkSpecQuota(ctx, db, name.Project()); err != nil {
+			return err
+		}
+		if err := s.checkContentsQuota(ctx, db, name.Project(), int64(len(body.GetContents())), 0); err != nil {
+			return err
+		}
+		if err := db.SaveSpecRevision(ctx, spec); err != nil {
+			return err
+		}
+		return db.SaveSpecRevisionContents(ctx, spec, body.GetContents())
+	}); err != nil {
 		return nil, err
 	}
 
@@ -126,16 +132,21 @@ func (s *RegistryServer) DeleteApiSpec(ctx context.Context, req *rpc.DeleteApiSp
 
 // GetApiSpec handles the corresponding API request.
 func (s *RegistryServer) GetApiSpec(ctx context.Context, req *rpc.GetApiSpecRequest) (*rpc.ApiSpec, error) {
+	v, err := newView(&rpc.ApiSpec{}, req.GetView(), req.GetReadMask())
+	if err != nil {
+		return nil, err
+	}
+
 	if name, err := names.ParseSpec(req.GetName()); err == nil {
-		return s.getApiSpec(ctx, name)
+		return s.getApiSpec(ctx, name, v)
 	} else if name, err := names.ParseSpecRevision(req.GetName()); err == nil {
-		return s.getApiSpecRevision(ctx, name)
+		return s.getApiSpecRevision(ctx, name, v)
 	}
 
 	return nil, invalidArgumentError(fmt.Errorf("invalid resource name %q, must be an API spec or revision", req.GetName()))
 }
 
-func (s *RegistryServer) getApiSpec(ctx context.Context, name names.Spec) (*rpc.ApiSpec, error) {
+func (s *RegistryServer) getApiSpec(ctx context.Context, name names.Spec, v view) (*rpc.ApiSpec, error) {
 	client, err := s.getStorageClient(ctx)
 	if err != nil {
 		return nil, unavailableError(err)
@@ -148,15 +159,10 @@ func (s *RegistryServer) getApiSpec(ctx context.Context, name names.Spec) (*rpc.
 		return nil, err
 	}
 
-	message, err := spec.BasicMessage(name.String())
-	if err != nil {
-		return nil, internalError(err)
-	}
-
-	return message, nil
+	return v.specMessage(ctx, db, spec, name.String())
 }
 
-func (s *RegistryServer) getApiSpecRevision(ctx context.Context, name names.SpecRevision) (*rpc.ApiSpec, error) {
+func (s *RegistryServer) getApiSpecRevision(ctx context.Context, name names.SpecRevision, v view) (*rpc.ApiSpec, error) {
 	client, err := s.getStorageClient(ctx)
 	if err != nil {
 		return nil, unavailableError(err)
@@ -169,12 +175,7 @@ func (s *RegistryServer) getApiSpecRevision(ctx context.Context, name names.Spec
 		return nil, err
 	}
 
-	message, err := revision.BasicMessage(name.String())
-	if err != nil {
-		return nil, internalError(err)
-	}
-
-	return message, nil
+	return v.specMessage(ctx, db, revision, name.String())
 }
 
 // GUnzippedBytes uncompresses a slice of bytes.
@@ -212,28 +213,24 @@ func (s *RegistryServer) GetApiSpecContents(ctx context.Context, req *rpc.GetApi
 		if spec, err = db.GetSpecRevision(ctx, name); err != nil {
 			return nil, err
 		}
-		revisionName = name
+		// Tags are resolved to the revisions they point to.
+		revisionName = name.Spec().Revision(spec.RevisionID)
 	} else {
 		return nil, invalidArgumentError(fmt.Errorf("invalid resource name %q, must be an API spec or revision", specName))
 	}
-	blob, err := db.GetSpecRevisionContents(ctx, revisionName)
-	if err != nil {
-		return nil, err
-	}
-	if strings.Contains(spec.MimeType, "+gzip") {
-		contents, err := GUnzippedBytes(blob.Contents)
-		if err != nil {
-			return nil, status.Errorf(codes.FailedPrecondition, "failed to unzip contents with gzip MIME type: %s", err)
-		}
-		return &httpbody.HttpBody{
-			ContentType: strings.Replace(spec.MimeType, "+gzip", "", 1),
-			Data:        contents,
-		}, nil
-	}
-	return &httpbody.HttpBody{
-		ContentType: spec.MimeType,
-		Data:        blob.Contents,
-	}, nil
+
+	return s.contentsResponse(ctx, contents{
+		key:      revisionName.String(),
+		hash:     spec.Hash,
+		mimeType: spec.MimeType,
+		load: func() ([]byte, error) {
+			blob, err := db.GetSpecRevisionContents(ctx, revisionName)
+			if err != nil {
+				return nil, err
+			}
+			return blob.Contents, nil
+		},
+	})
 }
 
 // ListApiSpecs handles the corresponding API request.
@@ -258,9 +255,16 @@ func (s *RegistryServer) ListApiSpecs(ctx context.Context, req *rpc.ListApiSpecs
 		return nil, invalidArgumentError(err)
 	}
 
+	v, err := newView(&rpc.ApiSpec{}, req.GetView(), req.GetReadMask())
+	if err != nil {
+		return nil, err
+	}
+	v = v.forList()
+
 	listing, err := db.ListSpecs(ctx, parent, dao.PageOptions{
 		Size:   req.GetPageSize(),
 		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
 		Token:  req.GetPageToken(),
 	})
 	if err != nil {
@@ -273,9 +277,9 @@ func (s *RegistryServer) ListApiSpecs(ctx context.Context, req *rpc.ListApiSpecs
 	}
 
 	for i, spec := range listing.Specs {
-		response.ApiSpecs[i], err = spec.BasicMessage(spec.Name())
+		response.ApiSpecs[i], err = v.specMessage(ctx, db, &spec, spec.Name())
 		if err != nil {
-			return nil, internalError(err)
+			return nil, err
 		}
 	}
 
@@ -310,22 +314,37 @@ func (s *RegistryServer) UpdateApiSpec(ctx context.Context, req *rpc.UpdateApiSp
 	}
 
 	// Apply the update to the spec - possibly changing the revision ID.
+	previousRevisionID := spec.RevisionID
 	if err := spec.Update(req.GetApiSpec(), models.ExpandMask(req.GetApiSpec(), req.GetUpdateMask())); err != nil {
 		return nil, internalError(err)
 	}
 
-	// Save the updated/current spec. This creates a new revision or updates the previous one.
-	if err := db.SaveSpecRevision(ctx, spec); err != nil {
-		return nil, err
-	}
-
-	// If the spec contents were updated, save a new blob.
 	implicitUpdate := req.GetUpdateMask() == nil && len(req.ApiSpec.GetContents()) > 0
 	explicitUpdate := len(fieldmaskpb.Intersect(req.GetUpdateMask(), &fieldmaskpb.FieldMask{Paths: []string{"contents"}}).GetPaths()) > 0
-	if implicitUpdate || explicitUpdate {
-		if err := db.SaveSpecRevisionContents(ctx, spec, req.ApiSpec.GetContents()); err != nil {
-			return nil, err
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		// New revisions count against quotas, which are checked in the transaction
+		// that saves them. Other updates leave the stored contents unchanged.
+		if spec.RevisionID != previousRevisionID {
+			if err := s.checkRevisionQuota(ctx, db, name); err != nil {
+				return err
+			}
+			if err := s.checkContentsQuota(ctx, db, name.Project(), int64(spec.SizeInBytes), 0); err != nil {
+				return err
+			}
 		}
+
+		// Save the updated/current spec. This creates a new revision or updates the previous one.
+		if err := db.SaveSpecRevision(ctx, spec); err != nil {
+			return err
+		}
+
+		// If the spec contents were updated, save a new blob.
+		if implicitUpdate || explicitUpdate {
+			return db.SaveSpecRevisionContents(ctx, spec, req.ApiSpec.GetContents())
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	message, err := spec.BasicMessage(name.String())
@@ -27,6 +27,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
@@ -348,6 +349,58 @@ func TestGetApiSpec(t *testing.T) {
 			},
 			want: basicSpec,
 		},
+		{
+			desc: "basic view",
+			seed: fullSpec,
+			req: &rpc.GetApiSpecRequest{
+				Name: fullSpec.Name,
+				View: rpc.View_BASIC,
+			},
+			want: basicSpec,
+		},
+		{
+			desc: "full view",
+			seed: fullSpec,
+			req: &rpc.GetApiSpecRequest{
+				Name: fullSpec.Name,
+				View: rpc.View_FULL,
+			},
+			want: fullSpec,
+		},
+		{
+			desc: "read mask",
+			seed: fullSpec,
+			req: &rpc.GetApiSpecRequest{
+				Name:     fullSpec.Name,
+				View:     rpc.View_FULL,
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "size_bytes"}},
+			},
+			want: &rpc.ApiSpec{
+				Name:      fullSpec.Name,
+				SizeBytes: fullSpec.SizeBytes,
+			},
+		},
+		{
+			desc: "read mask including contents",
+			seed: fullSpec,
+			req: &rpc.GetApiSpecRequest{
+				Name:     fullSpec.Name,
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "contents"}},
+			},
+			want: &rpc.ApiSpec{
+				Name:     fullSpec.Name,
+				Contents: fullSpec.Contents,
+			},
+		},
+		{
+			desc: "wildcard read mask",
+			seed: fullSpec,
+			req: &rpc.GetApiSpecRequest{
+				Name:     fullSpec.Name,
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"*"}},
+			},
+			want: basicSpec,
+		},
 	}
 
 	for _, test := range tests {
@@ -396,6 +449,24 @@ func TestGetApiSpecResponseCodes(t *testing.T) {
 			},
 			want: codes.OK,
 		},
+		{
+			desc: "invalid view",
+			seed: &rpc.ApiSpec{Name: "projects/my-project/apis/my-api/versions/v1/specs/my-spec"},
+			req: &rpc.GetApiSpecRequest{
+				Name: "projects/my-project/apis/my-api/versions/v1/specs/my-spec",
+				View: rpc.View(-1),
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			desc: "invalid read mask",
+			seed: &rpc.ApiSpec{Name: "projects/my-project/apis/my-api/versions/v1/specs/my-spec"},
+			req: &rpc.GetApiSpecRequest{
+				Name:     "projects/my-project/apis/my-api/versions/v1/specs/my-spec",
+				ReadMask: &fieldmaskpb.FieldMask{Paths: []string{"this field does not exist"}},
+			},
+			want: codes.InvalidArgument,
+		},
 	}
 
 	for _, test := range tests {
@@ -899,6 +970,83 @@ func TestListApiSpecsLargeCollectionFiltering(t *testing.T) {
 	}
 }
 
+func TestListApiSpecsParentFilters(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	seedApis(ctx, t, server,
+		&rpc.Api{Name: "projects/my-project/apis/payments", Labels: map[string]string{"team": "payments"}},
+		&rpc.Api{Name: "projects/my-project/apis/shipping", Labels: map[string]string{"team": "shipping"}},
+	)
+	seedVersions(ctx, t, server,
+		&rpc.ApiVersion{Name: "projects/my-project/apis/payments/versions/v1", State: "PRODUCTION"},
+		&rpc.ApiVersion{Name: "projects/my-project/apis/payments/versions/v2", State: "DESIGN"},
+		&rpc.ApiVersion{Name: "projects/my-project/apis/shipping/versions/v1", State: "PRODUCTION"},
+	)
+	seedSpecs(ctx, t, server,
+		&rpc.ApiSpec{Name: "projects/my-project/apis/payments/versions/v1/specs/openapi.yaml"},
+		&rpc.ApiSpec{Name: "projects/my-project/apis/payments/versions/v2/specs/openapi.yaml"},
+		&rpc.ApiSpec{Name: "projects/my-project/apis/shipping/versions/v1/specs/openapi.yaml"},
+	)
+
+	req := &rpc.ListApiSpecsRequest{
+		Parent: "projects/my-project/apis/-/versions/-",
+		Filter: "api.labels.team == 'payments' && version.state == 'PRODUCTION'",
+	}
+
+	got, err := server.ListApiSpecs(ctx, req)
+	if err != nil {
+		t.Fatalf("ListApiSpecs(%+v) returned error: %s", req, err)
+	}
+
+	want := "projects/my-project/apis/payments/versions/v1/specs/openapi.yaml"
+	if len(got.GetApiSpecs()) != 1 || got.GetApiSpecs()[0].GetName() != want {
+		t.Errorf("ListApiSpecs(%+v) returned %+v, want only %q", req, got.GetApiSpecs(), want)
+	}
+
+	req.Filter = "api.owner == 'me'"
+	if _, err := server.ListApiSpecs(ctx, req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListApiSpecs(%+v) returned status code %s, want %s", req, status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestListApiSpecsContentsBudget(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	contents := []byte(strings.Repeat("x", maxViewContentsSize))
+	for i := 1; i <= 5; i++ {
+		seedSpecs(ctx, t, server, &rpc.ApiSpec{
+			Name:     fmt.Sprintf("projects/my-project/apis/my-api/versions/v1/specs/s%d", i),
+			Contents: contents,
+		})
+	}
+
+	req := &rpc.ListApiSpecsRequest{
+		Parent: "projects/my-project/apis/my-api/versions/v1",
+		View:   rpc.View_FULL,
+	}
+	got, err := server.ListApiSpecs(ctx, req)
+	if err != nil {
+		t.Fatalf("ListApiSpecs(%+v) returned error: %s", req, err)
+	}
+	if len(got.GetApiSpecs()) != 5 {
+		t.Fatalf("ListApiSpecs(%+v) returned %d specs, expected 5", req, len(got.GetApiSpecs()))
+	}
+
+	// Contents are returned until the budget is spent.
+	var returned int
+	for _, spec := range got.GetApiSpecs() {
+		if len(spec.GetContents()) > 0 {
+			returned++
+		}
+	}
+	if want := maxListContentsSize / maxViewContentsSize; returned != want {
+		t.Errorf("ListApiSpecs(%+v) returned contents of %d specs, expected %d", req, returned, want)
+	}
+	if size := proto.Size(got); size > 4<<20 {
+		t.Errorf("ListApiSpecs(%+v) returned %d bytes, expected at most gRPC's default limit", req, size)
+	}
+}
+
 func TestUpdateApiSpec(t *testing.T) {
 	tests := []struct {
 		desc string
@@ -68,7 +68,13 @@ func (s *RegistryServer) CreateApiVersion(ctx context.Context, req *rpc.CreateAp
 		return nil, invalidArgumentError(err)
 	}
 
-	if err := db.SaveVersion(ctx, version); err != nil {
+	// The quota is checked in the transaction that saves the version (see checkCount).
+	if err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		if err := s.checkVersionQuota(ctx, db, name.Project()); err != nil {
+			return err
+		}
+		return db.SaveVersion(ctx, version)
+	}); err != nil {
 		return nil, err
 	}
 
@@ -160,6 +166,7 @@ func (s *RegistryServer) ListApiVersions(ctx context.Context, req *rpc.ListApiVe
 	listing, err := db.ListVersions(ctx, parent, dao.PageOptions{
 		Size:   req.GetPageSize(),
 		Filter: req.GetFilter(),
+		Order:  req.GetOrderBy(),
 		Token:  req.GetPageToken(),
 	})
 	if err != nil {
@@ -0,0 +1,294 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/server/auth"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Prefix of the full names of Registry service methods.
+const registryServicePrefix = "/google.cloud.apigee.registry.v1.Registry/"
+
+// Maximum length of request summaries stored in audit events.
+const maxRequestSummary = 4096
+
+var auditProjectPattern = regexp.MustCompile(`^projects/([^/]+)`)
+
+// isMutatingMethod returns true for Registry methods that can change resources.
+func isMutatingMethod(fullMethod string) bool {
+	if !strings.HasPrefix(fullMethod, registryServicePrefix) {
+		return false
+	}
+	method := filepath.Base(fullMethod)
+	return !strings.HasPrefix(method, "Get") && !strings.HasPrefix(method, "List")
+}
+
+// auditedMethodKey is the context key of the audited method being called.
+type auditedMethodKey struct{}
+
+// auditedMethod returns the full name of the audited method being called.
+func auditedMethod(ctx context.Context) string {
+	method, _ := ctx.Value(auditedMethodKey{}).(string)
+	return method
+}
+
+// auditHandler records an audit event for each call that can change resources.
+// Failures to record events are logged and do not affect the call.
+// Calls that start operations are recorded when their operations finish,
+// so that their events have the operations' results.
+func (s *RegistryServer) auditHandler(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(context.WithValue(ctx, auditedMethodKey{}, info.FullMethod), req)
+	if op, ok := resp.(*longrunning.Operation); ok && err == nil && !op.GetDone() {
+		return resp, err
+	}
+	s.recordAuditEvent(ctx, info.FullMethod, req, resp, err)
+	return resp, err
+}
+
+// recordDeniedCall records an audit event for a call rejected during authorization.
+// Rejections of unauthenticated and anonymous callers are only logged, because
+// anyone can make those calls and the events would fill the database.
+func (s *RegistryServer) recordDeniedCall(ctx context.Context, fullMethod string, req interface{}, callErr error) {
+	if p := auth.PrincipalFromContext(ctx); p == "" || p == auth.Anonymous {
+		if isMutatingMethod(fullMethod) && s.loggingLevel >= loggingDebug {
+			log.Printf("[%s] rejected call from %s: %s", filepath.Base(fullMethod), principal(ctx), callErr)
+		}
+		return
+	}
+	s.recordAuditEvent(ctx, fullMethod, req, nil, callErr)
+}
+
+// recordAuditEvent records an audit event for a call if it can change resources.
+// Calls rejected during authorization are recorded without responses.
+func (s *RegistryServer) recordAuditEvent(ctx context.Context, fullMethod string, req, resp interface{}, callErr error) {
+	if !isMutatingMethod(fullMethod) {
+		return
+	}
+	if err := s.audit(ctx, fullMethod, req, resp, callErr); err != nil && s.loggingLevel >= loggingError {
+		log.Printf("[%s] failed to record audit event: %s", filepath.Base(fullMethod), err)
+	}
+}
+
+func (s *RegistryServer) audit(ctx context.Context, fullMethod string, req, resp interface{}, callErr error) error {
+	event := newAuditEvent(filepath.Base(fullMethod), principal(ctx), req, resp, callErr)
+	if event == nil {
+		return nil
+	}
+
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+	return db.SaveAuditEvent(ctx, event)
+}
+
+// recordOperationResult records an audit event for the call that started an
+// operation, with the result of the operation.
+func (s *RegistryServer) recordOperationResult(ctx context.Context, op *models.Operation, runErr error) {
+	if op.Method == "" {
+		return
+	}
+	err := func() error {
+		req, err := op.StartingRequest()
+		if err != nil {
+			return err
+		}
+		event := newAuditEvent(op.Method, op.Principal, req, nil, runErr)
+		if event == nil {
+			return nil
+		}
+		client, err := s.getStorageClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer s.releaseStorageClient(client)
+		db := dao.NewDAO(client)
+		return db.SaveAuditEvent(ctx, event)
+	}()
+	if err != nil && s.loggingLevel >= loggingError {
+		log.Printf("[%s] failed to record audit event: %s", op.Method, err)
+	}
+}
+
+// newAuditEvent returns an audit event for a call,
+// or nil if the call doesn't act on a project.
+func newAuditEvent(method, principal string, req, resp interface{}, callErr error) *models.AuditEvent {
+	resource := auditedResource(req, resp)
+	project := ""
+	if m := auditProjectPattern.FindStringSubmatch(resource); m != nil {
+		project = m[1]
+	} else if m, ok := req.(proto.Message); ok {
+		// Failed project creations have no resource name.
+		r := m.ProtoReflect()
+		if f := r.Descriptor().Fields().ByName("project_id"); f != nil && f.Kind() == protoreflect.StringKind {
+			project = r.Get(f).String()
+			resource = "projects/" + project
+		}
+	}
+	if project == "" {
+		return nil
+	}
+
+	event := models.NewAuditEvent(names.Project{ProjectID: project}, time.Now())
+	event.Method = method
+	event.Resource = resource
+	event.Principal = principal
+	event.Request = summarizeRequest(req)
+	event.UpdateMask = updateMask(req)
+	event.Code = int32(status.Code(callErr))
+	if callErr != nil {
+		event.ErrorMessage = status.Convert(callErr).Message()
+	}
+	return event
+}
+
+// principal returns the authenticated identity of the caller. Callers that
+// weren't authenticated are identified as anonymous with their addresses,
+// because headers that name them can't be trusted.
+func principal(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != "" {
+		return p
+	}
+	if addr := clientAddress(ctx); addr != "" {
+		return fmt.Sprintf("%s (%s)", auth.Anonymous, addr)
+	}
+	return auth.Anonymous
+}
+
+// auditedResource returns the name of the resource changed by a call.
+// Responses name created resources; requests name the rest.
+func auditedResource(req, resp interface{}) string {
+	// Operations are named separately from the resources that they act on.
+	if _, ok := resp.(*longrunning.Operation); ok {
+		resp = nil
+	}
+	if m, ok := resp.(proto.Message); ok {
+		if name := nameField(m.ProtoReflect()); name != "" {
+			return name
+		}
+	}
+	m, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	r := m.ProtoReflect()
+	if name := nameField(r); name != "" {
+		return name
+	}
+	fields := r.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if f.Kind() == protoreflect.MessageKind && !f.IsList() && !f.IsMap() && r.Has(f) {
+			if name := nameField(r.Get(f).Message()); name != "" {
+				return name
+			}
+		}
+	}
+	if f := fields.ByName("parent"); f != nil && f.Kind() == protoreflect.StringKind {
+		return r.Get(f).String()
+	}
+	return ""
+}
+
+func nameField(m protoreflect.Message) string {
+	if f := m.Descriptor().Fields().ByName("name"); f != nil && f.Kind() == protoreflect.StringKind && !f.IsList() {
+		return m.Get(f).String()
+	}
+	return ""
+}
+
+// updateMask returns the comma-separated paths of a request's update mask.
+func updateMask(req interface{}) string {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	r := m.ProtoReflect()
+	f := r.Descriptor().Fields().ByName("update_mask")
+	if f == nil || f.Kind() != protoreflect.MessageKind || !r.Has(f) {
+		return ""
+	}
+	mask := r.Get(f).Message()
+	pf := mask.Descriptor().Fields().ByName("paths")
+	if pf == nil || !pf.IsList() {
+		return ""
+	}
+	list := mask.Get(pf).List()
+	paths := make([]string, list.Len())
+	for i := range paths {
+		paths[i] = list.Get(i).String()
+	}
+	return strings.Join(paths, ",")
+}
+
+// summarizeRequest returns a JSON representation of a request
+// with contents removed and its length limited.
+func summarizeRequest(req interface{}) string {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	c := proto.Clone(m)
+	clearBytes(c.ProtoReflect())
+	b, err := protojson.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	if len(b) > maxRequestSummary {
+		return string(b[:maxRequestSummary])
+	}
+	return string(b)
+}
+
+// clearBytes removes all bytes fields from a message and its submessages.
+func clearBytes(m protoreflect.Message) {
+	var cleared []protoreflect.FieldDescriptor
+	m.Range(func(f protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case f.Kind() == protoreflect.BytesKind:
+			cleared = append(cleared, f)
+		case f.Kind() == protoreflect.MessageKind && f.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				clearBytes(list.Get(i).Message())
+			}
+		case f.Kind() == protoreflect.MessageKind && !f.IsMap():
+			clearBytes(v.Message())
+		}
+		return true
+	})
+	for _, f := range cleared {
+		m.Clear(f)
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	service    = "/google.cloud.apigee.registry.v1.Registry/"
+	operations = "/google.longrunning.Operations/"
+)
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// request returns a message whose name refers to the given resource.
+func request(name string) interface{} {
+	return &longrunning.GetOperationRequest{Name: name}
+}
+
+func TestAuthorization(t *testing.T) {
+	checker, err := NewChecker(Config{
+		Enabled: true,
+		Tokens: map[string]string{
+			"viewer-token": "viewer@example.com",
+			"editor-token": "editor@example.com",
+			"specs-token":  "specs@example.com",
+			"admin-token":  "admin@example.com",
+		},
+		Bindings: []Binding{
+			{Principals: []string{"*@example.com"}, Role: "viewer", Projects: []string{"my-project"}},
+			{Principals: []string{"editor@example.com"}, Role: "editor", Projects: []string{"my-*"}},
+			{Principals: []string{"specs@example.com"}, Role: "editor", Projects: []string{"my-project"}, Collections: []string{"specs"}},
+			{Principals: []string{"admin@example.com"}, Role: "admin", Projects: []string{"*"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker() returned error: %s", err)
+	}
+
+	tests := []struct {
+		desc   string
+		token  string
+		method string
+		name   string
+		want   codes.Code
+	}{
+		{"public method without credentials", "", "GetStatus", "", codes.OK},
+		{"missing credentials", "", "GetApi", "projects/my-project/apis/a", codes.Unauthenticated},
+		{"unknown token", "bogus", "GetApi", "projects/my-project/apis/a", codes.Unauthenticated},
+		{"viewer reads", "viewer-token", "GetApi", "projects/my-project/apis/a", codes.OK},
+		{"viewer lists", "viewer-token", "ListApiSpecs", "projects/my-project/apis/a/versions/v", codes.OK},
+		{"viewer writes", "viewer-token", "DeleteApi", "projects/my-project/apis/a", codes.PermissionDenied},
+		{"viewer reads another project", "viewer-token", "GetApi", "projects/other/apis/a", codes.PermissionDenied},
+		{"viewer lists projects", "viewer-token", "ListProjects", "", codes.PermissionDenied},
+		{"editor writes", "editor-token", "CreateApi", "projects/my-other-project", codes.OK},
+		{"editor updates project", "editor-token", "UpdateProject", "projects/my-project", codes.PermissionDenied},
+		{"collection editor writes specs", "specs-token", "UpdateApiSpec", "projects/my-project/apis/a/versions/v/specs/s", codes.OK},
+		{"collection editor writes apis", "specs-token", "UpdateApi", "projects/my-project/apis/a", codes.PermissionDenied},
+		{"collection editor writes deployments", "specs-token", "UpdateApiDeployment", "projects/my-project/apis/a/deployments/d", codes.PermissionDenied},
+		{"admin deletes project", "admin-token", "DeleteProject", "projects/any", codes.OK},
+		{"admin lists projects", "admin-token", "ListProjects", "", codes.OK},
+		{"editor lists audit events", "editor-token", "ListAuditEvents", "projects/my-project", codes.PermissionDenied},
+		{"admin lists audit events", "admin-token", "ListAuditEvents", "projects/my-project", codes.OK},
+		{"viewer waits for operation", "viewer-token", operations + "WaitOperation", "projects/my-project/operations/o", codes.OK},
+		{"viewer cancels operation", "viewer-token", operations + "CancelOperation", "projects/my-project/operations/o", codes.PermissionDenied},
+		{"editor cancels operation", "editor-token", operations + "CancelOperation", "projects/my-project/operations/o", codes.OK},
+		{"viewer lists spec revision tags", "viewer-token", "ListApiSpecRevisionTags", "projects/my-project/apis/a/versions/v/specs/s", codes.OK},
+		{"editor calls unknown method", "editor-token", "UpdateApiSpecPolicy", "projects/my-project/apis/a/versions/v/specs/s", codes.PermissionDenied},
+		{"editor calls registry method on operations service", "editor-token", operations + "UpdateApi", "projects/my-project/apis/a", codes.PermissionDenied},
+		{"editor cancels operation in another project", "editor-token", operations + "CancelOperation", "projects/other/operations/o", codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if test.token != "" {
+				ctx = withToken(test.token)
+			}
+			method := test.method
+			if !strings.HasPrefix(method, "/") {
+				method = service + method
+			}
+			info := &grpc.UnaryServerInfo{FullMethod: method}
+			_, err := checker.UnaryInterceptor(ctx, request(test.name), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if test.token != "" && PrincipalFromContext(ctx) == "" {
+					t.Errorf("handler called without principal")
+				}
+				return nil, nil
+			})
+			if got := status.Code(err); got != test.want {
+				t.Errorf("UnaryInterceptor(%s) returned %s, expected %s: %v", test.method, got, test.want, err)
+			}
+		})
+	}
+}
+
+func TestAnonymous(t *testing.T) {
+	checker, err := NewChecker(Config{
+		Enabled:   true,
+		Anonymous: true,
+		Bindings: []Binding{
+			{Principals: []string{Anonymous}, Role: "viewer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker() returned error: %s", err)
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: service + "GetApi"}
+	_, err = checker.UnaryInterceptor(context.Background(), request("projects/p/apis/a"), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if got := PrincipalFromContext(ctx); got != Anonymous {
+			t.Errorf("PrincipalFromContext() returned %q, expected %q", got, Anonymous)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Errorf("UnaryInterceptor() returned error: %s", err)
+	}
+}
+
+func TestOnDenied(t *testing.T) {
+	checker, err := NewChecker(Config{
+		Enabled: true,
+		Tokens:  map[string]string{"viewer-token": "viewer@example.com"},
+		Bindings: []Binding{
+			{Principals: []string{"viewer@example.com"}, Role: "viewer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker() returned error: %s", err)
+	}
+	var denied []string
+	checker.OnDenied(func(ctx context.Context, method string, req interface{}, err error) {
+		denied = append(denied, strings.TrimSpace(fmt.Sprintf("%s %s %s", PrincipalFromContext(ctx), path.Base(method), status.Code(err))))
+	})
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	for _, call := range []struct {
+		ctx    context.Context
+		method string
+	}{
+		{withToken("viewer-token"), "GetApi"},
+		{withToken("viewer-token"), "DeleteApi"},
+		{context.Background(), "DeleteApi"},
+	} {
+		info := &grpc.UnaryServerInfo{FullMethod: service + call.method}
+		_, _ = checker.UnaryInterceptor(call.ctx, request("projects/p/apis/a"), info, handler)
+	}
+
+	want := "viewer@example.com DeleteApi PermissionDenied; DeleteApi Unauthenticated"
+	if got := strings.Join(denied, "; "); got != want {
+		t.Errorf("OnDenied() recorded %q, expected %q", got, want)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config Config
+	}{
+		{"unknown role", Config{Enabled: true, Bindings: []Binding{{Principals: []string{"*"}, Role: "owner"}}}},
+		{"missing principals", Config{Enabled: true, Bindings: []Binding{{Role: "viewer"}}}},
+		{"bad pattern", Config{Enabled: true, Bindings: []Binding{{Principals: []string{"["}, Role: "viewer"}}}},
+		{"missing issuer", Config{Enabled: true, OIDC: []OIDCProvider{{JWKS: "keys.json"}}}},
+		{"missing jwks", Config{Enabled: true, OIDC: []OIDCProvider{{Issuer: "https://example.com"}}}},
+		{"missing audiences", Config{Enabled: true, OIDC: []OIDCProvider{{Issuer: "https://example.com", JWKS: "keys.json"}}}},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := test.config.Validate(); err == nil {
+				t.Errorf("Validate() succeeded, expected error")
+			}
+		})
+	}
+}
+
+// signRS256 creates a signed JWT with the given key ID and claims.
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Setup: failed to marshal claims: %s", err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Setup: failed to sign token: %s", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestOIDC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Setup: failed to generate key: %s", err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Setup: failed to generate key: %s", err)
+	}
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	const issuer = "https://issuer.example.com"
+	checker, err := NewChecker(Config{
+		Enabled: true,
+		OIDC: []OIDCProvider{{
+			Issuer:    issuer,
+			JWKS:      jwks.URL,
+			Audiences: []string{"registry"},
+		}},
+		Bindings: []Binding{
+			{Principals: []string{"*@example.com"}, Role: "viewer"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewChecker() returned error: %s", err)
+	}
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":            issuer,
+			"aud":            "registry",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"email":          "someone@example.com",
+			"email_verified": true,
+		}
+	}
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAudience := valid()
+	wrongAudience["aud"] = []string{"other"}
+	untrusted := valid()
+	untrusted["iss"] = "https://elsewhere.example.com"
+	noAudience := valid()
+	delete(noAudience, "aud")
+	noEmail := valid()
+	delete(noEmail, "email")
+	unverified := valid()
+	unverified["email_verified"] = false
+	unverifiedString := valid()
+	unverifiedString["email_verified"] = "false"
+	verifiedString := valid()
+	verifiedString["email_verified"] = "true"
+
+	tests := []struct {
+		desc  string
+		token string
+		want  codes.Code
+	}{
+		{"valid", signRS256(t, key, "key-1", valid()), codes.OK},
+		{"expired", signRS256(t, key, "key-1", expired), codes.Unauthenticated},
+		{"wrong audience", signRS256(t, key, "key-1", wrongAudience), codes.Unauthenticated},
+		{"missing audience", signRS256(t, key, "key-1", noAudience), codes.Unauthenticated},
+		{"untrusted issuer", signRS256(t, key, "key-1", untrusted), codes.Unauthenticated},
+		{"missing claim", signRS256(t, key, "key-1", noEmail), codes.Unauthenticated},
+		{"unverified email", signRS256(t, key, "key-1", unverified), codes.Unauthenticated},
+		{"unverified email string", signRS256(t, key, "key-1", unverifiedString), codes.Unauthenticated},
+		{"verified email string", signRS256(t, key, "key-1", verifiedString), codes.OK},
+		{"wrong key", signRS256(t, other, "key-1", valid()), codes.Unauthenticated},
+		{"unknown key", signRS256(t, key, "key-2", valid()), codes.Unauthenticated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			info := &grpc.UnaryServerInfo{FullMethod: service + "GetApi"}
+			_, err := checker.UnaryInterceptor(withToken(test.token), request("projects/p/apis/a"), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				if got := PrincipalFromContext(ctx); got != "someone@example.com" {
+					t.Errorf("PrincipalFromContext() returned %q, expected %q", got, "someone@example.com")
+				}
+				return nil, nil
+			})
+			if got := status.Code(err); got != test.want {
+				t.Errorf("UnaryInterceptor() returned %s, expected %s: %v", got, test.want, err)
+			}
+		})
+	}
+}
+
+func TestKeyFetchDoesNotBlockCachedKeys(t *testing.T) {
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, `{"keys":[]}`)
+	}))
+	defer jwks.Close()
+	defer close(release)
+
+	v := newVerifier(OIDCProvider{Issuer: "https://issuer.example.com", JWKS: jwks.URL, Audiences: []string{"registry"}})
+	cached := &rsa.PublicKey{N: big.NewInt(1), E: 65537}
+	v.keys = map[string]crypto.PublicKey{"key-1": cached}
+	// Keys were fetched long enough ago that unknown keys cause a refetch.
+	v.fetched = time.Now().Add(-2 * time.Minute)
+
+	fetched := make(chan error)
+	go func() {
+		_, err := v.key(context.Background(), "key-2")
+		fetched <- err
+	}()
+	// Wait for the fetch to start.
+	for {
+		v.mu.Lock()
+		started := v.fetching != nil
+		v.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if k, err := v.key(context.Background(), "key-1"); err != nil || k != cached {
+		t.Errorf("key() returned (%v, %v) during a fetch, expected the cached key", k, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v.key(ctx, "key-3"); err != context.Canceled {
+		t.Errorf("key() returned %v while waiting for a fetch, expected %v", err, context.Canceled)
+	}
+
+	release <- struct{}{}
+	if err := <-fetched; err != errUnknownKey {
+		t.Errorf("key() returned %v after the fetch, expected %v", err, errUnknownKey)
+	}
+}
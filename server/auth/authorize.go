@@ -0,0 +1,266 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Role is a set of permissions that can be granted to principals.
+// Each role includes the permissions of the roles before it.
+type Role int
+
+const (
+	roleNone Role = iota
+	RoleViewer
+	RoleEditor
+	RoleAdmin
+)
+
+func parseRole(s string) (Role, error) {
+	switch s {
+	case "viewer":
+		return RoleViewer, nil
+	case "editor":
+		return RoleEditor, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return roleNone, fmt.Errorf("invalid role %q: must be one of [viewer, editor, admin]", s)
+	}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// Methods that can be called by anyone, including unauthenticated callers.
+var publicMethods = map[string]bool{
+	"/google.cloud.apigee.registry.v1.Registry/GetStatus":            true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+const (
+	registryService   = "/google.cloud.apigee.registry.v1.Registry/"
+	operationsService = "/google.longrunning.Operations/"
+)
+
+// Resource collections affected by each method, keyed by full method name.
+// Methods that aren't listed can only be called by administrators.
+var collections = map[string]string{
+	registryService + "ListProjects":                "projects",
+	registryService + "GetProject":                  "projects",
+	registryService + "CreateProject":               "projects",
+	registryService + "UpdateProject":               "projects",
+	registryService + "DeleteProject":               "projects",
+	registryService + "GetProjectUsage":             "projects",
+	registryService + "ListApis":                    "apis",
+	registryService + "GetApi":                      "apis",
+	registryService + "CreateApi":                   "apis",
+	registryService + "UpdateApi":                   "apis",
+	registryService + "DeleteApi":                   "apis",
+	registryService + "ListApiVersions":             "versions",
+	registryService + "GetApiVersion":               "versions",
+	registryService + "CreateApiVersion":            "versions",
+	registryService + "UpdateApiVersion":            "versions",
+	registryService + "DeleteApiVersion":            "versions",
+	registryService + "ListApiSpecs":                "specs",
+	registryService + "GetApiSpec":                  "specs",
+	registryService + "GetApiSpecContents":          "specs",
+	registryService + "CreateApiSpec":               "specs",
+	registryService + "UpdateApiSpec":               "specs",
+	registryService + "DeleteApiSpec":               "specs",
+	registryService + "TagApiSpecRevision":          "specs",
+	registryService + "ListApiSpecRevisions":        "specs",
+	registryService + "ListApiSpecRevisionTags":     "specs",
+	registryService + "RollbackApiSpec":             "specs",
+	registryService + "DeleteApiSpecRevision":       "specs",
+	registryService + "ListApiDeployments":          "deployments",
+	registryService + "GetApiDeployment":            "deployments",
+	registryService + "CreateApiDeployment":         "deployments",
+	registryService + "UpdateApiDeployment":         "deployments",
+	registryService + "DeleteApiDeployment":         "deployments",
+	registryService + "TagApiDeploymentRevision":    "deployments",
+	registryService + "ListApiDeploymentRevisions":  "deployments",
+	registryService + "RollbackApiDeployment":       "deployments",
+	registryService + "DeleteApiDeploymentRevision": "deployments",
+	registryService + "ListArtifacts":               "artifacts",
+	registryService + "GetArtifact":                 "artifacts",
+	registryService + "GetArtifactContents":         "artifacts",
+	registryService + "CreateArtifact":              "artifacts",
+	registryService + "ReplaceArtifact":             "artifacts",
+	registryService + "DeleteArtifact":              "artifacts",
+	registryService + "ListAuditEvents":             "auditEvents",
+	operationsService + "ListOperations":            "operations",
+	operationsService + "GetOperation":              "operations",
+	operationsService + "DeleteOperation":           "operations",
+	operationsService + "CancelOperation":           "operations",
+	operationsService + "WaitOperation":             "operations",
+}
+
+// collection returns the resource collection affected by a method,
+// or the empty string if the method is unknown.
+func collection(method string) string {
+	return collections[method]
+}
+
+// requiredRole returns the role needed to call a method.
+func requiredRole(method string) Role {
+	name := path.Base(method)
+	switch {
+	case collection(method) == "":
+		// Unknown methods aren't covered by collection bindings.
+		return RoleAdmin
+	case collection(method) == "auditEvents":
+		// Audit logs reveal the activity of all principals.
+		return RoleAdmin
+	case strings.HasPrefix(name, "Get"), strings.HasPrefix(name, "List"), strings.HasPrefix(name, "Wait"):
+		return RoleViewer
+	case collection(method) == "projects":
+		// Creating, changing, or deleting projects is an administrative action.
+		return RoleAdmin
+	default:
+		return RoleEditor
+	}
+}
+
+var projectPattern = regexp.MustCompile(`^projects/([^/]+)`)
+
+// project returns the ID of the project that a request refers to,
+// or the empty string if the request is not scoped to a project.
+func project(req interface{}) string {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	if name := resourceName(m.ProtoReflect()); name != "" {
+		if match := projectPattern.FindStringSubmatch(name); match != nil {
+			return match[1]
+		}
+	}
+	// CreateProject identifies the project by ID.
+	r := m.ProtoReflect()
+	if f := r.Descriptor().Fields().ByName("project_id"); f != nil && f.Kind() == protoreflect.StringKind {
+		return r.Get(f).String()
+	}
+	return ""
+}
+
+// resourceName returns the name or parent of the resource a request refers to.
+// Update requests carry the resource as a message with a name field.
+func resourceName(m protoreflect.Message) string {
+	fields := m.Descriptor().Fields()
+	for _, n := range []protoreflect.Name{"name", "parent"} {
+		if f := fields.ByName(n); f != nil && f.Kind() == protoreflect.StringKind && !f.IsList() {
+			if v := m.Get(f).String(); v != "" {
+				return v
+			}
+		}
+	}
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if f.Kind() != protoreflect.MessageKind || f.IsList() || f.IsMap() || !m.Has(f) {
+			continue
+		}
+		sub := m.Get(f).Message()
+		if nf := sub.Descriptor().Fields().ByName("name"); nf != nil && nf.Kind() == protoreflect.StringKind {
+			if v := sub.Get(nf).String(); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// authorizer grants access according to configured bindings.
+type authorizer struct {
+	bindings []Binding
+}
+
+// role returns the strongest role a principal holds for a project and collection.
+// An empty project matches only bindings that apply to all projects.
+func (a *authorizer) role(principal, project, collection string) Role {
+	best := roleNone
+	for _, b := range a.bindings {
+		if !matchesAny(b.Principals, principal) {
+			continue
+		}
+		if len(b.Projects) > 0 && !projectMatches(b.Projects, project) {
+			continue
+		}
+		if len(b.Collections) > 0 && !matchesAny(b.Collections, collection) {
+			continue
+		}
+		if r, err := parseRole(b.Role); err == nil && r > best {
+			best = r
+		}
+	}
+	return best
+}
+
+// check returns an error if the principal may not call the method with the request.
+func (a *authorizer) check(principal, method string, req interface{}) error {
+	want := requiredRole(method)
+	p := project(req)
+	c := collection(method)
+	if got := a.role(principal, p, c); got < want {
+		if p == "" {
+			return fmt.Errorf("%s requires role %q on all projects", path.Base(method), want)
+		}
+		return fmt.Errorf("%s requires role %q on %s in project %q", path.Base(method), want, c, p)
+	}
+	return nil
+}
+
+func projectMatches(patterns []string, project string) bool {
+	if project == "" {
+		return contains(patterns, "*")
+	}
+	return matchesAny(patterns, project)
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if m, err := filepath.Match(p, s); err == nil && m {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
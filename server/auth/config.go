@@ -0,0 +1,102 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth authenticates and authorizes calls to the registry server.
+package auth
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Config configures authentication and authorization.
+type Config struct {
+	// Enabled turns on authentication and authorization checks.
+	Enabled bool `yaml:"enabled"`
+	// Anonymous allows uncredentialed calls, which are made by the
+	// "anonymous" principal and can be granted roles in bindings.
+	Anonymous bool `yaml:"anonymous"`
+	// Tokens maps static bearer tokens to principals (for testing only).
+	Tokens map[string]string `yaml:"tokens"`
+	// OIDC lists identity providers whose JWTs are accepted.
+	OIDC []OIDCProvider `yaml:"oidc"`
+	// Bindings grant roles to principals.
+	Bindings []Binding `yaml:"bindings"`
+}
+
+// OIDCProvider configures verification of JWTs issued by an identity provider.
+type OIDCProvider struct {
+	// Issuer must match the "iss" claim of accepted tokens.
+	Issuer string `yaml:"issuer"`
+	// JWKS is the URL or local file path of the provider's JSON Web Key Set.
+	JWKS string `yaml:"jwks"`
+	// Audiences lists accepted values of the "aud" claim and must not be empty,
+	// since providers like accounts.google.com issue tokens for many clients.
+	Audiences []string `yaml:"audiences"`
+	// Claim names the claim used as the principal. Defaults to "email".
+	// Email principals are only accepted from tokens with a true
+	// "email_verified" claim.
+	Claim string `yaml:"claim"`
+	// RefreshInterval is the maximum age of cached keys. Defaults to one hour.
+	RefreshInterval time.Duration `yaml:"refreshinterval"`
+}
+
+// Binding grants a role to a set of principals.
+type Binding struct {
+	// Principals are patterns matched against principal names
+	// using filepath.Match, e.g. "*@example.com".
+	Principals []string `yaml:"principals"`
+	// Role is one of "viewer", "editor", or "admin".
+	Role string `yaml:"role"`
+	// Projects lists project IDs (or patterns) where the role applies.
+	// If empty, the role applies to all projects.
+	Projects []string `yaml:"projects"`
+	// Collections lists resource collections where the role applies,
+	// e.g. "apis", "versions", "specs", "artifacts".
+	// If empty, the role applies to all collections.
+	Collections []string `yaml:"collections"`
+}
+
+// Validate checks the configuration for errors.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	for _, p := range c.OIDC {
+		if p.Issuer == "" {
+			return fmt.Errorf("invalid oidc provider: issuer must not be empty")
+		}
+		if p.JWKS == "" {
+			return fmt.Errorf("invalid oidc provider %q: jwks must not be empty", p.Issuer)
+		}
+		if len(p.Audiences) == 0 {
+			return fmt.Errorf("invalid oidc provider %q: audiences must not be empty", p.Issuer)
+		}
+	}
+	for _, b := range c.Bindings {
+		if _, err := parseRole(b.Role); err != nil {
+			return err
+		}
+		if len(b.Principals) == 0 {
+			return fmt.Errorf("invalid binding for role %q: principals must not be empty", b.Role)
+		}
+		for _, p := range append(append(b.Principals, b.Projects...), b.Collections...) {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %s", p, err)
+			}
+		}
+	}
+	return nil
+}
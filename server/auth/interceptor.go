@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var bearerPattern = regexp.MustCompile("^[bB]earer[ ]+(.*)$")
+
+// Checker authenticates callers and authorizes their calls.
+type Checker struct {
+	config     Config
+	verifiers  []*verifier
+	authorizer *authorizer
+	denied     func(ctx context.Context, method string, req interface{}, err error)
+}
+
+// NewChecker creates a Checker from a configuration.
+func NewChecker(config Config) (*Checker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	c := &Checker{
+		config:     config,
+		authorizer: &authorizer{bindings: config.Bindings},
+	}
+	for _, p := range config.OIDC {
+		c.verifiers = append(c.verifiers, newVerifier(p))
+	}
+	return c, nil
+}
+
+// OnDenied sets a function to be called with each unary call that is
+// rejected. Its context carries the principal if the caller was
+// authenticated but not authorized.
+func (c *Checker) OnDenied(f func(ctx context.Context, method string, req interface{}, err error)) {
+	c.denied = f
+}
+
+// UnaryInterceptor checks unary calls.
+func (c *Checker) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := c.check(ctx, info.FullMethod, req)
+	if err != nil {
+		if c.denied != nil {
+			c.denied(ctx, info.FullMethod, req, err)
+		}
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor checks streaming calls.
+// Requests on streams are not inspected, so only bindings that
+// apply to all projects authorize them.
+func (c *Checker) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := c.check(ss.Context(), info.FullMethod, nil)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+}
+
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+// check authenticates the caller and returns a context carrying the principal.
+func (c *Checker) check(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	principal, err := c.authenticate(ctx)
+	if err != nil && !publicMethods[method] {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if principal != "" {
+		ctx = NewContext(ctx, principal)
+	}
+	if publicMethods[method] {
+		return ctx, nil
+	}
+	if err := c.authorizer.check(principal, method, req); err != nil {
+		return ctx, status.Errorf(codes.PermissionDenied, "permission denied for %q: %s", principal, err)
+	}
+	return ctx, nil
+}
+
+// authenticate returns the principal identified by the call's credentials.
+func (c *Checker) authenticate(ctx context.Context) (string, error) {
+	credential := bearerToken(ctx)
+	if credential == "" {
+		if c.config.Anonymous {
+			return Anonymous, nil
+		}
+		return "", errors.New("missing credentials")
+	}
+
+	// Static tokens are compared in constant time so that response times
+	// don't reveal how much of a token was guessed.
+	for token, principal := range c.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(credential)) == 1 {
+			return principal, nil
+		}
+	}
+
+	header, claims, sig, err := splitJWT(credential)
+	if err != nil {
+		return "", errors.New("invalid credentials")
+	}
+	for _, v := range c.verifiers {
+		if v.provider.Issuer != claims.Issuer {
+			continue
+		}
+		principal, err := v.verify(ctx, credential, header, claims, sig)
+		if err != nil {
+			return "", err
+		}
+		return principal, nil
+	}
+	return "", errors.New("token issuer is not trusted")
+}
+
+// bearerToken returns the bearer token in the call's authorization header.
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, v := range md.Get("authorization") {
+		if m := bearerPattern.FindStringSubmatch(strings.TrimSpace(v)); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
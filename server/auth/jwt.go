@@ -0,0 +1,397 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Allowed difference between the server clock and token timestamps.
+const clockSkew = time.Minute
+
+var errUnknownKey = errors.New("unknown signing key")
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims holds the registered claims checked by the verifier
+// along with all claims for principal lookup.
+type jwtClaims struct {
+	Issuer    string
+	Audiences []string
+	Expiry    time.Time
+	NotBefore time.Time
+	All       map[string]interface{}
+}
+
+// splitJWT decodes the parts of a compact-serialized JWT.
+func splitJWT(token string) (*jwtHeader, *jwtClaims, []byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, nil, errors.New("malformed token")
+	}
+	h, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed token header: %s", err)
+	}
+	header := &jwtHeader{}
+	if err := json.Unmarshal(h, header); err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed token header: %s", err)
+	}
+	p, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed token payload: %s", err)
+	}
+	claims, err := parseClaims(p)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("malformed token signature: %s", err)
+	}
+	return header, claims, sig, nil
+}
+
+func parseClaims(b []byte) (*jwtClaims, error) {
+	all := make(map[string]interface{})
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, fmt.Errorf("malformed token payload: %s", err)
+	}
+	c := &jwtClaims{All: all}
+	c.Issuer, _ = all["iss"].(string)
+	switch aud := all["aud"].(type) {
+	case string:
+		c.Audiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				c.Audiences = append(c.Audiences, s)
+			}
+		}
+	}
+	if exp, ok := all["exp"].(float64); ok {
+		c.Expiry = time.Unix(int64(exp), 0)
+	}
+	if nbf, ok := all["nbf"].(float64); ok {
+		c.NotBefore = time.Unix(int64(nbf), 0)
+	}
+	return c, nil
+}
+
+// verifier checks tokens issued by a single OIDC provider.
+// The module has no JOSE dependency, so verification is limited to the
+// RS* and ES* algorithms, each bound to the type of the signing key;
+// unsigned and HMAC-signed tokens are always rejected.
+type verifier struct {
+	provider OIDCProvider
+	client   *http.Client
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	fetched  time.Time
+	fetching chan struct{} // closed when the current fetch of the key set ends
+}
+
+func newVerifier(p OIDCProvider) *verifier {
+	if p.Claim == "" {
+		p.Claim = "email"
+	}
+	if p.RefreshInterval <= 0 {
+		p.RefreshInterval = time.Hour
+	}
+	return &verifier{
+		provider: p,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// verify checks the token signature and claims and returns the principal.
+func (v *verifier) verify(ctx context.Context, token string, header *jwtHeader, claims *jwtClaims, sig []byte) (string, error) {
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return "", err
+	}
+	signed := token[:strings.LastIndex(token, ".")]
+	if err := verifySignature(header.Alg, key, []byte(signed), sig); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if claims.Expiry.IsZero() || now.After(claims.Expiry.Add(clockSkew)) {
+		return "", errors.New("token is expired")
+	}
+	if now.Add(clockSkew).Before(claims.NotBefore) {
+		return "", errors.New("token is not yet valid")
+	}
+	if !intersects(v.provider.Audiences, claims.Audiences) {
+		return "", fmt.Errorf("token audience %v is not accepted", claims.Audiences)
+	}
+
+	principal, _ := claims.All[v.provider.Claim].(string)
+	if principal == "" {
+		return "", fmt.Errorf("token has no %q claim", v.provider.Claim)
+	}
+	// Providers may issue tokens with addresses that their owners haven't confirmed.
+	if v.provider.Claim == "email" && !isTrue(claims.All["email_verified"]) {
+		return "", errors.New("token email is not verified")
+	}
+	return principal, nil
+}
+
+// isTrue reports whether a claim is true. Some providers send booleans as strings.
+func isTrue(claim interface{}) bool {
+	switch c := claim.(type) {
+	case bool:
+		return c
+	case string:
+		return c == "true"
+	}
+	return false
+}
+
+// key returns the public key with the given ID, fetching the key set
+// when it is stale or does not contain the key. The lock is not held
+// during fetches; concurrent callers wait for the fetch in progress.
+func (v *verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	for {
+		stale := time.Since(v.fetched) > v.provider.RefreshInterval
+		if k, ok := v.lookup(kid); ok && !stale {
+			v.mu.Unlock()
+			return k, nil
+		}
+		// Avoid refetching on every call with an unknown key.
+		if !stale && time.Since(v.fetched) < time.Minute {
+			v.mu.Unlock()
+			return nil, errUnknownKey
+		}
+		if v.fetching == nil {
+			break
+		}
+		fetching := v.fetching
+		v.mu.Unlock()
+		select {
+		case <-fetching:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		v.mu.Lock()
+	}
+	done := make(chan struct{})
+	v.fetching = done
+	v.mu.Unlock()
+
+	keys, err := v.fetch(ctx)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.fetching = nil
+	close(done)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keys for %q: %s", v.provider.Issuer, err)
+	}
+	v.keys = keys
+	v.fetched = time.Now()
+	if k, ok := v.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, errUnknownKey
+}
+
+// lookup returns a cached key. It must be called with v.mu held.
+func (v *verifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if k, ok := v.keys[kid]; ok {
+		return k, true
+	}
+	// Tokens without a key ID can be verified if the set has only one key.
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func (v *verifier) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	location := v.provider.JWKS
+	var b []byte
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := v.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected response status %q", resp.Status)
+		}
+		b, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		b, err = ioutil.ReadFile(strings.TrimPrefix(location, "file://"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parseJWKS(b)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the signing keys in a JSON Web Key Set.
+// Unsupported keys are ignored.
+func parseJWKS(b []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err := decodeBigInt(k.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := decodeBigInt(k.Y)
+			if err != nil {
+				return nil, err
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	return keys, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %s", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// verifySignature checks a JWS signature for the supported algorithms.
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %q does not match RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, sig); err != nil {
+			return errors.New("invalid token signature")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %q does not match EC key", alg)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+	default:
+		return errors.New("unsupported key type")
+	}
+	return nil
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apigee/registry/server/gateway"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// defaultCacheBytes is the size of the contents cache when none is configured.
+const defaultCacheBytes = 32 << 20
+
+// CacheConfig configures the in-process cache of spec and artifact contents.
+type CacheConfig struct {
+	// MaxBytes is the total size of cached contents. Zero uses a default
+	// of 32 MiB and negative values disable caching.
+	MaxBytes int64 `yaml:"maxbytes"`
+}
+
+// contents describes stored contents that are served by a contents method.
+type contents struct {
+	// key identifies the stored contents, e.g. a spec revision name.
+	key string
+	// hash is the hash of the stored contents.
+	hash string
+	// mimeType is the stored MIME type, which may include a "+gzip" suffix.
+	mimeType string
+	// load reads the stored contents.
+	load func() ([]byte, error)
+}
+
+// contentsResponse serves stored contents. Responses carry a strong ETag
+// derived from the hash of the stored contents, and callers that send a
+// matching If-None-Match get an empty response marked as not modified.
+// Gzipped contents are unzipped unless the caller accepts gzip encoding,
+// which HTTP clients indicate with Accept-Encoding headers and gRPC and
+// gRPC-Web clients indicate with x-http-accept-encoding metadata.
+func (s *RegistryServer) contentsResponse(ctx context.Context, c contents) (*httpbody.HttpBody, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	gzipped := strings.Contains(c.mimeType, "+gzip")
+	encoded := gzipped && acceptsGzip(md.Get(gateway.AcceptEncodingKey))
+	body := &httpbody.HttpBody{
+		ContentType: strings.Replace(c.mimeType, "+gzip", "", 1),
+	}
+
+	header := metadata.MD{}
+	if gzipped {
+		// The representation of gzipped contents depends on the accepted
+		// encodings, so shared caches must key responses on them.
+		header.Set("vary", "Accept-Encoding")
+	}
+	etag := ""
+	if c.hash != "" {
+		etag = strconv.Quote(c.hash)
+		if gzipped && !encoded {
+			// Unzipped contents are a different representation of the stored contents.
+			etag = strconv.Quote(c.hash + "-identity")
+		}
+		header.Set("etag", etag)
+	}
+
+	if etag != "" && etagMatches(md.Get("if-none-match"), etag) {
+		header.Set(gateway.StatusCodeKey, strconv.Itoa(http.StatusNotModified))
+		setHeader(ctx, header)
+		return body, nil
+	}
+
+	key := c.key + "#" + etag
+	data, ok := s.cache.get(key)
+	if !ok {
+		var err error
+		if data, err = c.load(); err != nil {
+			return nil, err
+		}
+		if gzipped && !encoded {
+			if data, err = GUnzippedBytes(data); err != nil {
+				return nil, status.Errorf(codes.FailedPrecondition, "failed to unzip contents with gzip MIME type: %s", err)
+			}
+		}
+		if etag != "" {
+			s.cache.put(key, data)
+		}
+	}
+
+	if encoded {
+		header.Set(gateway.ContentEncodingKey, "gzip")
+	}
+	setHeader(ctx, header)
+	body.Data = data
+	return body, nil
+}
+
+// setHeader sends response headers when the call has a transport stream.
+// Calls made directly on the server, as in tests, have no headers to set.
+func setHeader(ctx context.Context, header metadata.MD) {
+	if len(header) > 0 {
+		_ = grpc.SetHeader(ctx, header)
+	}
+}
+
+// acceptsGzip returns true if Accept-Encoding values allow gzip encoding.
+func acceptsGzip(values []string) bool {
+	for _, v := range values {
+		for _, coding := range strings.Split(v, ",") {
+			parts := strings.Split(coding, ";")
+			if name := strings.TrimSpace(parts[0]); name != "gzip" && name != "*" {
+				continue
+			}
+			// Codings with a zero quality value are not acceptable.
+			if len(parts) > 1 {
+				if q := strings.TrimSpace(parts[1]); strings.HasPrefix(q, "q=") {
+					if f, err := strconv.ParseFloat(q[2:], 64); err == nil && f == 0 {
+						continue
+					}
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches returns true if If-None-Match values match an entity tag.
+// As described in RFC 7232, the comparison is weak.
+func etagMatches(values []string, etag string) bool {
+	for _, v := range values {
+		for _, tag := range strings.Split(v, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contentsCache is a least-recently-used cache of contents, limited by total size.
+type contentsCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+// newContentsCache returns a cache of the configured size, or nil if caching is disabled.
+func newContentsCache(config CacheConfig) *contentsCache {
+	size := config.MaxBytes
+	if size == 0 {
+		size = defaultCacheBytes
+	} else if size < 0 {
+		return nil
+	}
+	return &contentsCache{
+		maxBytes: size,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns cached contents. A nil cache contains nothing.
+func (c *contentsCache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).data, true
+}
+
+// put adds contents to the cache, removing the least recently used
+// contents as needed. Contents larger than the cache are not added.
+func (c *contentsCache) put(key string, data []byte) {
+	if c == nil || int64(len(data)) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.bytes -= int64(len(e.Value.(*cacheEntry).data))
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+	for c.bytes+int64(len(data)) > c.maxBytes {
+		oldest := c.order.Back()
+		entry := c.order.Remove(oldest).(*cacheEntry)
+		delete(c.entries, entry.key)
+		c.bytes -= int64(len(entry.data))
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, data: data})
+	c.bytes += int64(len(data))
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	"github.com/apigee/registry/server/gateway"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"gzip"}, true},
+		{[]string{"deflate, gzip;q=0.5"}, true},
+		{[]string{"br", "gzip"}, true},
+		{[]string{"*"}, true},
+		{[]string{"gzip;q=0"}, false},
+		{[]string{"identity"}, false},
+		{[]string{"x-gzip"}, false},
+	}
+
+	for _, test := range tests {
+		if got := acceptsGzip(test.values); got != test.want {
+			t.Errorf("acceptsGzip(%q) returned %t, want %t", test.values, got, test.want)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{`"abc"`}, true},
+		{[]string{`W/"abc"`}, true},
+		{[]string{`"xyz", "abc"`}, true},
+		{[]string{`*`}, true},
+		{[]string{`"xyz"`}, false},
+		{[]string{`abc`}, false},
+	}
+
+	for _, test := range tests {
+		if got := etagMatches(test.values, `"abc"`); got != test.want {
+			t.Errorf("etagMatches(%q) returned %t, want %t", test.values, got, test.want)
+		}
+	}
+}
+
+func TestContentsCache(t *testing.T) {
+	c := newContentsCache(CacheConfig{MaxBytes: 10})
+	c.put("a", []byte("aaaa"))
+	c.put("b", []byte("bbbb"))
+
+	// Reading "a" makes "b" the least recently used.
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("get(a) missed")
+	}
+	c.put("c", []byte("cccc"))
+
+	if _, ok := c.get("b"); ok {
+		t.Errorf("get(b) hit after it should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("get(%s) missed", key)
+		}
+	}
+	if c.bytes != 8 {
+		t.Errorf("cache holds %d bytes, want %d", c.bytes, 8)
+	}
+
+	// Contents larger than the cache are not cached.
+	c.put("big", []byte("0123456789a"))
+	if _, ok := c.get("big"); ok {
+		t.Errorf("get(big) hit for contents larger than the cache")
+	}
+
+	if c := newContentsCache(CacheConfig{MaxBytes: -1}); c != nil {
+		t.Errorf("newContentsCache() with negative size returned a cache")
+	}
+	if c := newContentsCache(CacheConfig{}); c.maxBytes != defaultCacheBytes {
+		t.Errorf("newContentsCache() with no size has %d bytes, want %d", c.maxBytes, defaultCacheBytes)
+	}
+}
+
+func TestContentsResponse(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	s := &RegistryServer{cache: newContentsCache(CacheConfig{})}
+	loads := 0
+	c := contents{
+		key:      "projects/p/apis/a/versions/v/specs/s@r",
+		hash:     "abc",
+		mimeType: "application/x.openapi+gzip;version=3",
+		load: func() ([]byte, error) {
+			loads++
+			return zipped.Bytes(), nil
+		},
+	}
+
+	ctx := context.Background()
+	resp, err := s.contentsResponse(ctx, c)
+	if err != nil {
+		t.Fatalf("contentsResponse() returned error: %s", err)
+	}
+	if string(resp.GetData()) != "hello" {
+		t.Errorf("contentsResponse() returned %q, want unzipped contents", resp.GetData())
+	}
+	if resp.GetContentType() != "application/x.openapi;version=3" {
+		t.Errorf("contentsResponse() returned content type %q", resp.GetContentType())
+	}
+
+	// Repeated reads are served from the cache.
+	if _, err := s.contentsResponse(ctx, c); err != nil {
+		t.Fatalf("contentsResponse() returned error: %s", err)
+	}
+	if loads != 1 {
+		t.Errorf("contents were loaded %d times, want %d", loads, 1)
+	}
+
+	// Callers that accept gzip get the stored contents.
+	gzipCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(gateway.AcceptEncodingKey, "gzip"))
+	resp, err = s.contentsResponse(gzipCtx, c)
+	if err != nil {
+		t.Fatalf("contentsResponse() returned error: %s", err)
+	}
+	if !bytes.Equal(resp.GetData(), zipped.Bytes()) {
+		t.Errorf("contentsResponse() accepting gzip returned %q, want zipped contents", resp.GetData())
+	}
+
+	// Callers that already have the contents get no data.
+	cachedCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("if-none-match", `"abc-identity"`))
+	resp, err = s.contentsResponse(cachedCtx, c)
+	if err != nil {
+		t.Fatalf("contentsResponse() returned error: %s", err)
+	}
+	if len(resp.GetData()) != 0 {
+		t.Errorf("contentsResponse() with matching If-None-Match returned %q, want no data", resp.GetData())
+	}
+
+	// Unreadable gzipped contents are reported.
+	c.key, c.hash = "other", "xyz"
+	c.load = func() ([]byte, error) { return []byte("not gzip"), nil }
+	if _, err := s.contentsResponse(ctx, c); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("contentsResponse() with invalid gzip returned status code %s, want %s", status.Code(err), codes.FailedPrecondition)
+	}
+}
@@ -43,6 +43,7 @@ var apiFields = []filtering.Field{
 	{Name: "availability", Type: filtering.String},
 	{Name: "recommended_version", Type: filtering.String},
 	{Name: "labels", Type: filtering.StringMap},
+	{Name: "annotations", Type: filtering.StringMap},
 }
 
 func (d *DAO) ListApis(ctx context.Context, parent names.Project, opts PageOptions) (ApiList, error) {
@@ -59,6 +60,18 @@ func (d *DAO) ListApis(ctx context.Context, parent names.Project, opts PageOptio
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ApiList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, apiFields)
+	if err != nil {
+		return ApiList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if parent.ProjectID != "-" {
@@ -118,6 +131,11 @@ func apiMap(api models.Api) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	annotations, err := api.AnnotationsMap()
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
 		"name":                api.Name(),
 		"project_id":          api.ProjectID,
@@ -129,6 +147,7 @@ func apiMap(api models.Api) (map[string]interface{}, error) {
 		"availability":        api.Availability,
 		"recommended_version": api.RecommendedVersion,
 		"labels":              labels,
+		"annotations":         annotations,
 	}, nil
 }
 
@@ -59,6 +59,18 @@ func (d *DAO) ListSpecArtifacts(ctx context.Context, parent names.Spec, opts Pag
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, artifactFields)
+	if err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if id := parent.ProjectID; id != "-" {
@@ -112,6 +124,18 @@ func (d *DAO) ListVersionArtifacts(ctx context.Context, parent names.Version, op
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, artifactFields)
+	if err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if id := parent.ProjectID; id != "-" {
@@ -159,6 +183,18 @@ func (d *DAO) ListApiArtifacts(ctx context.Context, parent names.Api, opts PageO
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, artifactFields)
+	if err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if id := parent.ProjectID; id != "-" {
@@ -200,6 +236,18 @@ func (d *DAO) ListProjectArtifacts(ctx context.Context, parent names.Project, op
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, artifactFields)
+	if err != nil {
+		return ArtifactList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if id := parent.ProjectID; id != "-" {
@@ -0,0 +1,144 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/apigee/registry/server/storage"
+	"github.com/apigee/registry/server/storage/filtering"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditEventList contains a page of audit events.
+type AuditEventList struct {
+	AuditEvents []models.AuditEvent
+	Token       string
+}
+
+var auditEventFields = []filtering.Field{
+	{Name: "name", Type: filtering.String},
+	{Name: "project_id", Type: filtering.String},
+	{Name: "event_time", Type: filtering.Timestamp},
+	{Name: "method", Type: filtering.String},
+	{Name: "resource", Type: filtering.String},
+	{Name: "principal", Type: filtering.String},
+	{Name: "request", Type: filtering.String},
+	{Name: "update_mask", Type: filtering.String},
+	{Name: "code", Type: filtering.Int},
+	{Name: "message", Type: filtering.String},
+}
+
+// ListAuditEvents lists audit events in the order they were recorded.
+// Events remain listable after their project has been deleted.
+func (d *DAO) ListAuditEvents(ctx context.Context, parent names.Project, opts PageOptions) (AuditEventList, error) {
+	q := d.NewQuery(storage.AuditEventEntityName)
+
+	token, err := decodeToken(opts.Token)
+	if err != nil {
+		return AuditEventList{}, status.Errorf(codes.InvalidArgument, "invalid page token %q: %s", opts.Token, err.Error())
+	}
+
+	if err := token.ValidateFilter(opts.Filter); err != nil {
+		return AuditEventList{}, status.Errorf(codes.InvalidArgument, "invalid filter %q: %s", opts.Filter, err)
+	} else {
+		token.Filter = opts.Filter
+	}
+
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return AuditEventList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, auditEventFields)
+	if err != nil {
+		return AuditEventList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
+	q = q.ApplyOffset(token.Offset)
+
+	if parent.ProjectID != "-" {
+		q = q.Require("ProjectID", parent.ProjectID)
+	}
+
+	filter, err := filtering.NewFilter(opts.Filter, auditEventFields)
+	if err != nil {
+		return AuditEventList{}, err
+	}
+
+	it := d.Run(ctx, q)
+	response := AuditEventList{
+		AuditEvents: make([]models.AuditEvent, 0, opts.Size),
+	}
+
+	event := new(models.AuditEvent)
+	for _, err = it.Next(event); err == nil; _, err = it.Next(event) {
+		match, err := filter.Matches(auditEventMap(*event))
+		if err != nil {
+			return response, err
+		} else if !match {
+			token.Offset++
+			continue
+		} else if len(response.AuditEvents) == int(opts.Size) {
+			break
+		}
+
+		response.AuditEvents = append(response.AuditEvents, *event)
+		token.Offset++
+	}
+	if err != nil && err != iterator.Done {
+		return response, status.Error(codes.Internal, err.Error())
+	}
+
+	if err == nil {
+		response.Token, err = encodeToken(token)
+		if err != nil {
+			return response, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return response, nil
+}
+
+func auditEventMap(e models.AuditEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        e.Name(),
+		"project_id":  e.ProjectID,
+		"event_time":  e.EventTime,
+		"method":      e.Method,
+		"resource":    e.Resource,
+		"principal":   e.Principal,
+		"request":     e.Request,
+		"update_mask": e.UpdateMask,
+		"code":        int64(e.Code),
+		"message":     e.ErrorMessage,
+	}
+}
+
+// SaveAuditEvent records an audit event.
+func (d *DAO) SaveAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	k := d.NewKey(storage.AuditEventEntityName, event.Name())
+	if _, err := d.Put(ctx, k, event); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
@@ -16,11 +16,14 @@ package dao
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/gob"
 	"fmt"
 
 	"github.com/apigee/registry/server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // PageOptions contains custom arguments for listing requests.
@@ -30,6 +33,8 @@ type PageOptions struct {
 	Size int32
 	// Filter is the filter string for this listing request, as described at https://google.aip.dev/160.
 	Filter string
+	// Order is the order_by string for this listing request, as described at https://google.aip.dev/132#ordering.
+	Order string
 	// Token is a value returned from with a previous page in a series of listing requests.
 	// If specified, listing will continue from the end of the previous page. Otherwise,
 	// the first page in a listing series will be returned.
@@ -46,6 +51,19 @@ func NewDAO(c storage.Client) DAO {
 	}
 }
 
+// Transaction calls f with a DAO whose changes are saved together if f
+// returns nil, and discarded otherwise. Transactions that fail because
+// they conflict with others return Aborted errors and can be retried.
+func (d *DAO) Transaction(ctx context.Context, f func(ctx context.Context, db DAO) error) error {
+	err := d.Client.Transaction(ctx, func(ctx context.Context, tx storage.Client) error {
+		return f(ctx, NewDAO(tx))
+	})
+	if _, ok := status.FromError(err); !ok {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return err
+}
+
 // token contains information to share between sequential page iterators.
 type token struct {
 	// Offset is the number of resources that should be skipped before the page begins.
@@ -53,6 +71,8 @@ type token struct {
 	Offset int32
 	// Filter is the filter string for this listing request. It should be consistent between sequential pages.
 	Filter string
+	// Order is the order_by string for this listing request. It should be consistent between sequential pages.
+	Order string
 }
 
 // ValidateFilter returns an error if the new filter doesn't match the token's encoded filter.
@@ -65,6 +85,16 @@ func (t token) ValidateFilter(newFilter string) error {
 	return nil
 }
 
+// ValidateOrder returns an error if the new ordering doesn't match the token's encoded ordering.
+// When the token represents the first page, any ordering is valid and no error will be returned.
+func (t token) ValidateOrder(newOrder string) error {
+	if t.Offset > 0 && newOrder != t.Order {
+		return fmt.Errorf("new order_by does not match previous order_by %q", t.Order)
+	}
+
+	return nil
+}
+
 // encodeToken converts a token struct into an opaque string that can be converted back into struct form using decodeToken().
 func encodeToken(o token) (string, error) {
 	var encoding bytes.Buffer
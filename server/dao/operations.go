@@ -0,0 +1,175 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/apigee/registry/server/storage"
+	"github.com/apigee/registry/server/storage/filtering"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OperationList contains a page of operation resources.
+type OperationList struct {
+	Operations []models.Operation
+	Token      string
+}
+
+var operationFields = []filtering.Field{
+	{Name: "name", Type: filtering.String},
+	{Name: "project_id", Type: filtering.String},
+	{Name: "create_time", Type: filtering.Timestamp},
+	{Name: "end_time", Type: filtering.Timestamp},
+	{Name: "verb", Type: filtering.String},
+	{Name: "target", Type: filtering.String},
+	{Name: "done", Type: filtering.Bool},
+	{Name: "cancel_requested", Type: filtering.Bool},
+	{Name: "code", Type: filtering.Int},
+}
+
+// ListOperations lists the operations of a project in the order they were created.
+// Operations remain listable after their project has been deleted.
+func (d *DAO) ListOperations(ctx context.Context, parent names.Project, opts PageOptions) (OperationList, error) {
+	q := d.NewQuery(storage.OperationEntityName)
+
+	token, err := decodeToken(opts.Token)
+	if err != nil {
+		return OperationList{}, status.Errorf(codes.InvalidArgument, "invalid page token %q: %s", opts.Token, err.Error())
+	}
+
+	if err := token.ValidateFilter(opts.Filter); err != nil {
+		return OperationList{}, status.Errorf(codes.InvalidArgument, "invalid filter %q: %s", opts.Filter, err)
+	} else {
+		token.Filter = opts.Filter
+	}
+
+	q = q.Ascending("CreateTime")
+	q = q.ApplyOffset(token.Offset)
+
+	if parent.ProjectID != "-" {
+		q = q.Require("ProjectID", parent.ProjectID)
+	}
+
+	filter, err := filtering.NewFilter(opts.Filter, operationFields)
+	if err != nil {
+		return OperationList{}, err
+	}
+
+	it := d.Run(ctx, q)
+	response := OperationList{
+		Operations: make([]models.Operation, 0, opts.Size),
+	}
+
+	op := new(models.Operation)
+	for _, err = it.Next(op); err == nil; _, err = it.Next(op) {
+		match, err := filter.Matches(operationMap(*op))
+		if err != nil {
+			return response, err
+		} else if !match {
+			token.Offset++
+			continue
+		} else if len(response.Operations) == int(opts.Size) {
+			break
+		}
+
+		response.Operations = append(response.Operations, *op)
+		token.Offset++
+	}
+	if err != nil && err != iterator.Done {
+		return response, status.Error(codes.Internal, err.Error())
+	}
+
+	if err == nil {
+		response.Token, err = encodeToken(token)
+		if err != nil {
+			return response, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return response, nil
+}
+
+func operationMap(o models.Operation) map[string]interface{} {
+	return map[string]interface{}{
+		"name":             o.Name(),
+		"project_id":       o.ProjectID,
+		"create_time":      o.CreateTime,
+		"end_time":         o.EndTime,
+		"verb":             o.Verb,
+		"target":           o.Target,
+		"done":             o.Done,
+		"cancel_requested": o.CancelRequested,
+		"code":             int64(o.Code),
+	}
+}
+
+// ListUnfinishedOperations returns all operations that have not finished, in
+// all projects, in the order they were created.
+func (d *DAO) ListUnfinishedOperations(ctx context.Context) ([]models.Operation, error) {
+	q := d.NewQuery(storage.OperationEntityName)
+	q = q.Require("Done", false)
+	q = q.Ascending("CreateTime")
+
+	var ops []models.Operation
+	it := d.Run(ctx, q)
+	op := new(models.Operation)
+	var err error
+	for _, err = it.Next(op); err == nil; _, err = it.Next(op) {
+		ops = append(ops, *op)
+	}
+	if err != iterator.Done {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return ops, nil
+}
+
+// GetOperation returns the named operation.
+func (d *DAO) GetOperation(ctx context.Context, name names.Operation) (*models.Operation, error) {
+	op := new(models.Operation)
+	k := d.NewKey(storage.OperationEntityName, name.String())
+	if err := d.Get(ctx, k, op); d.IsNotFound(err) {
+		return nil, status.Errorf(codes.NotFound, "%q not found", name)
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return op, nil
+}
+
+// SaveOperation saves the state of an operation.
+func (d *DAO) SaveOperation(ctx context.Context, op *models.Operation) error {
+	k := d.NewKey(storage.OperationEntityName, op.Name())
+	if _, err := d.Put(ctx, k, op); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
+
+// DeleteOperation deletes the record of an operation.
+func (d *DAO) DeleteOperation(ctx context.Context, name names.Operation) error {
+	k := d.NewKey(storage.OperationEntityName, name.String())
+	if err := d.Delete(ctx, k); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
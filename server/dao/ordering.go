@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/apigee/registry/server/storage"
+	"github.com/apigee/registry/server/storage/filtering"
+)
+
+// storageFields maps the names of fields that are stored under different
+// names to their storage names. Other fields are stored under the
+// CamelCase forms of their names, e.g. "create_time" as "CreateTime".
+var storageFields = map[string]string{
+	"name":       "Key",
+	"filename":   "FileName",
+	"size_bytes": "SizeInBytes",
+	"source_uri": "SourceURI",
+}
+
+// orderField is a single field of an ordering.
+type orderField struct {
+	field      string // storage name of the field
+	descending bool
+}
+
+// ordering is a parsed order_by string, as described at https://google.aip.dev/132#ordering.
+type ordering []orderField
+
+// parseOrdering parses an order_by string. Results may be ordered by any
+// field that can be used in filters, except for map and parent fields.
+func parseOrdering(orderBy string, fields []filtering.Field) (ordering, error) {
+	var o ordering
+	if strings.TrimSpace(orderBy) == "" {
+		return o, nil
+	}
+
+	types := make(map[string]filtering.FieldType, len(fields))
+	for _, f := range fields {
+		types[f.Name] = f.Type
+	}
+
+	for _, clause := range strings.Split(orderBy, ",") {
+		words := strings.Fields(clause)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid clause %q: must be a field name optionally followed by \"desc\"", strings.TrimSpace(clause))
+		}
+
+		name := words[0]
+		if t, ok := types[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		} else if t == filtering.StringMap || t == filtering.Parent {
+			return nil, fmt.Errorf("field %q cannot be used for ordering", name)
+		}
+
+		descending := false
+		if len(words) == 2 {
+			switch words[1] {
+			case "desc":
+				descending = true
+			case "asc":
+			default:
+				return nil, fmt.Errorf("invalid direction %q for field %q: must be \"asc\" or \"desc\"", words[1], name)
+			}
+		}
+
+		o = append(o, orderField{field: storageName(name), descending: descending})
+	}
+	return o, nil
+}
+
+// storageName returns the storage name of a field.
+func storageName(name string) string {
+	if s, ok := storageFields[name]; ok {
+		return s
+	}
+	parts := strings.Split(name, "_")
+	for i, p := range parts {
+		if p == "id" {
+			parts[i] = "ID"
+		} else if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// apply adds the ordering to a query. Results are finally ordered by key
+// so that pages are stable when ordered fields have equal values.
+func (o ordering) apply(q storage.Query) storage.Query {
+	for _, f := range o {
+		if f.descending {
+			q = q.Descending(f.field)
+		} else {
+			q = q.Ascending(f.field)
+		}
+	}
+	return q
+}
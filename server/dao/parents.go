@@ -0,0 +1,140 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/apigee/registry/server/names"
+	"github.com/apigee/registry/server/storage"
+	"github.com/apigee/registry/server/storage/filtering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// parentMaps holds the filter maps of parent resources, keyed by resource name.
+// Filters on child collections can refer to parent fields, e.g. api.labels.team,
+// so parents are joined with each child. Parents are loaded when their first
+// child is checked, so list calls only load the parents of listed resources.
+type parentMaps struct {
+	load func(ctx context.Context, name string) (map[string]interface{}, error)
+	maps map[string]map[string]interface{}
+}
+
+// join adds the parent with a name to a child's filter map under a field name.
+// It returns false if the parent is missing, so that its children don't match.
+func (p *parentMaps) join(ctx context.Context, child map[string]interface{}, field, name string) (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+	parent, ok := p.maps[name]
+	if !ok {
+		var err error
+		parent, err = p.load(ctx, name)
+		if status.Code(err) == codes.NotFound {
+			parent = nil
+		} else if err != nil {
+			return false, err
+		}
+		p.maps[name] = parent
+	}
+	child[field] = parent
+	return parent != nil, nil
+}
+
+// apiColumns are the model fields that store api fields used in filters.
+var apiColumns = map[string]string{
+	"api_id":              "ApiID",
+	"display_name":        "DisplayName",
+	"description":         "Description",
+	"availability":        "Availability",
+	"recommended_version": "RecommendedVersion",
+}
+
+// versionColumns are the model fields that store version fields used in filters.
+var versionColumns = map[string]string{
+	"version_id":   "VersionID",
+	"display_name": "DisplayName",
+	"description":  "Description",
+	"state":        "State",
+}
+
+// requireParents adds the conditions that a filter requires of the stored
+// fields of a parent to a query, so that storage only returns the children
+// of parents that can match.
+func requireParents(q storage.Query, filter filtering.Filter, field, kind string, columns map[string]string) storage.Query {
+	for _, e := range filter.Equalities(field) {
+		if column, ok := columns[e.Field]; ok {
+			q = q.RequireParent(kind, column, e.Value)
+		}
+	}
+	return q
+}
+
+// apiParents returns the filter maps of apis that are parents of listed
+// resources if a filter refers to them, and adds the filter's conditions
+// on apis to the query of listed resources.
+func (d *DAO) apiParents(filter filtering.Filter, q storage.Query) (*parentMaps, storage.Query) {
+	if !filter.References("api") {
+		return nil, q
+	}
+
+	return &parentMaps{
+		load: func(ctx context.Context, name string) (map[string]interface{}, error) {
+			n, err := names.ParseApi(name)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			api, err := d.GetApi(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			m, err := apiMap(*api)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return m, nil
+		},
+		maps: make(map[string]map[string]interface{}),
+	}, requireParents(q, filter, "api", storage.ApiEntityName, apiColumns)
+}
+
+// versionParents returns the filter maps of versions that are parents of
+// listed resources if a filter refers to them, and adds the filter's
+// conditions on versions to the query of listed resources.
+func (d *DAO) versionParents(filter filtering.Filter, q storage.Query) (*parentMaps, storage.Query) {
+	if !filter.References("version") {
+		return nil, q
+	}
+
+	return &parentMaps{
+		load: func(ctx context.Context, name string) (map[string]interface{}, error) {
+			n, err := names.ParseVersion(name)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			version, err := d.GetVersion(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			m, err := versionMap(*version)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			return m, nil
+		},
+		maps: make(map[string]map[string]interface{}),
+	}, requireParents(q, filter, "version", storage.VersionEntityName, versionColumns)
+}
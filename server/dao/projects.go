@@ -55,6 +55,18 @@ func (d *DAO) ListProjects(ctx context.Context, opts PageOptions) (ProjectList,
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return ProjectList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, projectFields)
+	if err != nil {
+		return ProjectList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	filter, err := filtering.NewFilter(opts.Filter, projectFields)
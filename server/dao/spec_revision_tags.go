@@ -0,0 +1,218 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/apigee/registry/server/storage"
+	"github.com/apigee/registry/server/storage/filtering"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SpecRevisionTagList contains a page of spec revision tags.
+type SpecRevisionTagList struct {
+	Tags  []models.SpecRevisionTag
+	Token string
+}
+
+// SpecRevisionTagEventList contains a page of spec revision tag moves.
+type SpecRevisionTagEventList struct {
+	Events []models.SpecRevisionTagEvent
+	Token  string
+}
+
+var specRevisionTagFields = []filtering.Field{
+	{Name: "name", Type: filtering.String},
+	{Name: "tag", Type: filtering.String},
+	{Name: "revision_id", Type: filtering.String},
+	{Name: "previous_revision_id", Type: filtering.String},
+	{Name: "principal", Type: filtering.String},
+	{Name: "create_time", Type: filtering.Timestamp},
+	{Name: "update_time", Type: filtering.Timestamp},
+}
+
+// GetSpecRevisionTag gets a tag of a spec, which is named like a spec revision.
+func (d *DAO) GetSpecRevisionTag(ctx context.Context, name names.SpecRevision) (*models.SpecRevisionTag, error) {
+	tag := new(models.SpecRevisionTag)
+	k := d.NewKey(storage.SpecRevisionTagEntityName, name.String())
+	if err := d.Get(ctx, k, tag); d.IsNotFound(err) {
+		return nil, status.Errorf(codes.NotFound, "spec revision tag %q not found", name)
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return tag, nil
+}
+
+// ListSpecRevisionTags lists the current tags of a spec's revisions in order of tag name.
+func (d *DAO) ListSpecRevisionTags(ctx context.Context, parent names.Spec, opts PageOptions) (SpecRevisionTagList, error) {
+	q := d.NewQuery(storage.SpecRevisionTagEntityName)
+	q = q.Require("ProjectID", parent.ProjectID)
+	q = q.Require("ApiID", parent.ApiID)
+	q = q.Require("VersionID", parent.VersionID)
+	q = q.Require("SpecID", parent.SpecID)
+	q = q.Ascending("Tag")
+
+	token, err := decodeToken(opts.Token)
+	if err != nil {
+		return SpecRevisionTagList{}, status.Errorf(codes.InvalidArgument, "invalid page token %q: %s", opts.Token, err.Error())
+	}
+
+	if err := token.ValidateFilter(opts.Filter); err != nil {
+		return SpecRevisionTagList{}, status.Errorf(codes.InvalidArgument, "invalid filter %q: %s", opts.Filter, err)
+	} else {
+		token.Filter = opts.Filter
+	}
+
+	q = q.ApplyOffset(token.Offset)
+
+	filter, err := filtering.NewFilter(opts.Filter, specRevisionTagFields)
+	if err != nil {
+		return SpecRevisionTagList{}, err
+	}
+
+	it := d.Run(ctx, q)
+	response := SpecRevisionTagList{
+		Tags: make([]models.SpecRevisionTag, 0, opts.Size),
+	}
+
+	tag := new(models.SpecRevisionTag)
+	for _, err = it.Next(tag); err == nil; _, err = it.Next(tag) {
+		match, err := filter.Matches(specRevisionTagMap(*tag))
+		if err != nil {
+			return response, err
+		} else if !match {
+			token.Offset++
+			continue
+		} else if len(response.Tags) == int(opts.Size) {
+			break
+		}
+
+		response.Tags = append(response.Tags, *tag)
+		token.Offset++
+	}
+	if err != nil && err != iterator.Done {
+		return response, status.Error(codes.Internal, err.Error())
+	}
+
+	if err == nil {
+		response.Token, err = encodeToken(token)
+		if err != nil {
+			return response, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return response, nil
+}
+
+func specRevisionTagMap(t models.SpecRevisionTag) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                 t.String(),
+		"tag":                  t.Tag,
+		"revision_id":          t.RevisionID,
+		"previous_revision_id": "",
+		"principal":            "",
+		"create_time":          t.CreateTime,
+		"update_time":          t.UpdateTime,
+	}
+}
+
+// ListSpecRevisionTagEvents lists the recorded moves of a spec's revision tags, most recent first.
+func (d *DAO) ListSpecRevisionTagEvents(ctx context.Context, parent names.Spec, opts PageOptions) (SpecRevisionTagEventList, error) {
+	q := d.NewQuery(storage.SpecRevisionTagEventEntityName)
+	q = q.Require("ProjectID", parent.ProjectID)
+	q = q.Require("ApiID", parent.ApiID)
+	q = q.Require("VersionID", parent.VersionID)
+	q = q.Require("SpecID", parent.SpecID)
+	q = q.Descending("EventID")
+
+	token, err := decodeToken(opts.Token)
+	if err != nil {
+		return SpecRevisionTagEventList{}, status.Errorf(codes.InvalidArgument, "invalid page token %q: %s", opts.Token, err.Error())
+	}
+
+	if err := token.ValidateFilter(opts.Filter); err != nil {
+		return SpecRevisionTagEventList{}, status.Errorf(codes.InvalidArgument, "invalid filter %q: %s", opts.Filter, err)
+	} else {
+		token.Filter = opts.Filter
+	}
+
+	q = q.ApplyOffset(token.Offset)
+
+	filter, err := filtering.NewFilter(opts.Filter, specRevisionTagFields)
+	if err != nil {
+		return SpecRevisionTagEventList{}, err
+	}
+
+	it := d.Run(ctx, q)
+	response := SpecRevisionTagEventList{
+		Events: make([]models.SpecRevisionTagEvent, 0, opts.Size),
+	}
+
+	event := new(models.SpecRevisionTagEvent)
+	for _, err = it.Next(event); err == nil; _, err = it.Next(event) {
+		match, err := filter.Matches(specRevisionTagEventMap(*event))
+		if err != nil {
+			return response, err
+		} else if !match {
+			token.Offset++
+			continue
+		} else if len(response.Events) == int(opts.Size) {
+			break
+		}
+
+		response.Events = append(response.Events, *event)
+		token.Offset++
+	}
+	if err != nil && err != iterator.Done {
+		return response, status.Error(codes.Internal, err.Error())
+	}
+
+	if err == nil {
+		response.Token, err = encodeToken(token)
+		if err != nil {
+			return response, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return response, nil
+}
+
+func specRevisionTagEventMap(e models.SpecRevisionTagEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"name":                 e.TagName(),
+		"tag":                  e.Tag,
+		"revision_id":          e.RevisionID,
+		"previous_revision_id": e.PreviousRevisionID,
+		"principal":            e.Principal,
+		"create_time":          e.EventTime,
+		"update_time":          e.EventTime,
+	}
+}
+
+// SaveSpecRevisionTagEvent records a move of a spec revision tag.
+func (d *DAO) SaveSpecRevisionTagEvent(ctx context.Context, event *models.SpecRevisionTagEvent) error {
+	k := d.NewKey(storage.SpecRevisionTagEventEntityName, event.String())
+	if _, err := d.Put(ctx, k, event); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	return nil
+}
@@ -31,7 +31,6 @@ func (d *DAO) ListSpecRevisions(ctx context.Context, parent names.Spec, opts Pag
 	q = q.Require("ApiID", parent.ApiID)
 	q = q.Require("VersionID", parent.VersionID)
 	q = q.Require("SpecID", parent.SpecID)
-	q = q.Descending("RevisionCreateTime")
 
 	token, err := decodeToken(opts.Token)
 	if err != nil {
@@ -42,6 +41,21 @@ func (d *DAO) ListSpecRevisions(ctx context.Context, parent names.Spec, opts Pag
 		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid filter %q: %s", opts.Filter, err)
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	// Revisions are listed newest first unless another order is requested.
+	order, err := parseOrdering(opts.Order, specFields)
+	if err != nil {
+		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else if len(order) == 0 {
+		q = q.Descending("RevisionCreateTime")
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	it := d.Run(ctx, q)
@@ -153,6 +167,27 @@ func (d *DAO) SaveSpecRevisionTag(ctx context.Context, tag *models.SpecRevisionT
 	return nil
 }
 
+// GetSpecRevisionTags returns the tags of a spec's revisions, keyed by revision name.
+func (d *DAO) GetSpecRevisionTags(ctx context.Context, parent names.Spec) (map[string][]string, error) {
+	q := d.NewQuery(storage.SpecRevisionTagEntityName)
+	q = q.Require("ProjectID", parent.ProjectID)
+	q = q.Require("ApiID", parent.ApiID)
+	q = q.Require("VersionID", parent.VersionID)
+	q = q.Require("SpecID", parent.SpecID)
+
+	tags := make(map[string][]string)
+	it := d.Run(ctx, q)
+	tag := new(models.SpecRevisionTag)
+	var err error
+	for _, err = it.Next(tag); err == nil; _, err = it.Next(tag) {
+		tags[tag.RevisionName()] = append(tags[tag.RevisionName()], tag.Tag)
+	}
+	if err != nil && err != iterator.Done {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return tags, nil
+}
+
 func (d *DAO) unwrapSpecRevisionTag(ctx context.Context, name names.SpecRevision) (names.SpecRevision, error) {
 	tag := new(models.SpecRevisionTag)
 	if err := d.Get(ctx, d.NewKey(storage.SpecRevisionTagEntityName, name.String()), tag); d.IsNotFound(err) {
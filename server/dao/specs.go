@@ -47,9 +47,14 @@ var specFields = []filtering.Field{
 	{Name: "size_bytes", Type: filtering.Int},
 	{Name: "source_uri", Type: filtering.String},
 	{Name: "labels", Type: filtering.StringMap},
+	{Name: "annotations", Type: filtering.StringMap},
+	{Name: "api", Type: filtering.Parent, Fields: apiFields},
+	{Name: "version", Type: filtering.Parent, Fields: versionFields},
 }
 
 func (d *DAO) ListSpecs(ctx context.Context, parent names.Version, opts PageOptions) (SpecList, error) {
+	q := d.NewQuery(storage.SpecEntityName)
+
 	token, err := decodeToken(opts.Token)
 	if err != nil {
 		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid page token %q: %s", opts.Token, err.Error())
@@ -61,6 +66,18 @@ func (d *DAO) ListSpecs(ctx context.Context, parent names.Version, opts PageOpti
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, specFields)
+	if err != nil {
+		return SpecList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	if parent.ProjectID != "-" && parent.ApiID != "-" && parent.VersionID != "-" {
 		if _, err := d.GetVersion(ctx, parent); err != nil {
 			return SpecList{}, err
@@ -80,7 +97,11 @@ func (d *DAO) ListSpecs(ctx context.Context, parent names.Version, opts PageOpti
 		return SpecList{}, err
 	}
 
-	it := d.GetRecentSpecRevisions(ctx, token.Offset, parent.ProjectID, parent.ApiID, parent.VersionID)
+	apis, q := d.apiParents(filter, q)
+	versions, q := d.versionParents(filter, q)
+
+	q = q.ApplyOffset(token.Offset)
+	it := d.GetRecentSpecRevisions(ctx, q, parent.ProjectID, parent.ApiID, parent.VersionID)
 	response := SpecList{
 		Specs: make([]models.Spec, 0, opts.Size),
 	}
@@ -92,6 +113,20 @@ func (d *DAO) ListSpecs(ctx context.Context, parent names.Version, opts PageOpti
 			return response, status.Error(codes.Internal, err.Error())
 		}
 
+		version := names.Version{ProjectID: spec.ProjectID, ApiID: spec.ApiID, VersionID: spec.VersionID}
+		if ok, err := apis.join(ctx, specMap, "api", version.Api().String()); err != nil {
+			return response, err
+		} else if !ok {
+			token.Offset++
+			continue
+		}
+		if ok, err := versions.join(ctx, specMap, "version", version.String()); err != nil {
+			return response, err
+		} else if !ok {
+			token.Offset++
+			continue
+		}
+
 		match, err := filter.Matches(specMap)
 		if err != nil {
 			return response, err
@@ -125,6 +160,11 @@ func specMap(spec models.Spec) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	annotations, err := spec.AnnotationsMap()
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
 		"name":                 spec.Name(),
 		"project_id":           spec.ProjectID,
@@ -142,6 +182,7 @@ func specMap(spec models.Spec) (map[string]interface{}, error) {
 		"hash":                 spec.Hash,
 		"source_uri":           spec.SourceURI,
 		"labels":               labels,
+		"annotations":          annotations,
 	}, nil
 }
 
@@ -0,0 +1,113 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"github.com/apigee/registry/server/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Usage describes the resources stored in a project.
+type Usage struct {
+	Apis      int64
+	Versions  int64
+	Specs     int64
+	Revisions int64
+	Artifacts int64
+	// SizeBytes is the total size of all spec revision and artifact contents.
+	SizeBytes int64
+}
+
+func (d *DAO) GetProjectUsage(ctx context.Context, name names.Project) (*Usage, error) {
+	var (
+		usage = new(Usage)
+		err   error
+	)
+
+	if usage.Apis, err = d.CountApis(ctx, name); err != nil {
+		return nil, err
+	}
+	if usage.Versions, err = d.CountVersions(ctx, name); err != nil {
+		return nil, err
+	}
+	if usage.Specs, err = d.CountSpecs(ctx, name); err != nil {
+		return nil, err
+	}
+	if usage.Revisions, err = d.count(ctx, d.NewQuery(storage.SpecEntityName).Require("ProjectID", name.ProjectID)); err != nil {
+		return nil, err
+	}
+	if usage.Artifacts, err = d.CountArtifacts(ctx, name); err != nil {
+		return nil, err
+	}
+	if usage.SizeBytes, err = d.SumContentSizes(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (d *DAO) CountApis(ctx context.Context, parent names.Project) (int64, error) {
+	return d.count(ctx, d.NewQuery(storage.ApiEntityName).Require("ProjectID", parent.ProjectID))
+}
+
+func (d *DAO) CountVersions(ctx context.Context, parent names.Project) (int64, error) {
+	return d.count(ctx, d.NewQuery(storage.VersionEntityName).Require("ProjectID", parent.ProjectID))
+}
+
+func (d *DAO) CountSpecs(ctx context.Context, parent names.Project) (int64, error) {
+	// Each revision of a spec is stored separately, so specs are counted by their distinct names.
+	q := d.NewQuery(storage.SpecEntityName)
+	q = q.Require("ProjectID", parent.ProjectID)
+	q = q.Distinct("ApiID", "VersionID", "SpecID")
+	return d.count(ctx, q)
+}
+
+func (d *DAO) CountSpecRevisions(ctx context.Context, parent names.Spec) (int64, error) {
+	q := d.NewQuery(storage.SpecEntityName)
+	q = q.Require("ProjectID", parent.ProjectID)
+	q = q.Require("ApiID", parent.ApiID)
+	q = q.Require("VersionID", parent.VersionID)
+	q = q.Require("SpecID", parent.SpecID)
+	return d.count(ctx, q)
+}
+
+func (d *DAO) CountArtifacts(ctx context.Context, parent names.Project) (int64, error) {
+	return d.count(ctx, d.NewQuery(storage.ArtifactEntityName).Require("ProjectID", parent.ProjectID))
+}
+
+// SumContentSizes returns the total size of all spec revision and artifact contents in a project.
+func (d *DAO) SumContentSizes(ctx context.Context, parent names.Project) (int64, error) {
+	q := d.NewQuery(models.BlobEntityName).Require("ProjectID", parent.ProjectID)
+	sum, err := d.Sum(ctx, q, "SizeInBytes")
+	if err != nil {
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+
+	return sum, nil
+}
+
+func (d *DAO) count(ctx context.Context, q storage.Query) (int64, error) {
+	n, err := d.Count(ctx, q)
+	if err != nil {
+		return 0, status.Error(codes.Internal, err.Error())
+	}
+
+	return n, nil
+}
@@ -43,6 +43,8 @@ var versionFields = []filtering.Field{
 	{Name: "update_time", Type: filtering.Timestamp},
 	{Name: "state", Type: filtering.String},
 	{Name: "labels", Type: filtering.StringMap},
+	{Name: "annotations", Type: filtering.StringMap},
+	{Name: "api", Type: filtering.Parent, Fields: apiFields},
 }
 
 func (d *DAO) ListVersions(ctx context.Context, parent names.Api, opts PageOptions) (VersionList, error) {
@@ -59,6 +61,18 @@ func (d *DAO) ListVersions(ctx context.Context, parent names.Api, opts PageOptio
 		token.Filter = opts.Filter
 	}
 
+	if err := token.ValidateOrder(opts.Order); err != nil {
+		return VersionList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	} else {
+		token.Order = opts.Order
+	}
+
+	order, err := parseOrdering(opts.Order, versionFields)
+	if err != nil {
+		return VersionList{}, status.Errorf(codes.InvalidArgument, "invalid order_by %q: %s", opts.Order, err)
+	}
+	q = order.apply(q)
+
 	q = q.ApplyOffset(token.Offset)
 
 	if parent.ProjectID != "-" {
@@ -82,6 +96,8 @@ func (d *DAO) ListVersions(ctx context.Context, parent names.Api, opts PageOptio
 		return VersionList{}, err
 	}
 
+	apis, q := d.apiParents(filter, q)
+
 	it := d.Run(ctx, q)
 	response := VersionList{
 		Versions: make([]models.Version, 0, opts.Size),
@@ -94,6 +110,14 @@ func (d *DAO) ListVersions(ctx context.Context, parent names.Api, opts PageOptio
 			return response, status.Error(codes.Internal, err.Error())
 		}
 
+		api := names.Api{ProjectID: version.ProjectID, ApiID: version.ApiID}
+		if ok, err := apis.join(ctx, versionMap, "api", api.String()); err != nil {
+			return response, err
+		} else if !ok {
+			token.Offset++
+			continue
+		}
+
 		match, err := filter.Matches(versionMap)
 		if err != nil {
 			return response, err
@@ -127,9 +151,15 @@ func versionMap(version models.Version) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	annotations, err := version.AnnotationsMap()
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
 		"name":         version.Name(),
 		"project_id":   version.ProjectID,
+		"api_id":       version.ApiID,
 		"version_id":   version.VersionID,
 		"display_name": version.DisplayName,
 		"description":  version.Description,
@@ -137,6 +167,7 @@ func versionMap(version models.Version) (map[string]interface{}, error) {
 		"update_time":  version.UpdateTime,
 		"state":        version.State,
 		"labels":       labels,
+		"annotations":  annotations,
 	}, nil
 }
 
@@ -55,3 +55,10 @@ func alreadyExistsError(err error) error {
 	}
 	return status.Error(codes.AlreadyExists, err.Error())
 }
+
+func resourceExhaustedError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return status.Error(codes.ResourceExhausted, err.Error())
+}
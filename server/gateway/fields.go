@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fieldByPath returns the message containing the last field of a dotted
+// path along with that field, creating intermediate messages as needed.
+func fieldByPath(m protoreflect.Message, path string) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		f := m.Descriptor().Fields().ByName(protoreflect.Name(part))
+		if f == nil {
+			f = m.Descriptor().Fields().ByJSONName(part)
+		}
+		if f == nil {
+			return nil, nil, fmt.Errorf("unknown field %q in %s", part, m.Descriptor().FullName())
+		}
+		if i == len(parts)-1 {
+			return m, f, nil
+		}
+		if f.Kind() != protoreflect.MessageKind || f.IsList() || f.IsMap() {
+			return nil, nil, fmt.Errorf("field %q in %s is not a message", part, m.Descriptor().FullName())
+		}
+		m = m.Mutable(f).Message()
+	}
+	return nil, nil, fmt.Errorf("empty field path")
+}
+
+// setField sets the field named by a dotted path from string values.
+// Repeated fields receive all values; other fields receive the last one.
+func setField(m protoreflect.Message, path string, values ...string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	parent, f, err := fieldByPath(m, path)
+	if err != nil {
+		return err
+	}
+	if f.IsMap() {
+		return fmt.Errorf("map field %q cannot be set from a string", path)
+	}
+	if f.IsList() {
+		list := parent.Mutable(f).List()
+		for _, s := range values {
+			v, err := parseValue(f, s)
+			if err != nil {
+				return fmt.Errorf("invalid value for %q: %s", path, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	v, err := parseValue(f, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("invalid value for %q: %s", path, err)
+	}
+	parent.Set(f, v)
+	return nil
+}
+
+// parseValue converts a string to a value of a field's type.
+func parseValue(f protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch f.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if e := f.Enum().Values().ByName(protoreflect.Name(s)); e != nil {
+			return protoreflect.ValueOfEnum(e.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	case protoreflect.MessageKind:
+		// Well-known types with string representations.
+		var m proto.Message
+		switch f.Message().FullName() {
+		case "google.protobuf.FieldMask":
+			m = &fieldmaskpb.FieldMask{}
+			if s != "" {
+				for _, p := range strings.Split(s, ",") {
+					m.(*fieldmaskpb.FieldMask).Paths = append(m.(*fieldmaskpb.FieldMask).Paths, toSnakeCase(p))
+				}
+			}
+			return protoreflect.ValueOfMessage(m.ProtoReflect()), nil
+		case "google.protobuf.Timestamp":
+			m = &timestamppb.Timestamp{}
+		default:
+			return protoreflect.Value{}, fmt.Errorf("message fields cannot be set from a string")
+		}
+		if err := protojson.Unmarshal([]byte(strconv.Quote(s)), m); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(m.ProtoReflect()), nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", f.Kind())
+	}
+}
+
+// toSnakeCase converts a lowerCamelCase JSON field path to snake_case.
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+			b.WriteRune(r - 'A' + 'a')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,422 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gateway serves gRPC services as HTTP/JSON APIs using the
+// google.api.http annotations of their methods.
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Headers that are forwarded to gRPC methods as metadata.
+var forwardedHeaders = []string{"authorization", "if-none-match"}
+
+// Metadata keys that carry HTTP semantics between the gateway and gRPC methods.
+// These are distinct from the HTTP headers that they correspond to because
+// gRPC and gRPC-Web use those headers to describe their own encodings.
+const (
+	// AcceptEncodingKey carries the Accept-Encoding header of a request.
+	AcceptEncodingKey = "x-http-accept-encoding"
+	// ClientAddressKey carries the network address of the HTTP client. Methods
+	// should only trust it on calls that they know came from the gateway.
+	ClientAddressKey = "x-http-client-address"
+	// ContentEncodingKey is set by methods to the Content-Encoding of an HttpBody response.
+	ContentEncodingKey = "x-http-content-encoding"
+	// StatusCodeKey is set by methods to override the HTTP status of a successful response.
+	StatusCodeKey = "x-http-code"
+)
+
+// Response headers that are returned to HTTP clients from method metadata.
+var returnedHeaders = map[string]string{
+	"etag":             "ETag",
+	"vary":             "Vary",
+	ContentEncodingKey: "Content-Encoding",
+}
+
+// route maps an HTTP method and path template to a gRPC method.
+type route struct {
+	verb     string
+	template *template
+	body     string // "*", a field name, or "" for no body
+	method   string // full gRPC method name, e.g. "/pkg.Service/Method"
+	input    protoreflect.MessageType
+	output   protoreflect.MessageType
+}
+
+// Handler transcodes HTTP/JSON requests into calls of gRPC methods.
+type Handler struct {
+	conn     grpc.ClientConnInterface
+	routes   []*route
+	maxBytes int64
+}
+
+// NewHandler creates a handler for the named services, which must be
+// registered in the global protobuf registry. Calls are made on conn.
+func NewHandler(conn grpc.ClientConnInterface, services ...string) (*Handler, error) {
+	h := &Handler{conn: conn}
+	for _, name := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find service %q: %s", name, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a service", name)
+		}
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			if err := h.addMethod(sd, methods.Get(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+// AddRule adds an HTTP binding for a method that is named by its full name,
+// e.g. "google.longrunning.Operations.GetOperation". Rules can bind methods
+// of services that the handler does not otherwise serve, or bind methods with
+// resource names that differ from those of their default bindings.
+func (h *Handler) AddRule(method string, rule *annotations.HttpRule) error {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(method))
+	if err != nil {
+		return fmt.Errorf("failed to find method %q: %s", method, err)
+	}
+	md, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return fmt.Errorf("%q is not a method", method)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return fmt.Errorf("%q is a streaming method", method)
+	}
+	return h.addRule(md.Parent().(protoreflect.ServiceDescriptor), md, rule)
+}
+
+func (h *Handler) addMethod(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor) error {
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil
+	}
+	rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil
+	}
+	return h.addRule(sd, md, rule)
+}
+
+func (h *Handler) addRule(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor, rule *annotations.HttpRule) error {
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return fmt.Errorf("failed to find type %q: %s", md.Input().FullName(), err)
+	}
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return fmt.Errorf("failed to find type %q: %s", md.Output().FullName(), err)
+	}
+	method := fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		verb, path := ruleVerbAndPath(r)
+		if path == "" {
+			continue
+		}
+		t, err := parseTemplate(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s", method, err)
+		}
+		h.routes = append(h.routes, &route{
+			verb:     verb,
+			template: t,
+			body:     r.GetBody(),
+			method:   method,
+			input:    input,
+			output:   output,
+		})
+	}
+	return nil
+}
+
+func ruleVerbAndPath(r *annotations.HttpRule) (string, string) {
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
+
+// LimitRequestBytes limits the size of request bodies. Reading a larger
+// body fails, and the request is rejected. Zero or negative limits allow
+// bodies of any size.
+func (h *Handler) LimitRequestBytes(n int64) {
+	h.maxBytes = n
+}
+
+// Match returns true if the handler has a route for the request.
+func (h *Handler) Match(r *http.Request) bool {
+	_, _, ok := h.find(r)
+	return ok
+}
+
+func (h *Handler) find(r *http.Request) (*route, map[string]string, bool) {
+	path := r.URL.EscapedPath()
+	for _, rt := range h.routes {
+		if rt.verb != r.Method {
+			continue
+		}
+		if vars, ok := rt.template.match(path); ok {
+			return rt, vars, true
+		}
+	}
+	return nil, nil, false
+}
+
+// ServeHTTP transcodes a request, calls the corresponding method,
+// and writes its response.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt, vars, ok := h.find(r)
+	if !ok {
+		writeError(w, status.Errorf(codes.NotFound, "no method matches %s %s", r.Method, r.URL.Path))
+		return
+	}
+	if h.maxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes)
+	}
+
+	req, err := rt.request(r, vars)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	ctx := outgoingContext(r)
+	resp := rt.output.New().Interface()
+	var header metadata.MD
+	if err := h.conn.Invoke(ctx, rt.method, req, resp, grpc.Header(&header)); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeResponse(w, resp, header)
+}
+
+// request builds the gRPC request message from the body, path variables,
+// and query parameters of an HTTP request.
+func (rt *route) request(r *http.Request, vars map[string]string) (proto.Message, error) {
+	req := rt.input.New()
+	bound := make(map[string]bool)
+
+	if rt.body != "" {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %s", err)
+		}
+		if rt.body == "*" {
+			if len(b) > 0 {
+				if err := protojson.Unmarshal(b, req.Interface()); err != nil {
+					return nil, fmt.Errorf("invalid body: %s", err)
+				}
+			}
+		} else {
+			parent, f, err := fieldByPath(req, rt.body)
+			if err != nil {
+				return nil, err
+			}
+			if len(b) > 0 {
+				if err := unmarshalField(parent, f, b); err != nil {
+					return nil, fmt.Errorf("invalid body: %s", err)
+				}
+			}
+			bound[rt.body] = true
+		}
+	}
+
+	for path, value := range vars {
+		if err := setField(req, path, value); err != nil {
+			return nil, err
+		}
+		bound[path] = true
+	}
+
+	// Query parameters may set any field not bound by the body or path.
+	if rt.body != "*" {
+		for key, values := range r.URL.Query() {
+			if bound[key] || isBoundPrefix(bound, key) {
+				continue
+			}
+			if err := setField(req, key, values...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return req.Interface(), nil
+}
+
+func isBoundPrefix(bound map[string]bool, key string) bool {
+	for b := range bound {
+		if strings.HasPrefix(key, b+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalField parses JSON into a single field of a message.
+func unmarshalField(parent protoreflect.Message, f protoreflect.FieldDescriptor, b []byte) error {
+	if f.Kind() == protoreflect.MessageKind && !f.IsList() && !f.IsMap() {
+		m := parent.Mutable(f).Message().Interface()
+		// HttpBody fields receive the raw request body.
+		if body, ok := m.(*httpbody.HttpBody); ok {
+			body.Data = b
+			return nil
+		}
+		return protojson.Unmarshal(b, m)
+	}
+	// Wrap scalar, repeated, and map fields in an object and parse the parent.
+	wrapped, err := json.Marshal(map[string]json.RawMessage{f.JSONName(): b})
+	if err != nil {
+		return err
+	}
+	tmp := parent.New()
+	if err := protojson.Unmarshal(wrapped, tmp.Interface()); err != nil {
+		return err
+	}
+	parent.Set(f, tmp.Get(f))
+	return nil
+}
+
+// outgoingContext returns a context carrying forwarded request headers.
+func outgoingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, h := range forwardedHeaders {
+		if v := r.Header.Values(h); len(v) > 0 {
+			md.Set(h, v...)
+		}
+	}
+	if v := r.Header.Values("accept-encoding"); len(v) > 0 {
+		md.Set(AcceptEncodingKey, v...)
+	}
+	if r.RemoteAddr != "" {
+		md.Set(ClientAddressKey, r.RemoteAddr)
+	}
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+// writeResponse writes a response message as JSON, or as raw data
+// with its content type if it is an HttpBody. Headers and status codes
+// set by the method are applied to the response.
+func writeResponse(w http.ResponseWriter, resp proto.Message, header metadata.MD) {
+	for key, name := range returnedHeaders {
+		for _, v := range header.Get(key) {
+			w.Header().Add(name, v)
+		}
+	}
+	code := http.StatusOK
+	if v := header.Get(StatusCodeKey); len(v) > 0 {
+		if c, err := strconv.Atoi(v[0]); err == nil {
+			code = c
+		}
+	}
+	if code == http.StatusNotModified {
+		w.WriteHeader(code)
+		return
+	}
+
+	if body, ok := resp.(*httpbody.HttpBody); ok {
+		if body.GetContentType() != "" {
+			w.Header().Set("Content-Type", body.GetContentType())
+		}
+		w.WriteHeader(code)
+		w.Write(body.GetData())
+		return
+	}
+	b, err := protojson.Marshal(resp)
+	if err != nil {
+		writeError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(b)
+}
+
+// writeError writes an error as a JSON-encoded google.rpc.Status.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	b, merr := protojson.Marshal(st.Proto())
+	if merr != nil {
+		b = []byte(fmt.Sprintf(`{"code":%d,"message":%q}`, st.Code(), st.Message()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	w.Write(b)
+}
+
+// HTTPStatusFromCode returns the HTTP status corresponding to a gRPC code.
+// See https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
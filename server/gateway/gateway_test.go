@@ -0,0 +1,303 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// operations is a fake Operations service that records its requests.
+type operations struct {
+	longrunning.UnimplementedOperationsServer
+	list           *longrunning.ListOperationsRequest
+	token          string
+	acceptEncoding string
+	clientAddress  string
+}
+
+func (s *operations) GetOperation(ctx context.Context, req *longrunning.GetOperationRequest) (*longrunning.Operation, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		s.token = md.Get("authorization")[0]
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(ClientAddressKey)) > 0 {
+		s.clientAddress = md.Get(ClientAddressKey)[0]
+	}
+	if req.GetName() == "operations/missing" {
+		return nil, status.Errorf(codes.NotFound, "%q not found", req.GetName())
+	}
+	if req.GetName() == "operations/cached" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get(AcceptEncodingKey); len(v) > 0 {
+			s.acceptEncoding = v[0]
+		}
+		header := metadata.Pairs("etag", `"v1"`, "vary", "Accept-Encoding")
+		if v := md.Get("if-none-match"); len(v) > 0 && v[0] == `"v1"` {
+			header.Set(StatusCodeKey, "304")
+		}
+		if err := grpc.SetHeader(ctx, header); err != nil {
+			return nil, err
+		}
+	}
+	return &longrunning.Operation{Name: req.GetName(), Done: true}, nil
+}
+
+func (s *operations) ListOperations(ctx context.Context, req *longrunning.ListOperationsRequest) (*longrunning.ListOperationsResponse, error) {
+	s.list = req
+	return &longrunning.ListOperationsResponse{}, nil
+}
+
+func (s *operations) CancelOperation(ctx context.Context, req *longrunning.CancelOperationRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func setup(t *testing.T) (*operations, *httptest.Server) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	fake := &operations{}
+	s := grpc.NewServer()
+	longrunning.RegisterOperationsServer(s, fake)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }))
+	if err != nil {
+		t.Fatalf("Setup: failed to dial: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	h, err := NewHandler(conn, "google.longrunning.Operations")
+	if err != nil {
+		t.Fatalf("Setup: NewHandler() returned error: %s", err)
+	}
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestGateway(t *testing.T) {
+	fake, server := setup(t)
+
+	tests := []struct {
+		desc   string
+		method string
+		path   string
+		want   int
+		body   string // substring expected in the response body
+	}{
+		{"get", http.MethodGet, "/v1/operations/abc", http.StatusOK, `"name":"operations/abc"`},
+		{"get escaped", http.MethodGet, "/v1/operations/a%20b", http.StatusOK, `"name":"operations/a b"`},
+		{"not found", http.MethodGet, "/v1/operations/missing", http.StatusNotFound, `"code":5`},
+		{"list", http.MethodGet, "/v1/operations?filter=done&pageSize=10", http.StatusOK, `{}`},
+		{"custom verb", http.MethodPost, "/v1/operations/abc:cancel", http.StatusOK, `{}`},
+		{"unknown route", http.MethodGet, "/v2/other", http.StatusNotFound, `"code":5`},
+		{"wrong method", http.MethodPut, "/v1/operations/abc", http.StatusNotFound, `"code":5`},
+		{"bad parameter", http.MethodGet, "/v1/operations?pageSize=many", http.StatusBadRequest, `"code":3`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader("{}"))
+			if err != nil {
+				t.Fatalf("Setup: failed to create request: %s", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s returned error: %s", test.method, test.path, err)
+			}
+			defer resp.Body.Close()
+			b, _ := ioutil.ReadAll(resp.Body)
+			if resp.StatusCode != test.want {
+				t.Errorf("%s %s returned status %d, expected %d: %s", test.method, test.path, resp.StatusCode, test.want, b)
+			}
+			if !strings.Contains(string(b), test.body) {
+				t.Errorf("%s %s returned %s, expected it to contain %s", test.method, test.path, b, test.body)
+			}
+			if resp.StatusCode == http.StatusOK && !json.Valid(b) {
+				t.Errorf("%s %s returned invalid JSON %s", test.method, test.path, b)
+			}
+		})
+	}
+
+	if fake.list.GetFilter() != "done" || fake.list.GetPageSize() != 10 {
+		t.Errorf("ListOperations received %+v, expected query parameters to be set", fake.list)
+	}
+}
+
+func TestGatewayForwardsAuthorization(t *testing.T) {
+	fake, server := setup(t)
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/operations/abc", nil)
+	if err != nil {
+		t.Fatalf("Setup: failed to create request: %s", err)
+	}
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET returned error: %s", err)
+	}
+	resp.Body.Close()
+	if fake.token != "Bearer token" {
+		t.Errorf("GetOperation received authorization %q, expected %q", fake.token, "Bearer token")
+	}
+	if !strings.HasPrefix(fake.clientAddress, "127.0.0.1:") {
+		t.Errorf("GetOperation received client address %q, expected the test client's address", fake.clientAddress)
+	}
+}
+
+func TestGatewayCaching(t *testing.T) {
+	fake, server := setup(t)
+
+	get := func(header http.Header) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/operations/cached", nil)
+		if err != nil {
+			t.Fatalf("Setup: failed to create request: %s", err)
+		}
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET returned error: %s", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := get(http.Header{"Accept-Encoding": []string{"gzip, br"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET returned status %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"v1"` {
+		t.Errorf("GET returned ETag %q, expected %q", etag, `"v1"`)
+	}
+	if vary := resp.Header.Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("GET returned Vary %q, expected %q", vary, "Accept-Encoding")
+	}
+	if fake.acceptEncoding != "gzip, br" {
+		t.Errorf("GetOperation received accept encoding %q, expected %q", fake.acceptEncoding, "gzip, br")
+	}
+
+	resp = get(http.Header{"If-None-Match": []string{`"v1"`}})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET with matching If-None-Match returned status %d, expected %d", resp.StatusCode, http.StatusNotModified)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"v1"` {
+		t.Errorf("GET with matching If-None-Match returned ETag %q, expected %q", etag, `"v1"`)
+	}
+}
+
+func TestGatewayAddRule(t *testing.T) {
+	_, server := setup(t)
+	h := server.Config.Handler.(*Handler)
+	if err := h.AddRule("google.longrunning.Operations.GetOperation", &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=projects/*/operations/*}"},
+	}); err != nil {
+		t.Fatalf("AddRule() returned error: %s", err)
+	}
+
+	resp, err := http.Get(server.URL + "/v1/projects/p/operations/abc")
+	if err != nil {
+		t.Fatalf("GET returned error: %s", err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(b), `"name":"projects/p/operations/abc"`) {
+		t.Errorf("GET returned status %d and %s, expected the named operation", resp.StatusCode, b)
+	}
+
+	for _, method := range []string{"google.longrunning.Operations.Missing", "google.longrunning.Operations"} {
+		if err := h.AddRule(method, &annotations.HttpRule{}); err == nil {
+			t.Errorf("AddRule(%q) succeeded, expected error", method)
+		}
+	}
+}
+
+func TestGatewayLimitRequestBytes(t *testing.T) {
+	_, server := setup(t)
+	h := server.Config.Handler.(*Handler)
+	h.LimitRequestBytes(16)
+
+	for _, test := range []struct {
+		body string
+		want int
+	}{
+		{"{}", http.StatusOK},
+		{`{"name": "` + strings.Repeat("x", 32) + `"}`, http.StatusBadRequest},
+	} {
+		resp, err := http.Post(server.URL+"/v1/operations/abc:cancel", "application/json", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatalf("POST returned error: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.want {
+			t.Errorf("POST with %d-byte body returned status %d, expected %d", len(test.body), resp.StatusCode, test.want)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     map[string]string
+	}{
+		{"/v1/{name=projects/*}", "/v1/projects/p", map[string]string{"name": "projects/p"}},
+		{"/v1/{name=projects/*/apis/*}", "/v1/projects/p/apis/a", map[string]string{"name": "projects/p/apis/a"}},
+		{"/v1/{parent=projects/*}/apis", "/v1/projects/p/apis", map[string]string{"parent": "projects/p"}},
+		{"/v1/{name=projects/*/apis/*}:verb", "/v1/projects/p/apis/a:verb", map[string]string{"name": "projects/p/apis/a"}},
+		{"/v1/{name=**}", "/v1/a/b/c", map[string]string{"name": "a/b/c"}},
+		{"/v1/{api.name=projects/*/apis/*}", "/v1/projects/p/apis/a", map[string]string{"api.name": "projects/p/apis/a"}},
+		{"/v1/{name=projects/*}", "/v1/projects/p/apis/a", nil},
+		{"/v1/{name=projects/*}:verb", "/v1/projects/p", nil},
+	}
+	for _, test := range tests {
+		tmpl, err := parseTemplate(test.template)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q) returned error: %s", test.template, err)
+		}
+		got, ok := tmpl.match(test.path)
+		if ok != (test.want != nil) {
+			t.Errorf("parseTemplate(%q).match(%q) returned %t, expected %t", test.template, test.path, ok, test.want != nil)
+			continue
+		}
+		for k, v := range test.want {
+			if got[k] != v {
+				t.Errorf("parseTemplate(%q).match(%q) returned %s=%q, expected %q", test.template, test.path, k, got[k], v)
+			}
+		}
+	}
+
+	for _, bad := range []string{"v1/x", "/v1/{name", "/v1/{=x}"} {
+		if _, err := parseTemplate(bad); err == nil {
+			t.Errorf("parseTemplate(%q) succeeded, expected error", bad)
+		}
+	}
+}
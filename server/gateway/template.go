@@ -0,0 +1,128 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// template is a compiled google.api.http path template.
+// See https://github.com/googleapis/googleapis/blob/master/google/api/http.proto.
+type template struct {
+	pattern   *regexp.Regexp
+	variables []string // field paths, in the order of the pattern's groups
+}
+
+// parseTemplate compiles a path template such as
+// "/v1/{name=projects/*/apis/*}:verb" into a regular expression.
+func parseTemplate(s string) (*template, error) {
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid path template %q: must begin with '/'", s)
+	}
+
+	t := &template{}
+	var b strings.Builder
+	b.WriteString("^")
+
+	rest := s
+	for len(rest) > 0 {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			b.WriteString(literal(rest))
+			break
+		}
+		b.WriteString(literal(rest[:open]))
+		end := strings.Index(rest[open:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid path template %q: unclosed variable", s)
+		}
+		variable := rest[open+1 : open+end]
+		rest = rest[open+end+1:]
+
+		field, segments := variable, "*"
+		if eq := strings.Index(variable, "="); eq >= 0 {
+			field, segments = variable[:eq], variable[eq+1:]
+		}
+		if field == "" {
+			return nil, fmt.Errorf("invalid path template %q: empty variable name", s)
+		}
+		t.variables = append(t.variables, field)
+		b.WriteString("(")
+		b.WriteString(segmentsPattern(segments))
+		b.WriteString(")")
+	}
+
+	b.WriteString("$")
+	p, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template %q: %s", s, err)
+	}
+	t.pattern = p
+	return t, nil
+}
+
+// literal returns a pattern matching literal template text.
+// Wildcards outside of variables match but are not captured.
+func literal(s string) string {
+	parts := strings.Split(s, "/")
+	for i, part := range parts {
+		switch part {
+		case "*":
+			parts[i] = `[^/]+`
+		case "**":
+			parts[i] = `.+`
+		default:
+			parts[i] = regexp.QuoteMeta(part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// segmentsPattern returns a pattern matching the segments of a variable.
+func segmentsPattern(s string) string {
+	parts := strings.Split(s, "/")
+	for i, part := range parts {
+		switch part {
+		case "*":
+			parts[i] = `[^/:]+`
+		case "**":
+			parts[i] = `[^:]+`
+		default:
+			parts[i] = regexp.QuoteMeta(part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// match returns the values of the template variables for a path,
+// or false if the path does not match the template.
+func (t *template) match(path string) (map[string]string, bool) {
+	m := t.pattern.FindStringSubmatch(path)
+	if m == nil {
+		return nil, false
+	}
+	values := make(map[string]string, len(t.variables))
+	for i, v := range t.variables {
+		value, err := url.PathUnescape(m[i+1])
+		if err != nil {
+			return nil, false
+		}
+		values[v] = value
+	}
+	return values, true
+}
@@ -17,6 +17,7 @@ package gorm
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"runtime"
@@ -44,6 +45,7 @@ func getGID() uint64 {
 // Client represents a connection to a storage provider.
 type Client struct {
 	db *gorm.DB
+	tx bool // True for clients of transactions, which hold the lock.
 }
 
 var mutex sync.Mutex
@@ -61,6 +63,20 @@ func myunlock() {
 	}
 }
 
+// lock acquires the lock for a client's operation, unless the client belongs
+// to a transaction that already holds it.
+func (c *Client) lock() {
+	if !c.tx {
+		mylock()
+	}
+}
+
+func (c *Client) unlock() {
+	if !c.tx {
+		myunlock()
+	}
+}
+
 func config() *gorm.Config {
 	return &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // https://gorm.io/docs/logger.html
@@ -177,6 +193,11 @@ func (c *Client) reset() {
 	c.resetTable(&models.Blob{})
 	c.resetTable(&models.Artifact{})
 	c.resetTable(&models.SpecRevisionTag{})
+	c.resetTable(&models.SpecRevisionTagEvent{})
+	c.resetTable(&models.Deployment{})
+	c.resetTable(&models.DeploymentRevisionTag{})
+	c.resetTable(&models.AuditEvent{})
+	c.resetTable(&models.Operation{})
 }
 
 func (c *Client) ensure() *Client {
@@ -187,6 +208,11 @@ func (c *Client) ensure() *Client {
 	c.ensureTable(&models.Blob{})
 	c.ensureTable(&models.Artifact{})
 	c.ensureTable(&models.SpecRevisionTag{})
+	c.ensureTable(&models.SpecRevisionTagEvent{})
+	c.ensureTable(&models.Deployment{})
+	c.ensureTable(&models.DeploymentRevisionTag{})
+	c.ensureTable(&models.AuditEvent{})
+	c.ensureTable(&models.Operation{})
 	return c
 }
 
@@ -202,15 +228,15 @@ func (c *Client) NotFoundError() error {
 
 // Get gets an entity using the storage client.
 func (c *Client) Get(ctx context.Context, k storage.Key, v interface{}) error {
-	mylock()
-	defer myunlock()
+	c.lock()
+	defer c.unlock()
 	return c.db.Where("key = ?", k.(*Key).Name).First(v).Error
 }
 
 // Put puts an entity using the storage client.
 func (c *Client) Put(ctx context.Context, k storage.Key, v interface{}) (storage.Key, error) {
-	mylock()
-	defer myunlock()
+	c.lock()
+	defer c.unlock()
 	switch r := v.(type) {
 	case *models.Project:
 		r.Key = k.(*Key).Name
@@ -222,30 +248,63 @@ func (c *Client) Put(ctx context.Context, k storage.Key, v interface{}) (storage
 		r.Key = k.(*Key).Name
 	case *models.SpecRevisionTag:
 		r.Key = k.(*Key).Name
+	case *models.SpecRevisionTagEvent:
+		r.Key = k.(*Key).Name
+	case *models.Deployment:
+		r.Key = k.(*Key).Name
+	case *models.DeploymentRevisionTag:
+		r.Key = k.(*Key).Name
 	case *models.Blob:
 		r.Key = k.(*Key).Name
 	case *models.Artifact:
 		r.Key = k.(*Key).Name
+	case *models.AuditEvent:
+		r.Key = k.(*Key).Name
+	case *models.Operation:
+		r.Key = k.(*Key).Name
 	}
-	c.db.Transaction(
+	// Failures are returned so that transactions containing them are rolled back.
+	err := c.db.Transaction(
 		func(tx *gorm.DB) error {
 			// Update all fields from model: https://gorm.io/docs/update.html#Update-Selected-Fields
-			rowsAffected := tx.Model(v).Select("*").Where("key = ?", k.(*Key).Name).Updates(v).RowsAffected
-			if rowsAffected == 0 {
-				err := tx.Create(v).Error
-				if err != nil {
-					log.Printf("CREATE ERROR %s", err.Error())
-				}
+			result := tx.Model(v).Select("*").Where("key = ?", k.(*Key).Name).Updates(v)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return tx.Create(v).Error
 			}
 			return nil
 		})
+	if err != nil {
+		return nil, err
+	}
 	return k, nil
 }
 
-// Delete deletes an entity using the storage client.
-func (c *Client) Delete(ctx context.Context, k storage.Key) error {
+// Transaction calls f with a client whose changes are committed together if
+// f returns nil, and discarded otherwise. Postgres transactions are
+// serializable, so transactions that conflict with others fail instead of
+// acting on stale reads. Clients of transactions must not be closed.
+func (c *Client) Transaction(ctx context.Context, f func(ctx context.Context, tx storage.Client) error) error {
+	if c.tx {
+		return f(ctx, c)
+	}
 	mylock()
 	defer myunlock()
+	var opts []*sql.TxOptions
+	if c.db.Dialector.Name() == "postgres" {
+		opts = append(opts, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	}
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		return f(ctx, &Client{db: tx, tx: true})
+	}, opts...)
+}
+
+// Delete deletes an entity using the storage client.
+func (c *Client) Delete(ctx context.Context, k storage.Key) error {
+	c.lock()
+	defer c.unlock()
 	var err error
 	switch k.(*Key).Kind {
 	case "Project":
@@ -258,10 +317,20 @@ func (c *Client) Delete(ctx context.Context, k storage.Key) error {
 		err = c.db.Delete(&models.Spec{}, "key = ?", k.(*Key).Name).Error
 	case "SpecRevisionTag":
 		err = c.db.Delete(&models.SpecRevisionTag{}, "key = ?", k.(*Key).Name).Error
+	case "SpecRevisionTagEvent":
+		err = c.db.Delete(&models.SpecRevisionTagEvent{}, "key = ?", k.(*Key).Name).Error
+	case "Deployment":
+		err = c.db.Delete(&models.Deployment{}, "key = ?", k.(*Key).Name).Error
+	case "DeploymentRevisionTag":
+		err = c.db.Delete(&models.DeploymentRevisionTag{}, "key = ?", k.(*Key).Name).Error
 	case "Blob":
 		err = c.db.Delete(&models.Blob{}, "key = ?", k.(*Key).Name).Error
 	case "Artifact":
 		err = c.db.Delete(&models.Artifact{}, "key = ?", k.(*Key).Name).Error
+	case "AuditEvent":
+		err = c.db.Delete(&models.AuditEvent{}, "key = ?", k.(*Key).Name).Error
+	case "Operation":
+		err = c.db.Delete(&models.Operation{}, "key = ?", k.(*Key).Name).Error
 	default:
 		return fmt.Errorf("invalid key type (fix in client.go): %s", k.(*Key).Kind)
 	}
@@ -273,8 +342,8 @@ func (c *Client) Delete(ctx context.Context, k storage.Key) error {
 
 // Run runs a query using the storage client, returning an iterator.
 func (c *Client) Run(ctx context.Context, q storage.Query) storage.Iterator {
-	mylock()
-	defer myunlock()
+	c.lock()
+	defer c.unlock()
 
 	// Filtering is currently implemented by skipping iterator elements that
 	// don't match the filter criteria, and expects to only reach the end of
@@ -282,15 +351,19 @@ func (c *Client) Run(ctx context.Context, q storage.Query) storage.Iterator {
 	// the entire table would be read into memory. This limit should maintain
 	// that behavior until we improve our iterator implementation.
 	op := c.db.Offset(q.(*Query).Offset).Limit(100000)
+	prefix := ""
+	if len(q.(*Query).Joins) > 0 {
+		// Qualify columns that queried entities share with their parents,
+		// and don't select the parents' columns.
+		table := tableName(q.(*Query).Kind)
+		prefix = table + "."
+		op = q.(*Query).joinParents(op.Table(table).Select(table+".*"), table)
+	}
 	for _, r := range q.(*Query).Requirements {
-		op = op.Where(r.Name+" = ?", r.Value)
+		op = op.Where(prefix+r.Name+" = ?", r.Value)
 	}
 
-	if order := q.(*Query).Order; order != "" {
-		op = op.Order(order)
-	} else {
-		op = op.Order("key")
-	}
+	op = op.Order(q.(*Query).orderClause(prefix))
 
 	switch q.(*Query).Kind {
 	case "Project":
@@ -321,15 +394,112 @@ func (c *Client) Run(ctx context.Context, q storage.Query) storage.Iterator {
 		var v []models.SpecRevisionTag
 		_ = op.Find(&v).Error
 		return &Iterator{Client: c, Values: v, Index: 0}
+	case "SpecRevisionTagEvent":
+		var v []models.SpecRevisionTagEvent
+		_ = op.Find(&v).Error
+		return &Iterator{Client: c, Values: v, Index: 0}
+	case "Deployment":
+		var v []models.Deployment
+		_ = op.Find(&v).Error
+		return &Iterator{Client: c, Values: v, Index: 0}
+	case "DeploymentRevisionTag":
+		var v []models.DeploymentRevisionTag
+		_ = op.Find(&v).Error
+		return &Iterator{Client: c, Values: v, Index: 0}
+	case "AuditEvent":
+		var v []models.AuditEvent
+		_ = op.Find(&v).Error
+		return &Iterator{Client: c, Values: v, Index: 0}
+	case "Operation":
+		var v []models.Operation
+		_ = op.Find(&v).Error
+		return &Iterator{Client: c, Values: v, Index: 0}
 	default:
 		log.Printf("Unable to run query for kind %s", q.(*Query).Kind)
 		return nil
 	}
 }
 
-func (c *Client) GetRecentSpecRevisions(ctx context.Context, offset int32, projectID, apiID, versionID string) storage.Iterator {
-	mylock()
-	defer myunlock()
+// model returns an empty value of the model stored for an entity kind.
+func model(kind string) (interface{}, error) {
+	switch kind {
+	case "Project":
+		return &models.Project{}, nil
+	case "Api":
+		return &models.Api{}, nil
+	case "Version":
+		return &models.Version{}, nil
+	case "Spec":
+		return &models.Spec{}, nil
+	case "Blob":
+		return &models.Blob{}, nil
+	case "Artifact":
+		return &models.Artifact{}, nil
+	case "SpecRevisionTag":
+		return &models.SpecRevisionTag{}, nil
+	case "SpecRevisionTagEvent":
+		return &models.SpecRevisionTagEvent{}, nil
+	case "Deployment":
+		return &models.Deployment{}, nil
+	case "DeploymentRevisionTag":
+		return &models.DeploymentRevisionTag{}, nil
+	case "AuditEvent":
+		return &models.AuditEvent{}, nil
+	case "Operation":
+		return &models.Operation{}, nil
+	default:
+		return nil, fmt.Errorf("invalid query kind (fix in client.go): %s", kind)
+	}
+}
+
+// Count returns the number of entities matching a query. When the query
+// has distinct fields, entities with equal values of those fields are
+// counted once.
+func (c *Client) Count(ctx context.Context, q storage.Query) (int64, error) {
+	c.lock()
+	defer c.unlock()
+
+	v, err := model(q.(*Query).Kind)
+	if err != nil {
+		return 0, err
+	}
+
+	op := c.db.Model(v)
+	for _, r := range q.(*Query).Requirements {
+		op = op.Where(r.Name+" = ?", r.Value)
+	}
+
+	var count int64
+	if fields := q.(*Query).DistinctFields; len(fields) > 0 {
+		op = c.db.Table("(?) AS matches", op.Distinct(fields))
+	}
+	err = op.Count(&count).Error
+	return count, err
+}
+
+// Sum returns the total of a numeric field over all entities matching a query.
+func (c *Client) Sum(ctx context.Context, q storage.Query, field string) (int64, error) {
+	c.lock()
+	defer c.unlock()
+
+	v, err := model(q.(*Query).Kind)
+	if err != nil {
+		return 0, err
+	}
+
+	op := c.db.Model(v)
+	for _, r := range q.(*Query).Requirements {
+		op = op.Where(r.Name+" = ?", r.Value)
+	}
+
+	var sum int64
+	err = op.Select("COALESCE(SUM(" + columnName(field) + "), 0)").Row().Scan(&sum)
+	return sum, err
+}
+
+func (c *Client) GetRecentSpecRevisions(ctx context.Context, q storage.Query, projectID, apiID, versionID string) storage.Iterator {
+	c.lock()
+	defer c.unlock()
 
 	// Select all columns from `specs` table specifically.
 	// We do not want to select duplicates from the joined subquery result.
@@ -347,8 +517,8 @@ func (c *Client) GetRecentSpecRevisions(ctx context.Context, offset int32, proje
 			c.db.Select("project_id, api_id, version_id, spec_id, MAX(revision_create_time) AS recent_create_time").
 				Table("specs").
 				Group("project_id, api_id, version_id, spec_id")).
-		Order("key").
-		Offset(int(offset)).
+		Order(q.(*Query).orderClause("specs.")).
+		Offset(q.(*Query).Offset).
 		Limit(100000)
 
 	if projectID != "-" {
@@ -360,8 +530,43 @@ func (c *Client) GetRecentSpecRevisions(ctx context.Context, offset int32, proje
 	if versionID != "-" {
 		op = op.Where("specs.version_id = ?", versionID)
 	}
+	op = q.(*Query).joinParents(op, "specs")
 
 	var v []models.Spec
 	_ = op.Scan(&v).Error
 	return &Iterator{Client: c, Values: v, Index: 0}
 }
+
+func (c *Client) GetRecentDeploymentRevisions(ctx context.Context, q storage.Query, projectID, apiID string) storage.Iterator {
+	c.lock()
+	defer c.unlock()
+
+	// Select all columns from `deployments` table specifically.
+	// We do not want to select duplicates from the joined subquery result.
+	op := c.db.Select("deployments.*").
+		Table("deployments").
+		// Join missing columns that couldn't be selected in the subquery.
+		Joins(`JOIN (?) AS grp ON deployments.project_id = grp.project_id AND
+			deployments.api_id = grp.api_id AND
+			deployments.deployment_id = grp.deployment_id AND
+			deployments.revision_create_time = grp.recent_create_time`,
+			// Select deployment names and only their most recent revision_create_time
+			c.db.Select("project_id, api_id, deployment_id, MAX(revision_create_time) AS recent_create_time").
+				Table("deployments").
+				Group("project_id, api_id, deployment_id")).
+		Order(q.(*Query).orderClause("deployments.")).
+		Offset(q.(*Query).Offset).
+		Limit(100000)
+
+	if projectID != "-" {
+		op = op.Where("deployments.project_id = ?", projectID)
+	}
+	if apiID != "-" {
+		op = op.Where("deployments.api_id = ?", apiID)
+	}
+	op = q.(*Query).joinParents(op, "deployments")
+
+	var v []models.Deployment
+	_ = op.Scan(&v).Error
+	return &Iterator{Client: c, Values: v, Index: 0}
+}
@@ -43,6 +43,16 @@ func (c *Client) DeleteAllMatches(ctx context.Context, q storage.Query) error {
 		return op.Delete(models.Artifact{}).Error
 	case "SpecRevisionTag":
 		return op.Delete(models.SpecRevisionTag{}).Error
+	case "SpecRevisionTagEvent":
+		return op.Delete(models.SpecRevisionTagEvent{}).Error
+	case "Deployment":
+		return op.Delete(models.Deployment{}).Error
+	case "DeploymentRevisionTag":
+		return op.Delete(models.DeploymentRevisionTag{}).Error
+	case "AuditEvent":
+		return op.Delete(models.AuditEvent{}).Error
+	case "Operation":
+		return op.Delete(models.Operation{}).Error
 	}
 	return nil
 }
@@ -54,10 +64,17 @@ func (c *Client) DeleteChildrenOfProject(ctx context.Context, project names.Proj
 		models.BlobEntityName,
 		storage.SpecEntityName,
 		storage.SpecRevisionTagEntityName,
+		storage.SpecRevisionTagEventEntityName,
+		storage.DeploymentEntityName,
+		storage.DeploymentRevisionTagEntityName,
 		storage.VersionEntityName,
 		storage.ApiEntityName,
 	}
 	for _, entityName := range entityNames {
+		// Stop between entity kinds if the deletion has been cancelled.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		q := c.NewQuery(entityName)
 		q = q.Require("ProjectID", project.ProjectID)
 		err := c.DeleteAllMatches(ctx, q)
@@ -73,6 +90,10 @@ func (c *Client) DeleteChildrenOfApi(ctx context.Context, api names.Api) error {
 	for _, entityName := range []string{
 		models.BlobEntityName,
 		storage.SpecEntityName,
+		storage.SpecRevisionTagEntityName,
+		storage.SpecRevisionTagEventEntityName,
+		storage.DeploymentEntityName,
+		storage.DeploymentRevisionTagEntityName,
 		storage.VersionEntityName,
 	} {
 		q := c.NewQuery(entityName)
@@ -91,6 +112,8 @@ func (c *Client) DeleteChildrenOfVersion(ctx context.Context, version names.Vers
 	for _, entityName := range []string{
 		models.BlobEntityName,
 		storage.SpecEntityName,
+		storage.SpecRevisionTagEntityName,
+		storage.SpecRevisionTagEventEntityName,
 	} {
 		q := c.NewQuery(entityName)
 		q = q.Require("ProjectID", version.ProjectID)
@@ -105,10 +128,35 @@ func (c *Client) DeleteChildrenOfVersion(ctx context.Context, version names.Vers
 
 // DeleteChildrenOfSpec deletes all the children of a spec.
 func (c *Client) DeleteChildrenOfSpec(ctx context.Context, spec names.Spec) error {
-	q := c.NewQuery(models.BlobEntityName)
-	q = q.Require("ProjectID", spec.ProjectID)
-	q = q.Require("ApiID", spec.ApiID)
-	q = q.Require("VersionID", spec.VersionID)
-	q = q.Require("SpecID", spec.SpecID)
-	return c.DeleteAllMatches(ctx, q)
+	for _, entityName := range []string{
+		models.BlobEntityName,
+		storage.SpecRevisionTagEntityName,
+		storage.SpecRevisionTagEventEntityName,
+	} {
+		q := c.NewQuery(entityName)
+		q = q.Require("ProjectID", spec.ProjectID)
+		q = q.Require("ApiID", spec.ApiID)
+		q = q.Require("VersionID", spec.VersionID)
+		q = q.Require("SpecID", spec.SpecID)
+		if err := c.DeleteAllMatches(ctx, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteChildrenOfDeployment deletes all the children of a deployment.
+func (c *Client) DeleteChildrenOfDeployment(ctx context.Context, deployment names.Deployment) error {
+	for _, entityName := range []string{
+		storage.DeploymentRevisionTagEntityName,
+	} {
+		q := c.NewQuery(entityName)
+		q = q.Require("ProjectID", deployment.ProjectID)
+		q = q.Require("ApiID", deployment.ApiID)
+		q = q.Require("DeploymentID", deployment.DeploymentID)
+		if err := c.DeleteAllMatches(ctx, q); err != nil {
+			return err
+		}
+	}
+	return nil
 }
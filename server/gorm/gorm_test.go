@@ -172,3 +172,239 @@ func TestLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestCountAndSum(t *testing.T) {
+	ctx := context.TODO()
+
+	c, err := NewClient(ctx, "sqlite3", "/tmp/testing.db")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %s", err)
+	}
+	defer c.Close()
+	c.reset()
+
+	revisions := []*models.Spec{
+		{ProjectID: "p", ApiID: "a", VersionID: "v", SpecID: "s1", RevisionID: "r1", SizeInBytes: 10},
+		{ProjectID: "p", ApiID: "a", VersionID: "v", SpecID: "s1", RevisionID: "r2", SizeInBytes: 20},
+		{ProjectID: "p", ApiID: "a", VersionID: "v", SpecID: "s2", RevisionID: "r1", SizeInBytes: 30},
+		{ProjectID: "other", ApiID: "a", VersionID: "v", SpecID: "s1", RevisionID: "r1", SizeInBytes: 40},
+	}
+	for _, r := range revisions {
+		k := c.NewKey(storage.SpecEntityName, r.RevisionName())
+		if _, err := c.Put(ctx, k, r); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+		b := models.NewBlobForSpec(r, make([]byte, r.SizeInBytes))
+		k = c.NewKey(models.BlobEntityName, r.RevisionName())
+		if _, err := c.Put(ctx, k, b); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+	}
+
+	tests := []struct {
+		desc  string
+		query storage.Query
+		want  int64
+	}{
+		{
+			desc:  "revisions in project",
+			query: c.NewQuery(storage.SpecEntityName).Require("ProjectID", "p"),
+			want:  3,
+		},
+		{
+			desc:  "specs in project",
+			query: c.NewQuery(storage.SpecEntityName).Require("ProjectID", "p").Distinct("ApiID", "VersionID", "SpecID"),
+			want:  2,
+		},
+		{
+			desc:  "revisions of spec",
+			query: c.NewQuery(storage.SpecEntityName).Require("ProjectID", "p").Require("SpecID", "s1"),
+			want:  2,
+		},
+		{
+			desc:  "empty project",
+			query: c.NewQuery(storage.SpecEntityName).Require("ProjectID", "missing"),
+			want:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := c.Count(ctx, test.query)
+			if err != nil {
+				t.Fatalf("Count() returned error: %s", err)
+			}
+			if got != test.want {
+				t.Errorf("Count() returned %d, want %d", got, test.want)
+			}
+		})
+	}
+
+	sum, err := c.Sum(ctx, c.NewQuery(models.BlobEntityName).Require("ProjectID", "p"), "SizeInBytes")
+	if err != nil {
+		t.Fatalf("Sum() returned error: %s", err)
+	}
+	if sum != 60 {
+		t.Errorf("Sum() returned %d, want %d", sum, 60)
+	}
+
+	sum, err = c.Sum(ctx, c.NewQuery(models.BlobEntityName).Require("ProjectID", "missing"), "SizeInBytes")
+	if err != nil {
+		t.Fatalf("Sum() returned error: %s", err)
+	}
+	if sum != 0 {
+		t.Errorf("Sum() returned %d, want %d", sum, 0)
+	}
+}
+
+func TestGetRecentDeploymentRevisions(t *testing.T) {
+	ctx := context.TODO()
+
+	c, err := NewClient(ctx, "sqlite3", "/tmp/testing.db")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %s", err)
+	}
+	defer c.Close()
+	c.reset()
+
+	now := time.Now()
+	revisions := []*models.Deployment{
+		{ProjectID: "p", ApiID: "a", DeploymentID: "d1", RevisionID: "r1", RevisionCreateTime: now},
+		{ProjectID: "p", ApiID: "a", DeploymentID: "d1", RevisionID: "r2", RevisionCreateTime: now.Add(time.Second)},
+		{ProjectID: "p", ApiID: "b", DeploymentID: "d2", RevisionID: "r1", RevisionCreateTime: now},
+		{ProjectID: "other", ApiID: "a", DeploymentID: "d1", RevisionID: "r1", RevisionCreateTime: now},
+	}
+	for _, r := range revisions {
+		k := c.NewKey(storage.DeploymentEntityName, r.RevisionName())
+		if _, err := c.Put(ctx, k, r); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+	}
+
+	it := c.GetRecentDeploymentRevisions(ctx, c.NewQuery(storage.DeploymentEntityName), "p", "-")
+	got := make([]string, 0)
+	d := new(models.Deployment)
+	for _, err = it.Next(d); err == nil; _, err = it.Next(d) {
+		got = append(got, d.RevisionName())
+	}
+
+	want := []string{
+		"projects/p/apis/a/deployments/d1@r2",
+		"projects/p/apis/b/deployments/d2@r1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetRecentDeploymentRevisions() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRequireParent(t *testing.T) {
+	ctx := context.TODO()
+
+	c, err := NewClient(ctx, "sqlite3", "/tmp/testing.db")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %s", err)
+	}
+	defer c.Close()
+	c.reset()
+
+	now := time.Now()
+	apis := []*models.Api{
+		{ProjectID: "p", ApiID: "a", DisplayName: "Pets"},
+		{ProjectID: "p", ApiID: "b", DisplayName: "Stores"},
+		{ProjectID: "other", ApiID: "a", DisplayName: "Stores"},
+	}
+	for _, a := range apis {
+		k := c.NewKey(storage.ApiEntityName, a.Name())
+		if _, err := c.Put(ctx, k, a); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+	}
+	versions := []*models.Version{
+		{ProjectID: "p", ApiID: "a", VersionID: "v1", State: "production", DisplayName: "One"},
+		{ProjectID: "p", ApiID: "a", VersionID: "v2", State: "staging", DisplayName: "Two"},
+		{ProjectID: "p", ApiID: "b", VersionID: "v1", State: "production", DisplayName: "One"},
+		{ProjectID: "other", ApiID: "a", VersionID: "v1", State: "production", DisplayName: "One"},
+	}
+	for _, v := range versions {
+		k := c.NewKey(storage.VersionEntityName, v.Name())
+		if _, err := c.Put(ctx, k, v); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+	}
+	specs := []*models.Spec{
+		{ProjectID: "p", ApiID: "a", VersionID: "v1", SpecID: "s", RevisionID: "r1", RevisionCreateTime: now},
+		{ProjectID: "p", ApiID: "a", VersionID: "v1", SpecID: "s", RevisionID: "r2", RevisionCreateTime: now.Add(time.Second)},
+		{ProjectID: "p", ApiID: "a", VersionID: "v2", SpecID: "s", RevisionID: "r1", RevisionCreateTime: now},
+		{ProjectID: "p", ApiID: "b", VersionID: "v1", SpecID: "s", RevisionID: "r1", RevisionCreateTime: now},
+	}
+	for _, s := range specs {
+		k := c.NewKey(storage.SpecEntityName, s.RevisionName())
+		if _, err := c.Put(ctx, k, s); err != nil {
+			t.Fatalf("Setup: Put(%q) returned error: %s", k, err)
+		}
+	}
+
+	q := c.NewQuery(storage.VersionEntityName).
+		Require("ProjectID", "p").
+		RequireParent(storage.ApiEntityName, "DisplayName", "Pets").
+		Ascending("DisplayName")
+	it := c.Run(ctx, q)
+	got := make([]string, 0)
+	v := new(models.Version)
+	for _, err = it.Next(v); err == nil; _, err = it.Next(v) {
+		got = append(got, v.Name()+" "+v.DisplayName)
+	}
+	want := []string{
+		"projects/p/apis/a/versions/v1 One",
+		"projects/p/apis/a/versions/v2 Two",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run() returned unexpected diff (-want +got):\n%s", diff)
+	}
+
+	q = c.NewQuery(storage.SpecEntityName).
+		RequireParent(storage.ApiEntityName, "ApiID", "a").
+		RequireParent(storage.VersionEntityName, "State", "production")
+	it = c.GetRecentSpecRevisions(ctx, q, "p", "-", "-")
+	got = make([]string, 0)
+	s := new(models.Spec)
+	for _, err = it.Next(s); err == nil; _, err = it.Next(s) {
+		got = append(got, s.RevisionName())
+	}
+	want = []string{
+		"projects/p/apis/a/versions/v1/specs/s@r2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetRecentSpecRevisions() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestPutErrorRollsBackTransaction(t *testing.T) {
+	ctx := context.TODO()
+
+	c, err := NewClient(ctx, "sqlite3", "/tmp/testing.db")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %s", err)
+	}
+	defer c.Close()
+	c.reset()
+
+	project := &models.Project{ProjectID: "my-project"}
+	k := c.NewKey(storage.ProjectEntityName, project.Name())
+	err = c.Transaction(ctx, func(ctx context.Context, tx storage.Client) error {
+		if _, err := tx.Put(ctx, k, project); err != nil {
+			return err
+		}
+		// Entities without tables can't be saved.
+		unknown := &struct{ Key string }{}
+		_, err := tx.Put(ctx, tx.NewKey("Unknown", "unknown"), unknown)
+		return err
+	})
+	if err == nil {
+		t.Fatalf("Transaction() succeeded, expected the failed Put to return an error")
+	}
+
+	if err := c.Get(ctx, k, new(models.Project)); err == nil {
+		t.Errorf("Get(%q) succeeded, expected the project to be rolled back", k)
+	}
+}
@@ -103,6 +103,51 @@ func (it *Iterator) Next(v interface{}) (storage.Key, error) {
 			return it.Client.NewKey("SpecRevisionTag", x.Key), nil
 		}
 		return nil, iterator.Done
+	case *models.SpecRevisionTagEvent:
+		values := it.Values.([]models.SpecRevisionTagEvent)
+		if it.Index < len(values) {
+			*x = values[it.Index]
+			it.Cursor = x.Key
+			it.Index++
+			return it.Client.NewKey("SpecRevisionTagEvent", x.Key), nil
+		}
+		return nil, iterator.Done
+	case *models.Deployment:
+		values := it.Values.([]models.Deployment)
+		if it.Index < len(values) {
+			*x = values[it.Index]
+			it.Cursor = x.Key
+			it.Index++
+			return it.Client.NewKey("Deployment", x.Key), nil
+		}
+		return nil, iterator.Done
+	case *models.DeploymentRevisionTag:
+		values := it.Values.([]models.DeploymentRevisionTag)
+		if it.Index < len(values) {
+			*x = values[it.Index]
+			it.Cursor = x.Key
+			it.Index++
+			return it.Client.NewKey("DeploymentRevisionTag", x.Key), nil
+		}
+		return nil, iterator.Done
+	case *models.AuditEvent:
+		values := it.Values.([]models.AuditEvent)
+		if it.Index < len(values) {
+			*x = values[it.Index]
+			it.Cursor = x.Key
+			it.Index++
+			return it.Client.NewKey("AuditEvent", x.Key), nil
+		}
+		return nil, iterator.Done
+	case *models.Operation:
+		values := it.Values.([]models.Operation)
+		if it.Index < len(values) {
+			*x = values[it.Index]
+			it.Cursor = x.Key
+			it.Index++
+			return it.Client.NewKey("Operation", x.Key), nil
+		}
+		return nil, iterator.Done
 	default:
 		return nil, fmt.Errorf("unsupported iterator type: %t", v)
 	}
@@ -15,17 +15,23 @@
 package gorm
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/apigee/registry/server/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // Query represents a query in a storage provider.
 type Query struct {
-	Kind         string
-	Offset       int
-	Order        string
-	Requirements []*Requirement
+	Kind           string
+	Offset         int
+	Order          []string
+	Requirements   []*Requirement
+	DistinctFields []string
+	Joins          []*Join
 }
 
 // Requirement adds an equality filter to a query.
@@ -34,6 +40,19 @@ type Requirement struct {
 	Value interface{}
 }
 
+// Join adds the parents of queried entities to a query, with equality
+// filters on the parents' fields.
+type Join struct {
+	Kind         string
+	Requirements []*Requirement
+}
+
+// parentKeys are the columns that entities share with their parents.
+var parentKeys = map[string][]string{
+	"Api":     {"project_id", "api_id"},
+	"Version": {"project_id", "api_id", "version_id"},
+}
+
 // NewQuery creates a new query.
 func (c *Client) NewQuery(kind string) storage.Query {
 	return &Query{
@@ -52,6 +71,10 @@ func (q *Query) Require(name string, value interface{}) storage.Query {
 		name = "version_id"
 	case "SpecID":
 		name = "spec_id"
+	case "DeploymentID":
+		name = "deployment_id"
+	case "Done":
+		name = "done"
 	default:
 		log.Fatalf("UNEXPECTED REQUIRE TYPE: %s", name)
 	}
@@ -59,15 +82,84 @@ func (q *Query) Require(name string, value interface{}) storage.Query {
 	return q
 }
 
-func (q *Query) Descending(field string) storage.Query {
-	switch field {
-	case "RevisionCreateTime":
-		q.Order = "revision_create_time desc"
+// RequireParent adds a filter to a query that requires a field of the parents
+// of queried entities to have a specified value. Parents may be APIs or versions.
+func (q *Query) RequireParent(kind, name string, value interface{}) storage.Query {
+	if _, ok := parentKeys[kind]; !ok {
+		log.Fatalf("UNEXPECTED PARENT TYPE: %s", kind)
+	}
+	var join *Join
+	for _, j := range q.Joins {
+		if j.Kind == kind {
+			join = j
+		}
 	}
+	if join == nil {
+		join = &Join{Kind: kind}
+		q.Joins = append(q.Joins, join)
+	}
+	join.Requirements = append(join.Requirements, &Requirement{Name: columnName(name), Value: value})
+	return q
+}
 
+// Ascending adds a field to the ordering of a query's results.
+func (q *Query) Ascending(field string) storage.Query {
+	q.Order = append(q.Order, columnName(field))
 	return q
 }
 
+// Descending adds a field to the ordering of a query's results, in reverse order.
+func (q *Query) Descending(field string) storage.Query {
+	q.Order = append(q.Order, columnName(field)+" desc")
+	return q
+}
+
+// Distinct restricts counts of a query's results to distinct values of the given fields.
+func (q *Query) Distinct(fields ...string) storage.Query {
+	for _, f := range fields {
+		q.DistinctFields = append(q.DistinctFields, columnName(f))
+	}
+	return q
+}
+
+// columnName returns the column that stores a model field.
+func columnName(field string) string {
+	return schema.NamingStrategy{}.ColumnName("", field)
+}
+
+// tableName returns the table that stores entities of a kind.
+func tableName(kind string) string {
+	return schema.NamingStrategy{}.TableName(kind)
+}
+
+// joinParents joins the parents required by a query to an operation on the
+// table of queried entities, and filters the result by the parents' fields.
+func (q *Query) joinParents(op *gorm.DB, table string) *gorm.DB {
+	for _, j := range q.Joins {
+		parent := tableName(j.Kind)
+		on := make([]string, 0, len(parentKeys[j.Kind]))
+		for _, k := range parentKeys[j.Kind] {
+			on = append(on, fmt.Sprintf("%s.%s = %s.%s", parent, k, table, k))
+		}
+		op = op.Joins("JOIN " + parent + " ON " + strings.Join(on, " AND "))
+		for _, r := range j.Requirements {
+			op = op.Where(parent+"."+r.Name+" = ?", r.Value)
+		}
+	}
+	return op
+}
+
+// orderClause returns the ordering of a query's results, with the given
+// table prefix. Results are finally ordered by key so that ordering is
+// stable when ordered fields have equal values.
+func (q *Query) orderClause(prefix string) string {
+	clause := ""
+	for _, o := range q.Order {
+		clause += prefix + o + ", "
+	}
+	return clause + prefix + "key"
+}
+
 func (q *Query) ApplyOffset(offset int32) storage.Query {
 	q.Offset = int(offset)
 	return q
@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/apigee/registry/server/gateway"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var errLocalListenerClosed = errors.New("local listener closed")
+
+// localListener is a listener for in-process connections, which are
+// made with DialContext instead of through the network.
+type localListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newLocalListener() *localListener {
+	return &localListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// DialContext connects to the listener, waiting until the connection
+// is accepted.
+func (l *localListener) DialContext(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+	case <-ctx.Done():
+	}
+	server.Close()
+	client.Close()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, errLocalListenerClosed
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *localListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return localConn{conn}, nil
+	case <-l.done:
+		return nil, errLocalListenerClosed
+	}
+}
+
+// Close closes the listener. Accepted connections are not closed.
+func (l *localListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr returns the listener's address.
+func (l *localListener) Addr() net.Addr {
+	return localAddr{}
+}
+
+type localAddr struct{}
+
+func (localAddr) Network() string { return "local" }
+func (localAddr) String() string  { return "local" }
+
+// localConn is an accepted in-process connection, which has local
+// addresses so that calls made on it can be recognized.
+type localConn struct {
+	net.Conn
+}
+
+func (localConn) LocalAddr() net.Addr  { return localAddr{} }
+func (localConn) RemoteAddr() net.Addr { return localAddr{} }
+
+// clientAddress returns the network address of a caller, or the empty
+// string if it is unknown. Calls from the HTTP/JSON gateway arrive over
+// in-process connections and carry the addresses of its clients.
+func clientAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	if _, local := p.Addr.(localAddr); local {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get(gateway.ClientAddressKey); len(v) > 0 {
+				return v[0]
+			}
+		}
+		return ""
+	}
+	return p.Addr.String()
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalListener(t *testing.T) {
+	l := newLocalListener()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Errorf("Accept() returned error: %s", err)
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	conn, err := l.DialContext(context.Background())
+	if err != nil {
+		t.Fatalf("DialContext() returned error: %s", err)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write() returned error: %s", err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil || string(b) != "ping" {
+		t.Errorf("ReadFull() returned %q and %v, expected %q", b, err, "ping")
+	}
+	conn.Close()
+
+	l.Close()
+	if _, err := l.Accept(); err == nil {
+		t.Errorf("Accept() after Close() succeeded, expected error")
+	}
+	if _, err := l.DialContext(context.Background()); err == nil {
+		t.Errorf("DialContext() after Close() succeeded, expected error")
+	}
+}
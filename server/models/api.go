@@ -139,3 +139,8 @@ func (api *Api) Update(message *rpc.Api, mask *fieldmaskpb.FieldMask) error {
 func (api *Api) LabelsMap() (map[string]string, error) {
 	return mapForBytes(api.Labels)
 }
+
+// AnnotationsMap returns a map representation of stored annotations.
+func (api *Api) AnnotationsMap() (map[string]string, error) {
+	return mapForBytes(api.Annotations)
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// AuditEvent is the storage-side representation of an audit event.
+type AuditEvent struct {
+	Key          string    `gorm:"primaryKey"`
+	ProjectID    string    // Project containing the changed resource.
+	AuditEventID string    // Uniquely identifies an event within a project.
+	EventTime    time.Time // Time when the call completed.
+	Method       string    // Name of the called method.
+	Resource     string    // Name of the changed resource.
+	Principal    string    // Identity of the caller.
+	Request      string    // JSON summary of the request.
+	UpdateMask   string    // Comma-separated update mask paths.
+	Code         int32     // Status code of the call.
+	ErrorMessage string    `gorm:"column:message"` // Error message of the call.
+}
+
+// NewAuditEvent initializes a new audit event in a project.
+// Event IDs sort in the order that events are created.
+func NewAuditEvent(project names.Project, t time.Time) *AuditEvent {
+	return &AuditEvent{
+		ProjectID:    project.ProjectID,
+		AuditEventID: fmt.Sprintf("%019d-%s", t.UnixNano(), names.GenerateID()),
+		EventTime:    t,
+	}
+}
+
+// Name returns the resource name of the audit event.
+func (e *AuditEvent) Name() string {
+	return fmt.Sprintf("projects/%s/auditEvents/%s", e.ProjectID, e.AuditEventID)
+}
+
+// Message returns a message representing an audit event.
+func (e *AuditEvent) Message() (message *rpc.AuditEvent, err error) {
+	message = &rpc.AuditEvent{
+		Name:      e.Name(),
+		Method:    e.Method,
+		Resource:  e.Resource,
+		Principal: e.Principal,
+		Request:   e.Request,
+		Code:      e.Code,
+		Message:   e.ErrorMessage,
+	}
+
+	if e.UpdateMask != "" {
+		message.UpdateMask = &fieldmaskpb.FieldMask{Paths: strings.Split(e.UpdateMask, ",")}
+	}
+
+	message.EventTime, err = ptypes.TimestampProto(e.EventTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,261 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Deployment is the storage-side representation of a deployment.
+type Deployment struct {
+	Key                string    `gorm:"primaryKey"`
+	ProjectID          string    // Uniquely identifies a project.
+	ApiID              string    // Uniquely identifies an api within a project.
+	DeploymentID       string    // Uniquely identifies a deployment within an api.
+	RevisionID         string    // Uniquely identifies a revision of a deployment.
+	DisplayName        string    // A human-friendly name.
+	Description        string    // A detailed description.
+	CreateTime         time.Time // Creation time.
+	RevisionCreateTime time.Time // Revision creation time.
+	RevisionUpdateTime time.Time // Time of last change.
+	ApiSpecRevision    string    // The spec revision served by the deployment.
+	EndpointURI        string    // The address where the deployment is serving.
+	ExternalChannelURI string    // The address of the external channel of the API.
+	IntendedAudience   string    // The intended audience of the API.
+	AccessGuidance     string    // How to access the endpoint.
+	Environment        string    // The environment of the deployment.
+	Gateway            string    // The gateway or runtime that serves the deployment.
+	Labels             []byte    // Serialized labels.
+	Annotations        []byte    // Serialized annotations.
+}
+
+// NewDeployment initializes a new resource.
+func NewDeployment(name names.Deployment, body *rpc.ApiDeployment) (deployment *Deployment, err error) {
+	now := time.Now()
+	deployment = &Deployment{
+		ProjectID:          name.ProjectID,
+		ApiID:              name.ApiID,
+		DeploymentID:       name.DeploymentID,
+		DisplayName:        body.GetDisplayName(),
+		Description:        body.GetDescription(),
+		ApiSpecRevision:    body.GetApiSpecRevision(),
+		EndpointURI:        body.GetEndpointUri(),
+		ExternalChannelURI: body.GetExternalChannelUri(),
+		IntendedAudience:   body.GetIntendedAudience(),
+		AccessGuidance:     body.GetAccessGuidance(),
+		Environment:        body.GetEnvironment(),
+		Gateway:            body.GetGateway(),
+		CreateTime:         now,
+		RevisionCreateTime: now,
+		RevisionUpdateTime: now,
+		RevisionID:         newRevisionID(),
+	}
+
+	deployment.Labels, err = bytesForMap(body.GetLabels())
+	if err != nil {
+		return nil, err
+	}
+
+	deployment.Annotations, err = bytesForMap(body.GetAnnotations())
+	if err != nil {
+		return nil, err
+	}
+
+	return deployment, nil
+}
+
+// NewRevision returns a new revision based on the deployment.
+func (d *Deployment) NewRevision() *Deployment {
+	now := time.Now()
+	return &Deployment{
+		ProjectID:          d.ProjectID,
+		ApiID:              d.ApiID,
+		DeploymentID:       d.DeploymentID,
+		DisplayName:        d.DisplayName,
+		Description:        d.Description,
+		ApiSpecRevision:    d.ApiSpecRevision,
+		EndpointURI:        d.EndpointURI,
+		ExternalChannelURI: d.ExternalChannelURI,
+		IntendedAudience:   d.IntendedAudience,
+		AccessGuidance:     d.AccessGuidance,
+		Environment:        d.Environment,
+		Gateway:            d.Gateway,
+		Labels:             d.Labels,
+		Annotations:        d.Annotations,
+		CreateTime:         d.CreateTime,
+		RevisionCreateTime: now,
+		RevisionUpdateTime: now,
+		RevisionID:         newRevisionID(),
+	}
+}
+
+// Name returns the resource name of the deployment.
+func (d *Deployment) Name() string {
+	return names.Deployment{
+		ProjectID:    d.ProjectID,
+		ApiID:        d.ApiID,
+		DeploymentID: d.DeploymentID,
+	}.String()
+}
+
+// RevisionName generates the resource name of the deployment revision.
+func (d *Deployment) RevisionName() string {
+	return fmt.Sprintf("projects/%s/apis/%s/deployments/%s@%s", d.ProjectID, d.ApiID, d.DeploymentID, d.RevisionID)
+}
+
+// Message returns a message representing a deployment with the provided resource name.
+func (d *Deployment) Message(name string) (message *rpc.ApiDeployment, err error) {
+	message = &rpc.ApiDeployment{
+		Name:               name,
+		DisplayName:        d.DisplayName,
+		Description:        d.Description,
+		RevisionId:         d.RevisionID,
+		ApiSpecRevision:    d.ApiSpecRevision,
+		EndpointUri:        d.EndpointURI,
+		ExternalChannelUri: d.ExternalChannelURI,
+		IntendedAudience:   d.IntendedAudience,
+		AccessGuidance:     d.AccessGuidance,
+		Environment:        d.Environment,
+		Gateway:            d.Gateway,
+	}
+
+	message.CreateTime, err = ptypes.TimestampProto(d.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	message.RevisionCreateTime, err = ptypes.TimestampProto(d.RevisionCreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	message.RevisionUpdateTime, err = ptypes.TimestampProto(d.RevisionUpdateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	message.Labels, err = d.LabelsMap()
+	if err != nil {
+		return nil, err
+	}
+
+	message.Annotations, err = mapForBytes(d.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Update modifies a deployment using the contents of a message.
+// Changes to the served spec revision, endpoint, environment, or gateway create a new revision.
+func (d *Deployment) Update(message *rpc.ApiDeployment, mask *fieldmaskpb.FieldMask) error {
+	d.RevisionUpdateTime = time.Now()
+	revised := false
+	for _, field := range mask.Paths {
+		switch field {
+		case "display_name":
+			d.DisplayName = message.GetDisplayName()
+		case "description":
+			d.Description = message.GetDescription()
+		case "api_spec_revision":
+			revised = revised || d.ApiSpecRevision != message.GetApiSpecRevision()
+			d.ApiSpecRevision = message.GetApiSpecRevision()
+		case "endpoint_uri":
+			revised = revised || d.EndpointURI != message.GetEndpointUri()
+			d.EndpointURI = message.GetEndpointUri()
+		case "external_channel_uri":
+			d.ExternalChannelURI = message.GetExternalChannelUri()
+		case "intended_audience":
+			d.IntendedAudience = message.GetIntendedAudience()
+		case "access_guidance":
+			d.AccessGuidance = message.GetAccessGuidance()
+		case "environment":
+			revised = revised || d.Environment != message.GetEnvironment()
+			d.Environment = message.GetEnvironment()
+		case "gateway":
+			revised = revised || d.Gateway != message.GetGateway()
+			d.Gateway = message.GetGateway()
+		case "labels":
+			var err error
+			if d.Labels, err = bytesForMap(message.GetLabels()); err != nil {
+				return err
+			}
+		case "annotations":
+			var err error
+			if d.Annotations, err = bytesForMap(message.GetAnnotations()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if revised {
+		d.RevisionID = newRevisionID()
+		d.RevisionCreateTime = d.RevisionUpdateTime
+	}
+
+	return nil
+}
+
+// LabelsMap returns a map representation of stored labels.
+func (d *Deployment) LabelsMap() (map[string]string, error) {
+	return mapForBytes(d.Labels)
+}
+
+// AnnotationsMap returns a map representation of stored annotations.
+func (d *Deployment) AnnotationsMap() (map[string]string, error) {
+	return mapForBytes(d.Annotations)
+}
+
+// DeploymentRevisionTag is the storage-side representation of a deployment revision tag.
+type DeploymentRevisionTag struct {
+	Key          string    `gorm:"primaryKey"`
+	ProjectID    string    // Uniquely identifies a project.
+	ApiID        string    // Uniquely identifies an api within a project.
+	DeploymentID string    // Uniquely identifies a deployment within an api.
+	RevisionID   string    // Uniquely identifies a revision of a deployment.
+	Tag          string    // The tag to use for the revision.
+	CreateTime   time.Time // Creation time.
+	UpdateTime   time.Time // Time of last change.
+}
+
+// NewDeploymentRevisionTag initializes a new revision tag from a given revision name and tag string.
+func NewDeploymentRevisionTag(name names.DeploymentRevision, tag string) *DeploymentRevisionTag {
+	now := time.Now()
+	return &DeploymentRevisionTag{
+		ProjectID:    name.ProjectID,
+		ApiID:        name.ApiID,
+		DeploymentID: name.DeploymentID,
+		RevisionID:   name.RevisionID,
+		Tag:          tag,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+}
+
+func (t *DeploymentRevisionTag) String() string {
+	return fmt.Sprintf("projects/%s/apis/%s/deployments/%s@%s", t.ProjectID, t.ApiID, t.DeploymentID, t.Tag)
+}
+
+// RevisionName returns the resource name of the tagged deployment revision.
+func (t *DeploymentRevisionTag) RevisionName() string {
+	return fmt.Sprintf("projects/%s/apis/%s/deployments/%s@%s", t.ProjectID, t.ApiID, t.DeploymentID, t.RevisionID)
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/longrunning"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Operation is the storage-side representation of a long-running operation.
+type Operation struct {
+	Key             string    `gorm:"primaryKey"`
+	ProjectID       string    // Project containing the operation.
+	OperationID     string    // Uniquely identifies an operation within a project.
+	CreateTime      time.Time // Creation time.
+	EndTime         time.Time // Time when the operation finished.
+	Verb            string    // Verb describing the operation.
+	Target          string    // Name of the resource that the operation acts on.
+	Request         []byte    // Serialized Any containing the request that started the operation.
+	Done            bool      // True if the operation has finished.
+	CancelRequested bool      // True if cancellation has been requested.
+	Code            int32     // Status code of a failed operation.
+	ErrorMessage    string    // Error message of a failed operation.
+	Response        []byte    // Serialized Any containing the response of a successful operation.
+	Owner           string    // Identifies the server that is running the operation.
+	LeaseExpireTime time.Time // Time after which other servers may take over the operation.
+	Method          string    // Name of the audited method that started the operation.
+	Principal       string    // Identity of the caller that started the operation.
+}
+
+// NewOperation initializes a new operation that acts on a target resource.
+// The request that started the operation is saved so that the operation can
+// be resumed if it is interrupted.
+func NewOperation(project names.Project, verb, target string, req proto.Message) (*Operation, error) {
+	request, err := marshalAny(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Operation{
+		ProjectID:   project.ProjectID,
+		OperationID: names.GenerateID(),
+		CreateTime:  time.Now(),
+		Verb:        verb,
+		Target:      target,
+		Request:     request,
+	}, nil
+}
+
+// Name returns the resource name of the operation.
+func (o *Operation) Name() string {
+	return fmt.Sprintf("projects/%s/operations/%s", o.ProjectID, o.OperationID)
+}
+
+// StartingRequest returns the request that started the operation.
+func (o *Operation) StartingRequest() (proto.Message, error) {
+	a := new(anypb.Any)
+	if err := proto.Unmarshal(o.Request, a); err != nil {
+		return nil, err
+	}
+	return a.UnmarshalNew()
+}
+
+// Lease records that an owner is running the operation until a time.
+func (o *Operation) Lease(owner string, expire time.Time) {
+	o.Owner = owner
+	o.LeaseExpireTime = expire
+}
+
+// LeaseExpired returns true if the operation's owner has stopped renewing its lease.
+func (o *Operation) LeaseExpired(now time.Time) bool {
+	return !now.Before(o.LeaseExpireTime)
+}
+
+// Finish records the result of an operation. If err is not nil, the operation
+// failed with the status of the error; otherwise it returned the response.
+func (o *Operation) Finish(response proto.Message, err error) error {
+	o.Done = true
+	o.EndTime = time.Now()
+	if err != nil {
+		s := status.Convert(err)
+		o.Code = int32(s.Code())
+		o.ErrorMessage = s.Message()
+		return nil
+	}
+
+	if response == nil {
+		response = &emptypb.Empty{}
+	}
+	o.Response, err = marshalAny(response)
+	return err
+}
+
+// Message returns a message representing an operation.
+func (o *Operation) Message() (message *longrunning.Operation, err error) {
+	metadata := &rpc.OperationMetadata{
+		Target:          o.Target,
+		Verb:            o.Verb,
+		CancelRequested: o.CancelRequested,
+	}
+
+	metadata.CreateTime, err = ptypes.TimestampProto(o.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.Done {
+		metadata.EndTime, err = ptypes.TimestampProto(o.EndTime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	message = &longrunning.Operation{
+		Name: o.Name(),
+		Done: o.Done,
+	}
+
+	message.Metadata, err = anypb.New(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !o.Done:
+	case o.Code != 0:
+		message.Result = &longrunning.Operation_Error{
+			Error: &statuspb.Status{Code: o.Code, Message: o.ErrorMessage},
+		}
+	default:
+		response := new(anypb.Any)
+		if err := proto.Unmarshal(o.Response, response); err != nil {
+			return nil, err
+		}
+		message.Result = &longrunning.Operation_Response{Response: response}
+	}
+
+	return message, nil
+}
+
+func marshalAny(m proto.Message) ([]byte, error) {
+	a, err := anypb.New(m)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(a)
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// ApplyReadMask clears the fields of a message that are not included in a
+// read mask, following AIP-157 guidance. Masks should be validated with
+// ValidateMask before they are applied.
+func ApplyReadMask(m protoreflect.ProtoMessage, mask *fieldmaskpb.FieldMask) {
+	if len(mask.GetPaths()) == 0 {
+		return
+	}
+	for _, path := range mask.GetPaths() {
+		if path == "*" {
+			return
+		}
+	}
+	prune(m.ProtoReflect(), mask.GetPaths())
+}
+
+// prune clears the fields of a message that are not named by any of the paths.
+func prune(m protoreflect.Message, paths []string) {
+	// Map each top-level field to the subpaths that are kept within it.
+	// A nil entry means that the entire field is kept.
+	keep := make(map[string][]string)
+	for _, path := range paths {
+		head, rest := path, ""
+		if i := strings.Index(path, "."); i >= 0 {
+			head, rest = path[:i], path[i+1:]
+		}
+		if sub, ok := keep[head]; ok && sub == nil {
+			continue // Already keeping the entire field.
+		}
+		if rest == "" {
+			keep[head] = nil
+		} else {
+			keep[head] = append(keep[head], rest)
+		}
+	}
+
+	// Collect fields before changing them, since messages
+	// should not be modified while they are being ranged over.
+	var clear []protoreflect.FieldDescriptor
+	var nested []protoreflect.FieldDescriptor
+	m.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		sub, ok := keep[string(field.Name())]
+		if !ok {
+			clear = append(clear, field)
+		} else if sub != nil && field.Message() != nil && !field.IsList() && !field.IsMap() {
+			nested = append(nested, field)
+		}
+		return true
+	})
+	for _, field := range clear {
+		m.Clear(field)
+	}
+	for _, field := range nested {
+		prune(m.Mutable(field).Message(), keep[string(field.Name())])
+	}
+}
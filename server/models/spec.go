@@ -160,6 +160,17 @@ func (s *Spec) BasicMessage(name string) (message *rpc.ApiSpec, err error) {
 	return message, nil
 }
 
+// FullMessage returns the full view of the spec resource as an RPC message.
+func (s *Spec) FullMessage(blob *Blob, name string) (message *rpc.ApiSpec, err error) {
+	message, err = s.BasicMessage(name)
+	if err != nil {
+		return nil, err
+	}
+
+	message.Contents = blob.Contents
+	return message, nil
+}
+
 // Update modifies a spec using the contents of a message.
 func (s *Spec) Update(message *rpc.ApiSpec, mask *fieldmaskpb.FieldMask) error {
 	s.RevisionUpdateTime = time.Now()
@@ -208,6 +219,11 @@ func (s *Spec) LabelsMap() (map[string]string, error) {
 	return mapForBytes(s.Labels)
 }
 
+// AnnotationsMap returns a map representation of stored annotations.
+func (s *Spec) AnnotationsMap() (map[string]string, error) {
+	return mapForBytes(s.Annotations)
+}
+
 func newRevisionID() string {
 	s := uuid.New().String()
 	return s[len(s)-8:]
@@ -255,3 +271,29 @@ func NewSpecRevisionTag(name names.SpecRevision, tag string) *SpecRevisionTag {
 func (t *SpecRevisionTag) String() string {
 	return fmt.Sprintf("projects/%s/apis/%s/versions/%s/specs/%s@%s", t.ProjectID, t.ApiID, t.VersionID, t.SpecID, t.Tag)
 }
+
+// RevisionName returns the resource name of the tagged spec revision.
+func (t *SpecRevisionTag) RevisionName() string {
+	return fmt.Sprintf("projects/%s/apis/%s/versions/%s/specs/%s@%s", t.ProjectID, t.ApiID, t.VersionID, t.SpecID, t.RevisionID)
+}
+
+// Message returns a message representing a spec revision tag.
+func (t *SpecRevisionTag) Message() (message *rpc.ApiSpecRevisionTag, err error) {
+	message = &rpc.ApiSpecRevisionTag{
+		Name:       t.String(),
+		Tag:        t.Tag,
+		RevisionId: t.RevisionID,
+	}
+
+	message.CreateTime, err = ptypes.TimestampProto(t.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	message.UpdateTime, err = ptypes.TimestampProto(t.UpdateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/names"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// SpecRevisionTagEvent is the storage-side representation of a move of a spec revision tag.
+type SpecRevisionTagEvent struct {
+	Key                string    `gorm:"primaryKey"`
+	ProjectID          string    // Uniquely identifies a project.
+	ApiID              string    // Uniquely identifies an api within a project.
+	VersionID          string    // Uniquely identifies a version within a api.
+	SpecID             string    // Uniquely identifies a spec within a version.
+	Tag                string    // The tag that was moved.
+	EventID            string    // Uniquely identifies a move of a tag.
+	RevisionID         string    // The revision that the tag was moved to.
+	PreviousRevisionID string    // The revision that the tag was moved from, if any.
+	Principal          string    // Identity of the caller that moved the tag.
+	EventTime          time.Time // Time when the tag was moved.
+}
+
+// NewSpecRevisionTagEvent records a tag being moved from a previous revision, which is empty for new tags.
+// Event IDs sort in the order that events are created.
+func NewSpecRevisionTagEvent(tag *SpecRevisionTag, previous, principal string) *SpecRevisionTagEvent {
+	return &SpecRevisionTagEvent{
+		ProjectID:          tag.ProjectID,
+		ApiID:              tag.ApiID,
+		VersionID:          tag.VersionID,
+		SpecID:             tag.SpecID,
+		Tag:                tag.Tag,
+		EventID:            fmt.Sprintf("%019d-%s", tag.UpdateTime.UnixNano(), names.GenerateID()),
+		RevisionID:         tag.RevisionID,
+		PreviousRevisionID: previous,
+		Principal:          principal,
+		EventTime:          tag.UpdateTime,
+	}
+}
+
+// TagName returns the name of the tagged spec.
+func (e *SpecRevisionTagEvent) TagName() string {
+	return fmt.Sprintf("projects/%s/apis/%s/versions/%s/specs/%s@%s", e.ProjectID, e.ApiID, e.VersionID, e.SpecID, e.Tag)
+}
+
+// String returns the storage name of the event.
+func (e *SpecRevisionTagEvent) String() string {
+	return fmt.Sprintf("%s/events/%s", e.TagName(), e.EventID)
+}
+
+// Message returns a message representing a move of a spec revision tag.
+func (e *SpecRevisionTagEvent) Message() (message *rpc.ApiSpecRevisionTag, err error) {
+	message = &rpc.ApiSpecRevisionTag{
+		Name:               e.TagName(),
+		Tag:                e.Tag,
+		RevisionId:         e.RevisionID,
+		PreviousRevisionId: e.PreviousRevisionID,
+		Principal:          e.Principal,
+	}
+
+	message.CreateTime, err = ptypes.TimestampProto(e.EventTime)
+	if err != nil {
+		return nil, err
+	}
+	message.UpdateTime = message.CreateTime
+
+	return message, nil
+}
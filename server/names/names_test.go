@@ -50,6 +50,21 @@ func TestResourceNames(t *testing.T) {
 				"-",
 			},
 		},
+		{
+			name:   "operation",
+			regexp: OperationRegexp(),
+			pass: []string{
+				"projects/google/operations/1234abcd",
+				"projects/-/operations/-",
+			},
+			fail: []string{
+				"-",
+				"projects/google",
+				"projects/google/operations",
+				"projects/google/operations/",
+				"operations/1234abcd",
+			},
+		},
 		{
 			name:   "apis",
 			regexp: ApisRegexp(),
@@ -108,6 +123,32 @@ func TestResourceNames(t *testing.T) {
 				"projects/123/apis/ 123",
 			},
 		},
+		{
+			name:   "deployments",
+			regexp: DeploymentsRegexp(),
+			pass: []string{
+				"projects/google/apis/sample/deployments",
+				"projects/-/apis/-/deployments",
+			},
+			fail: []string{
+				"-",
+				"projects/google/apis/sample/versions/v1/deployments",
+			},
+		},
+		{
+			name:   "deployment",
+			regexp: DeploymentRegexp(),
+			pass: []string{
+				"projects/google/apis/sample/deployments/prod",
+				"projects/google/apis/sample/deployments/prod@1234abcd",
+				"projects/-/apis/-/deployments/-",
+			},
+			fail: []string{
+				"-",
+				"projects/google/apis/sample/deployments",
+				"projects/google/apis/sample/deployments/prod@",
+			},
+		},
 		{
 			name:   "specs",
 			regexp: SpecsRegexp(),
@@ -0,0 +1,56 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package names
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Operation represents a resource name for a long-running operation.
+type Operation struct {
+	ProjectID   string
+	OperationID string
+}
+
+// Project returns the name of this operation's parent project.
+func (o Operation) Project() Project {
+	return Project{
+		ProjectID: o.ProjectID,
+	}
+}
+
+func (o Operation) String() string {
+	return normalize(fmt.Sprintf("projects/%s/operations/%s", o.ProjectID, o.OperationID))
+}
+
+// OperationRegexp returns a regular expression that matches an operation resource name.
+func OperationRegexp() *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf("^projects/%s/operations/%s$", identifier, identifier))
+}
+
+// ParseOperation parses the name of an operation.
+func ParseOperation(name string) (Operation, error) {
+	r := OperationRegexp()
+	if !r.MatchString(name) {
+		return Operation{}, fmt.Errorf("invalid operation name %q: must match %q", name, r)
+	}
+
+	m := r.FindStringSubmatch(name)
+	return Operation{
+		ProjectID:   m[1],
+		OperationID: m[2],
+	}, nil
+}
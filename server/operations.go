@@ -0,0 +1,359 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"google.golang.org/genproto/googleapis/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// operationFunc performs the work of a long-running operation.
+// It returns the response of the operation or the error that caused it to fail.
+type operationFunc func(ctx context.Context) (proto.Message, error)
+
+// operationLeaseDuration is how long a server may run an operation without
+// renewing its lease. Operations whose leases expire are taken over by other
+// servers, which assume that the owner stopped.
+const operationLeaseDuration = time.Minute
+
+// errLeaseLost is returned when an operation is taken over by another server.
+var errLeaseLost = status.Error(codes.Aborted, "operation was taken over by another server")
+
+// operationRunner tracks the operations that are running in this server.
+// The zero value is ready to use.
+type operationRunner struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+	once    sync.Once
+	owner   string
+}
+
+// id returns the owner ID that identifies this server in operation leases.
+func (r *operationRunner) id() string {
+	r.once.Do(func() { r.owner = names.GenerateID() })
+	return r.owner
+}
+
+func (r *operationRunner) add(name string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancels == nil {
+		r.cancels = make(map[string]context.CancelFunc)
+	}
+	r.cancels[name] = cancel
+	r.wg.Add(1)
+}
+
+func (r *operationRunner) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[name]; ok {
+		cancel()
+		delete(r.cancels, name)
+		r.wg.Done()
+	}
+}
+
+// running returns true if an operation is running in this server.
+func (r *operationRunner) running(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.cancels[name]
+	return ok
+}
+
+// cancel stops a running operation. It has no effect on operations that are
+// not running in this server.
+func (r *operationRunner) cancel(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.cancels[name]; ok {
+		cancel()
+	}
+}
+
+// wait blocks until all running operations have finished.
+func (r *operationRunner) wait() {
+	r.wg.Wait()
+}
+
+// operationName returns the resource name of an operation.
+func operationName(op *models.Operation) names.Operation {
+	return names.Operation{
+		ProjectID:   op.ProjectID,
+		OperationID: op.OperationID,
+	}
+}
+
+// startOperation saves a new operation and runs it in the background.
+func (s *RegistryServer) startOperation(ctx context.Context, op *models.Operation, run operationFunc) (*longrunning.Operation, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	// Audited calls are recorded when their operations finish.
+	if method := auditedMethod(ctx); method != "" {
+		op.Method = filepath.Base(method)
+		op.Principal = principal(ctx)
+	}
+	op.Lease(s.operations.id(), time.Now().Add(operationLeaseDuration))
+	if err := db.SaveOperation(ctx, op); err != nil {
+		return nil, err
+	}
+
+	message, err := op.Message()
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	s.runOperation(op, run)
+	return message, nil
+}
+
+// runOperation runs an operation in the background and saves its result when it finishes.
+// Operations are not bound to the context of the call that started them.
+// The operation's lease is renewed while it runs.
+func (s *RegistryServer) runOperation(op *models.Operation, run operationFunc) {
+	name := operationName(op)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.operations.add(name.String(), cancel)
+	go func() {
+		defer s.operations.remove(name.String())
+		go s.renewLease(ctx, name)
+		response, err := run(ctx)
+		if err := s.finishOperation(name, response, err); err != nil && s.loggingLevel >= loggingError {
+			log.Printf("[%s] failed to save operation result: %s", name, err)
+		}
+	}()
+}
+
+// renewLease extends the lease of a running operation until its context is done.
+// Operations are stopped if they are cancelled by other servers or if their leases are lost.
+func (s *RegistryServer) renewLease(ctx context.Context, name names.Operation) {
+	ticker := time.NewTicker(operationLeaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cancelled, err := s.extendLease(ctx, name)
+		if err == errLeaseLost || cancelled {
+			s.operations.cancel(name.String())
+			return
+		} else if err != nil && s.loggingLevel >= loggingError {
+			log.Printf("[%s] failed to renew operation lease: %s", name, err)
+		}
+	}
+}
+
+// extendLease extends the lease of an operation owned by this server.
+// It returns true if cancellation of the operation has been requested.
+func (s *RegistryServer) extendLease(ctx context.Context, name names.Operation) (bool, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	var cancelled bool
+	err = db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		op, err := db.GetOperation(ctx, name)
+		if err != nil {
+			return err
+		}
+		if op.Done || op.Owner != s.operations.id() {
+			return errLeaseLost
+		}
+		cancelled = op.CancelRequested
+		op.Lease(op.Owner, time.Now().Add(operationLeaseDuration))
+		return db.SaveOperation(ctx, op)
+	})
+	return cancelled, err
+}
+
+// finishOperation saves the result of an operation, unless another server
+// has taken it over, and records the result in the audit log.
+func (s *RegistryServer) finishOperation(name names.Operation, response proto.Message, runErr error) error {
+	ctx := context.Background()
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	var finished *models.Operation
+	err = db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		// Reload the operation, which may have been cancelled, deleted,
+		// or taken over while it ran.
+		op, err := db.GetOperation(ctx, name)
+		if isNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if op.Done || op.Owner != s.operations.id() {
+			return nil
+		}
+
+		if runErr != nil && op.CancelRequested {
+			runErr = status.Error(codes.Canceled, "operation was cancelled")
+		}
+		if err := op.Finish(response, runErr); err != nil {
+			return err
+		}
+
+		if err := db.SaveOperation(ctx, op); err != nil {
+			return err
+		}
+		finished = op
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if finished != nil {
+		s.recordOperationResult(ctx, finished, runErr)
+	}
+	return nil
+}
+
+// operationFor returns the function that performs the operation started by a request,
+// or nil if operations started by the request cannot be run again.
+// DeleteProject is currently the only method that starts operations.
+func (s *RegistryServer) operationFor(req proto.Message) operationFunc {
+	switch req := req.(type) {
+	case *rpc.DeleteProjectRequest:
+		if name, err := names.ParseProject(req.GetName()); err == nil {
+			return s.deleteProjectOperation(name)
+		}
+	}
+	return nil
+}
+
+// resumedOperation returns the function that performs an interrupted operation,
+// or nil if the operation cannot be run again.
+func (s *RegistryServer) resumedOperation(op *models.Operation) operationFunc {
+	req, err := op.StartingRequest()
+	if err != nil {
+		return nil
+	}
+	return s.operationFor(req)
+}
+
+// resumeOperationsUntilDone periodically takes over operations whose leases
+// have expired, until the context is done.
+func (s *RegistryServer) resumeOperationsUntilDone(ctx context.Context) {
+	for {
+		if err := s.resumeOperations(ctx); err != nil && ctx.Err() == nil && s.loggingLevel >= loggingError {
+			log.Printf("Failed to resume operations: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(operationLeaseDuration):
+		}
+	}
+}
+
+// resumeOperations takes over unfinished operations whose leases have expired,
+// which were interrupted when the servers running them stopped. Operations that
+// cannot be run again are recorded as failed.
+func (s *RegistryServer) resumeOperations(ctx context.Context) error {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	ops, err := db.ListUnfinishedOperations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range ops {
+		name := operationName(&ops[i])
+		if s.operations.running(name.String()) {
+			continue
+		}
+		op, err := s.claimOperation(ctx, db, name)
+		if err != nil {
+			return err
+		} else if op == nil || op.Done {
+			continue
+		}
+
+		if run := s.resumedOperation(op); run != nil {
+			s.runOperation(op, run)
+		}
+	}
+
+	return nil
+}
+
+// claimOperation takes over an operation if its lease has expired. Operations
+// that cannot be run again are finished. It returns nil if the operation
+// is leased by another server.
+func (s *RegistryServer) claimOperation(ctx context.Context, db dao.DAO, name names.Operation) (*models.Operation, error) {
+	var claimed *models.Operation
+	err := db.Transaction(ctx, func(ctx context.Context, db dao.DAO) error {
+		op, err := db.GetOperation(ctx, name)
+		if isNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		now := time.Now()
+		if op.Done || !op.LeaseExpired(now) {
+			return nil
+		}
+
+		op.Lease(s.operations.id(), now.Add(operationLeaseDuration))
+		if op.CancelRequested {
+			err = op.Finish(nil, status.Error(codes.Canceled, "operation was cancelled"))
+		} else if s.resumedOperation(op) == nil {
+			err = op.Finish(nil, status.Error(codes.Aborted, "operation was interrupted by a server restart"))
+		}
+		if err != nil {
+			return err
+		}
+		if err := db.SaveOperation(ctx, op); err != nil {
+			return err
+		}
+		claimed = op
+		return nil
+	})
+	return claimed, err
+}
@@ -0,0 +1,214 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/names"
+)
+
+// QuotaConfig configures limits on the resources stored in each project.
+type QuotaConfig struct {
+	// Limits are the default limits that apply to every project.
+	Limits `yaml:",inline"`
+	// Projects overrides the default limits for individual projects, keyed by
+	// project ID. Zero values in an override keep the default limit.
+	Projects map[string]Limits `yaml:"projects"`
+	// MaxRequestBytes is the largest request message the server will receive.
+	// Defaults to the gRPC default of 4 MiB.
+	MaxRequestBytes int `yaml:"maxrequestbytes"`
+}
+
+// Limits are the maximum amounts of resources stored in a project.
+// Zero or negative values are unlimited.
+type Limits struct {
+	MaxApis             int64 `yaml:"maxapis"`
+	MaxVersions         int64 `yaml:"maxversions"`
+	MaxSpecs            int64 `yaml:"maxspecs"`
+	MaxRevisionsPerSpec int64 `yaml:"maxrevisionsperspec"`
+	MaxArtifacts        int64 `yaml:"maxartifacts"`
+	// MaxSizeBytes limits the total size of spec revision and artifact contents.
+	MaxSizeBytes int64 `yaml:"maxsizebytes"`
+	// MaxBlobSizeBytes limits the size of the contents of a single spec revision or artifact.
+	MaxBlobSizeBytes int64 `yaml:"maxblobsizebytes"`
+}
+
+// Validate checks the quota configuration for errors.
+func (c QuotaConfig) Validate() error {
+	if c.MaxRequestBytes < 0 {
+		return fmt.Errorf("invalid quotas config: maxrequestbytes must not be negative")
+	}
+	for project := range c.Projects {
+		if err := (names.Project{ProjectID: project}).Validate(); err != nil {
+			return fmt.Errorf("invalid quotas config: %s", err)
+		}
+	}
+	return nil
+}
+
+// limits returns the limits that apply to a project.
+func (c QuotaConfig) limits(project string) Limits {
+	l := c.Limits
+	o, ok := c.Projects[project]
+	if !ok {
+		return l
+	}
+	override := func(v *int64, o int64) {
+		if o != 0 {
+			*v = o
+		}
+	}
+	override(&l.MaxApis, o.MaxApis)
+	override(&l.MaxVersions, o.MaxVersions)
+	override(&l.MaxSpecs, o.MaxSpecs)
+	override(&l.MaxRevisionsPerSpec, o.MaxRevisionsPerSpec)
+	override(&l.MaxArtifacts, o.MaxArtifacts)
+	override(&l.MaxSizeBytes, o.MaxSizeBytes)
+	override(&l.MaxBlobSizeBytes, o.MaxBlobSizeBytes)
+	return l
+}
+
+// message returns the limits as reported by GetProjectUsage,
+// where unlimited values are always zero.
+func (l Limits) message() *rpc.ProjectUsage_Limits {
+	unlimited := func(v int64) int64 {
+		if v < 0 {
+			return 0
+		}
+		return v
+	}
+	return &rpc.ProjectUsage_Limits{
+		MaxApis:             unlimited(l.MaxApis),
+		MaxVersions:         unlimited(l.MaxVersions),
+		MaxSpecs:            unlimited(l.MaxSpecs),
+		MaxRevisionsPerSpec: unlimited(l.MaxRevisionsPerSpec),
+		MaxArtifacts:        unlimited(l.MaxArtifacts),
+		MaxSizeBytes:        unlimited(l.MaxSizeBytes),
+		MaxBlobSizeBytes:    unlimited(l.MaxBlobSizeBytes),
+	}
+}
+
+// checkCount returns an error if adding one more resource to those counted
+// would exceed a limit. Resources are only counted when a limit is set.
+//
+// Quotas are checked in the transactions that save resources. Postgres
+// transactions are serializable, and each server runs its SQLite transactions
+// one at a time, so concurrent calls can't exceed a quota together. Servers
+// that share a SQLite database don't coordinate their transactions, so their
+// concurrent calls can exceed quotas.
+func checkCount(owner fmt.Stringer, resources string, limit int64, count func() (int64, error)) error {
+	if limit <= 0 {
+		return nil
+	}
+	n, err := count()
+	if err != nil {
+		return err
+	}
+	if n+1 > limit {
+		return resourceExhaustedError(fmt.Errorf("%q has reached its quota of %d %s", owner, limit, resources))
+	}
+	return nil
+}
+
+func (s *RegistryServer) checkApiQuota(ctx context.Context, db dao.DAO, project names.Project) error {
+	return checkCount(project, "APIs", s.quotas.limits(project.ProjectID).MaxApis, func() (int64, error) {
+		return db.CountApis(ctx, project)
+	})
+}
+
+func (s *RegistryServer) checkVersionQuota(ctx context.Context, db dao.DAO, project names.Project) error {
+	return checkCount(project, "versions", s.quotas.limits(project.ProjectID).MaxVersions, func() (int64, error) {
+		return db.CountVersions(ctx, project)
+	})
+}
+
+func (s *RegistryServer) checkSpecQuota(ctx context.Context, db dao.DAO, project names.Project) error {
+	return checkCount(project, "specs", s.quotas.limits(project.ProjectID).MaxSpecs, func() (int64, error) {
+		return db.CountSpecs(ctx, project)
+	})
+}
+
+func (s *RegistryServer) checkRevisionQuota(ctx context.Context, db dao.DAO, spec names.Spec) error {
+	return checkCount(spec, "revisions", s.quotas.limits(spec.ProjectID).MaxRevisionsPerSpec, func() (int64, error) {
+		return db.CountSpecRevisions(ctx, spec)
+	})
+}
+
+func (s *RegistryServer) checkArtifactQuota(ctx context.Context, db dao.DAO, project names.Project) error {
+	return checkCount(project, "artifacts", s.quotas.limits(project.ProjectID).MaxArtifacts, func() (int64, error) {
+		return db.CountArtifacts(ctx, project)
+	})
+}
+
+// checkContentsQuota returns an error if storing contents of the given size
+// would exceed a project's limits. Replaced is the size of any contents that
+// the new contents will replace.
+func (s *RegistryServer) checkContentsQuota(ctx context.Context, db dao.DAO, project names.Project, size, replaced int64) error {
+	l := s.quotas.limits(project.ProjectID)
+	if l.MaxBlobSizeBytes > 0 && size > l.MaxBlobSizeBytes {
+		return resourceExhaustedError(fmt.Errorf("contents of %d bytes exceed the quota of %d bytes per blob in %q", size, l.MaxBlobSizeBytes, project))
+	}
+	if l.MaxSizeBytes <= 0 {
+		return nil
+	}
+	total, err := db.SumContentSizes(ctx, project)
+	if err != nil {
+		return err
+	}
+	if total-replaced+size > l.MaxSizeBytes {
+		return resourceExhaustedError(fmt.Errorf("%q has reached its quota of %d bytes of contents", project, l.MaxSizeBytes))
+	}
+	return nil
+}
+
+// GetProjectUsage handles the corresponding API request.
+func (s *RegistryServer) GetProjectUsage(ctx context.Context, req *rpc.GetProjectUsageRequest) (*rpc.ProjectUsage, error) {
+	client, err := s.getStorageClient(ctx)
+	if err != nil {
+		return nil, unavailableError(err)
+	}
+	defer s.releaseStorageClient(client)
+	db := dao.NewDAO(client)
+
+	name, err := names.ParseProject(strings.TrimSuffix(req.GetName(), "/usage"))
+	if err != nil || !strings.HasSuffix(req.GetName(), "/usage") {
+		return nil, invalidArgumentError(fmt.Errorf("invalid resource name %q, must be a project usage", req.GetName()))
+	}
+
+	if _, err := db.GetProject(ctx, name); err != nil {
+		return nil, err
+	}
+
+	usage, err := db.GetProjectUsage(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.ProjectUsage{
+		Name:          name.String() + "/usage",
+		ApiCount:      usage.Apis,
+		VersionCount:  usage.Versions,
+		SpecCount:     usage.Specs,
+		RevisionCount: usage.Revisions,
+		ArtifactCount: usage.Artifacts,
+		SizeBytes:     usage.SizeBytes,
+		Limits:        s.quotas.limits(name.ProjectID).message(),
+	}, nil
+}
@@ -0,0 +1,393 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestQuotaLimits(t *testing.T) {
+	config := QuotaConfig{
+		Limits: Limits{MaxApis: 10, MaxSpecs: 20},
+		Projects: map[string]Limits{
+			"big-project":       {MaxApis: 100},
+			"unlimited-project": {MaxApis: -1, MaxSpecs: -1},
+		},
+	}
+
+	tests := []struct {
+		project string
+		want    Limits
+	}{
+		{"my-project", Limits{MaxApis: 10, MaxSpecs: 20}},
+		{"big-project", Limits{MaxApis: 100, MaxSpecs: 20}},
+		{"unlimited-project", Limits{MaxApis: -1, MaxSpecs: -1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.project, func(t *testing.T) {
+			if got := config.limits(test.project); got != test.want {
+				t.Errorf("limits(%q) returned %+v, want %+v", test.project, got, test.want)
+			}
+		})
+	}
+}
+
+func TestQuotaConfigValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config QuotaConfig
+		valid  bool
+	}{
+		{"empty", QuotaConfig{}, true},
+		{"project override", QuotaConfig{Projects: map[string]Limits{"my-project": {MaxApis: 1}}}, true},
+		{"invalid project", QuotaConfig{Projects: map[string]Limits{"My Project": {MaxApis: 1}}}, false},
+		{"negative request size", QuotaConfig{MaxRequestBytes: -1}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := test.config.Validate(); (err == nil) != test.valid {
+				t.Errorf("Validate() returned error %v, want valid=%t", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc   string
+		limits Limits
+		seed   func(t *testing.T, s *RegistryServer)
+		call   func(t *testing.T, s *RegistryServer) error
+	}{
+		{
+			desc:   "apis",
+			limits: Limits{MaxApis: 1},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedApis(ctx, t, s, &rpc.Api{Name: "projects/my-project/apis/a1"})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateApi(ctx, &rpc.CreateApiRequest{
+					Parent: "projects/my-project",
+					ApiId:  "a2",
+					Api:    &rpc.Api{},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "versions",
+			limits: Limits{MaxVersions: 1},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedVersions(ctx, t, s, &rpc.ApiVersion{Name: "projects/my-project/apis/a/versions/v1"})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateApiVersion(ctx, &rpc.CreateApiVersionRequest{
+					Parent:       "projects/my-project/apis/a",
+					ApiVersionId: "v2",
+					ApiVersion:   &rpc.ApiVersion{},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "specs",
+			limits: Limits{MaxSpecs: 1},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedSpecs(ctx, t, s, &rpc.ApiSpec{Name: "projects/my-project/apis/a/versions/v/specs/s1"})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateApiSpec(ctx, &rpc.CreateApiSpecRequest{
+					Parent:    "projects/my-project/apis/a/versions/v",
+					ApiSpecId: "s2",
+					ApiSpec:   &rpc.ApiSpec{},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "revisions",
+			limits: Limits{MaxRevisionsPerSpec: 1},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedSpecs(ctx, t, s, &rpc.ApiSpec{
+					Name:     "projects/my-project/apis/a/versions/v/specs/s",
+					Contents: []byte("first"),
+				})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+					ApiSpec: &rpc.ApiSpec{
+						Name:     "projects/my-project/apis/a/versions/v/specs/s",
+						Contents: []byte("second"),
+					},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "artifacts",
+			limits: Limits{MaxArtifacts: 1},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedArtifacts(ctx, t, s, &rpc.Artifact{Name: "projects/my-project/artifacts/a1"})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateArtifact(ctx, &rpc.CreateArtifactRequest{
+					Parent:     "projects/my-project",
+					ArtifactId: "a2",
+					Artifact:   &rpc.Artifact{},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "blob size",
+			limits: Limits{MaxBlobSizeBytes: 4},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedVersions(ctx, t, s, &rpc.ApiVersion{Name: "projects/my-project/apis/a/versions/v"})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateApiSpec(ctx, &rpc.CreateApiSpecRequest{
+					Parent:    "projects/my-project/apis/a/versions/v",
+					ApiSpecId: "s",
+					ApiSpec:   &rpc.ApiSpec{Contents: []byte("too large")},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "total size",
+			limits: Limits{MaxSizeBytes: 10},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedArtifacts(ctx, t, s, &rpc.Artifact{
+					Name:     "projects/my-project/artifacts/a",
+					Contents: []byte("0123456789"),
+				})
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				_, err := s.CreateArtifact(ctx, &rpc.CreateArtifactRequest{
+					Parent:     "projects/my-project",
+					ArtifactId: "b",
+					Artifact:   &rpc.Artifact{Contents: []byte("x")},
+				})
+				return err
+			},
+		},
+		{
+			desc:   "total size after rollback",
+			limits: Limits{MaxSizeBytes: 11},
+			seed: func(t *testing.T, s *RegistryServer) {
+				seedSpecs(ctx, t, s, &rpc.ApiSpec{
+					Name:     "projects/my-project/apis/a/versions/v/specs/s",
+					Contents: []byte("first"),
+				})
+				if _, err := s.UpdateApiSpec(ctx, &rpc.UpdateApiSpecRequest{
+					ApiSpec: &rpc.ApiSpec{
+						Name:     "projects/my-project/apis/a/versions/v/specs/s",
+						Contents: []byte("second"),
+					},
+				}); err != nil {
+					t.Fatalf("Setup: UpdateApiSpec() returned error: %s", err)
+				}
+			},
+			call: func(t *testing.T, s *RegistryServer) error {
+				revisions, err := s.ListApiSpecRevisions(ctx, &rpc.ListApiSpecRevisionsRequest{
+					Name: "projects/my-project/apis/a/versions/v/specs/s",
+				})
+				if err != nil {
+					t.Fatalf("Setup: ListApiSpecRevisions() returned error: %s", err)
+				}
+				_, err = s.RollbackApiSpec(ctx, &rpc.RollbackApiSpecRequest{
+					Name:       "projects/my-project/apis/a/versions/v/specs/s",
+					RevisionId: revisions.GetApiSpecs()[1].GetRevisionId(),
+				})
+				return err
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			server := defaultTestServer(t)
+			test.seed(t, server)
+			if err := test.call(t, server); err != nil {
+				t.Fatalf("call without quotas returned error: %s", err)
+			}
+
+			server = defaultTestServer(t)
+			server.quotas = QuotaConfig{Limits: test.limits}
+			test.seed(t, server)
+			if err := test.call(t, server); status.Code(err) != codes.ResourceExhausted {
+				t.Errorf("call over quota returned status code %s, want %s: %v", status.Code(err), codes.ResourceExhausted, err)
+			}
+
+			server = defaultTestServer(t)
+			server.quotas = QuotaConfig{
+				Limits:   test.limits,
+				Projects: map[string]Limits{"my-project": unlimited},
+			}
+			test.seed(t, server)
+			if err := test.call(t, server); err != nil {
+				t.Errorf("call with project override returned error: %s", err)
+			}
+		})
+	}
+}
+
+var unlimited = Limits{
+	MaxApis:             -1,
+	MaxVersions:         -1,
+	MaxSpecs:            -1,
+	MaxRevisionsPerSpec: -1,
+	MaxArtifacts:        -1,
+	MaxSizeBytes:        -1,
+	MaxBlobSizeBytes:    -1,
+}
+
+func TestReplaceArtifactQuota(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	server.quotas = QuotaConfig{Limits: Limits{MaxSizeBytes: 10}}
+	seedArtifacts(ctx, t, server, &rpc.Artifact{
+		Name:     "projects/my-project/artifacts/a",
+		Contents: []byte("0123456789"),
+	})
+
+	// Replaced contents no longer count against the quota.
+	req := &rpc.ReplaceArtifactRequest{
+		Artifact: &rpc.Artifact{
+			Name:     "projects/my-project/artifacts/a",
+			Contents: []byte("9876543210"),
+		},
+	}
+	if _, err := server.ReplaceArtifact(ctx, req); err != nil {
+		t.Fatalf("ReplaceArtifact(%+v) returned error: %s", req, err)
+	}
+
+	req.Artifact.Contents = []byte("01234567890")
+	if _, err := server.ReplaceArtifact(ctx, req); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("ReplaceArtifact(%+v) returned status code %s, want %s: %v", req, status.Code(err), codes.ResourceExhausted, err)
+	}
+}
+
+func TestGetProjectUsage(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	server.quotas = QuotaConfig{
+		Limits:   Limits{MaxApis: 10, MaxSizeBytes: 1000},
+		Projects: map[string]Limits{"my-project": {MaxSizeBytes: -1}},
+	}
+	seedSpecs(ctx, t, server,
+		&rpc.ApiSpec{Name: "projects/my-project/apis/a/versions/v/specs/s1", Contents: []byte("abc")},
+		&rpc.ApiSpec{Name: "projects/my-project/apis/a/versions/v/specs/s2", Contents: []byte("defg")},
+		&rpc.ApiSpec{Name: "projects/other-project/apis/a/versions/v/specs/s", Contents: []byte("hijkl")},
+	)
+	updateReq := &rpc.UpdateApiSpecRequest{
+		ApiSpec: &rpc.ApiSpec{
+			Name:     "projects/my-project/apis/a/versions/v/specs/s1",
+			Contents: []byte("abcde"),
+		},
+	}
+	if _, err := server.UpdateApiSpec(ctx, updateReq); err != nil {
+		t.Fatalf("Setup: UpdateApiSpec(%+v) returned error: %s", updateReq, err)
+	}
+	seedArtifacts(ctx, t, server, &rpc.Artifact{
+		Name:     "projects/my-project/apis/a/artifacts/x",
+		Contents: []byte("xy"),
+	})
+
+	req := &rpc.GetProjectUsageRequest{
+		Name: "projects/my-project/usage",
+	}
+
+	got, err := server.GetProjectUsage(ctx, req)
+	if err != nil {
+		t.Fatalf("GetProjectUsage(%+v) returned error: %s", req, err)
+	}
+
+	want := &rpc.ProjectUsage{
+		Name:          "projects/my-project/usage",
+		ApiCount:      1,
+		VersionCount:  1,
+		SpecCount:     2,
+		RevisionCount: 3,
+		ArtifactCount: 1,
+		SizeBytes:     3 + 4 + 5 + 2,
+		Limits:        &rpc.ProjectUsage_Limits{MaxApis: 10},
+	}
+
+	if !cmp.Equal(want, got, protocmp.Transform()) {
+		t.Errorf("GetProjectUsage(%+v) returned unexpected diff (-want +got):\n%s", req, cmp.Diff(want, got, protocmp.Transform()))
+	}
+
+	for _, name := range []string{"projects/my-project", "projects/my-project/apis/a/usage", "projects/My Project/usage"} {
+		req := &rpc.GetProjectUsageRequest{Name: name}
+		if _, err := server.GetProjectUsage(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("GetProjectUsage(%+v) returned status code %s, want %s: %v", req, status.Code(err), codes.InvalidArgument, err)
+		}
+	}
+
+	req = &rpc.GetProjectUsageRequest{Name: "projects/missing/usage"}
+	if _, err := server.GetProjectUsage(ctx, req); status.Code(err) != codes.NotFound {
+		t.Errorf("GetProjectUsage(%+v) returned status code %s, want %s: %v", req, status.Code(err), codes.NotFound, err)
+	}
+}
+
+func TestConcurrentQuota(t *testing.T) {
+	ctx := context.Background()
+	server := defaultTestServer(t)
+	server.quotas = QuotaConfig{Limits: Limits{MaxApis: 3}}
+	seedProjects(ctx, t, server, &rpc.Project{Name: "projects/my-project"})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = server.CreateApi(ctx, &rpc.CreateApiRequest{
+				Parent: "projects/my-project",
+				ApiId:  fmt.Sprintf("a%d", i),
+				Api:    &rpc.Api{},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch status.Code(err) {
+		case codes.OK:
+			created++
+		case codes.ResourceExhausted, codes.Aborted:
+		default:
+			t.Errorf("CreateApi() returned unexpected error: %s", err)
+		}
+	}
+	if created > 3 {
+		t.Errorf("Concurrent calls created %d APIs, expected no more than the quota of 3", created)
+	}
+}
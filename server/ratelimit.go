@@ -0,0 +1,247 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apigee/registry/server/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimitConfig configures limits on the rate of calls to the server.
+type RateLimitConfig struct {
+	// Reads limits calls that get, list, or wait for resources.
+	Reads RateLimit `yaml:"reads"`
+	// Writes limits calls that create, change, or delete resources.
+	Writes RateLimit `yaml:"writes"`
+	// Contents limits downloads of spec and artifact contents.
+	Contents RateLimit `yaml:"contents"`
+	// MaxInFlight limits the number of calls handled at once by the server.
+	// Zero is unlimited.
+	MaxInFlight int `yaml:"maxinflight"`
+}
+
+// RateLimit configures a token bucket that is kept for each principal.
+type RateLimit struct {
+	// Rate is the number of calls allowed each second. Zero is unlimited.
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of calls that can be made at once.
+	// Defaults to Rate, rounded up.
+	Burst int `yaml:"burst"`
+}
+
+// Validate checks the rate limit configuration for errors.
+func (c RateLimitConfig) Validate() error {
+	for class, l := range map[string]RateLimit{"reads": c.Reads, "writes": c.Writes, "contents": c.Contents} {
+		if l.Rate < 0 || l.Burst < 0 {
+			return fmt.Errorf("invalid ratelimits config: %s rate and burst must not be negative", class)
+		}
+	}
+	if c.MaxInFlight < 0 {
+		return fmt.Errorf("invalid ratelimits config: maxinflight must not be negative")
+	}
+	return nil
+}
+
+// Methods that are never limited, so that health checks and tools keep working under load.
+var unlimitedMethods = map[string]bool{
+	"/google.cloud.apigee.registry.v1.Registry/GetStatus":            true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+// methodClass returns the rate limiting class of a method.
+func methodClass(fullMethod string) string {
+	method := path.Base(fullMethod)
+	switch {
+	case strings.HasPrefix(method, "Get") && strings.HasSuffix(method, "Contents"):
+		return "contents"
+	case strings.HasPrefix(method, "Get"), strings.HasPrefix(method, "List"), strings.HasPrefix(method, "Wait"):
+		return "reads"
+	default:
+		return "writes"
+	}
+}
+
+// rateLimiter limits the rate of calls by each principal and the number of calls in flight.
+type rateLimiter struct {
+	buckets  map[string]*tokenBuckets
+	inFlight chan struct{}
+}
+
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{buckets: make(map[string]*tokenBuckets)}
+	for class, rl := range map[string]RateLimit{"reads": config.Reads, "writes": config.Writes, "contents": config.Contents} {
+		if rl.Rate > 0 {
+			l.buckets[class] = newTokenBuckets(rl, time.Now)
+		}
+	}
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+// UnaryInterceptor limits unary calls.
+func (l *rateLimiter) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := l.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return handler(ctx, req)
+}
+
+// StreamInterceptor limits streaming calls.
+func (l *rateLimiter) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := l.admit(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return handler(srv, ss)
+}
+
+// admit returns an error if a call should be rejected, or a function
+// to call when the accepted call is finished.
+func (l *rateLimiter) admit(ctx context.Context, fullMethod string) (func(), error) {
+	if unlimitedMethods[fullMethod] {
+		return func() {}, nil
+	}
+
+	class := methodClass(fullMethod)
+	if b, ok := l.buckets[class]; ok {
+		caller := rateLimitKey(ctx)
+		if wait := b.take(caller); wait > 0 {
+			return nil, rateLimitError(fmt.Errorf("rate limit exceeded for %s by %q", class, caller), wait)
+		}
+	}
+
+	if l.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, nil
+	default:
+		return nil, rateLimitError(fmt.Errorf("server is handling its limit of %d calls", cap(l.inFlight)), time.Second)
+	}
+}
+
+// rateLimitKey identifies the caller whose calls share token buckets.
+// Callers that weren't authenticated are identified by their addresses.
+func rateLimitKey(ctx context.Context) string {
+	if p := auth.PrincipalFromContext(ctx); p != "" && p != auth.Anonymous {
+		return p
+	}
+	if addr := clientAddress(ctx); addr != "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return host
+		}
+		return addr
+	}
+	return auth.Anonymous
+}
+
+// rateLimitError returns a RESOURCE_EXHAUSTED error with details suggesting when to retry.
+func rateLimitError(err error, wait time.Duration) error {
+	st := status.New(codes.ResourceExhausted, err.Error())
+	if detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); detailsErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// Buckets are swept for removal at this interval.
+const bucketSweepInterval = time.Minute
+
+// tokenBuckets keeps a token bucket for each of a set of keys.
+type tokenBuckets struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	now     func() time.Time
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBuckets(config RateLimit, now func() time.Time) *tokenBuckets {
+	burst := float64(config.Burst)
+	if burst == 0 {
+		burst = math.Ceil(config.Rate)
+	}
+	return &tokenBuckets{
+		rate:    config.Rate,
+		burst:   burst,
+		now:     now,
+		buckets: make(map[string]*tokenBucket),
+		swept:   now(),
+	}
+}
+
+// take removes a token from a key's bucket. If the bucket is empty,
+// it returns the time until a token will be available.
+func (b *tokenBuckets) take(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.sweep(now)
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: b.burst, last: now}
+		b.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(b.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*b.rate)
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+	return time.Duration((1 - bucket.tokens) / b.rate * float64(time.Second))
+}
+
+// sweep periodically removes buckets that have refilled. Full buckets are
+// the same as new ones, so buckets are kept only while they limit callers.
+func (b *tokenBuckets) sweep(now time.Time) {
+	if now.Sub(b.swept) < bucketSweepInterval {
+		return
+	}
+	b.swept = now
+	refill := time.Duration(b.burst / b.rate * float64(time.Second))
+	for key, bucket := range b.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(b.buckets, key)
+		}
+	}
+}
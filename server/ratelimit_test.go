@@ -0,0 +1,248 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/apigee/registry/server/auth"
+	"github.com/apigee/registry/server/gateway"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestMethodClass(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"/google.cloud.apigee.registry.v1.Registry/GetApiSpec", "reads"},
+		{"/google.cloud.apigee.registry.v1.Registry/ListApis", "reads"},
+		{"/google.longrunning.Operations/WaitOperation", "reads"},
+		{"/google.cloud.apigee.registry.v1.Registry/GetApiSpecContents", "contents"},
+		{"/google.cloud.apigee.registry.v1.Registry/GetArtifactContents", "contents"},
+		{"/google.cloud.apigee.registry.v1.Registry/UpdateApiSpec", "writes"},
+		{"/google.cloud.apigee.registry.v1.Registry/TagApiSpecRevision", "writes"},
+		{"/google.longrunning.Operations/CancelOperation", "writes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			if got := methodClass(test.method); got != test.want {
+				t.Errorf("methodClass(%q) returned %q, want %q", test.method, got, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBuckets(RateLimit{Rate: 2, Burst: 3}, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		if wait := b.take("alice"); wait != 0 {
+			t.Fatalf("take(alice) #%d returned wait %s, want 0", i+1, wait)
+		}
+	}
+	if wait := b.take("alice"); wait != 500*time.Millisecond {
+		t.Errorf("take(alice) on empty bucket returned wait %s, want %s", wait, 500*time.Millisecond)
+	}
+
+	// Each caller has a separate bucket.
+	if wait := b.take("bob"); wait != 0 {
+		t.Errorf("take(bob) returned wait %s, want 0", wait)
+	}
+
+	// Buckets refill at the configured rate.
+	now = now.Add(500 * time.Millisecond)
+	if wait := b.take("alice"); wait != 0 {
+		t.Errorf("take(alice) after refill returned wait %s, want 0", wait)
+	}
+
+	// Buckets that have refilled are forgotten.
+	now = now.Add(bucketSweepInterval)
+	b.take("carol")
+	if _, ok := b.buckets["alice"]; ok {
+		t.Errorf("refilled bucket for alice was not removed")
+	}
+	if _, ok := b.buckets["carol"]; !ok {
+		t.Errorf("bucket for carol was removed while in use")
+	}
+}
+
+func TestTokenBucketsDefaultBurst(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBuckets(RateLimit{Rate: 1.5}, func() time.Time { return now })
+	if b.burst != 2 {
+		t.Errorf("newTokenBuckets() set burst %v, want %v", b.burst, 2)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	const (
+		read     = "/google.cloud.apigee.registry.v1.Registry/GetApi"
+		write    = "/google.cloud.apigee.registry.v1.Registry/UpdateApi"
+		contents = "/google.cloud.apigee.registry.v1.Registry/GetApiSpecContents"
+		health   = "/google.cloud.apigee.registry.v1.Registry/GetStatus"
+	)
+
+	l := newRateLimiter(RateLimitConfig{
+		Reads:  RateLimit{Rate: 1},
+		Writes: RateLimit{Rate: 1, Burst: 2},
+	})
+	alice := auth.NewContext(context.Background(), "alice")
+	bob := auth.NewContext(context.Background(), "bob")
+
+	call := func(ctx context.Context, method string) error {
+		_, err := l.UnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return err
+	}
+
+	tests := []struct {
+		desc   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{"first read", alice, read, codes.OK},
+		{"second read", alice, read, codes.ResourceExhausted},
+		{"read by another principal", bob, read, codes.OK},
+		{"first write", alice, write, codes.OK},
+		{"second write within burst", alice, write, codes.OK},
+		{"third write", alice, write, codes.ResourceExhausted},
+		{"unlimited class", alice, contents, codes.OK},
+		{"unlimited method", alice, health, codes.OK},
+		{"unlimited method again", alice, health, codes.OK},
+	}
+
+	for _, test := range tests {
+		err := call(test.ctx, test.method)
+		if status.Code(err) != test.want {
+			t.Errorf("%s: returned status code %s, want %s: %v", test.desc, status.Code(err), test.want, err)
+		}
+	}
+
+	// Unauthenticated callers are limited by address, even if they claim
+	// other addresses, unless they call through the gateway.
+	caller := func(addr net.Addr, md ...string) context.Context {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(md...))
+		return peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	host := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+	otherPort := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5678}
+	for _, test := range []struct {
+		desc string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"anonymous read", caller(host), codes.OK},
+		{"anonymous read from another port", caller(otherPort), codes.ResourceExhausted},
+		{"anonymous read claiming another address", caller(host, gateway.ClientAddressKey, "10.0.0.2:1"), codes.ResourceExhausted},
+		{"gateway read", caller(localAddr{}, gateway.ClientAddressKey, "10.0.0.2:1"), codes.OK},
+		{"gateway read from the same client", caller(localAddr{}, gateway.ClientAddressKey, "10.0.0.2:2"), codes.ResourceExhausted},
+		{"gateway read from another client", caller(localAddr{}, gateway.ClientAddressKey, "10.0.0.3:1"), codes.OK},
+	} {
+		err := call(test.ctx, read)
+		if status.Code(err) != test.want {
+			t.Errorf("%s: returned status code %s, want %s: %v", test.desc, status.Code(err), test.want, err)
+		}
+	}
+
+	err := call(alice, read)
+	info := retryInfo(status.Convert(err))
+	if info == nil {
+		t.Fatalf("rate limit error %v has no retry info", err)
+	}
+	if d := info.GetRetryDelay().AsDuration(); d <= 0 || d > time.Second {
+		t.Errorf("rate limit error has retry delay %s, want (0s, 1s]", d)
+	}
+}
+
+func TestRateLimiterInFlight(t *testing.T) {
+	const method = "/google.cloud.apigee.registry.v1.Registry/GetApi"
+	l := newRateLimiter(RateLimitConfig{MaxInFlight: 1})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := l.UnaryInterceptor(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	_, err := l.UnaryInterceptor(context.Background(), nil, info, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("call over in-flight limit returned status code %s, want %s", status.Code(err), codes.ResourceExhausted)
+	}
+	if retryInfo(status.Convert(err)) == nil {
+		t.Errorf("in-flight limit error %v has no retry info", err)
+	}
+
+	close(finish)
+	if err := <-done; err != nil {
+		t.Fatalf("first call returned error: %s", err)
+	}
+
+	if _, err := l.UnaryInterceptor(context.Background(), nil, info, handler); err != nil {
+		t.Errorf("call after in-flight call finished returned error: %s", err)
+	}
+}
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config RateLimitConfig
+		valid  bool
+	}{
+		{"empty", RateLimitConfig{}, true},
+		{"limits", RateLimitConfig{Reads: RateLimit{Rate: 10, Burst: 20}, MaxInFlight: 100}, true},
+		{"negative rate", RateLimitConfig{Writes: RateLimit{Rate: -1}}, false},
+		{"negative burst", RateLimitConfig{Contents: RateLimit{Rate: 1, Burst: -1}}, false},
+		{"negative in-flight", RateLimitConfig{MaxInFlight: -1}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if err := test.config.Validate(); (err == nil) != test.valid {
+				t.Errorf("Validate() returned error %v, want valid=%t", err, test.valid)
+			}
+		})
+	}
+}
+
+func retryInfo(st *status.Status) *errdetails.RetryInfo {
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
@@ -16,20 +16,27 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"log"
 	"net"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/auth"
+	"github.com/apigee/registry/server/gateway"
 	"github.com/apigee/registry/server/gorm"
 	"github.com/apigee/registry/server/storage"
 
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"github.com/soheilhy/cmux"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/longrunning"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 )
@@ -45,13 +52,48 @@ const (
 	loggingDebug LogLevel = iota
 )
 
+// defaultMaxRequestBytes is the gRPC default limit on the size of
+// messages that servers receive.
+const defaultMaxRequestBytes = 4 * 1024 * 1024
+
+// maxLocalResponseBytes limits the size of responses that the HTTP/JSON
+// gateway receives over its in-process connection.
+const maxLocalResponseBytes = 64 * 1024 * 1024
+
+// operationRules are the HTTP/JSON bindings of the Operations service,
+// which override its default bindings to use project-scoped operation names.
+var operationRules = map[string]*annotations.HttpRule{
+	"google.longrunning.Operations.ListOperations": {
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=projects/*}/operations"},
+	},
+	"google.longrunning.Operations.GetOperation": {
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=projects/*/operations/*}"},
+	},
+	"google.longrunning.Operations.DeleteOperation": {
+		Pattern: &annotations.HttpRule_Delete{Delete: "/v1/{name=projects/*/operations/*}"},
+	},
+	"google.longrunning.Operations.CancelOperation": {
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/{name=projects/*/operations/*}:cancel"},
+		Body:    "*",
+	},
+	"google.longrunning.Operations.WaitOperation": {
+		Pattern: &annotations.HttpRule_Post{Post: "/v1/{name=projects/*/operations/*}:wait"},
+		Body:    "*",
+	},
+}
+
 // Config configures the registry server.
 type Config struct {
-	Database  string `yaml:"database"`
-	DBConfig  string `yaml:"dbconfig"`
-	Log       string `yaml:"log"`
-	Notify    bool   `yaml:"notify"`
-	ProjectID string `yaml:"project"`
+	Database   string          `yaml:"database"`
+	DBConfig   string          `yaml:"dbconfig"`
+	Log        string          `yaml:"log"`
+	Notify     bool            `yaml:"notify"`
+	ProjectID  string          `yaml:"project"`
+	TLS        TLSConfig       `yaml:"tls"`
+	Auth       auth.Config     `yaml:"auth"`
+	Quotas     QuotaConfig     `yaml:"quotas"`
+	RateLimits RateLimitConfig `yaml:"ratelimits"`
+	Cache      CacheConfig     `yaml:"cache"`
 }
 
 // RegistryServer implements a Registry server.
@@ -61,6 +103,12 @@ type RegistryServer struct {
 	notifyEnabled bool
 	loggingLevel  LogLevel
 	projectID     string
+	tls           TLSConfig
+	auth          auth.Config
+	quotas        QuotaConfig
+	rateLimits    RateLimitConfig
+	cache         *contentsCache
+	operations    operationRunner
 }
 
 func New(config Config) *RegistryServer {
@@ -69,6 +117,11 @@ func New(config Config) *RegistryServer {
 		dbConfig:      config.DBConfig,
 		notifyEnabled: config.Notify,
 		projectID:     config.ProjectID,
+		tls:           config.TLS,
+		auth:          config.Auth,
+		quotas:        config.Quotas,
+		rateLimits:    config.RateLimits,
+		cache:         newContentsCache(config.Cache),
 	}
 
 	if s.database == "" {
@@ -104,37 +157,125 @@ func (s *RegistryServer) releaseStorageClient(client storage.Client) {
 }
 
 // Start runs the Registry server using the provided listener.
+// If TLS is configured, connections on the listener are secured with
+// certificates that are reloaded when their files change.
 // It blocks until the context is cancelled.
 func (s *RegistryServer) Start(ctx context.Context, listener net.Listener) {
+	if s.tls.Enabled() {
+		reloader, err := newCertReloader(s.tls)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %s", err)
+		}
+		config, err := reloader.tlsConfig()
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %s", err)
+		}
+		listener = tls.NewListener(listener, config)
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{s.logHandler}
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if s.auth.Enabled {
+		checker, err := auth.NewChecker(s.auth)
+		if err != nil {
+			log.Fatalf("Failed to configure authorization: %s", err)
+		}
+		// Rejected calls never reach the audit interceptor, so they are recorded here.
+		checker.OnDenied(s.recordDeniedCall)
+		unaryInterceptors = append(unaryInterceptors, checker.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, checker.StreamInterceptor)
+	}
+	// Calls are limited after authentication so that limits apply to each principal.
+	limiter := newRateLimiter(s.rateLimits)
+	unaryInterceptors = append(unaryInterceptors, limiter.UnaryInterceptor, s.auditHandler)
+	streamInterceptors = append(streamInterceptors, limiter.StreamInterceptor)
+
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		// Clients can send keepalive pings as often as gRPC allows.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}
+	if s.quotas.MaxRequestBytes > 0 {
+		serverOptions = append(serverOptions, grpc.MaxRecvMsgSize(s.quotas.MaxRequestBytes))
+	}
+
 	var (
 		mux          = cmux.New(listener)
 		grpcListener = mux.Match(cmux.HTTP2())
 		httpListener = mux.Match(cmux.HTTP1Fast())
 
-		logInterceptor = grpc.UnaryInterceptor(s.logHandler)
-		grpcServer     = grpc.NewServer(logInterceptor)
-		grpcWebServer  = grpcweb.WrapServer(grpcServer)
-
-		httpServer = http.Server{
-			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if grpcWebServer.IsGrpcWebRequest(r) {
-					grpcWebServer.ServeHTTP(w, r)
-				} else {
-					http.NotFound(w, r)
-				}
-			}),
-		}
+		grpcServer    = grpc.NewServer(serverOptions...)
+		grpcWebServer = grpcweb.WrapServer(grpcServer)
+
+		// HTTP/JSON requests are transcoded and sent to the gRPC server
+		// over an in-process connection so that all interceptors apply.
+		localListener = newLocalListener()
 	)
 
 	reflection.Register(grpcServer)
 	rpc.RegisterRegistryServer(grpcServer, s)
+	longrunning.RegisterOperationsServer(grpcServer, s)
+
+	// Operations that were interrupted when their servers stopped are resumed in the background.
+	go s.resumeOperationsUntilDone(ctx)
 
+	maxRequestBytes := defaultMaxRequestBytes
+	if s.quotas.MaxRequestBytes > 0 {
+		maxRequestBytes = s.quotas.MaxRequestBytes
+	}
+	conn, err := grpc.DialContext(ctx, "local",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return localListener.DialContext(ctx)
+		}),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(maxRequestBytes),
+			grpc.MaxCallRecvMsgSize(maxLocalResponseBytes),
+		))
+	if err != nil {
+		log.Fatalf("Failed to connect to local server: %s", err)
+	}
+	defer conn.Close()
+	gatewayHandler, err := gateway.NewHandler(conn, "google.cloud.apigee.registry.v1.Registry")
+	if err != nil {
+		log.Fatalf("Failed to configure HTTP/JSON gateway: %s", err)
+	}
+	// JSON bodies encode bytes fields in base64, so they can be larger
+	// than the messages they carry.
+	gatewayHandler.LimitRequestBytes(2 * int64(maxRequestBytes))
+	for method, rule := range operationRules {
+		if err := gatewayHandler.AddRule(method, rule); err != nil {
+			log.Fatalf("Failed to configure HTTP/JSON gateway: %s", err)
+		}
+	}
+
+	httpServer := http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if grpcWebServer.IsGrpcWebRequest(r) {
+				grpcWebServer.ServeHTTP(w, r)
+			} else {
+				gatewayHandler.ServeHTTP(w, r)
+			}
+		}),
+	}
+
+	go grpcServer.Serve(localListener)
 	go grpcServer.Serve(grpcListener)
 	go httpServer.Serve(httpListener)
 	go mux.Serve()
 
 	// Block until the context is cancelled.
 	<-ctx.Done()
+
+	// Stop serving and let running operations finish, so that they
+	// aren't left waiting for other servers to take them over.
+	httpServer.Close()
+	grpcServer.Stop()
+	s.operations.wait()
 }
 
 func (s *RegistryServer) logHandler(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
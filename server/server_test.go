@@ -21,9 +21,12 @@ import (
 
 func defaultTestServer(t *testing.T) *RegistryServer {
 	t.Helper()
-	return &RegistryServer{
+	s := &RegistryServer{
 		database:     "sqlite3",
 		dbConfig:     fmt.Sprintf("%s/registry.db", t.TempDir()),
 		loggingLevel: loggingError,
 	}
+	// Operations must finish before their database is removed.
+	t.Cleanup(s.operations.wait)
+	return s
 }
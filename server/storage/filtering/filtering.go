@@ -15,8 +15,14 @@
 package filtering
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/operators"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -30,15 +36,49 @@ const (
 	Int       FieldType = iota
 	Timestamp FieldType = iota
 	StringMap FieldType = iota
+	Bool      FieldType = iota
+	// Parent fields refer to the parent resources of listed resources,
+	// e.g. api.labels.team, and have the fields listed in Field.Fields.
+	Parent FieldType = iota
 )
 
 type Field struct {
-	Name string
-	Type FieldType
+	Name   string
+	Type   FieldType
+	Fields []Field
 }
 
 type Filter struct {
-	program cel.Program
+	program    cel.Program
+	references map[string]bool
+	equalities []Equality
+}
+
+// Equality is a condition of a filter that requires a field of a parent
+// to equal a constant, e.g. api.display_name == "Pets".
+type Equality struct {
+	Parent string
+	Field  string
+	Value  interface{}
+}
+
+// References returns true if the filter refers to a field. Callers use
+// this to avoid loading parent resources that aren't used.
+func (f *Filter) References(name string) bool {
+	return f.references[name]
+}
+
+// Equalities returns the conditions on fields of a parent that must hold
+// for the filter to match, so that callers can apply them in storage
+// queries. Matches still checks all conditions.
+func (f *Filter) Equalities(parent string) []Equality {
+	var equalities []Equality
+	for _, e := range f.equalities {
+		if e.Parent == parent {
+			equalities = append(equalities, e)
+		}
+	}
+	return equalities
 }
 
 func (f *Filter) Matches(model map[string]interface{}) (bool, error) {
@@ -46,7 +86,7 @@ func (f *Filter) Matches(model map[string]interface{}) (bool, error) {
 		return true, nil
 	}
 
-	out, _, err := f.program.Eval(model)
+	out, _, err := f.program.Eval(activation(model))
 	if err != nil {
 		return false, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -75,25 +115,205 @@ func NewFilter(filter string, fields []Field) (Filter, error) {
 			declarations = append(declarations, decls.NewIdent(field.Name, decls.Timestamp, nil))
 		case StringMap:
 			declarations = append(declarations, decls.NewIdent(field.Name, decls.NewMapType(decls.String, decls.String), nil))
+		case Bool:
+			declarations = append(declarations, decls.NewIdent(field.Name, decls.Bool, nil))
+		case Parent:
+			declarations = append(declarations, decls.NewIdent(field.Name, decls.NewMapType(decls.String, decls.Dyn), nil))
 		default:
 			return Filter{}, status.Errorf(codes.InvalidArgument, "unknown filter argument type")
 		}
 	}
 
-	env, err := cel.NewEnv(cel.Container("filter"), cel.Declarations(declarations...))
+	declarations = append(declarations, functionDeclarations...)
+
+	env, err := cel.NewEnv(
+		cel.Container("filter"),
+		cel.Declarations(declarations...),
+		cel.Macros(mapMacros(fields)...),
+	)
 	if err != nil {
 		return Filter{}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	ast, iss := env.Compile(filter)
 	if iss.Err() != nil {
+		for _, e := range iss.Errors() {
+			if m := undeclaredReference.FindStringSubmatch(e.Message); m != nil {
+				return Filter{}, status.Errorf(codes.InvalidArgument, "unknown field or function %q in filter, fields are %s", m[1], fieldNames(fields))
+			}
+		}
 		return Filter{}, status.Error(codes.InvalidArgument, iss.Err().Error())
 	}
 
-	prg, err := env.Program(ast)
+	references := make(map[string]bool)
+	if err := checkReferences(ast.Expr(), fields, references); err != nil {
+		return Filter{}, err
+	}
+
+	prg, err := env.Program(ast, cel.Functions(functionOverloads...))
 	if err != nil {
 		return Filter{}, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	return Filter{program: prg}, nil
+	return Filter{
+		program:    prg,
+		references: references,
+		equalities: parentEqualities(ast.Expr(), fields),
+	}, nil
+}
+
+// parentEqualities returns the comparisons of parent fields with constants
+// that are required by an expression, which are the expression itself or
+// the operands of its top-level conjunctions.
+func parentEqualities(e *exprpb.Expr, fields []Field) []Equality {
+	call := e.GetCallExpr()
+	switch call.GetFunction() {
+	case operators.LogicalAnd:
+		var equalities []Equality
+		for _, arg := range call.GetArgs() {
+			equalities = append(equalities, parentEqualities(arg, fields)...)
+		}
+		return equalities
+	case operators.Equals:
+		args := call.GetArgs()
+		if len(args) != 2 {
+			return nil
+		}
+		if eq, ok := parentEquality(args[0], args[1], fields); ok {
+			return []Equality{eq}
+		}
+		if eq, ok := parentEquality(args[1], args[0], fields); ok {
+			return []Equality{eq}
+		}
+	}
+	return nil
+}
+
+// parentEquality returns the condition that a selected parent field equals
+// a constant of the field's type.
+func parentEquality(selection, constant *exprpb.Expr, fields []Field) (Equality, bool) {
+	sel := selection.GetSelectExpr()
+	if sel == nil || sel.GetTestOnly() {
+		return Equality{}, false
+	}
+	parent, ok := parentField(sel.GetOperand(), fields)
+	if !ok {
+		return Equality{}, false
+	}
+	for _, field := range parent.Fields {
+		if field.Name != sel.GetField() {
+			continue
+		}
+		var value interface{}
+		switch c := constant.GetConstExpr().GetConstantKind().(type) {
+		case *exprpb.Constant_StringValue:
+			if field.Type == String {
+				value = c.StringValue
+			}
+		case *exprpb.Constant_Int64Value:
+			if field.Type == Int {
+				value = c.Int64Value
+			}
+		case *exprpb.Constant_BoolValue:
+			if field.Type == Bool {
+				value = c.BoolValue
+			}
+		}
+		if value == nil {
+			return Equality{}, false
+		}
+		return Equality{Parent: parent.Name, Field: field.Name, Value: value}, true
+	}
+	return Equality{}, false
+}
+
+// checkReferences records the fields that an expression refers to and
+// checks that fields selected from parents, e.g. api.labels, exist.
+func checkReferences(e *exprpb.Expr, fields []Field, references map[string]bool) error {
+	if e == nil {
+		return nil
+	}
+
+	children := make([]*exprpb.Expr, 0)
+	switch x := e.ExprKind.(type) {
+	case *exprpb.Expr_IdentExpr:
+		references[x.IdentExpr.GetName()] = true
+	case *exprpb.Expr_SelectExpr:
+		operand := x.SelectExpr.GetOperand()
+		if parent, ok := parentField(operand, fields); ok {
+			if !hasField(parent.Fields, x.SelectExpr.GetField()) {
+				return status.Errorf(codes.InvalidArgument, "unknown field \"%s.%s\" in filter, %s fields are %s",
+					parent.Name, x.SelectExpr.GetField(), parent.Name, fieldNames(parent.Fields))
+			}
+		}
+		children = append(children, operand)
+	case *exprpb.Expr_CallExpr:
+		children = append(children, x.CallExpr.GetTarget())
+		children = append(children, x.CallExpr.GetArgs()...)
+	case *exprpb.Expr_ListExpr:
+		children = append(children, x.ListExpr.GetElements()...)
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range x.StructExpr.GetEntries() {
+			children = append(children, entry.GetMapKey(), entry.GetValue())
+		}
+	case *exprpb.Expr_ComprehensionExpr:
+		c := x.ComprehensionExpr
+		children = append(children, c.GetIterRange(), c.GetAccuInit(), c.GetLoopCondition(), c.GetLoopStep(), c.GetResult())
+	}
+
+	for _, child := range children {
+		if err := checkReferences(child, fields, references); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parentField returns the parent field named by an identifier expression.
+func parentField(e *exprpb.Expr, fields []Field) (Field, bool) {
+	name := e.GetIdentExpr().GetName()
+	for _, field := range fields {
+		if field.Type == Parent && field.Name == name {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// hasField returns true if a parent has a field. Parents of parents
+// can't be used in filters.
+func hasField(fields []Field, name string) bool {
+	for _, field := range fields {
+		if field.Name == name && field.Type != Parent {
+			return true
+		}
+	}
+	return false
+}
+
+var undeclaredReference = regexp.MustCompile(`^undeclared reference to '([^']*)'`)
+
+func fieldNames(fields []Field) string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return fmt.Sprintf("[%s]", strings.Join(names, ", "))
+}
+
+// activation converts model values that CEL can't use directly,
+// including the values of parent models.
+func activation(model map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{}, len(model))
+	for k, v := range model {
+		switch x := v.(type) {
+		case time.Time:
+			vars[k] = timestampValue(x)
+		case map[string]interface{}:
+			vars[k] = activation(x)
+		default:
+			vars[k] = v
+		}
+	}
+	return vars
 }
@@ -0,0 +1,200 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filtering
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testFields = []Field{
+	{Name: "name", Type: String},
+	{Name: "version_id", Type: String},
+	{Name: "size_bytes", Type: Int},
+	{Name: "create_time", Type: Timestamp},
+	{Name: "labels", Type: StringMap},
+	{Name: "annotations", Type: StringMap},
+	{Name: "api", Type: Parent, Fields: []Field{
+		{Name: "name", Type: String},
+		{Name: "create_time", Type: Timestamp},
+		{Name: "labels", Type: StringMap},
+	}},
+}
+
+func TestFilterMatches(t *testing.T) {
+	fixed := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return fixed }
+	defer func() { now = time.Now }()
+
+	model := map[string]interface{}{
+		"name":        "projects/p/apis/a/versions/v1.2.0",
+		"version_id":  "v1.2.0",
+		"size_bytes":  int64(100),
+		"create_time": fixed.Add(-48 * time.Hour),
+		"labels":      map[string]string{"tier": "gold"},
+		"annotations": map[string]string{"owner": "team-a"},
+		"api": map[string]interface{}{
+			"name":        "projects/p/apis/a",
+			"create_time": fixed.Add(-72 * time.Hour),
+			"labels":      map[string]string{"team": "payments"},
+		},
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`name.matches("^projects/p/apis/a/")`, true},
+		{`matches(name, "/apis/b/")`, false},
+		{`has_label("tier")`, true},
+		{`has_label("owner")`, false},
+		{`has_annotation("owner") && annotations.owner == "team-a"`, true},
+		{`is_semver(version_id)`, true},
+		{`semver_compare(version_id, "v1.10") < 0`, true},
+		{`semver_compare(version_id, "1.2") == 0`, true},
+		{`create_time > now() - days(3)`, true},
+		{`create_time > now() - duration("24h")`, false},
+		{`size_bytes > 10`, true},
+		{`api.labels.team == "payments" && api.create_time < create_time`, true},
+		{`"team" in api.labels && api.name.endsWith("/b")`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			f, err := NewFilter(test.filter, testFields)
+			if err != nil {
+				t.Fatalf("NewFilter(%q) returned error: %s", test.filter, err)
+			}
+			got, err := f.Matches(model)
+			if err != nil {
+				t.Fatalf("Matches() returned error: %s", err)
+			}
+			if got != test.want {
+				t.Errorf("Matches() returned %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		fields []Field
+		want   string
+	}{
+		{`owner == "x"`, testFields, `unknown field or function "owner"`},
+		{`has_label("x")`, testFields[:4], `unknown field or function "has_label"`},
+		{`api.owner == "x"`, testFields, `unknown field "api.owner"`},
+		{`name ==`, testFields, "Syntax error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			_, err := NewFilter(test.filter, test.fields)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("NewFilter(%q) returned status code %s, want %s", test.filter, status.Code(err), codes.InvalidArgument)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("NewFilter(%q) returned error %q, want it to contain %q", test.filter, err, test.want)
+			}
+		})
+	}
+
+	f, err := NewFilter(`semver_compare(version_id, "1.0") > 0`, testFields)
+	if err != nil {
+		t.Fatalf("NewFilter() returned error: %s", err)
+	}
+	if _, err := f.Matches(map[string]interface{}{"version_id": "latest"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Matches() with invalid version returned status code %s, want %s", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestFilterReferences(t *testing.T) {
+	f, err := NewFilter(`name != "" && api.labels.team == "payments"`, testFields)
+	if err != nil {
+		t.Fatalf("NewFilter() returned error: %s", err)
+	}
+	for name, want := range map[string]bool{"name": true, "api": true, "labels": false} {
+		if got := f.References(name); got != want {
+			t.Errorf("References(%q) returned %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestFilterEqualities(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []Equality
+	}{
+		{`api.name == "a"`, []Equality{{Parent: "api", Field: "name", Value: "a"}}},
+		{`name != "" && "b" == api.name && (api.name == "c" && size_bytes > 0)`, []Equality{
+			{Parent: "api", Field: "name", Value: "b"},
+			{Parent: "api", Field: "name", Value: "c"},
+		}},
+		{`api.name == "a" || name == "b"`, nil},
+		{`!(api.name == "a")`, nil},
+		{`api.labels.team == "payments"`, nil},
+		{`api.name == name`, nil},
+		{`api.name == 1`, nil},
+	}
+	for _, test := range tests {
+		f, err := NewFilter(test.filter, testFields)
+		if err != nil {
+			t.Errorf("NewFilter(%q) returned error: %s", test.filter, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, f.Equalities("api")); diff != "" {
+			t.Errorf("Equalities(%q) returned unexpected conditions (-want +got):\n%s", test.filter, diff)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v1.0.0", 0},
+		{"1.2.3", "1.2.10", -1},
+		{"v2", "v1.9", 1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.11", "1.0.0-beta.2", 1},
+		{"1.0.0+build.5", "1.0.0", 0},
+	}
+
+	for _, test := range tests {
+		a, aok := parseSemver(test.a)
+		b, bok := parseSemver(test.b)
+		if !aok || !bok {
+			t.Fatalf("parseSemver(%q) or parseSemver(%q) failed", test.a, test.b)
+		}
+		if got := a.compare(b); got != test.want {
+			t.Errorf("compare(%q, %q) returned %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+
+	for _, s := range []string{"", "latest", "v1.2.3.4", "1..2", "1.0-", "1.-1"} {
+		if _, ok := parseSemver(s); ok {
+			t.Errorf("parseSemver(%q) succeeded, want failure", s)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filtering
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/interpreter/functions"
+	"github.com/google/cel-go/parser"
+
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// Registry-specific functions that can be used in any filter, in addition
+// to the CEL standard functions such as size() and duration().
+var functionDeclarations = []*exprpb.Decl{
+	decls.NewFunction("matches",
+		decls.NewOverload("matches_string_string", []*exprpb.Type{decls.String, decls.String}, decls.Bool)),
+	decls.NewFunction("now",
+		decls.NewOverload("now", []*exprpb.Type{}, decls.Timestamp)),
+	decls.NewFunction("days",
+		decls.NewOverload("days_int", []*exprpb.Type{decls.Int}, decls.Duration)),
+	decls.NewFunction("is_semver",
+		decls.NewOverload("is_semver_string", []*exprpb.Type{decls.String}, decls.Bool)),
+	decls.NewFunction("semver_compare",
+		decls.NewOverload("semver_compare_string_string", []*exprpb.Type{decls.String, decls.String}, decls.Int)),
+}
+
+var functionOverloads = []*functions.Overload{
+	{
+		// The standard library only declares the receiver form, name.matches(regex).
+		Operator: "matches_string_string",
+		Binary: func(lhs, rhs ref.Val) ref.Val {
+			s, ok := lhs.(types.String)
+			if !ok {
+				return types.NoSuchOverloadErr()
+			}
+			return s.Match(rhs)
+		},
+	},
+	{
+		Operator: "now",
+		Function: func(args ...ref.Val) ref.Val {
+			return timestampValue(now())
+		},
+	},
+	{
+		Operator: "days",
+		Unary: func(v ref.Val) ref.Val {
+			n, ok := v.(types.Int)
+			if !ok {
+				return types.NoSuchOverloadErr()
+			}
+			return types.Duration{Duration: ptypes.DurationProto(time.Duration(n) * 24 * time.Hour)}
+		},
+	},
+	{
+		Operator: "is_semver",
+		Unary: func(v ref.Val) ref.Val {
+			s, ok := v.(types.String)
+			if !ok {
+				return types.NoSuchOverloadErr()
+			}
+			_, ok = parseSemver(string(s))
+			return types.Bool(ok)
+		},
+	},
+	{
+		Operator: "semver_compare",
+		Binary: func(lhs, rhs ref.Val) ref.Val {
+			a, aok := lhs.(types.String)
+			b, bok := rhs.(types.String)
+			if !aok || !bok {
+				return types.NoSuchOverloadErr()
+			}
+			va, ok := parseSemver(string(a))
+			if !ok {
+				return types.NewErr("semver_compare: %q is not a semantic version", a)
+			}
+			vb, ok := parseSemver(string(b))
+			if !ok {
+				return types.NewErr("semver_compare: %q is not a semantic version", b)
+			}
+			return types.Int(va.compare(vb))
+		},
+	},
+}
+
+// mapMacros returns macros that test for keys of map fields, e.g.
+// has_label("k") expands to "k" in labels.
+func mapMacros(fields []Field) []parser.Macro {
+	macros := make([]parser.Macro, 0)
+	for _, field := range fields {
+		var function string
+		switch field.Name {
+		case "labels":
+			function = "has_label"
+		case "annotations":
+			function = "has_annotation"
+		default:
+			continue
+		}
+		name := field.Name
+		macros = append(macros, parser.NewGlobalMacro(function, 1,
+			func(eh parser.ExprHelper, target *exprpb.Expr, args []*exprpb.Expr) (*exprpb.Expr, *common.Error) {
+				return eh.GlobalCall(operators.In, args[0], eh.Ident(name)), nil
+			}))
+	}
+	return macros
+}
+
+// timestampValue converts a time to a CEL timestamp.
+func timestampValue(t time.Time) ref.Val {
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return types.NewErr(err.Error())
+	}
+	return types.Timestamp{Timestamp: ts}
+}
+
+// semver is a semantic version, as described at https://semver.org.
+// Versions are compared by their numbers and prerelease identifiers;
+// build metadata is ignored.
+type semver struct {
+	numbers    [3]int
+	prerelease []string
+}
+
+// parseSemver parses version IDs like "v1", "1.2" and "v1.2.3-beta.1".
+// An optional "v" prefix is allowed and missing numbers are zero.
+func parseSemver(s string) (semver, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	v := semver{}
+	if i := strings.Index(s, "-"); i >= 0 {
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+		for _, id := range v.prerelease {
+			if id == "" {
+				return semver{}, false
+			}
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return semver{}, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1 if v is less than, equal to or greater than other.
+func (v semver) compare(other semver) int {
+	for i := range v.numbers {
+		if c := compareInts(v.numbers[i], other.numbers[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A version without prerelease identifiers is greater than one with them.
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		if c := comparePrerelease(v.prerelease[i], other.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.prerelease), len(other.prerelease))
+}
+
+// comparePrerelease compares prerelease identifiers. Numeric identifiers
+// are compared numerically and are less than alphanumeric identifiers.
+func comparePrerelease(a, b string) int {
+	na, aerr := strconv.Atoi(a)
+	nb, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return compareInts(na, nb)
+	case aerr == nil:
+		return -1
+	case berr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -33,8 +33,18 @@ const (
 	SpecEntityName = "Spec"
 	// SpecRevisionTagEntityName is the storage entity name for API spec revision tag resources.
 	SpecRevisionTagEntityName = "SpecRevisionTag"
+	// SpecRevisionTagEventEntityName is the storage entity name for the history of API spec revision tags.
+	SpecRevisionTagEventEntityName = "SpecRevisionTagEvent"
+	// DeploymentEntityName is the storage entity name for API deployment resources.
+	DeploymentEntityName = "Deployment"
+	// DeploymentRevisionTagEntityName is the storage entity name for API deployment revision tag resources.
+	DeploymentRevisionTagEntityName = "DeploymentRevisionTag"
 	// ArtifactEntityName is the storage entity name for artifact resources.
 	ArtifactEntityName = "Artifact"
+	// AuditEventEntityName is the storage entity name for audit events.
+	AuditEventEntityName = "AuditEvent"
+	// OperationEntityName is the storage entity name for long-running operations.
+	OperationEntityName = "Operation"
 )
 
 type Client interface {
@@ -44,6 +54,9 @@ type Client interface {
 	Put(ctx context.Context, k Key, v interface{}) (Key, error)
 	Delete(ctx context.Context, k Key) error
 	Run(ctx context.Context, q Query) Iterator
+	Count(ctx context.Context, q Query) (int64, error)
+	Sum(ctx context.Context, q Query, field string) (int64, error)
+	Transaction(ctx context.Context, f func(ctx context.Context, tx Client) error) error
 
 	IsNotFound(err error) bool
 	NotFoundError() error
@@ -56,8 +69,10 @@ type Client interface {
 	DeleteChildrenOfVersion(ctx context.Context, version names.Version) error
 	DeleteAllMatches(ctx context.Context, q Query) error
 	DeleteChildrenOfSpec(ctx context.Context, spec names.Spec) error
+	DeleteChildrenOfDeployment(ctx context.Context, deployment names.Deployment) error
 
-	GetRecentSpecRevisions(ctx context.Context, offset int32, projectID, apiID, versionID string) Iterator
+	GetRecentSpecRevisions(ctx context.Context, q Query, projectID, apiID, versionID string) Iterator
+	GetRecentDeploymentRevisions(ctx context.Context, q Query, projectID, apiID string) Iterator
 }
 
 type Key interface {
@@ -66,7 +81,10 @@ type Key interface {
 
 type Query interface {
 	Require(name string, value interface{}) Query
+	RequireParent(kind, name string, value interface{}) Query
+	Ascending(field string) Query
 	Descending(field string) Query
+	Distinct(fields ...string) Query
 	ApplyOffset(int32) Query
 }
 
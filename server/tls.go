@@ -0,0 +1,205 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig configures transport security for the registry server.
+type TLSConfig struct {
+	// CertFile and KeyFile name PEM-encoded files containing the server
+	// certificate chain and private key. TLS is enabled when both are set.
+	CertFile string `yaml:"certfile"`
+	KeyFile  string `yaml:"keyfile"`
+	// ClientCAFile names a PEM-encoded bundle of certificate authorities
+	// used to verify client certificates.
+	ClientCAFile string `yaml:"clientcafile"`
+	// ClientAuth selects how client certificates are handled.
+	// Valid values are "none", "request", "verify", and "require".
+	// Defaults to "require" when ClientCAFile is set and "none" otherwise.
+	ClientAuth string `yaml:"clientauth"`
+	// ReloadInterval is the minimum time between checks of the certificate
+	// files for changes. Defaults to one minute.
+	ReloadInterval time.Duration `yaml:"reloadinterval"`
+}
+
+// Enabled returns true if the configuration requests TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// Validate checks the TLS configuration for errors.
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("invalid tls config: certfile and keyfile must be set together")
+	}
+	mode, err := c.clientAuthType()
+	if err != nil {
+		return err
+	}
+	if !c.Enabled() && (c.ClientCAFile != "" || mode != tls.NoClientCert) {
+		return fmt.Errorf("invalid tls config: client verification requires certfile and keyfile")
+	}
+	if c.ClientCAFile == "" && (mode == tls.VerifyClientCertIfGiven || mode == tls.RequireAndVerifyClientCert) {
+		return fmt.Errorf("invalid tls config: clientauth %q requires clientcafile", c.ClientAuth)
+	}
+	return nil
+}
+
+func (c TLSConfig) clientAuthType() (tls.ClientAuthType, error) {
+	switch c.ClientAuth {
+	case "":
+		if c.ClientCAFile != "" {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "verify":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("invalid clientauth value %q: must be one of [none, request, verify, require]", c.ClientAuth)
+	}
+}
+
+// certReloader serves certificates loaded from disk and reloads them
+// when the underlying files change.
+type certReloader struct {
+	config TLSConfig
+
+	mu        sync.Mutex
+	checked   time.Time
+	modified  time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func newCertReloader(config TLSConfig) (*certReloader, error) {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = time.Minute
+	}
+	r := &certReloader{config: config}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the certificate, key, and client CA files.
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %s", err)
+	}
+	var pool *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		b, err := ioutil.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %s", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return fmt.Errorf("no certificates found in client CA file %q", r.config.ClientCAFile)
+		}
+	}
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.clientCAs = pool
+	r.modified = modified
+	r.checked = time.Now()
+	return nil
+}
+
+// lastModified returns the latest modification time of the watched files.
+func (r *certReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return latest, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// refresh reloads the files if they have changed since the last load.
+// Failed reloads are logged and the previous certificates remain in use.
+func (r *certReloader) refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) < r.config.ReloadInterval {
+		return
+	}
+	r.checked = time.Now()
+	modified, err := r.lastModified()
+	if err != nil || !modified.After(r.modified) {
+		return
+	}
+	if err := r.load(); err != nil {
+		log.Printf("Failed to reload TLS certificates: %s", err)
+	}
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.refresh()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+// tlsConfig returns a TLS configuration that picks up reloaded certificates
+// for each new connection.
+func (r *certReloader) tlsConfig() (*tls.Config, error) {
+	mode, err := r.config.clientAuthType()
+	if err != nil {
+		return nil, err
+	}
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		ClientAuth:     mode,
+		GetCertificate: r.getCertificate,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.refresh()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		c := base.Clone()
+		c.ClientCAs = r.clientCAs
+		return c, nil
+	}
+	return base, nil
+}
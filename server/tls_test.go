@@ -0,0 +1,186 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and key for the
+// named host and returns the paths of the files.
+func writeTestCertificate(t *testing.T, dir, host string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Setup: failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Setup: failed to create certificate: %s", err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Setup: failed to marshal key: %s", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatalf("Setup: failed to write certificate: %s", err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatalf("Setup: failed to write key: %s", err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config TLSConfig
+		valid  bool
+	}{
+		{
+			desc:   "disabled",
+			config: TLSConfig{},
+			valid:  true,
+		},
+		{
+			desc:   "server only",
+			config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"},
+			valid:  true,
+		},
+		{
+			desc:   "mutual",
+			config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"},
+			valid:  true,
+		},
+		{
+			desc:   "missing key",
+			config: TLSConfig{CertFile: "cert.pem"},
+		},
+		{
+			desc:   "client verification without server certificate",
+			config: TLSConfig{ClientCAFile: "ca.pem"},
+		},
+		{
+			desc:   "require without client CA",
+			config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "require"},
+		},
+		{
+			desc:   "unknown client auth",
+			config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "sometimes"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.valid && err != nil {
+				t.Errorf("Validate() returned unexpected error: %s", err)
+			} else if !test.valid && err == nil {
+				t.Errorf("Validate() succeeded, expected error")
+			}
+		})
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "first.example.com")
+
+	r, err := newCertReloader(TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   certFile,
+		ReloadInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("newCertReloader() returned error: %s", err)
+	}
+
+	config, err := r.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig() returned error: %s", err)
+	}
+	if config.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("tlsConfig() returned client auth %v, expected %v", config.ClientAuth, tls.RequireAndVerifyClientCert)
+	}
+
+	first := leafName(t, r)
+	if first != "first.example.com" {
+		t.Errorf("getCertificate() returned certificate for %q, expected %q", first, "first.example.com")
+	}
+
+	// Replace the files and move their modification times forward.
+	writeTestCertificate(t, dir, "second.example.com")
+	later := time.Now().Add(time.Minute)
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.Chtimes(name, later, later); err != nil {
+			t.Fatalf("Setup: failed to update modification time: %s", err)
+		}
+	}
+
+	second := leafName(t, r)
+	if second != "second.example.com" {
+		t.Errorf("getCertificate() returned certificate for %q after reload, expected %q", second, "second.example.com")
+	}
+
+	c, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient() returned error: %s", err)
+	}
+	if c.ClientCAs == nil {
+		t.Errorf("GetConfigForClient() returned config without client CAs")
+	}
+}
+
+func leafName(t *testing.T, r *certReloader) string {
+	t.Helper()
+	cert, err := r.getCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("getCertificate() returned error: %s", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %s", err)
+	}
+	return leaf.Subject.CommonName
+}
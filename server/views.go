@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apigee/registry/rpc"
+	"github.com/apigee/registry/server/dao"
+	"github.com/apigee/registry/server/models"
+	"github.com/apigee/registry/server/names"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// maxViewContentsSize is the size of the largest contents that are returned
+// in the FULL view. Larger contents must be read with Get*Contents methods.
+const maxViewContentsSize = 1 << 20
+
+// maxListContentsSize is the total size of the contents returned in a List
+// response. It is half of gRPC's default 4 MiB message size limit, leaving
+// room for the other fields of up to 1000 messages in a page.
+const maxListContentsSize = 2 << 20
+
+// view describes the fields requested by a Get or List call.
+type view struct {
+	contents bool
+	mask     *fieldmaskpb.FieldMask
+	// budget is the size of the contents that remain to be returned
+	// in a List response. It is nil for Get calls.
+	budget *int64
+}
+
+// newView validates the view and read mask of a request for messages of the given type.
+func newView(message protoreflect.ProtoMessage, v rpc.View, mask *fieldmaskpb.FieldMask) (view, error) {
+	if _, ok := rpc.View_name[int32(v)]; !ok {
+		return view{}, invalidArgumentError(fmt.Errorf("invalid view %d", v))
+	}
+	if err := models.ValidateMask(message, mask); err != nil {
+		return view{}, invalidArgumentError(fmt.Errorf("invalid read_mask: %s", err))
+	}
+
+	contents := v == rpc.View_FULL
+	for _, path := range mask.GetPaths() {
+		if path == "contents" {
+			contents = true
+		}
+	}
+	return view{contents: contents, mask: mask}, nil
+}
+
+// forList returns a view for the messages of a List response, which share
+// a contents budget so that responses fit in a single gRPC message.
+func (v view) forList() view {
+	budget := int64(maxListContentsSize)
+	v.budget = &budget
+	return v
+}
+
+// includesContents reports whether contents of the given size are returned,
+// deducting them from the budget of a List response if they are.
+func (v view) includesContents(size int64) bool {
+	if !v.contents || size > maxViewContentsSize {
+		return false
+	}
+	if v.budget != nil {
+		if size > *v.budget {
+			return false
+		}
+		*v.budget -= size
+	}
+	return true
+}
+
+// specMessage returns the requested view of a spec revision.
+func (v view) specMessage(ctx context.Context, db dao.DAO, spec *models.Spec, name string) (*rpc.ApiSpec, error) {
+	var message *rpc.ApiSpec
+	var err error
+	if v.includesContents(int64(spec.SizeInBytes)) {
+		revision := names.Spec{
+			ProjectID: spec.ProjectID,
+			ApiID:     spec.ApiID,
+			VersionID: spec.VersionID,
+			SpecID:    spec.SpecID,
+		}.Revision(spec.RevisionID)
+
+		blob, err := db.GetSpecRevisionContents(ctx, revision)
+		if err != nil {
+			return nil, err
+		}
+		message, err = spec.FullMessage(blob, name)
+		if err != nil {
+			return nil, internalError(err)
+		}
+	} else {
+		message, err = spec.BasicMessage(name)
+		if err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	models.ApplyReadMask(message, v.mask)
+	return message, nil
+}
+
+// artifactMessage returns the requested view of an artifact.
+func (v view) artifactMessage(ctx context.Context, db dao.DAO, artifact *models.Artifact) (*rpc.Artifact, error) {
+	var message *rpc.Artifact
+	var err error
+	if v.includesContents(int64(artifact.SizeInBytes)) {
+		name, err := names.ParseArtifact(artifact.Name())
+		if err != nil {
+			return nil, internalError(err)
+		}
+
+		blob, err := db.GetArtifactContents(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		message, err = artifact.FullMessage(blob)
+		if err != nil {
+			return nil, internalError(err)
+		}
+	} else {
+		message, err = artifact.BasicMessage()
+		if err != nil {
+			return nil, internalError(err)
+		}
+	}
+
+	models.ApplyReadMask(message, v.mask)
+	return message, nil
+}
@@ -79,7 +79,10 @@ func TestCRUD(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: "projects/test",
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		if status.Code(err) != codes.NotFound {
 			check(t, "Failed to delete test project: %+v", err)
 		}
@@ -266,7 +269,10 @@ func TestCRUD(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: "projects/test",
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		check(t, "Failed to delete test project: %+v", err)
 	}
 }
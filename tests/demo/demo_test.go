@@ -124,7 +124,10 @@ func TestDemo(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: "projects/demo",
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		if status.Code(err) != codes.NotFound {
 			check(t, "Failed to delete demo project: %+v", err)
 		}
@@ -371,7 +374,10 @@ func TestDemo(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: "projects/demo",
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		check(t, "Failed to delete demo project: %+v", err)
 	}
 }
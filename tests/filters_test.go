@@ -88,7 +88,10 @@ func TestFilters(t *testing.T) {
 		req := &rpc.DeleteProjectRequest{
 			Name: "projects/filters",
 		}
-		err = registryClient.DeleteProject(ctx, req)
+		op, err := registryClient.DeleteProject(ctx, req)
+		if err == nil {
+			err = op.Wait(ctx)
+		}
 		if status.Code(err) != codes.NotFound {
 			check(t, "Failed to delete filters project: %+v", err)
 		}
@@ -244,7 +247,10 @@ func TestFilters(t *testing.T) {
 	req := &rpc.DeleteProjectRequest{
 		Name: "projects/filters",
 	}
-	err = registryClient.DeleteProject(ctx, req)
+	op, err := registryClient.DeleteProject(ctx, req)
+	if err == nil {
+		err = op.Wait(ctx)
+	}
 	check(t, "Failed to delete filters project: %+v", err)
 }
 